@@ -0,0 +1,111 @@
+// Package armschema loads resource body schemas from the alternatives to OpenAPI specs: ARM
+// template deployment schemas (as published at schema.management.azure.com) and Bicep type
+// definitions (a types.json file or the index.json of a types directory). Resources are
+// normalized into *openapi3.Schema so they feed the same generation pipeline as a PUT body.
+package armschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Resource is a resource type found in an ARM template schema or Bicep types.
+type Resource struct {
+	// Schema is the request body of the resource. Deployment-only fields are dropped and the
+	// name is read-only, as for a PUT body whose name comes from the instance path.
+	Schema *openapi3.Schema
+	// APIVersion is the API version the schema describes.
+	APIVersion string
+}
+
+// FindResource loads the ARM template schema or Bicep types at source, a file path or URL, and
+// returns resourceType, e.g. Microsoft.Storage/storageAccounts. Resource types match case
+// insensitively; when source describes several API versions of it, the latest is used, a stable
+// version ahead of a preview of the same date.
+func FindResource(source, resourceType string) (*Resource, error) {
+	data, err := readSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return findBicepResource(source, data, resourceType)
+	}
+
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", source, err)
+	}
+	for key := range top {
+		if strings.HasSuffix(strings.ToLower(key), "resourcedefinitions") {
+			return findTemplateResource(data, resourceType)
+		}
+	}
+	if _, ok := top["resources"]; ok {
+		return findBicepIndexResource(source, top["resources"], resourceType)
+	}
+	return nil, fmt.Errorf("%s is neither an ARM template schema nor Bicep types", source)
+}
+
+// deploymentOnlyProperties are the fields of a template resource that configure the deployment
+// rather than the resource.
+var deploymentOnlyProperties = []string{"apiVersion", "type", "dependsOn", "copy", "comments", "condition", "scope", "resources"}
+
+// requestBody returns a copy of the resource schema without the deployment-only fields, and
+// with a read-only name.
+func requestBody(resource *openapi3.Schema) *openapi3.Schema {
+	body := *resource
+	body.Properties = make(openapi3.Schemas, len(resource.Properties))
+	for name, ref := range resource.Properties {
+		if slices.Contains(deploymentOnlyProperties, name) {
+			continue
+		}
+		if name == "name" && ref != nil && ref.Value != nil {
+			nameSchema := *ref.Value
+			nameSchema.ReadOnly = true
+			ref = &openapi3.SchemaRef{Value: &nameSchema}
+		}
+		body.Properties[name] = ref
+	}
+	body.Required = slices.DeleteFunc(slices.Clone(resource.Required), func(name string) bool {
+		return name == "name" || slices.Contains(deploymentOnlyProperties, name)
+	})
+	return &body
+}
+
+// readSource reads a file path or an http(s) URL.
+func readSource(source string) ([]byte, error) {
+	if u, err := url.Parse(source); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		resp, err := http.Get(source) // #nosec G107 -- CLI tool loads user-provided schema URLs
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetching %s: %s", source, resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	}
+	return os.ReadFile(source)
+}
+
+// resolveRelative resolves ref, a path relative to the file or URL base.
+func resolveRelative(base, ref string) string {
+	if u, err := url.Parse(base); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		u.Path = path.Join(path.Dir(u.Path), ref)
+		return u.String()
+	}
+	return filepath.Join(filepath.Dir(base), filepath.FromSlash(ref))
+}
@@ -0,0 +1,227 @@
+package armschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const expressionRef = `{"$ref": "https://schema.management.azure.com/schemas/common/definitions.json#/definitions/expression"}`
+
+const templateSchemaFixture = `{
+  "$schema": "http://json-schema.org/draft-04/schema#",
+  "resourceDefinitions": {
+    "widgets_old": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string", "enum": ["2023-01-01"]},
+        "type": {"type": "string", "enum": ["Microsoft.Test/widgets"]},
+        "name": {"type": "string"}
+      }
+    },
+    "widgets": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string", "enum": ["2024-01-01"]},
+        "type": {"type": "string", "enum": ["Microsoft.Test/widgets"]},
+        "name": {"type": "string", "pattern": "^[a-z]+$"},
+        "location": {"type": "string"},
+        "properties": {"oneOf": [{"$ref": "#/definitions/WidgetProperties"}, ` + expressionRef + `]},
+        "dependsOn": {"type": "array", "items": {"type": "string"}}
+      },
+      "required": ["name", "type", "apiVersion", "location"]
+    }
+  },
+  "definitions": {
+    "WidgetProperties": {
+      "type": "object",
+      "properties": {
+        "sku": {"oneOf": [{"type": "string", "enum": ["Basic", "Premium"]}, ` + expressionRef + `], "description": "The SKU."},
+        "parent": {"oneOf": [{"$ref": "#/definitions/WidgetProperties"}, ` + expressionRef + `]}
+      }
+    }
+  }
+}`
+
+const bicepTypesFixture = `[
+  {"$type": "StringType", "pattern": "^[a-z]+$"},
+  {"$type": "StringLiteralType", "value": "Microsoft.Test/widgets"},
+  {"$type": "StringLiteralType", "value": "2024-01-01"},
+  {"$type": "ObjectType", "name": "Microsoft.Test/widgets", "properties": {
+    "id": {"type": {"$ref": "#/4"}, "flags": 10, "description": "The resource id"},
+    "name": {"type": {"$ref": "#/0"}, "flags": 9, "description": "The resource name"},
+    "type": {"type": {"$ref": "#/1"}, "flags": 10, "description": "The resource type"},
+    "apiVersion": {"type": {"$ref": "#/2"}, "flags": 10, "description": "The resource api version"},
+    "properties": {"type": {"$ref": "#/5"}, "flags": 1, "description": "Widget properties"}
+  }},
+  {"$type": "StringType"},
+  {"$type": "ObjectType", "name": "WidgetProperties", "properties": {
+    "sku": {"type": {"$ref": "#/8"}, "flags": 1, "description": "The SKU"},
+    "capacity": {"type": {"$ref": "#/9"}, "flags": 0},
+    "password": {"type": {"$ref": "#/10"}, "flags": 4},
+    "provisioningState": {"type": {"$ref": "#/4"}, "flags": 2},
+    "parent": {"type": {"$ref": "#/5"}, "flags": 0}
+  }},
+  {"$type": "StringLiteralType", "value": "Basic"},
+  {"$type": "StringLiteralType", "value": "Premium"},
+  {"$type": "UnionType", "elements": [{"$ref": "#/6"}, {"$ref": "#/7"}]},
+  {"$type": "IntegerType", "minValue": 1, "maxValue": 10},
+  {"$type": "StringType", "sensitive": true},
+  {"$type": "ResourceType", "name": "Microsoft.Test/widgets@2024-01-01", "scopeType": 8, "body": {"$ref": "#/3"}, "flags": 0}
+]`
+
+func writeFixture(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestFindResource_TemplateSchema(t *testing.T) {
+	path := writeFixture(t, t.TempDir(), "Microsoft.Test.json", templateSchemaFixture)
+
+	resource, err := FindResource(path, "microsoft.test/WIDGETS")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01", resource.APIVersion)
+
+	schema := resource.Schema
+	assert.NotContains(t, schema.Properties, "apiVersion")
+	assert.NotContains(t, schema.Properties, "type")
+	assert.NotContains(t, schema.Properties, "dependsOn")
+	assert.Equal(t, []string{"location"}, schema.Required)
+	require.Contains(t, schema.Properties, "name")
+	assert.True(t, schema.Properties["name"].Value.ReadOnly)
+	assert.Equal(t, "^[a-z]+$", schema.Properties["name"].Value.Pattern)
+
+	// The expression alternatives are dropped, leaving the value schema itself.
+	props := schema.Properties["properties"]
+	require.NotNil(t, props.Value)
+	assert.Equal(t, "#/definitions/WidgetProperties", props.Ref)
+	assert.True(t, props.Value.Type.Is(openapi3.TypeObject))
+	sku := props.Value.Properties["sku"].Value
+	assert.True(t, sku.Type.Is(openapi3.TypeString))
+	assert.Equal(t, []any{"Basic", "Premium"}, sku.Enum)
+	assert.Equal(t, "The SKU.", sku.Description)
+	assert.Empty(t, sku.OneOf)
+	// Recursive definitions resolve to the same schema.
+	assert.Same(t, props.Value, props.Value.Properties["parent"].Value)
+
+	_, err = FindResource(path, "Microsoft.Test/gadgets")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestFindResource_BicepTypes(t *testing.T) {
+	dir := t.TempDir()
+	typesPath := writeFixture(t, dir, "test/microsoft.test/2024-01-01/types.json", bicepTypesFixture)
+
+	assertWidget := func(t *testing.T, resource *Resource) {
+		t.Helper()
+		assert.Equal(t, "2024-01-01", resource.APIVersion)
+
+		schema := resource.Schema
+		assert.NotContains(t, schema.Properties, "apiVersion")
+		assert.NotContains(t, schema.Properties, "type")
+		assert.True(t, schema.Properties["id"].Value.ReadOnly)
+		assert.True(t, schema.Properties["name"].Value.ReadOnly)
+		assert.Equal(t, "^[a-z]+$", schema.Properties["name"].Value.Pattern)
+		assert.Equal(t, []string{"properties"}, schema.Required)
+
+		props := schema.Properties["properties"].Value
+		assert.Equal(t, "Widget properties", props.Description)
+		assert.ElementsMatch(t, []string{"sku"}, props.Required)
+		sku := props.Properties["sku"].Value
+		assert.True(t, sku.Type.Is(openapi3.TypeString))
+		assert.Equal(t, []any{"Basic", "Premium"}, sku.Enum)
+		assert.Equal(t, "The SKU", sku.Description)
+		capacity := props.Properties["capacity"].Value
+		require.NotNil(t, capacity.Min)
+		require.NotNil(t, capacity.Max)
+		assert.Equal(t, 1.0, *capacity.Min)
+		assert.Equal(t, 10.0, *capacity.Max)
+		assert.Equal(t, true, props.Properties["password"].Value.Extensions["x-ms-secret"])
+		assert.True(t, props.Properties["provisioningState"].Value.ReadOnly)
+		assert.True(t, props.Properties["parent"].Value.Type.Is(openapi3.TypeObject))
+	}
+
+	t.Run("types", func(t *testing.T) {
+		resource, err := FindResource(typesPath, "Microsoft.Test/widgets")
+		require.NoError(t, err)
+		assertWidget(t, resource)
+	})
+
+	t.Run("index", func(t *testing.T) {
+		indexPath := writeFixture(t, dir, "index.json", `{
+  "resources": {
+    "Microsoft.Test/widgets@2024-01-01": {"$ref": "test/microsoft.test/2024-01-01/types.json#/11"}
+  },
+  "resourceFunctions": {}
+}`)
+		resource, err := FindResource(indexPath, "Microsoft.Test/widgets")
+		require.NoError(t, err)
+		assertWidget(t, resource)
+	})
+}
+
+func TestFindResource_PrefersStableOverSameDatePreview(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("template schema", func(t *testing.T) {
+		path := writeFixture(t, dir, "Microsoft.Test.json", `{
+  "resourceDefinitions": {
+    "widgets": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string", "enum": ["2024-01-01"]},
+        "type": {"type": "string", "enum": ["Microsoft.Test/widgets"]}
+      }
+    },
+    "widgets_preview": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string", "enum": ["2024-01-01-preview"]},
+        "type": {"type": "string", "enum": ["Microsoft.Test/widgets"]}
+      }
+    },
+    "widgets_old": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string", "enum": ["2023-06-01"]},
+        "type": {"type": "string", "enum": ["Microsoft.Test/widgets"]}
+      }
+    }
+  },
+  "definitions": {}
+}`)
+		resource, err := FindResource(path, "Microsoft.Test/widgets")
+		require.NoError(t, err)
+		assert.Equal(t, "2024-01-01", resource.APIVersion)
+	})
+
+	t.Run("bicep index", func(t *testing.T) {
+		writeFixture(t, dir, "test/microsoft.test/2024-01-01/types.json", bicepTypesFixture)
+		indexPath := writeFixture(t, dir, "index.json", `{
+  "resources": {
+    "Microsoft.Test/widgets@2024-01-01-preview": {"$ref": "test/microsoft.test/2024-01-01/types.json#/11"},
+    "Microsoft.Test/widgets@2024-01-01": {"$ref": "test/microsoft.test/2024-01-01/types.json#/11"}
+  },
+  "resourceFunctions": {}
+}`)
+		resource, err := FindResource(indexPath, "Microsoft.Test/widgets")
+		require.NoError(t, err)
+		assert.Equal(t, "2024-01-01", resource.APIVersion)
+	})
+}
+
+func TestFindResource_UnknownFormat(t *testing.T) {
+	path := writeFixture(t, t.TempDir(), "spec.json", `{"swagger": "2.0", "paths": {}}`)
+
+	_, err := FindResource(path, "Microsoft.Test/widgets")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "neither an ARM template schema nor Bicep types")
+}
@@ -0,0 +1,333 @@
+package armschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/matt-FFFFFF/tfmodmake/openapi"
+)
+
+// Bicep property flags.
+const (
+	bicepFlagRequired = 1 << 0
+	bicepFlagReadOnly = 1 << 1
+)
+
+// bicepRef refers to a type by index, "#/12" within the same types.json or
+// "path/types.json#/12" from an index.json.
+type bicepRef struct {
+	Ref string `json:"$ref"`
+}
+
+type bicepProperty struct {
+	Type        bicepRef `json:"type"`
+	Flags       int      `json:"flags"`
+	Description string   `json:"description"`
+}
+
+// bicepType is an entry of a Bicep types.json, one of the types its $type names.
+type bicepType struct {
+	Kind string `json:"$type"`
+	Name string `json:"name"`
+
+	// ResourceType
+	Body *bicepRef `json:"body"`
+
+	// ObjectType and DiscriminatedObjectType
+	Properties           map[string]bicepProperty `json:"properties"`
+	AdditionalProperties *bicepRef                `json:"additionalProperties"`
+	Discriminator        string                   `json:"discriminator"`
+	BaseProperties       map[string]bicepProperty `json:"baseProperties"`
+	// Elements is a list of refs for a UnionType and a map of them by discriminator value for a
+	// DiscriminatedObjectType.
+	Elements json.RawMessage `json:"elements"`
+
+	// ArrayType
+	ItemType *bicepRef `json:"itemType"`
+
+	// StringType, ArrayType, IntegerType and StringLiteralType
+	MinLength *uint64 `json:"minLength"`
+	MaxLength *uint64 `json:"maxLength"`
+	Pattern   string  `json:"pattern"`
+	Sensitive bool    `json:"sensitive"`
+	MinValue  *int64  `json:"minValue"`
+	MaxValue  *int64  `json:"maxValue"`
+	Value     any     `json:"value"`
+}
+
+// findBicepResource returns resourceType from the Bicep types.json data read from source.
+func findBicepResource(source string, data []byte, resourceType string) (*Resource, error) {
+	var types []bicepType
+	if err := json.Unmarshal(data, &types); err != nil {
+		return nil, fmt.Errorf("parsing Bicep types %s: %w", source, err)
+	}
+
+	byVersion := make(map[string]int)
+	for i, t := range types {
+		if t.Kind != "ResourceType" {
+			continue
+		}
+		name, version, _ := strings.Cut(t.Name, "@")
+		if strings.EqualFold(name, resourceType) {
+			byVersion[version] = i
+		}
+	}
+	if len(byVersion) == 0 {
+		return nil, fmt.Errorf("resource type %s not found in Bicep types %s", resourceType, source)
+	}
+	apiVersion := slices.MaxFunc(slices.Collect(maps.Keys(byVersion)), openapi.CompareAPIVersions)
+	return bicepResource(types, byVersion[apiVersion], apiVersion)
+}
+
+// findBicepIndexResource returns resourceType from the types.json the resources of the Bicep
+// index.json at source refer to.
+func findBicepIndexResource(source string, data json.RawMessage, resourceType string) (*Resource, error) {
+	var resources map[string]bicepRef
+	if err := json.Unmarshal(data, &resources); err != nil {
+		return nil, fmt.Errorf("parsing Bicep index %s: %w", source, err)
+	}
+
+	byVersion := make(map[string]string)
+	for key, ref := range resources {
+		name, version, _ := strings.Cut(key, "@")
+		if strings.EqualFold(name, resourceType) {
+			byVersion[version] = ref.Ref
+		}
+	}
+	if len(byVersion) == 0 {
+		return nil, fmt.Errorf("resource type %s not found in Bicep index %s", resourceType, source)
+	}
+	apiVersion := slices.MaxFunc(slices.Collect(maps.Keys(byVersion)), openapi.CompareAPIVersions)
+
+	file, index, ok := strings.Cut(byVersion[apiVersion], "#/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported Bicep type reference %q", byVersion[apiVersion])
+	}
+	typesSource := resolveRelative(source, file)
+	typesData, err := readSource(typesSource)
+	if err != nil {
+		return nil, err
+	}
+	var types []bicepType
+	if err := json.Unmarshal(typesData, &types); err != nil {
+		return nil, fmt.Errorf("parsing Bicep types %s: %w", typesSource, err)
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 || i >= len(types) {
+		return nil, fmt.Errorf("unsupported Bicep type reference %q", byVersion[apiVersion])
+	}
+	return bicepResource(types, i, apiVersion)
+}
+
+// bicepResource converts the body of the ResourceType types[i].
+func bicepResource(types []bicepType, i int, apiVersion string) (*Resource, error) {
+	if types[i].Kind != "ResourceType" || types[i].Body == nil {
+		return nil, fmt.Errorf("bicep type %d is not a resource type", i)
+	}
+	c := &bicepConverter{types: types, schemas: make(map[int]*openapi3.Schema)}
+	body, err := c.schemaFor(*types[i].Body)
+	if err != nil {
+		return nil, err
+	}
+	for _, finish := range c.pending {
+		finish()
+	}
+	return &Resource{Schema: requestBody(body), APIVersion: apiVersion}, nil
+}
+
+// bicepConverter converts Bicep types into schemas. Types refer to each other, recursively at
+// times, so each is converted once; a property's read-only flag and description live on a
+// copy of its type made once every type is converted, through pending.
+type bicepConverter struct {
+	types   []bicepType
+	schemas map[int]*openapi3.Schema
+	pending []func()
+}
+
+// lookup returns the index of the type ref refers to within the same types.json.
+func (c *bicepConverter) lookup(ref bicepRef) (int, error) {
+	index, ok := strings.CutPrefix(ref.Ref, "#/")
+	if !ok {
+		return 0, fmt.Errorf("unsupported Bicep type reference %q", ref.Ref)
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 || i >= len(c.types) {
+		return 0, fmt.Errorf("unsupported Bicep type reference %q", ref.Ref)
+	}
+	return i, nil
+}
+
+func (c *bicepConverter) schemaFor(ref bicepRef) (*openapi3.Schema, error) {
+	i, err := c.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	if schema, ok := c.schemas[i]; ok {
+		return schema, nil
+	}
+	schema := &openapi3.Schema{}
+	c.schemas[i] = schema
+
+	t := c.types[i]
+	switch t.Kind {
+	case "StringType":
+		schema.Type = &openapi3.Types{openapi3.TypeString}
+		schema.Pattern = t.Pattern
+		if t.MinLength != nil {
+			schema.MinLength = *t.MinLength
+		}
+		schema.MaxLength = t.MaxLength
+		if t.Sensitive {
+			schema.Extensions = map[string]any{"x-ms-secret": true}
+		}
+	case "StringLiteralType":
+		schema.Type = &openapi3.Types{openapi3.TypeString}
+		schema.Enum = []any{t.Value}
+	case "IntegerType":
+		schema.Type = &openapi3.Types{openapi3.TypeInteger}
+		if t.MinValue != nil {
+			minValue := float64(*t.MinValue)
+			schema.Min = &minValue
+		}
+		if t.MaxValue != nil {
+			maxValue := float64(*t.MaxValue)
+			schema.Max = &maxValue
+		}
+	case "BooleanType":
+		schema.Type = &openapi3.Types{openapi3.TypeBoolean}
+	case "AnyType":
+	case "ArrayType":
+		schema.Type = &openapi3.Types{openapi3.TypeArray}
+		if t.MinLength != nil {
+			schema.MinItems = *t.MinLength
+		}
+		schema.MaxItems = t.MaxLength
+		if t.ItemType != nil {
+			items, err := c.schemaFor(*t.ItemType)
+			if err != nil {
+				return nil, err
+			}
+			schema.Items = &openapi3.SchemaRef{Value: items}
+		}
+	case "ObjectType":
+		schema.Type = &openapi3.Types{openapi3.TypeObject}
+		if err := c.addProperties(schema, t.Properties, true); err != nil {
+			return nil, err
+		}
+		if t.AdditionalProperties != nil {
+			additional, err := c.schemaFor(*t.AdditionalProperties)
+			if err != nil {
+				return nil, err
+			}
+			schema.AdditionalProperties.Schema = &openapi3.SchemaRef{Value: additional}
+		}
+	case "DiscriminatedObjectType":
+		// The generator has no notion of variants, so the object offers the properties of
+		// all of them, none required, and the discriminator selects the variant.
+		schema.Type = &openapi3.Types{openapi3.TypeObject}
+		if err := c.addProperties(schema, t.BaseProperties, true); err != nil {
+			return nil, err
+		}
+		var elements map[string]bicepRef
+		if err := json.Unmarshal(t.Elements, &elements); err != nil {
+			return nil, fmt.Errorf("parsing elements of Bicep type %s: %w", t.Name, err)
+		}
+		variants := slices.Sorted(maps.Keys(elements))
+		for _, variant := range variants {
+			element, err := c.lookup(elements[variant])
+			if err != nil {
+				return nil, err
+			}
+			if err := c.addProperties(schema, c.types[element].Properties, false); err != nil {
+				return nil, err
+			}
+		}
+		discriminator := &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}}
+		for _, variant := range variants {
+			discriminator.Enum = append(discriminator.Enum, variant)
+		}
+		schema.Properties[t.Discriminator] = &openapi3.SchemaRef{Value: discriminator}
+		if !slices.Contains(schema.Required, t.Discriminator) {
+			schema.Required = append(schema.Required, t.Discriminator)
+		}
+	case "UnionType":
+		var elements []bicepRef
+		if err := json.Unmarshal(t.Elements, &elements); err != nil {
+			return nil, fmt.Errorf("parsing elements of Bicep type %s: %w", t.Name, err)
+		}
+		// Unions of string literals are enums; a union with a free string is an extensible
+		// enum, so any string.
+		literals, allStrings := true, true
+		var values []any
+		for _, element := range elements {
+			j, err := c.lookup(element)
+			if err != nil {
+				return nil, err
+			}
+			switch c.types[j].Kind {
+			case "StringLiteralType":
+				values = append(values, c.types[j].Value)
+			case "StringType":
+				literals = false
+			default:
+				literals, allStrings = false, false
+			}
+		}
+		switch {
+		case allStrings:
+			schema.Type = &openapi3.Types{openapi3.TypeString}
+			if literals {
+				schema.Enum = values
+			}
+		default:
+			for _, element := range elements {
+				alternative, err := c.schemaFor(element)
+				if err != nil {
+					return nil, err
+				}
+				schema.OneOf = append(schema.OneOf, &openapi3.SchemaRef{Value: alternative})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported Bicep type %s", t.Kind)
+	}
+	return schema, nil
+}
+
+// addProperties adds the converted properties to schema, marking the required ones when
+// withRequired is set.
+func (c *bicepConverter) addProperties(schema *openapi3.Schema, properties map[string]bicepProperty, withRequired bool) error {
+	if schema.Properties == nil {
+		schema.Properties = make(openapi3.Schemas, len(properties))
+	}
+	for _, name := range slices.Sorted(maps.Keys(properties)) {
+		prop := properties[name]
+		if _, ok := schema.Properties[name]; ok {
+			continue
+		}
+		target, err := c.schemaFor(prop.Type)
+		if err != nil {
+			return err
+		}
+		ref := &openapi3.SchemaRef{Value: target}
+		schema.Properties[name] = ref
+		if withRequired && prop.Flags&bicepFlagRequired != 0 {
+			schema.Required = append(schema.Required, name)
+		}
+		if prop.Flags&bicepFlagReadOnly != 0 || prop.Description != "" {
+			c.pending = append(c.pending, func() {
+				value := *target
+				value.ReadOnly = prop.Flags&bicepFlagReadOnly != 0
+				value.Description = prop.Description
+				value.Extensions = maps.Clone(target.Extensions)
+				ref.Value = &value
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package armschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/matt-FFFFFF/tfmodmake/openapi"
+)
+
+// templateSchema is an ARM template deployment schema: JSON Schema draft 4 with the deployable
+// resources under resourceDefinitions, or its subscription_, tenant_, managementGroup_ and
+// extension_ variants, and the types they use under definitions.
+type templateSchema struct {
+	Definitions map[string]*openapi3.SchemaRef `json:"definitions"`
+}
+
+// expressionDefinition ends the $ref of the common definition of an ARM template expression,
+// offered as an alternative to the value of every property.
+const expressionDefinition = "/definitions/expression"
+
+// findTemplateResource returns resourceType from the ARM template schema data.
+func findTemplateResource(data []byte, resourceType string) (*Resource, error) {
+	var doc templateSchema
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing ARM template schema: %w", err)
+	}
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, fmt.Errorf("parsing ARM template schema: %w", err)
+	}
+
+	var matches []*openapi3.Schema
+	var versions []string
+	for _, key := range slices.Sorted(maps.Keys(top)) {
+		if !strings.HasSuffix(strings.ToLower(key), "resourcedefinitions") {
+			continue
+		}
+		var resources map[string]*openapi3.SchemaRef
+		if err := json.Unmarshal(top[key], &resources); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", key, err)
+		}
+		for _, ref := range resources {
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			if !strings.EqualFold(singleEnumValue(ref.Value.Properties["type"]), resourceType) {
+				continue
+			}
+			matches = append(matches, ref.Value)
+			versions = append(versions, singleEnumValue(ref.Value.Properties["apiVersion"]))
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("resource type %s not found in ARM template schema", resourceType)
+	}
+	apiVersion := slices.MaxFunc(versions, openapi.CompareAPIVersions)
+	resource := matches[slices.Index(versions, apiVersion)]
+
+	if err := resolveTemplateRefs(&openapi3.SchemaRef{Value: resource}, doc.Definitions, make(map[*openapi3.Schema]struct{})); err != nil {
+		return nil, err
+	}
+	dropExpressions(resource, make(map[*openapi3.Schema]struct{}))
+
+	return &Resource{Schema: requestBody(resource), APIVersion: apiVersion}, nil
+}
+
+// singleEnumValue returns the value of a single-valued string enum, such as the type and
+// apiVersion of a template resource, or "" when ref is not one.
+func singleEnumValue(ref *openapi3.SchemaRef) string {
+	if ref == nil || ref.Value == nil || len(ref.Value.Enum) != 1 {
+		return ""
+	}
+	value, _ := ref.Value.Enum[0].(string)
+	return value
+}
+
+// resolveTemplateRefs points every local #/definitions/ $ref below ref at its definition.
+// Other external refs, e.g. to the common definitions, resolve to an unconstrained schema,
+// apart from the expression alternative dropExpressions removes.
+func resolveTemplateRefs(ref *openapi3.SchemaRef, definitions map[string]*openapi3.SchemaRef, visited map[*openapi3.Schema]struct{}) error {
+	if ref == nil {
+		return nil
+	}
+	if ref.Value == nil && ref.Ref != "" {
+		switch {
+		case strings.HasSuffix(ref.Ref, expressionDefinition):
+			return nil
+		case strings.HasPrefix(ref.Ref, "#/definitions/"):
+			name := strings.TrimPrefix(ref.Ref, "#/definitions/")
+			seen := map[string]struct{}{}
+			target := definitions[name]
+			for target != nil && target.Value == nil && strings.HasPrefix(target.Ref, "#/definitions/") {
+				if _, ok := seen[target.Ref]; ok {
+					return fmt.Errorf("circular $ref %s", target.Ref)
+				}
+				seen[target.Ref] = struct{}{}
+				target = definitions[strings.TrimPrefix(target.Ref, "#/definitions/")]
+			}
+			if target == nil || target.Value == nil {
+				return fmt.Errorf("unresolved $ref %s", ref.Ref)
+			}
+			ref.Value = target.Value
+		default:
+			ref.Value = &openapi3.Schema{}
+		}
+	}
+	schema := ref.Value
+	if schema == nil {
+		return nil
+	}
+	if _, ok := visited[schema]; ok {
+		return nil
+	}
+	visited[schema] = struct{}{}
+
+	for _, refs := range []openapi3.SchemaRefs{schema.AllOf, schema.OneOf, schema.AnyOf} {
+		for _, child := range refs {
+			if err := resolveTemplateRefs(child, definitions, visited); err != nil {
+				return err
+			}
+		}
+	}
+	for _, child := range schema.Properties {
+		if err := resolveTemplateRefs(child, definitions, visited); err != nil {
+			return err
+		}
+	}
+	if err := resolveTemplateRefs(schema.Items, definitions, visited); err != nil {
+		return err
+	}
+	return resolveTemplateRefs(schema.AdditionalProperties.Schema, definitions, visited)
+}
+
+// dropExpressions removes the ARM template expression alternative from the oneOf or anyOf
+// wrapping each value below schema. A wrapper left with a single alternative is replaced by
+// it, keeping the wrapper's description.
+func dropExpressions(schema *openapi3.Schema, visited map[*openapi3.Schema]struct{}) {
+	if schema == nil {
+		return
+	}
+	if _, ok := visited[schema]; ok {
+		return
+	}
+	visited[schema] = struct{}{}
+
+	unwrap := func(ref *openapi3.SchemaRef) {
+		if ref == nil || ref.Value == nil {
+			return
+		}
+		wrapper := ref.Value
+		if alternative := withoutExpressions(wrapper); alternative != nil {
+			value := alternative.Value
+			if wrapper.Description != "" && value.Description != wrapper.Description {
+				described := *value
+				described.Description = wrapper.Description
+				described.Extensions = maps.Clone(value.Extensions)
+				value = &described
+			}
+			ref.Ref = alternative.Ref
+			ref.Value = value
+		}
+		dropExpressions(ref.Value, visited)
+	}
+
+	for _, refs := range []openapi3.SchemaRefs{schema.AllOf, schema.OneOf, schema.AnyOf} {
+		for _, ref := range refs {
+			unwrap(ref)
+		}
+	}
+	for _, ref := range schema.Properties {
+		unwrap(ref)
+	}
+	unwrap(schema.Items)
+	unwrap(schema.AdditionalProperties.Schema)
+}
+
+// withoutExpressions drops the expression alternatives from the oneOf and anyOf of schema and
+// returns the only alternative left when schema is nothing but that wrapper, or nil.
+func withoutExpressions(schema *openapi3.Schema) *openapi3.SchemaRef {
+	isExpression := func(ref *openapi3.SchemaRef) bool {
+		return ref == nil || ref.Value == nil || strings.HasSuffix(ref.Ref, expressionDefinition)
+	}
+	schema.OneOf = slices.DeleteFunc(schema.OneOf, isExpression)
+	schema.AnyOf = slices.DeleteFunc(schema.AnyOf, isExpression)
+
+	alternatives := append(slices.Clone(schema.OneOf), schema.AnyOf...)
+	if len(alternatives) != 1 || schema.Type != nil || len(schema.Properties) > 0 || len(schema.AllOf) > 0 {
+		return nil
+	}
+	return alternatives[0]
+}
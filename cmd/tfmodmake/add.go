@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/matt-FFFFFF/tfmodmake/openapi"
@@ -35,6 +36,74 @@ func AddCommand() *cli.Command {
 				},
 				Action: runAddAVMInterfaces,
 			},
+			{
+				Name:      "outputs",
+				Usage:     "Add computed outputs to outputs.tf, preserving existing outputs",
+				ArgsUsage: "[path]",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:     "spec",
+						Usage:    "Path or URL to the OpenAPI specification",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name:  "strict-outputs",
+						Usage: "Fail with an output precondition when a computed attribute is missing from the response, instead of returning null",
+					},
+					&cli.StringFlag{
+						Name:  "resource-name",
+						Value: "this",
+						Usage: "Name label of the module's resource block, referenced by the outputs",
+					},
+					&cli.StringFlag{
+						Name:  "acronyms",
+						Usage: "File listing domain acronyms, one per line (e.g. VNet), kept as one word in snake_case names",
+					},
+				},
+				Action: runAddOutputs,
+			},
+			{
+				Name:      "locals",
+				Usage:     "Regenerate locals.tf from the spec, leaving the other module files untouched",
+				ArgsUsage: "[path]",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:     "spec",
+						Usage:    "Path or URL to the OpenAPI specification",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "local-name",
+						Value: "resource_body",
+						Usage: "Name of the body local referenced by main.tf",
+					},
+					&cli.BoolFlag{
+						Name:  "identifier-maps",
+						Usage: "Rebuild lists from map variables generated with gen -identifier-maps",
+					},
+					&cli.BoolFlag{
+						Name:  "heuristic-secrets",
+						Usage: "Keep the secrets found by gen -heuristic-secrets out of the body, as they are sent through sensitive_body",
+					},
+					&cli.BoolFlag{
+						Name:  "disambiguate-collisions",
+						Usage: "Refer to the variables gen -disambiguate-collisions renamed with a _2, _3, ... suffix",
+					},
+					&cli.BoolFlag{
+						Name:  "ignore-null-property",
+						Usage: "Leave null attributes in the body objects, for a module generated with gen -ignore-null-property",
+					},
+					&cli.StringSliceFlag{
+						Name:  "default-tags",
+						Usage: "Module tag as key=value (repeatable), as given to gen -default-tags, so local.tags is kept",
+					},
+					&cli.StringFlag{
+						Name:  "acronyms",
+						Usage: "File listing domain acronyms, one per line (e.g. VNet), kept as one word in snake_case names",
+					},
+				},
+				Action: runAddLocals,
+			},
 		},
 	}
 }
@@ -68,7 +137,7 @@ func runAddAVMInterfaces(ctx context.Context, cmd *cli.Command) error {
 	}
 	defer os.Chdir(originalDir)
 
-	finalResourceType, err := inferResourceTypeFromMainTf()
+	finalResourceType, err := inferResourceTypeFromMainTf(".")
 	if err != nil {
 		return fmt.Errorf("failed to infer resource type from main.tf: %w\nEnsure main.tf exists in %s", err, targetDir)
 	}
@@ -88,3 +157,82 @@ func runAddAVMInterfaces(ctx context.Context, cmd *cli.Command) error {
 	fmt.Println("Successfully generated main.interfaces.tf")
 	return nil
 }
+
+func runAddOutputs(ctx context.Context, cmd *cli.Command) error {
+	specs := cmd.StringSlice("spec")
+	targetDir := "."
+	if cmd.NArg() > 0 {
+		targetDir = cmd.Args().First()
+	}
+
+	resourceType, err := inferResourceTypeFromMainTf(targetDir)
+	if err != nil {
+		return fmt.Errorf("failed to infer resource type from main.tf: %w\nEnsure main.tf exists in %s", err, targetDir)
+	}
+
+	result, err := terraform.LoadResource(ctx, specs, resourceType)
+	if err != nil {
+		return fmt.Errorf("failed to load resource: %w", err)
+	}
+	acronyms, err := readAcronymsFile(cmd.String("acronyms"))
+	if err != nil {
+		return err
+	}
+
+	if err := terraform.GenerateOutputsFile(result, terraform.WithOutputDir(targetDir), terraform.WithStrictOutputs(cmd.Bool("strict-outputs")), terraform.WithResourceName(cmd.String("resource-name")), terraform.WithAcronyms(acronyms)); err != nil {
+		return fmt.Errorf("failed to generate outputs: %w", err)
+	}
+
+	fmt.Println("Successfully updated outputs.tf")
+	return nil
+}
+
+func runAddLocals(ctx context.Context, cmd *cli.Command) error {
+	specs := cmd.StringSlice("spec")
+	localName := cmd.String("local-name")
+	targetDir := "."
+	if cmd.NArg() > 0 {
+		targetDir = cmd.Args().First()
+	}
+
+	resourceType, err := inferResourceTypeFromMainTf(targetDir)
+	if err != nil {
+		return fmt.Errorf("failed to infer resource type from main.tf: %w\nEnsure main.tf exists in %s", err, targetDir)
+	}
+
+	result, err := terraform.LoadResource(ctx, specs, resourceType)
+	if err != nil {
+		return fmt.Errorf("failed to load resource: %w", err)
+	}
+	acronyms, err := readAcronymsFile(cmd.String("acronyms"))
+	if err != nil {
+		return err
+	}
+	defaultTags, err := parseDefaultTags(cmd.StringSlice("default-tags"))
+	if err != nil {
+		return err
+	}
+
+	// Child modules generated by gen avm or gen submodule rename "version" with this prefix.
+	missing, err := terraform.GenerateLocalsFile(resourceType,
+		result,
+		terraform.WithOutputDir(targetDir),
+		terraform.WithLocalName(localName),
+		terraform.WithModuleNamePrefix(deriveModuleName(resourceType)),
+		terraform.WithIdentifierMaps(cmd.Bool("identifier-maps")),
+		terraform.WithDisambiguateCollisions(cmd.Bool("disambiguate-collisions")),
+		terraform.WithHeuristicSecrets(cmd.Bool("heuristic-secrets")),
+		terraform.WithIgnoreNullProperty(cmd.Bool("ignore-null-property")),
+		terraform.WithDefaultTags(defaultTags),
+		terraform.WithAcronyms(acronyms),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to generate locals: %w", err)
+	}
+
+	if len(missing) > 0 {
+		loggerFromContext(ctx).Warn("left out of locals.tf as variables.tf does not declare them: " + strings.Join(missing, ", "))
+	}
+	fmt.Println("Successfully regenerated locals.tf")
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+// avmManifestFile records the child modules produced by a `gen avm` run so a later run can
+// detect renamed children.
+const avmManifestFile = "avm.manifest.json"
+
+type avmManifest struct {
+	ResourceType string             `json:"resource_type"`
+	Children     []avmManifestChild `json:"children"`
+}
+
+type avmManifestChild struct {
+	ResourceType string `json:"resource_type"`
+	ModuleName   string `json:"module_name"`
+}
+
+// movedModule is a child module call whose address changed between runs.
+type movedModule struct {
+	From string
+	To   string
+}
+
+func readAVMManifest(path string) (*avmManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest avmManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	return &manifest, nil
+}
+
+// writeAVMManifest writes avm.manifest.json. Children are sorted by resource type so the file
+// is stable across runs, as discovery order is not.
+func writeAVMManifest(manifest *avmManifest) error {
+	sort.Slice(manifest.Children, func(i, j int) bool {
+		return manifest.Children[i].ResourceType < manifest.Children[j].ResourceType
+	})
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format manifest: %w", err)
+	}
+	return os.WriteFile(avmManifestFile, append(data, '\n'), 0o644)
+}
+
+// movedChildModules pairs children present in both manifests by resource type and returns
+// those whose module name changed, sorted by the new name.
+func movedChildModules(previous, current *avmManifest) []movedModule {
+	previousNames := make(map[string]string, len(previous.Children))
+	for _, child := range previous.Children {
+		previousNames[strings.ToLower(child.ResourceType)] = child.ModuleName
+	}
+
+	var moves []movedModule
+	for _, child := range current.Children {
+		from, ok := previousNames[strings.ToLower(child.ResourceType)]
+		if !ok || from == child.ModuleName {
+			continue
+		}
+		moves = append(moves, movedModule{From: from, To: child.ModuleName})
+	}
+	sort.Slice(moves, func(i, j int) bool {
+		return moves[i].To < moves[j].To
+	})
+	return moves
+}
+
+// writeMovedFile writes moved.tf with a moved block per renamed child module call, so
+// existing state follows the module to its new address.
+func writeMovedFile(moves []movedModule) error {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+	for i, move := range moves {
+		if i > 0 {
+			body.AppendNewline()
+		}
+		blockBody := body.AppendNewBlock("moved", nil).Body()
+		blockBody.SetAttributeRaw("from", hclgen.TokensForTraversal("module", move.From))
+		blockBody.SetAttributeRaw("to", hclgen.TokensForTraversal("module", move.To))
+	}
+	return hclgen.WriteFile("moved.tf", file)
+}
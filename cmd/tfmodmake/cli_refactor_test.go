@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -382,6 +383,522 @@ func TestAddAVMInterfacesWithInference(t *testing.T) {
 	}
 }
 
+// TestAddOutputs tests that `add outputs` appends computed outputs while preserving existing ones.
+func TestAddOutputs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testSpec := map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"version": "2024-01-01",
+		},
+		"paths": map[string]interface{}{
+			"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResources/{resourceName}": map[string]interface{}{
+				"put": map[string]interface{}{
+					"operationId": "TestResources_CreateOrUpdate",
+					"parameters": []interface{}{
+						map[string]interface{}{
+							"name":     "parameters",
+							"in":       "body",
+							"required": true,
+							"schema": map[string]interface{}{
+								"$ref": "#/definitions/TestResource",
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "OK",
+						},
+					},
+				},
+			},
+		},
+		"definitions": map[string]interface{}{
+			"TestResource": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"value": map[string]interface{}{
+								"type": "string",
+							},
+							"fqdn": map[string]interface{}{
+								"type":     "string",
+								"readOnly": true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	specPath := filepath.Join(tmpDir, "test_spec.json")
+	specData, err := json.MarshalIndent(testSpec, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal test spec: %v", err)
+	}
+	if err := os.WriteFile(specPath, specData, 0o644); err != nil {
+		t.Fatalf("Failed to write test spec: %v", err)
+	}
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	cmd := exec.Command(tfmodmakePath, "gen", "-spec", specPath, "-resource", "Microsoft.Test/testResources")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to generate base module: %v\n%s", err, output)
+	}
+
+	// Replace the generated outputs with a hand-written minimal set.
+	outputsPath := filepath.Join(tmpDir, "outputs.tf")
+	handWritten := "output \"custom\" {\n  value = \"kept\"\n}\n"
+	if err := os.WriteFile(outputsPath, []byte(handWritten), 0o644); err != nil {
+		t.Fatalf("Failed to write outputs.tf: %v", err)
+	}
+
+	cmd = exec.Command(tfmodmakePath, "add", "outputs", "-spec", specPath)
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run add outputs: %v\n%s", err, output)
+	}
+
+	content, err := os.ReadFile(outputsPath)
+	if err != nil {
+		t.Fatalf("Failed to read outputs.tf: %v", err)
+	}
+	contentStr := string(content)
+	for _, want := range []string{`output "custom"`, `output "resource_id"`, `output "name"`, `output "fqdn"`} {
+		if !strings.Contains(contentStr, want) {
+			t.Errorf("outputs.tf should contain %s, got:\n%s", want, contentStr)
+		}
+	}
+}
+
+func TestAddLocals(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeSpec := func(props map[string]interface{}) string {
+		testSpec := map[string]interface{}{
+			"swagger": "2.0",
+			"info": map[string]interface{}{
+				"version": "2024-01-01",
+			},
+			"paths": map[string]interface{}{
+				"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResources/{resourceName}": map[string]interface{}{
+					"put": map[string]interface{}{
+						"parameters": []interface{}{
+							map[string]interface{}{
+								"name":   "parameters",
+								"in":     "body",
+								"schema": map[string]interface{}{"$ref": "#/definitions/TestResource"},
+							},
+						},
+						"responses": map[string]interface{}{
+							"200": map[string]interface{}{"description": "OK"},
+						},
+					},
+				},
+			},
+			"definitions": map[string]interface{}{
+				"TestResource": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"properties": map[string]interface{}{
+							"type":       "object",
+							"properties": props,
+						},
+					},
+				},
+			},
+		}
+		specPath := filepath.Join(tmpDir, "test_spec.json")
+		specData, err := json.MarshalIndent(testSpec, "", "  ")
+		if err != nil {
+			t.Fatalf("Failed to marshal test spec: %v", err)
+		}
+		if err := os.WriteFile(specPath, specData, 0o644); err != nil {
+			t.Fatalf("Failed to write test spec: %v", err)
+		}
+		return specPath
+	}
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	specPath := writeSpec(map[string]interface{}{
+		"value": map[string]interface{}{"type": "string"},
+		"mode":  map[string]interface{}{"type": "string"},
+	})
+	cmd := exec.Command(tfmodmakePath, "gen", "-spec", specPath, "-resource", "Microsoft.Test/testResources")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to generate base module: %v\n%s", err, output)
+	}
+
+	// Hand-edit variables.tf and main.tf; both must survive add locals byte for byte.
+	variablesPath := filepath.Join(tmpDir, "variables.tf")
+	variables, err := os.ReadFile(variablesPath)
+	if err != nil {
+		t.Fatalf("Failed to read variables.tf: %v", err)
+	}
+	variables = append(variables, []byte("\n# hand-edited\n")...)
+	if err := os.WriteFile(variablesPath, variables, 0o644); err != nil {
+		t.Fatalf("Failed to write variables.tf: %v", err)
+	}
+	mainPath := filepath.Join(tmpDir, "main.tf")
+	mainTf, err := os.ReadFile(mainPath)
+	if err != nil {
+		t.Fatalf("Failed to read main.tf: %v", err)
+	}
+	mainTf = append(mainTf, []byte("\n# hand-edited\n")...)
+	if err := os.WriteFile(mainPath, mainTf, 0o644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+
+	// The spec drops "mode" and gains "tier", which has no variable yet.
+	specPath = writeSpec(map[string]interface{}{
+		"value": map[string]interface{}{"type": "string"},
+		"tier":  map[string]interface{}{"type": "string"},
+	})
+	cmd = exec.Command(tfmodmakePath, "add", "locals", "-spec", specPath)
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run add locals: %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "does not declare them: tier") {
+		t.Errorf("Expected a warning about the undeclared tier variable, got:\n%s", output)
+	}
+
+	locals, err := os.ReadFile(filepath.Join(tmpDir, "locals.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read locals.tf: %v", err)
+	}
+	localsStr := string(locals)
+	if !strings.Contains(localsStr, "var.value") {
+		t.Errorf("locals.tf should reference var.value, got:\n%s", localsStr)
+	}
+	for _, unwanted := range []string{"var.mode", "var.tier"} {
+		if strings.Contains(localsStr, unwanted) {
+			t.Errorf("locals.tf should not reference %s, got:\n%s", unwanted, localsStr)
+		}
+	}
+
+	for path, want := range map[string][]byte{variablesPath: variables, mainPath: mainTf} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s should be left untouched, got:\n%s", filepath.Base(path), got)
+		}
+	}
+}
+
+// TestAddLocalsHeuristicSecrets tests that add locals -heuristic-secrets keeps the secrets of a
+// module generated with gen -heuristic-secrets out of the body, as they are sent through
+// sensitive_body from ephemeral variables.
+func TestAddLocalsHeuristicSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testSpec := map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"version": "2024-01-01",
+		},
+		"paths": map[string]interface{}{
+			"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResources/{resourceName}": map[string]interface{}{
+				"put": map[string]interface{}{
+					"parameters": []interface{}{
+						map[string]interface{}{
+							"name":   "parameters",
+							"in":     "body",
+							"schema": map[string]interface{}{"$ref": "#/definitions/TestResource"},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{"description": "OK"},
+					},
+				},
+			},
+		},
+		"definitions": map[string]interface{}{
+			"TestResource": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"apiToken": map[string]interface{}{"type": "string"},
+							"mode":     map[string]interface{}{"type": "string"},
+						},
+					},
+				},
+			},
+		},
+	}
+	specPath := filepath.Join(tmpDir, "test_spec.json")
+	specData, err := json.MarshalIndent(testSpec, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal test spec: %v", err)
+	}
+	if err := os.WriteFile(specPath, specData, 0o644); err != nil {
+		t.Fatalf("Failed to write test spec: %v", err)
+	}
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	cmd := exec.Command(tfmodmakePath, "gen", "-spec", specPath, "-resource", "Microsoft.Test/testResources", "-heuristic-secrets")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to generate base module: %v\n%s", err, output)
+	}
+	generated, err := os.ReadFile(filepath.Join(tmpDir, "locals.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read locals.tf: %v", err)
+	}
+
+	cmd = exec.Command(tfmodmakePath, "add", "locals", "-spec", specPath, "-heuristic-secrets")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run add locals: %v\n%s", err, output)
+	}
+
+	locals, err := os.ReadFile(filepath.Join(tmpDir, "locals.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read locals.tf: %v", err)
+	}
+	if strings.Contains(string(locals), "api_token") {
+		t.Errorf("locals.tf should leave the api_token secret out of the body, got:\n%s", locals)
+	}
+	if string(locals) != string(generated) {
+		t.Errorf("add locals should reproduce the generated locals.tf, got:\n%s\nwant:\n%s", locals, generated)
+	}
+}
+
+// TestGenSchemaDefinition tests that `gen -schema-definition` generates from a named definition
+// rather than the resource's PUT body, while -resource and -override-api-version still drive main.tf.
+func TestGenSchemaDefinition(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testSpec := map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"version": "2024-01-01",
+		},
+		"paths": map[string]interface{}{
+			"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResources/{resourceName}": map[string]interface{}{
+				"put": map[string]interface{}{
+					"parameters": []interface{}{
+						map[string]interface{}{
+							"name":   "parameters",
+							"in":     "body",
+							"schema": map[string]interface{}{"$ref": "#/definitions/TestResource"},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{"description": "OK"},
+					},
+				},
+			},
+		},
+		"definitions": map[string]interface{}{
+			"TestResource": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"properties": map[string]interface{}{"$ref": "#/definitions/NetworkProfile"},
+				},
+			},
+			"NetworkProfile": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"subnetName": map[string]interface{}{"type": "string"},
+					"dns":        map[string]interface{}{"$ref": "#/definitions/DnsSettings"},
+				},
+			},
+			"DnsSettings": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"servers": map[string]interface{}{
+						"type":  "array",
+						"items": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+
+	specPath := filepath.Join(tmpDir, "test_spec.json")
+	specData, err := json.MarshalIndent(testSpec, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal test spec: %v", err)
+	}
+	if err := os.WriteFile(specPath, specData, 0o644); err != nil {
+		t.Fatalf("Failed to write test spec: %v", err)
+	}
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	cmd := exec.Command(tfmodmakePath, "gen",
+		"-spec", specPath,
+		"-resource", "Microsoft.Test/testResources",
+		"-schema-definition", "NetworkProfile",
+		"-override-api-version", "2024-06-01",
+	)
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to generate from schema definition: %v\n%s", err, output)
+	}
+
+	variables, err := os.ReadFile(filepath.Join(tmpDir, "variables.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read variables.tf: %v", err)
+	}
+	for _, want := range []string{`variable "subnet_name"`, `variable "dns"`, "servers = optional(list(string))"} {
+		if !strings.Contains(string(variables), want) {
+			t.Errorf("variables.tf should contain %s, got:\n%s", want, variables)
+		}
+	}
+
+	mainTf, err := os.ReadFile(filepath.Join(tmpDir, "main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read main.tf: %v", err)
+	}
+	if !strings.Contains(string(mainTf), `"Microsoft.Test/testResources@2024-06-01"`) {
+		t.Errorf("main.tf should use the -resource and -override-api-version values, got:\n%s", mainTf)
+	}
+
+	cmd = exec.Command(tfmodmakePath, "gen",
+		"-spec", specPath,
+		"-resource", "Microsoft.Test/testResources",
+		"-schema-definition", "Missing",
+	)
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected an error for an unknown schema definition")
+	}
+	if !strings.Contains(string(output), "schema definition Missing not found") {
+		t.Errorf("Unexpected error output: %s", output)
+	}
+}
+
+// TestValidateSpec tests that `validate-spec` reports generation-blocking issues
+func TestValidateSpec(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testSpec := map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"version": "2024-01-01",
+		},
+		"paths": map[string]interface{}{
+			"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResources/{resourceName}": map[string]interface{}{
+				"put": map[string]interface{}{
+					"parameters": []interface{}{
+						map[string]interface{}{
+							"name":   "parameters",
+							"in":     "body",
+							"schema": map[string]interface{}{"$ref": "#/definitions/TestResource"},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{"description": "OK"},
+					},
+				},
+			},
+		},
+		"definitions": map[string]interface{}{
+			"TestResource": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							// Collides with the module's name variable once flattened.
+							"name":     map[string]interface{}{"type": "string"},
+							"settings": map[string]interface{}{"description": "No type."},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	specPath := filepath.Join(tmpDir, "test_spec.json")
+	specData, err := json.MarshalIndent(testSpec, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal test spec: %v", err)
+	}
+	if err := os.WriteFile(specPath, specData, 0o644); err != nil {
+		t.Fatalf("Failed to write test spec: %v", err)
+	}
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	cmd := exec.Command(tfmodmakePath, "validate-spec", "-spec", specPath, "-resource", "Microsoft.Test/testResources", "-json")
+	cmd.Dir = tmpDir
+	output, err := cmd.Output()
+	if err == nil {
+		t.Fatalf("Expected validate-spec to fail for a spec with a name collision")
+	}
+
+	var report struct {
+		Issues []struct {
+			Severity string `json:"severity"`
+			Code     string `json:"code"`
+			Path     string `json:"path"`
+			Message  string `json:"message"`
+		} `json:"issues"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("Failed to parse JSON report: %v\n%s", err, output)
+	}
+
+	var foundCollision, foundUntyped bool
+	for _, issue := range report.Issues {
+		if issue.Code == "name-collision" && issue.Severity == "error" && strings.Contains(issue.Message, "properties.name") {
+			foundCollision = true
+		}
+		if issue.Code == "untyped-property" && issue.Path == "properties.settings" {
+			foundUntyped = true
+		}
+	}
+	if !foundCollision {
+		t.Errorf("Expected a name-collision error for properties.name, got: %s", output)
+	}
+	if !foundUntyped {
+		t.Errorf("Expected an untyped-property warning for properties.settings, got: %s", output)
+	}
+
+	// No files are generated.
+	if _, err := os.Stat(filepath.Join(tmpDir, "variables.tf")); !os.IsNotExist(err) {
+		t.Errorf("validate-spec should not write variables.tf")
+	}
+}
+
 // TestAddSubmodule tests that `add submodule` works correctly
 func TestAddSubmodule(t *testing.T) {
 	// Create a minimal dummy submodule
@@ -558,6 +1075,209 @@ func TestDiscoverChildren(t *testing.T) {
 	if !strings.Contains(outputStr, "Microsoft.Test/parents/children") {
 		t.Errorf("Expected text output to contain child resource type, got: %s", outputStr)
 	}
+
+	// Test JSON output with each child's input schema
+	schemaCmd := exec.Command(tfmodmakePath, "discover", "children", "-spec", specPath, "-parent", "Microsoft.Test/parents", "-json", "-json-schema")
+	schemaOutput, err := schemaCmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run discover children -json-schema: %v\n%s", err, schemaOutput)
+	}
+	var schemaResult struct {
+		Deployable []struct {
+			ResourceType string
+			Schema       struct {
+				Properties map[string]struct {
+					Properties map[string]any `json:"properties"`
+				} `json:"properties"`
+			} `json:"schema"`
+		} `json:"deployable"`
+	}
+	if err := json.Unmarshal(schemaOutput, &schemaResult); err != nil {
+		t.Fatalf("Failed to parse -json-schema output: %v\nOutput: %s", err, schemaOutput)
+	}
+	if len(schemaResult.Deployable) != 1 {
+		t.Fatalf("Expected one deployable child, got: %s", schemaOutput)
+	}
+	if _, ok := schemaResult.Deployable[0].Schema.Properties["properties"].Properties["childValue"]; !ok {
+		t.Errorf("Expected the child's schema to include properties.childValue, got: %s", schemaOutput)
+	}
+
+	textSchemaCmd := exec.Command(tfmodmakePath, "discover", "children", "-spec", specPath, "-parent", "Microsoft.Test/parents", "-json-schema")
+	if output, err := textSchemaCmd.CombinedOutput(); err == nil {
+		t.Errorf("Expected -json-schema without -json to fail, got:\n%s", output)
+	}
+
+	exactCmd := exec.Command(tfmodmakePath, "discover", "children", "-spec", specPath, "-parent", "Microsoft.Test/parents", "-spec-version-select", "exact", "-parent-version", "2024-01-01")
+	if output, err := exactCmd.CombinedOutput(); err != nil {
+		t.Errorf("Failed to run discover children -spec-version-select exact: %v\n%s", err, output)
+	} else if !strings.Contains(string(output), "Microsoft.Test/parents/children") {
+		t.Errorf("Expected exact selection to list the child, got: %s", output)
+	}
+
+	exactWithoutVersionCmd := exec.Command(tfmodmakePath, "discover", "children", "-spec", specPath, "-parent", "Microsoft.Test/parents", "-spec-version-select", "exact")
+	if output, err := exactWithoutVersionCmd.CombinedOutput(); err == nil {
+		t.Errorf("Expected -spec-version-select exact without -parent-version to fail, got:\n%s", output)
+	}
+
+	// -wrap scaffolds the same files as gen avm for the same parent.
+	avmDir := filepath.Join(tmpDir, "avm")
+	wrapDir := filepath.Join(tmpDir, "wrap")
+	for _, dir := range []string{avmDir, wrapDir} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	avmCmd := exec.Command(tfmodmakePath, "gen", "avm", "-spec", specPath, "-resource", "Microsoft.Test/parents")
+	avmCmd.Dir = avmDir
+	if output, err := avmCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run gen avm: %v\n%s", err, output)
+	}
+	wrapCmd := exec.Command(tfmodmakePath, "discover", "children", "-spec", specPath, "-parent", "Microsoft.Test/parents", "-wrap")
+	wrapCmd.Dir = wrapDir
+	wrapOutput, err := wrapCmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to run discover children -wrap: %v\n%s", err, wrapOutput)
+	}
+	if !strings.Contains(string(wrapOutput), "Microsoft.Test/parents/children") {
+		t.Errorf("Expected -wrap to still list the child, got: %s", wrapOutput)
+	}
+	avmFiles := readTree(t, avmDir)
+	wrapFiles := readTree(t, wrapDir)
+	for _, file := range []string{"main.tf", "main.interfaces.tf", "main.children.tf", "variables.children.tf", filepath.Join("modules", "children", "main.tf")} {
+		if _, ok := wrapFiles[file]; !ok {
+			t.Errorf("Expected -wrap to generate %s", file)
+		}
+	}
+	for file, content := range avmFiles {
+		if wrapContent, ok := wrapFiles[file]; !ok {
+			t.Errorf("gen avm generated %s but -wrap did not", file)
+		} else if wrapContent != content {
+			t.Errorf("%s differs between gen avm and -wrap:\n--- gen avm\n%s\n--- wrap\n%s", file, content, wrapContent)
+		}
+	}
+	for file := range wrapFiles {
+		if _, ok := avmFiles[file]; !ok {
+			t.Errorf("-wrap generated %s but gen avm did not", file)
+		}
+	}
+
+	deepWrapCmd := exec.Command(tfmodmakePath, "discover", "children", "-spec", specPath, "-parent", "Microsoft.Test/parents", "-wrap", "-depth", "2")
+	deepWrapCmd.Dir = wrapDir
+	if output, err := deepWrapCmd.CombinedOutput(); err == nil {
+		t.Errorf("Expected -wrap with -depth 2 to fail, got:\n%s", output)
+	}
+}
+
+// readTree returns the content of each file under dir, keyed by its path relative to dir.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", dir, err)
+	}
+	return files
+}
+
+// TestDiscoverDiff tests that `tfmodmake discover diff` reports a property added in the newer spec.
+func TestDiscoverDiff(t *testing.T) {
+	writeSpec := func(dir, apiVersion string, properties map[string]interface{}) string {
+		spec := map[string]interface{}{
+			"swagger": "2.0",
+			"info":    map[string]interface{}{"version": apiVersion},
+			"paths": map[string]interface{}{
+				"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/parents/{parentName}": map[string]interface{}{
+					"put": map[string]interface{}{
+						"parameters": []interface{}{
+							map[string]interface{}{"name": "parameters", "in": "body", "schema": map[string]interface{}{"$ref": "#/definitions/Parent"}},
+						},
+						"responses": map[string]interface{}{"200": map[string]interface{}{"description": "OK"}},
+					},
+				},
+			},
+			"definitions": map[string]interface{}{
+				"Parent": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"properties": map[string]interface{}{"type": "object", "properties": properties},
+					},
+				},
+			},
+		}
+		data, err := json.Marshal(spec)
+		if err != nil {
+			t.Fatalf("Failed to marshal test spec: %v", err)
+		}
+		path := filepath.Join(dir, apiVersion+".json")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("Failed to write test spec: %v", err)
+		}
+		return path
+	}
+
+	tmpDir := t.TempDir()
+	oldSpec := writeSpec(tmpDir, "2024-01-01", map[string]interface{}{
+		"value": map[string]interface{}{"type": "string"},
+	})
+	newSpec := writeSpec(tmpDir, "2025-01-01", map[string]interface{}{
+		"value":   map[string]interface{}{"type": "string"},
+		"newFlag": map[string]interface{}{"type": "boolean"},
+	})
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	mdCmd := exec.Command(tfmodmakePath, "discover", "diff", "-spec", oldSpec, "-spec", newSpec, "-resource", "Microsoft.Test/parents")
+	mdOutput, err := mdCmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run discover diff: %v\n%s", err, mdOutput)
+	}
+	if !strings.Contains(string(mdOutput), "## Added properties\n\n- `properties.newFlag` (boolean)") {
+		t.Errorf("Expected properties.newFlag under added properties, got: %s", mdOutput)
+	}
+
+	jsonCmd := exec.Command(tfmodmakePath, "discover", "diff", "-spec", oldSpec, "-spec", newSpec, "-resource", "Microsoft.Test/parents", "-format", "json")
+	jsonOutput, err := jsonCmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run discover diff -format json: %v\n%s", err, jsonOutput)
+	}
+	var result struct {
+		AddedProperties []struct {
+			Path string `json:"path"`
+		} `json:"added_properties"`
+		RemovedProperties []any `json:"removed_properties"`
+	}
+	if err := json.Unmarshal(jsonOutput, &result); err != nil {
+		t.Fatalf("Failed to parse output as JSON: %v\nOutput: %s", err, jsonOutput)
+	}
+	if len(result.AddedProperties) != 1 || result.AddedProperties[0].Path != "properties.newFlag" {
+		t.Errorf("Expected properties.newFlag to be the only added property, got: %s", jsonOutput)
+	}
+	if len(result.RemovedProperties) != 0 {
+		t.Errorf("Expected no removed properties, got: %s", jsonOutput)
+	}
+
+	oneSpecCmd := exec.Command(tfmodmakePath, "discover", "diff", "-spec", oldSpec, "-resource", "Microsoft.Test/parents")
+	if output, err := oneSpecCmd.CombinedOutput(); err == nil {
+		t.Errorf("Expected discover diff with a single -spec to fail, got:\n%s", output)
+	}
 }
 
 // TestGenAVM tests that `tfmodmake gen avm` creates base module + child modules + AVM interfaces
@@ -757,6 +1477,22 @@ func TestGenAVM(t *testing.T) {
 		}
 	}
 
+	// Verify the example calls the root module under the name derived from the resource type
+	exampleData, err := os.ReadFile(filepath.Join(tmpDir, "examples", "default", "main.tf"))
+	if err != nil {
+		t.Fatalf("Expected examples/default/main.tf to be created: %v", err)
+	}
+	if !strings.Contains(string(exampleData), `module "parents" {`) || !strings.Contains(string(exampleData), `source = "../../"`) {
+		t.Errorf("Unexpected example module block, got:\n%s", exampleData)
+	}
+	readmeData, err := os.ReadFile(filepath.Join(tmpDir, "README.md"))
+	if err != nil {
+		t.Fatalf("Expected README.md to be created: %v", err)
+	}
+	if !strings.HasPrefix(string(readmeData), "# parents\n") {
+		t.Errorf("Unexpected README.md title, got:\n%s", readmeData)
+	}
+
 	// Test idempotency - run again
 	cmd = exec.Command(tfmodmakePath, "gen", "avm", "-spec", specPath, "-resource", "Microsoft.Test/parents")
 	cmd.Dir = tmpDir
@@ -764,14 +1500,130 @@ func TestGenAVM(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to run gen avm second time (idempotency test): %v\n%s", err, output)
 	}
-
-	// Verify files still exist after second run
-	for _, file := range rootFiles {
-		path := filepath.Join(tmpDir, file)
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			t.Errorf("Root file %s should still exist after second run", file)
+
+	// Verify files still exist after second run
+	for _, file := range rootFiles {
+		path := filepath.Join(tmpDir, file)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			t.Errorf("Root file %s should still exist after second run", file)
+		}
+	}
+
+	// Verify the manifest records each child module for later runs
+	manifestData, err := os.ReadFile(filepath.Join(tmpDir, "avm.manifest.json"))
+	if err != nil {
+		t.Fatalf("Expected avm.manifest.json to be created: %v", err)
+	}
+	var manifest struct {
+		Children []struct {
+			ResourceType string `json:"resource_type"`
+			ModuleName   string `json:"module_name"`
+		} `json:"children"`
+	}
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		t.Fatalf("Failed to parse avm.manifest.json: %v", err)
+	}
+	if len(manifest.Children) != 2 || manifest.Children[0].ModuleName != "child_ones" || manifest.Children[1].ModuleName != "child_twos" {
+		t.Errorf("Unexpected manifest children: %+v", manifest.Children)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "moved.tf")); !os.IsNotExist(err) {
+		t.Errorf("moved.tf should not be created without -previous-manifest")
+	}
+
+	// Test -resource-prefix namespaces child module directories and wrapper files. Passing the
+	// unprefixed run's manifest should produce moved blocks for the renamed child modules.
+	prefixDir := t.TempDir()
+	cmd = exec.Command(tfmodmakePath, "gen", "avm", "-spec", specPath, "-resource", "Microsoft.Test/parents", "-resource-prefix", "parent",
+		"-previous-manifest", filepath.Join(tmpDir, "avm.manifest.json"))
+	cmd.Dir = prefixDir
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run gen avm with -resource-prefix: %v\n%s", err, output)
+	}
+
+	for _, childMod := range []string{"parent_child_ones", "parent_child_twos"} {
+		modulePath := filepath.Join(prefixDir, "modules", childMod)
+		if _, err := os.Stat(modulePath); os.IsNotExist(err) {
+			t.Errorf("Expected prefixed child module directory %s not created", modulePath)
+		}
+		for _, file := range []string{"variables." + childMod + ".tf", "main." + childMod + ".tf"} {
+			if _, err := os.Stat(filepath.Join(prefixDir, file)); os.IsNotExist(err) {
+				t.Errorf("Expected prefixed wrapper file %s not created", file)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(prefixDir, "modules", "child_ones")); !os.IsNotExist(err) {
+		t.Errorf("Unprefixed child module directory should not be created when -resource-prefix is set")
+	}
+
+	movedData, err := os.ReadFile(filepath.Join(prefixDir, "moved.tf"))
+	if err != nil {
+		t.Fatalf("Expected moved.tf to be created from -previous-manifest: %v", err)
+	}
+	moved := string(movedData)
+	for _, want := range []string{
+		"moved {\n  from = module.child_ones\n  to   = module.parent_child_ones\n}",
+		"moved {\n  from = module.child_twos\n  to   = module.parent_child_twos\n}",
+	} {
+		if !strings.Contains(moved, want) {
+			t.Errorf("moved.tf missing block %q, got:\n%s", want, moved)
+		}
+	}
+
+	// Test -module-name overrides the root module's name in the example and README.
+	moduleNameDir := t.TempDir()
+	cmd = exec.Command(tfmodmakePath, "gen", "avm", "-spec", specPath, "-resource", "Microsoft.Test/parents", "-module-name", "test_parent")
+	cmd.Dir = moduleNameDir
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run gen avm with -module-name: %v\n%s", err, output)
+	}
+	exampleData, err = os.ReadFile(filepath.Join(moduleNameDir, "examples", "default", "main.tf"))
+	if err != nil {
+		t.Fatalf("Expected examples/default/main.tf to be created with -module-name: %v", err)
+	}
+	if !strings.Contains(string(exampleData), `module "test_parent" {`) {
+		t.Errorf("Example should label the module with -module-name, got:\n%s", exampleData)
+	}
+	readmeData, err = os.ReadFile(filepath.Join(moduleNameDir, "README.md"))
+	if err != nil {
+		t.Fatalf("Expected README.md to be created with -module-name: %v", err)
+	}
+	if !strings.HasPrefix(string(readmeData), "# test_parent\n") {
+		t.Errorf("README.md should be titled with -module-name, got:\n%s", readmeData)
+	}
+
+	cmd = exec.Command(tfmodmakePath, "gen", "avm", "-spec", specPath, "-resource", "Microsoft.Test/parents", "-module-name", "test parent")
+	cmd.Dir = t.TempDir()
+	output, err = cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected gen avm to reject an invalid -module-name")
+	}
+	if !strings.Contains(string(output), "not a valid Terraform identifier") {
+		t.Errorf("Unexpected error output: %s", output)
+	}
+
+	// Test -no-wrappers generates the child modules without wiring them into the root module.
+	noWrappersDir := t.TempDir()
+	cmd = exec.Command(tfmodmakePath, "gen", "avm", "-spec", specPath, "-resource", "Microsoft.Test/parents", "-no-wrappers")
+	cmd.Dir = noWrappersDir
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run gen avm with -no-wrappers: %v\n%s", err, output)
+	}
+	for _, childMod := range childModules {
+		if _, err := os.Stat(filepath.Join(noWrappersDir, "modules", childMod, "main.tf")); err != nil {
+			t.Errorf("Expected child module %s to be generated with -no-wrappers: %v", childMod, err)
+		}
+	}
+	for _, file := range wrapperFiles {
+		if _, err := os.Stat(filepath.Join(noWrappersDir, file)); !os.IsNotExist(err) {
+			t.Errorf("Wrapper file %s should not be created with -no-wrappers", file)
 		}
 	}
+	if _, err := os.Stat(filepath.Join(noWrappersDir, "main.tf")); err != nil {
+		t.Errorf("Expected root main.tf with -no-wrappers: %v", err)
+	}
 }
 
 // TestGenAVMDryRun tests that `tfmodmake gen avm -dry-run` produces no file changes
@@ -896,3 +1748,432 @@ func TestGenAVMDryRun(t *testing.T) {
 		}
 	}
 }
+
+func TestGenVerbose(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testSpec := map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"version": "2024-01-01",
+		},
+		"paths": map[string]interface{}{
+			"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResources/{resourceName}": map[string]interface{}{
+				"put": map[string]interface{}{
+					"parameters": []interface{}{
+						map[string]interface{}{
+							"name":   "parameters",
+							"in":     "body",
+							"schema": map[string]interface{}{"$ref": "#/definitions/TestResource"},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{"description": "OK"},
+					},
+				},
+			},
+		},
+		"definitions": map[string]interface{}{
+			"TestResource": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"displayName":       map[string]interface{}{"type": "string"},
+							"provisioningState": map[string]interface{}{"type": "string", "readOnly": true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	specPath := filepath.Join(tmpDir, "test_spec.json")
+	specData, err := json.MarshalIndent(testSpec, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal test spec: %v", err)
+	}
+	if err := os.WriteFile(specPath, specData, 0o644); err != nil {
+		t.Fatalf("Failed to write test spec: %v", err)
+	}
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	cmd := exec.Command(tfmodmakePath, "gen", "-v", "-spec", specPath, "-resource", "Microsoft.Test/testResources")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run gen -v: %v\n%s", err, output)
+	}
+	outputStr := string(output)
+	for _, want := range []string{
+		"skipped property property=properties.provisioningState reason=read-only",
+		"generated variable variable=display_name",
+		"generated file file=variables.tf",
+		"tags disabled reason=body schema has no writable tags property",
+	} {
+		if !strings.Contains(outputStr, want) {
+			t.Errorf("Expected verbose output to contain %q, got:\n%s", want, outputStr)
+		}
+	}
+
+	cmd = exec.Command(tfmodmakePath, "gen", "-q", "-spec", specPath, "-resource", "Microsoft.Test/testResources")
+	cmd.Dir = tmpDir
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run gen -q: %v\n%s", err, output)
+	}
+	if len(output) != 0 {
+		t.Errorf("Expected no output with -q, got:\n%s", output)
+	}
+
+	cmd = exec.Command(tfmodmakePath, "gen", "-v", "-q", "-spec", specPath, "-resource", "Microsoft.Test/testResources")
+	cmd.Dir = tmpDir
+	output, err = cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected -v with -q to fail, got:\n%s", output)
+	}
+	if !strings.Contains(string(output), "mutually exclusive") {
+		t.Errorf("Expected a mutually exclusive error, got:\n%s", output)
+	}
+}
+
+func TestGenResourceFromMain(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeSpec := func(name, version string, properties map[string]interface{}) string {
+		t.Helper()
+		testSpec := map[string]interface{}{
+			"swagger": "2.0",
+			"info": map[string]interface{}{
+				"version": version,
+			},
+			"paths": map[string]interface{}{
+				"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResources/{resourceName}": map[string]interface{}{
+					"put": map[string]interface{}{
+						"parameters": []interface{}{
+							map[string]interface{}{
+								"name":   "parameters",
+								"in":     "body",
+								"schema": map[string]interface{}{"$ref": "#/definitions/TestResource"},
+							},
+						},
+						"responses": map[string]interface{}{
+							"200": map[string]interface{}{"description": "OK"},
+						},
+					},
+				},
+			},
+			"definitions": map[string]interface{}{
+				"TestResource": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"properties": map[string]interface{}{
+							"type":       "object",
+							"properties": properties,
+						},
+					},
+				},
+			},
+		}
+		specPath := filepath.Join(t.TempDir(), name)
+		specData, err := json.MarshalIndent(testSpec, "", "  ")
+		if err != nil {
+			t.Fatalf("Failed to marshal test spec: %v", err)
+		}
+		if err := os.WriteFile(specPath, specData, 0o644); err != nil {
+			t.Fatalf("Failed to write test spec: %v", err)
+		}
+		return specPath
+	}
+
+	oldSpec := writeSpec("old.json", "2024-01-01", map[string]interface{}{
+		"displayName": map[string]interface{}{"type": "string"},
+	})
+	newSpec := writeSpec("new.json", "2025-01-01", map[string]interface{}{
+		"displayName": map[string]interface{}{"type": "string"},
+		"tier":        map[string]interface{}{"type": "string"},
+	})
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	cmd := exec.Command(tfmodmakePath, "gen", "-resource-from-main", "-spec", newSpec)
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err == nil {
+		t.Fatalf("Expected -resource-from-main without main.tf to fail, got:\n%s", output)
+	}
+
+	// The -comment-generated banner must not get in the way of reading the type from main.tf.
+	cmd = exec.Command(tfmodmakePath, "gen", "-spec", oldSpec, "-resource", "Microsoft.Test/testResources", "-comment-generated")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run gen: %v\n%s", err, output)
+	}
+	mainContent, err := os.ReadFile(filepath.Join(tmpDir, "main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read main.tf: %v", err)
+	}
+	if !strings.HasPrefix(string(mainContent), "# Generated by tfmodmake; do not edit directly.\n# Resource: Microsoft.Test/testResources@2024-01-01\n") {
+		t.Errorf("Expected main.tf to start with the generated banner, got:\n%s", mainContent)
+	}
+
+	cmd = exec.Command(tfmodmakePath, "gen", "-resource-from-main", "-spec", newSpec)
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run gen -resource-from-main: %v\n%s", err, output)
+	}
+
+	mainContent, err = os.ReadFile(filepath.Join(tmpDir, "main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read main.tf: %v", err)
+	}
+	if !strings.Contains(string(mainContent), `"Microsoft.Test/testResources@2025-01-01"`) {
+		t.Errorf("Expected main.tf to target the new spec's API version, got:\n%s", mainContent)
+	}
+	variablesContent, err := os.ReadFile(filepath.Join(tmpDir, "variables.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read variables.tf: %v", err)
+	}
+	if !strings.Contains(string(variablesContent), `variable "tier"`) {
+		t.Errorf("Expected variables.tf to include the property added in the new spec, got:\n%s", variablesContent)
+	}
+
+	cmd = exec.Command(tfmodmakePath, "gen", "-resource-from-main", "-spec", newSpec, "-resource", "Microsoft.Test/testResources")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected -resource-from-main with -resource to fail, got:\n%s", output)
+	}
+	if !strings.Contains(string(output), "mutually exclusive") {
+		t.Errorf("Expected a mutually exclusive error, got:\n%s", output)
+	}
+}
+
+func TestGenSkipFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testSpec := map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"version": "2024-01-01",
+		},
+		"paths": map[string]interface{}{
+			"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResources/{resourceName}": map[string]interface{}{
+				"put": map[string]interface{}{
+					"parameters": []interface{}{
+						map[string]interface{}{
+							"name":   "parameters",
+							"in":     "body",
+							"schema": map[string]interface{}{"$ref": "#/definitions/TestResource"},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{"description": "OK"},
+					},
+				},
+			},
+		},
+		"definitions": map[string]interface{}{
+			"TestResource": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"displayName": map[string]interface{}{"type": "string"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	specPath := filepath.Join(t.TempDir(), "test_spec.json")
+	specData, err := json.MarshalIndent(testSpec, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal test spec: %v", err)
+	}
+	if err := os.WriteFile(specPath, specData, 0o644); err != nil {
+		t.Fatalf("Failed to write test spec: %v", err)
+	}
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	cmd := exec.Command(tfmodmakePath, "gen", "-spec", specPath, "-resource", "Microsoft.Test/testResources", "-skip-terraform", "-skip-outputs")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run gen -skip-terraform: %v\n%s", err, output)
+	}
+	for _, name := range []string{"terraform.tf", "outputs.tf"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be written, got err=%v", name, err)
+		}
+	}
+	for _, name := range []string{"variables.tf", "locals.tf", "main.tf"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("Expected %s to be written: %v", name, err)
+		}
+	}
+
+	// Skipping locals keeps the existing file, which must still define the body local.
+	customLocals := "locals {\n  resource_body = {}\n}\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "locals.tf"), []byte(customLocals), 0o644); err != nil {
+		t.Fatalf("Failed to write locals.tf: %v", err)
+	}
+	cmd = exec.Command(tfmodmakePath, "gen", "-spec", specPath, "-resource", "Microsoft.Test/testResources", "-skip-locals")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run gen -skip-locals: %v\n%s", err, output)
+	}
+	localsContent, err := os.ReadFile(filepath.Join(tmpDir, "locals.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read locals.tf: %v", err)
+	}
+	if string(localsContent) != customLocals {
+		t.Errorf("Expected -skip-locals to leave locals.tf untouched, got:\n%s", localsContent)
+	}
+
+	cmd = exec.Command(tfmodmakePath, "gen", "-spec", specPath, "-resource", "Microsoft.Test/testResources", "-skip-locals", "-local-name", "body")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected -skip-locals to fail when locals.tf lacks the body local, got:\n%s", output)
+	}
+	if !strings.Contains(string(output), "does not define local.body") {
+		t.Errorf("Expected guidance naming the missing local, got:\n%s", output)
+	}
+}
+
+// TestGenARMSchema tests generating from an ARM template deployment schema instead of a spec
+func TestGenARMSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	armSchema := `{
+  "$schema": "http://json-schema.org/draft-04/schema#",
+  "resourceDefinitions": {
+    "testResources": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string", "enum": ["2024-01-01"]},
+        "type": {"type": "string", "enum": ["Microsoft.Test/testResources"]},
+        "name": {"type": "string"},
+        "properties": {
+          "oneOf": [
+            {"$ref": "#/definitions/TestProperties"},
+            {"$ref": "https://schema.management.azure.com/schemas/common/definitions.json#/definitions/expression"}
+          ]
+        }
+      },
+      "required": ["name", "type", "apiVersion"]
+    }
+  },
+  "definitions": {
+    "TestProperties": {
+      "type": "object",
+      "properties": {
+        "subnetName": {
+          "oneOf": [
+            {"type": "string"},
+            {"$ref": "https://schema.management.azure.com/schemas/common/definitions.json#/definitions/expression"}
+          ]
+        }
+      }
+    }
+  }
+}`
+	schemaPath := filepath.Join(t.TempDir(), "Microsoft.Test.json")
+	if err := os.WriteFile(schemaPath, []byte(armSchema), 0o644); err != nil {
+		t.Fatalf("Failed to write ARM schema: %v", err)
+	}
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	cmd := exec.Command(tfmodmakePath, "gen", "-arm-schema", schemaPath, "-resource", "Microsoft.Test/testResources")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to generate from an ARM schema: %v\n%s", err, output)
+	}
+	variables, err := os.ReadFile(filepath.Join(tmpDir, "variables.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read variables.tf: %v", err)
+	}
+	if !strings.Contains(string(variables), `variable "subnet_name"`) {
+		t.Errorf("variables.tf should contain the subnet_name variable, got:\n%s", variables)
+	}
+	mainTf, err := os.ReadFile(filepath.Join(tmpDir, "main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read main.tf: %v", err)
+	}
+	if !strings.Contains(string(mainTf), `"Microsoft.Test/testResources@2024-01-01"`) {
+		t.Errorf("main.tf should use the API version of the ARM schema, got:\n%s", mainTf)
+	}
+
+	cmd = exec.Command(tfmodmakePath, "gen", "-arm-schema", schemaPath, "-spec", schemaPath, "-resource", "Microsoft.Test/testResources")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected -arm-schema with -spec to fail")
+	}
+	if !strings.Contains(string(output), "mutually exclusive") {
+		t.Errorf("Expected a mutually exclusive error, got:\n%s", output)
+	}
+}
+
+// TestGenEmitProviderRequirementsOnly tests that `gen -emit-provider-requirements-only` writes
+// nothing but terraform.tf
+func TestGenEmitProviderRequirementsOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tfmodmakePath := filepath.Join(t.TempDir(), "tfmodmake")
+	buildCmd := exec.Command("go", "build", "-o", tfmodmakePath, ".")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build tfmodmake: %v\n%s", err, output)
+	}
+
+	cmd := exec.Command(tfmodmakePath, "gen", "-emit-provider-requirements-only", "-provider-version", "~> 2.5")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run gen -emit-provider-requirements-only: %v\n%s", err, output)
+	}
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to read output directory: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "terraform.tf" {
+		t.Fatalf("Expected only terraform.tf to be written, got %v", entries)
+	}
+	content, err := os.ReadFile(filepath.Join(tmpDir, "terraform.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read terraform.tf: %v", err)
+	}
+	if !strings.Contains(string(content), `version = "~> 2.5"`) {
+		t.Errorf("terraform.tf should use the -provider-version constraint, got:\n%s", content)
+	}
+
+	cmd = exec.Command(tfmodmakePath, "gen", "-emit-provider-requirements-only", "-resource", "Microsoft.Test/testResources")
+	cmd.Dir = tmpDir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected -emit-provider-requirements-only with -resource to fail")
+	}
+	if !strings.Contains(string(output), "does not take a spec or resource") {
+		t.Errorf("Unexpected error output: %s", output)
+	}
+}
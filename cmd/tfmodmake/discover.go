@@ -45,17 +45,83 @@ func DiscoverCommand() *cli.Command {
 						Usage:    "Parent resource type",
 						Required: true,
 					},
+					&cli.StringFlag{
+						Name:  "parent-version",
+						Usage: "Only discover children from specs of this API version (YYYY-MM-DD)",
+					},
+					&cli.StringFlag{
+						Name:  "spec-version-select",
+						Value: openapi.VersionSelectLatestStable,
+						Usage: "Which API version of each child to report: latest-stable (ignore -preview versions when a stable one exists), latest-any, or exact (requires -parent-version)",
+					},
+					&cli.BoolFlag{
+						Name:  "include-non-deployable-actions",
+						Usage: "Also list POST actions (e.g. start, listKeys) in a separate actions section",
+					},
+					&cli.IntFlag{
+						Name:  "depth",
+						Value: 1,
+						Usage: "How many levels of descendants to discover (1 for direct children)",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "text",
+						Usage: "Output format: text, json or tree",
+					},
 					&cli.BoolFlag{
 						Name:  "json",
-						Usage: "Output results as JSON",
+						Usage: "Output results as JSON (same as -format json)",
+					},
+					&cli.BoolFlag{
+						Name:  "json-schema",
+						Usage: "Include each deployable child's writable request body schema under a schema key (requires -format json)",
+					},
+					&cli.BoolFlag{
+						Name:  "parent-id-template",
+						Usage: "Show how each deployable child's ID extends the parent's, e.g. <parent_id>/certificates, to help wire children by hand (text and json formats)",
 					},
 					&cli.BoolFlag{
 						Name:  "print-resolved-specs",
 						Usage: "Print the resolved spec list to stderr",
 					},
+					&cli.BoolFlag{
+						Name:  "wrap",
+						Usage: "After listing, scaffold the parent module, a child module and wrapper for each deployable child, and the AVM interfaces in the current directory, as gen avm does",
+					},
+					&cli.StringFlag{
+						Name:  "module-dir",
+						Value: "modules",
+						Usage: "Directory where child modules are generated (with -wrap)",
+					},
+					&cli.StringFlag{
+						Name:  "resource-prefix",
+						Usage: "Prefix prepended to child module directory and wrapper file names (with -wrap)",
+					},
 				},
 				Action: runDiscoverChildren,
 			},
+			{
+				Name:  "diff",
+				Usage: "Compare a resource type's properties and child resource types between two spec versions",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:     "spec",
+						Usage:    "Path or URL to OpenAPI spec, given twice: the old version, then the new one",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "resource",
+						Usage:    "Resource type to compare",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "md",
+						Usage: "Output format: md or json",
+					},
+				},
+				Action: runDiscoverDiff,
+			},
 		},
 	}
 }
@@ -67,8 +133,42 @@ func runDiscoverChildren(ctx context.Context, cmd *cli.Command) error {
 	includePreview := cmd.Bool("include-preview")
 	includeGlob := cmd.String("include")
 	parent := cmd.String("parent")
-	jsonOutput := cmd.Bool("json")
+	parentVersion := cmd.String("parent-version")
+	versionSelect := cmd.String("spec-version-select")
+	includeActions := cmd.Bool("include-non-deployable-actions")
+	depth := cmd.Int("depth")
+	format := cmd.String("format")
+	if cmd.Bool("json") {
+		format = "json"
+	}
 	printResolvedSpecs := cmd.Bool("print-resolved-specs")
+	includeSchemas := cmd.Bool("json-schema")
+	includeParentIDTemplates := cmd.Bool("parent-id-template")
+	wrap := cmd.Bool("wrap")
+	moduleDir := cmd.String("module-dir")
+	resourcePrefix := cmd.String("resource-prefix")
+
+	switch format {
+	case "text", "json", "tree":
+	default:
+		return fmt.Errorf("unsupported format %q: expected text, json or tree", format)
+	}
+	if includeSchemas && format != "json" {
+		return fmt.Errorf("-json-schema is only valid with -format json")
+	}
+	if includeParentIDTemplates && format == "tree" {
+		return fmt.Errorf("-parent-id-template is only valid with -format text or json")
+	}
+	if depth < 1 {
+		return fmt.Errorf("-depth must be at least 1")
+	}
+	if versionSelect == openapi.VersionSelectExact && parentVersion == "" {
+		return fmt.Errorf("-spec-version-select exact requires -parent-version")
+	}
+	if wrap && depth != 1 {
+		// gen avm only scaffolds direct children.
+		return fmt.Errorf("-wrap requires -depth 1")
+	}
 
 	githubToken := specpkg.GithubTokenFromEnv()
 
@@ -108,9 +208,14 @@ func runDiscoverChildren(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	opts := openapi.DiscoverChildrenOptions{
-		Specs:  specSources,
-		Parent: parent,
-		Depth:  1,
+		Specs:                    specSources,
+		Parent:                   parent,
+		Depth:                    depth,
+		APIVersion:               parentVersion,
+		VersionSelect:            versionSelect,
+		IncludeActions:           includeActions,
+		IncludeSchemas:           includeSchemas,
+		IncludeParentIDTemplates: includeParentIDTemplates,
 	}
 
 	result, err := openapi.DiscoverChildren(opts)
@@ -118,15 +223,55 @@ func runDiscoverChildren(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed to discover children: %w", err)
 	}
 
-	if jsonOutput {
+	switch format {
+	case "json":
 		jsonStr, err := openapi.FormatChildrenAsJSON(result)
 		if err != nil {
 			return fmt.Errorf("failed to format as JSON: %w", err)
 		}
 		fmt.Println(jsonStr)
-	} else {
-		text := openapi.FormatChildrenAsText(result)
-		fmt.Print(text)
+	case "tree":
+		fmt.Print(openapi.FormatChildrenAsTree(parent, result))
+	default:
+		fmt.Print(openapi.FormatChildrenAsText(result))
+	}
+
+	if wrap {
+		if err := orchestrateAVMGeneration(ctx, specSources, parent, "", moduleDir, resourcePrefix, deriveModuleName(parent), false, versionSelect, parentVersion, nil); err != nil {
+			return fmt.Errorf("failed to generate AVM module: %w", err)
+		}
+		loggerFromContext(ctx).Info("Successfully generated AVM module with child submodules and interfaces")
+	}
+	return nil
+}
+
+func runDiscoverDiff(ctx context.Context, cmd *cli.Command) error {
+	specs := cmd.StringSlice("spec")
+	resourceType := cmd.String("resource")
+	format := cmd.String("format")
+
+	switch format {
+	case "md", "json":
+	default:
+		return fmt.Errorf("unsupported format %q: expected md or json", format)
+	}
+	if len(specs) != 2 {
+		return fmt.Errorf("expected exactly two -spec values (old and new), got %d", len(specs))
+	}
+
+	diff, err := openapi.DiffSpecs(specs[0], specs[1], resourceType)
+	if err != nil {
+		return fmt.Errorf("failed to diff specs: %w", err)
+	}
+
+	if format == "json" {
+		jsonStr, err := openapi.FormatSpecDiffAsJSON(diff)
+		if err != nil {
+			return fmt.Errorf("failed to format as JSON: %w", err)
+		}
+		fmt.Println(jsonStr)
+		return nil
 	}
+	fmt.Print(openapi.FormatSpecDiffAsMarkdown(diff))
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/matt-FFFFFF/tfmodmake/openapi"
 	specpkg "github.com/matt-FFFFFF/tfmodmake/specs"
 	"github.com/matt-FFFFFF/tfmodmake/submodule"
@@ -25,15 +26,274 @@ func GenCommand() *cli.Command {
 				Name:  "spec",
 				Usage: "Path or URL to the OpenAPI specification",
 			},
+			&cli.StringSliceFlag{
+				Name:  "arm-schema",
+				Usage: "Path or URL to an ARM template deployment schema or Bicep types (types.json or index.json), used instead of -spec",
+			},
 			&cli.StringFlag{
 				Name:  "resource",
 				Usage: "Resource type to generate (e.g., Microsoft.ContainerService/managedClusters)",
 			},
+			&cli.BoolFlag{
+				Name:  "resource-from-main",
+				Usage: "Regenerate an existing module, reading the resource type from its main.tf instead of -resource",
+			},
 			&cli.StringFlag{
 				Name:  "local-name",
 				Usage: "Name of the local variable to generate (default: resource_body)",
 			},
+			&cli.StringFlag{
+				Name:  "resource-name",
+				Value: "this",
+				Usage: "Name label of the generated resource block, referenced by the outputs",
+			},
+			&cli.BoolFlag{
+				Name:  "multi",
+				Usage: "Create a resource per entry of var.instances, a map of the per-resource inputs; outputs become maps keyed by instance",
+			},
+			&cli.StringFlag{
+				Name:  "backend",
+				Usage: "Add a commented backend stub to terraform.tf (azurerm, s3, or local)",
+			},
+			&cli.StringFlag{
+				Name:  "locals-style",
+				Value: terraform.LocalsStyleFlat,
+				Usage: "How the top-level properties bag is exposed: flat (one variable per property) or nested (a single properties variable)",
+			},
+			&cli.IntFlag{
+				Name:  "inline-small-objects",
+				Usage: "With -locals-style flat, keep a properties bag with fewer than this many writable properties as a single properties variable (0 always flattens)",
+			},
+			&cli.StringFlag{
+				Name:  "sort-variables",
+				Value: terraform.SortVariablesAlpha,
+				Usage: "Order of the body variables: alpha, spec (spec declaration order where known) or required-first",
+			},
+			&cli.StringFlag{
+				Name:  "name-validation-source",
+				Value: terraform.NameValidationSourcePath,
+				Usage: "Where the name variable's validations come from: path (the PUT path parameter), body (the body's name property) or both",
+			},
+			&cli.StringFlag{
+				Name:  "provider-source",
+				Value: terraform.DefaultProviderSource,
+				Usage: "Registry address of the azapi provider (e.g., registry.internal/azure/azapi)",
+			},
+			&cli.StringFlag{
+				Name:  "required-version",
+				Value: terraform.DefaultRequiredVersion,
+				Usage: "Terraform version constraint of terraform.tf",
+			},
+			&cli.StringFlag{
+				Name:  "provider-version",
+				Value: terraform.DefaultProviderVersion,
+				Usage: "azapi provider version constraint of terraform.tf",
+			},
+			&cli.BoolFlag{
+				Name:  "emit-provider-requirements-only",
+				Usage: "Write only terraform.tf, with the provider and version settings, to bootstrap a module directory; no spec or resource is needed",
+			},
+			&cli.BoolFlag{
+				Name:  "azapi-schema-validation",
+				Value: true,
+				Usage: "Leave azapi's schema validation of the body enabled; set to false for preview resource types the provider doesn't know yet",
+			},
+			&cli.BoolFlag{
+				Name:  "timeouts",
+				Value: true,
+				Usage: "Add a timeouts block, set from a timeouts variable, to resources whose PUT is marked x-ms-long-running-operation",
+			},
+			&cli.BoolFlag{
+				Name:  "ignore-null-property",
+				Usage: "Set ignore_null_property = true on the generated resource so null body fields are omitted rather than sent as null",
+			},
+			&cli.BoolFlag{
+				Name:  "strict-outputs",
+				Usage: "Fail with an output precondition when a computed attribute is missing from the response, instead of returning null",
+			},
+			&cli.BoolFlag{
+				Name:  "disambiguate-collisions",
+				Usage: "Suffix variables whose names collide, e.g. ipAddress and IPAddress, with _2 instead of failing",
+			},
+			&cli.BoolFlag{
+				Name:  "identifier-maps",
+				Usage: "Type arrays whose items are identified by a single x-ms-identifiers property as maps keyed by it, for use with for_each",
+			},
+			&cli.StringSliceFlag{
+				Name:  "default-tags",
+				Usage: "Module tag as key=value (repeatable), merged into var.tags as local.tags which the resource uses",
+			},
+			&cli.StringFlag{
+				Name:  "acronyms",
+				Usage: "File listing domain acronyms, one per line (e.g. VNet), kept as one word in snake_case names",
+			},
+			&cli.BoolFlag{
+				Name:  "skip-variables",
+				Usage: "Don't write variables.tf (or .env.example); an existing variables.tf must declare the variables the other files use",
+			},
+			&cli.BoolFlag{
+				Name:  "skip-locals",
+				Usage: "Don't write locals.tf; an existing locals.tf must define the body local main.tf references",
+			},
+			&cli.BoolFlag{
+				Name:  "skip-outputs",
+				Usage: "Don't write outputs.tf",
+			},
+			&cli.BoolFlag{
+				Name:  "skip-terraform",
+				Usage: "Don't write terraform.tf",
+			},
+			&cli.BoolFlag{
+				Name:  "resource-id-heuristic",
+				Usage: "Also treat string fields named *ResourceId as ARM resource IDs and validate their shape",
+			},
+			&cli.BoolFlag{
+				Name:  "fail-on-empty-body",
+				Usage: "Fail instead of generating a module when the resource body has no writable properties",
+			},
+			&cli.BoolFlag{
+				Name:  "validate-after",
+				Usage: "Check that each generated .tf file parses as HCL, failing before any file is written otherwise",
+			},
+			&cli.BoolFlag{
+				Name:  "as-data-source",
+				Usage: "Generate a module reading an existing resource through the azapi_resource data source, e.g. for fully computed resources whose body is read-only",
+			},
+			&cli.BoolFlag{
+				Name:  "required-only",
+				Usage: "Generate variables and locals for required properties only, omitting optional ones",
+			},
+			&cli.BoolFlag{
+				Name:  "drop-empty",
+				Usage: "Make empty nested objects, maps and lists null in the body locals so azapi does not send {} or []",
+			},
+			&cli.BoolFlag{
+				Name:  "heuristic-secrets",
+				Usage: "Also treat string properties named like secrets (password, secret, key, token, connectionString) as secrets",
+			},
+			&cli.BoolFlag{
+				Name:  "no-flatten-secrets",
+				Usage: "Declare the secrets in a single ephemeral sensitive_body object variable nested like the request body, instead of a variable each",
+			},
+			&cli.BoolFlag{
+				Name:  "range-validations",
+				Usage: "Validate that paired min/max numeric fields (e.g. minReplicas/maxReplicas) are ordered",
+			},
+			&cli.BoolFlag{
+				Name:  "int-range-checks",
+				Usage: "Validate that int32 and int64 fields fit the range of their format",
+			},
+			&cli.BoolFlag{
+				Name:  "compact-validations",
+				Usage: "Merge each variable's validations into a single block with an AND-ed condition and a combined error message",
+			},
+			&cli.BoolFlag{
+				Name:  "open-enum-validation",
+				Usage: "Also validate open enums (x-ms-enum modelAsString: true), whose values the service may extend",
+			},
+			&cli.StringFlag{
+				Name:  "error-message-prefix",
+				Usage: "Start every validation error message with [prefix], e.g. the module name, for clearer diagnostics when the module is embedded",
+			},
+			&cli.BoolFlag{
+				Name:  "validate-identity-ids",
+				Usage: "Validate that managed_identities.user_assigned_resource_ids are user-assigned identity resource IDs",
+			},
+			&cli.BoolFlag{
+				Name:  "parent-id-validation",
+				Usage: "Validate that parent_id is an Azure resource ID starting with /subscriptions/ or /providers/",
+			},
+			&cli.BoolFlag{
+				Name:  "emit-variable-defaults-file",
+				Usage: "Generate a starter tfvars file assigning each variable its default, or a placeholder when it is required",
+			},
+			&cli.StringFlag{
+				Name:  "tfvars-name",
+				Value: terraform.DefaultVariableDefaultsFile,
+				Usage: "File name of the starter tfvars file generated with -emit-variable-defaults-file",
+			},
+			&cli.StringFlag{
+				Name:  "env-prefix",
+				Usage: "Annotate required variables with their environment variable (e.g. TF_VAR_) and write .env.example",
+			},
+			&cli.BoolFlag{
+				Name:  "comment-source",
+				Usage: "Annotate each generated variable with a comment naming its OpenAPI property path",
+			},
+			&cli.BoolFlag{
+				Name:  "preserve-unknown-extensions",
+				Usage: "Annotate each generated variable with a comment per x-ms-* extension of its schema the generator doesn't act on, e.g. x-ms-mutability",
+			},
+			&cli.BoolFlag{
+				Name:  "actions",
+				Usage: "Scaffold main.actions.tf with an azapi_resource_action, off by default behind an <action>_enabled variable, for each POST action on the resource",
+			},
+			&cli.BoolFlag{
+				Name:  "resource-group-output",
+				Usage: "Add resource_group_name and resource_group_id outputs derived from parent_id, for top-level resources deployed to a resource group",
+			},
+			&cli.BoolFlag{
+				Name:  "preflight",
+				Usage: "Generate main.preflight.tf with a check block validating a deployment of the resource body to the parent resource group on every plan",
+			},
+			&cli.BoolFlag{
+				Name:  "strip-description-html",
+				Usage: "Remove HTML tags and entities (e.g. <br>, &amp;) from spec descriptions and collapse whitespace",
+			},
+			&cli.IntFlag{
+				Name:  "var-description-max",
+				Usage: "Truncate spec descriptions of variables and their nested fields to this many characters at a word boundary, with an ellipsis (0 for unlimited)",
+			},
+			&cli.BoolFlag{
+				Name:  "comment-generated",
+				Usage: "Start each generated .tf file with a banner marking it as generated by tfmodmake, naming the resource type and API version",
+			},
+			&cli.StringFlag{
+				Name:  "schema-definition",
+				Usage: "Generate from a named definitions/components.schemas entry instead of the resource's PUT body",
+			},
+			&cli.StringFlag{
+				Name:  "override-api-version",
+				Usage: "Override the API version used in main.tf (default: the spec's info.version)",
+			},
+			&cli.StringFlag{
+				Name:  "mode",
+				Value: "arm",
+				Usage: "Resource plane to generate for: arm or data-plane (data-plane requires -schema-definition)",
+			},
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Usage: "Default data-plane endpoint host for -mode data-plane (e.g. myvault.vault.azure.net)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-telemetry",
+				Usage: "Omit the AVM enable_telemetry variable (for non-AVM modules)",
+			},
+			&cli.IntFlag{
+				Name:  "max-depth",
+				Value: terraform.DefaultMaxDepth,
+				Usage: "Maximum nesting depth to expand; deeper levels are typed as any",
+			},
+			&cli.StringFlag{
+				Name:  "title",
+				Usage: "Module title; writes a metadata.json manifest",
+			},
+			&cli.StringFlag{
+				Name:  "description",
+				Usage: "Module description; writes a metadata.json manifest",
+			},
+			&cli.BoolFlag{
+				Name:    "verbose",
+				Aliases: []string{"v"},
+				Usage:   "Log per-file and per-variable decisions, such as why properties were skipped",
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Aliases: []string{"q"},
+				Usage:   "Only log errors",
+			},
 		},
+		Before: setupLogging,
 		Action: runGen,
 		Commands: []*cli.Command{
 			{
@@ -108,6 +368,15 @@ func GenCommand() *cli.Command {
 						Usage:    "Parent resource type",
 						Required: true,
 					},
+					&cli.StringFlag{
+						Name:  "spec-version-select",
+						Value: openapi.VersionSelectLatestStable,
+						Usage: "Which API version of each child to generate: latest-stable (ignore -preview versions when a stable one exists), latest-any, or exact (requires -api-version)",
+					},
+					&cli.StringFlag{
+						Name:  "api-version",
+						Usage: "Only discover children from specs of this API version (YYYY-MM-DD)",
+					},
 					&cli.StringFlag{
 						Name:  "local-name",
 						Usage: "Name of the local variable to generate",
@@ -117,6 +386,22 @@ func GenCommand() *cli.Command {
 						Value: "modules",
 						Usage: "Directory where child modules live",
 					},
+					&cli.StringFlag{
+						Name:  "resource-prefix",
+						Usage: "Prefix prepended to child module directory and wrapper file names",
+					},
+					&cli.StringFlag{
+						Name:  "module-name",
+						Usage: "Name of the root module in the generated example's module block and README title; defaults to one derived from -resource",
+					},
+					&cli.StringFlag{
+						Name:  "previous-manifest",
+						Usage: "avm.manifest.json from a previous run; renamed child modules get moved blocks in moved.tf",
+					},
+					&cli.BoolFlag{
+						Name:  "no-wrappers",
+						Usage: "Generate child module directories only, without the root variables.<child>.tf and main.<child>.tf wrappers",
+					},
 					&cli.BoolFlag{
 						Name:  "dry-run",
 						Usage: "Print planned actions without writing files",
@@ -130,14 +415,225 @@ func GenCommand() *cli.Command {
 
 func runGen(ctx context.Context, cmd *cli.Command) error {
 	specs := cmd.StringSlice("spec")
+	armSchemas := cmd.StringSlice("arm-schema")
 	resourceType := cmd.String("resource")
 	localName := cmd.String("local-name")
+	resourceName := cmd.String("resource-name")
+	multi := cmd.Bool("multi")
+	backend := cmd.String("backend")
+	providerSource := cmd.String("provider-source")
+	requiredVersion := cmd.String("required-version")
+	providerVersion := cmd.String("provider-version")
+	localsStyle := cmd.String("locals-style")
+	inlineSmallObjects := cmd.Int("inline-small-objects")
+	sortVariables := cmd.String("sort-variables")
+	nameValidationSource := cmd.String("name-validation-source")
+	resourceIDHeuristic := cmd.Bool("resource-id-heuristic")
+	rangeValidations := cmd.Bool("range-validations")
+	intRangeChecks := cmd.Bool("int-range-checks")
+	compactValidations := cmd.Bool("compact-validations")
+	openEnumValidation := cmd.Bool("open-enum-validation")
+	errorMessagePrefix := cmd.String("error-message-prefix")
+	validateIdentityIDs := cmd.Bool("validate-identity-ids")
+	parentIDValidation := cmd.Bool("parent-id-validation")
+	failOnEmptyBody := cmd.Bool("fail-on-empty-body")
+	asDataSource := cmd.Bool("as-data-source")
+	validateAfter := cmd.Bool("validate-after")
+	requiredOnly := cmd.Bool("required-only")
+	heuristicSecrets := cmd.Bool("heuristic-secrets")
+	noFlattenSecrets := cmd.Bool("no-flatten-secrets")
+	dropEmpty := cmd.Bool("drop-empty")
+	schemaValidation := cmd.Bool("azapi-schema-validation")
+	ignoreNullProperty := cmd.Bool("ignore-null-property")
+	timeouts := cmd.Bool("timeouts")
+	strictOutputs := cmd.Bool("strict-outputs")
+	identifierMaps := cmd.Bool("identifier-maps")
+	disambiguateCollisions := cmd.Bool("disambiguate-collisions")
+	acronymsFile := cmd.String("acronyms")
+	commentSource := cmd.Bool("comment-source")
+	preserveUnknownExtensions := cmd.Bool("preserve-unknown-extensions")
+	commentGenerated := cmd.Bool("comment-generated")
+	stripDescriptionHTML := cmd.Bool("strip-description-html")
+	descriptionMax := cmd.Int("var-description-max")
+	actions := cmd.Bool("actions")
+	preflight := cmd.Bool("preflight")
+	resourceGroupOutputs := cmd.Bool("resource-group-output")
+	envPrefix := cmd.String("env-prefix")
+	var variableDefaultsFile string
+	if cmd.Bool("emit-variable-defaults-file") {
+		variableDefaultsFile = cmd.String("tfvars-name")
+	}
+	noTelemetry := cmd.Bool("no-telemetry")
+	schemaDefinition := cmd.String("schema-definition")
+	apiVersion := cmd.String("override-api-version")
+	mode := cmd.String("mode")
+	endpoint := cmd.String("endpoint")
+	title := cmd.String("title")
+	description := cmd.String("description")
+	maxDepth := cmd.Int("max-depth")
+
+	if cmd.Bool("resource-from-main") {
+		if resourceType != "" {
+			return fmt.Errorf("-resource-from-main and -resource are mutually exclusive")
+		}
+		var err error
+		resourceType, err = inferResourceTypeFromMainTf(".")
+		if err != nil {
+			return fmt.Errorf("failed to infer resource type from main.tf: %w", err)
+		}
+	}
+
+	if cmd.Bool("emit-provider-requirements-only") {
+		if len(specs) > 0 || len(armSchemas) > 0 || resourceType != "" {
+			return fmt.Errorf("-emit-provider-requirements-only does not take a spec or resource")
+		}
+		return terraform.GenerateProviderRequirements(
+			terraform.WithBackend(backend),
+			terraform.WithProviderSource(providerSource),
+			terraform.WithRequiredVersion(requiredVersion),
+			terraform.WithProviderVersion(providerVersion),
+		)
+	}
 
-	if len(specs) == 0 || resourceType == "" {
+	if (len(specs) == 0 && len(armSchemas) == 0) || resourceType == "" {
 		return cli.ShowSubcommandHelp(cmd)
 	}
+	if len(armSchemas) > 0 {
+		if len(specs) > 0 {
+			return fmt.Errorf("-arm-schema and -spec are mutually exclusive")
+		}
+		if schemaDefinition != "" {
+			return fmt.Errorf("-schema-definition is not supported with -arm-schema")
+		}
+	}
+
+	if maxDepth < 1 {
+		return fmt.Errorf("-max-depth must be at least 1")
+	}
+	if descriptionMax < 0 {
+		return fmt.Errorf("-var-description-max must not be negative")
+	}
+	if inlineSmallObjects < 0 {
+		return fmt.Errorf("-inline-small-objects must not be negative")
+	}
+	acronyms, err := readAcronymsFile(acronymsFile)
+	if err != nil {
+		return err
+	}
+	defaultTags, err := parseDefaultTags(cmd.StringSlice("default-tags"))
+	if err != nil {
+		return err
+	}
+
+	var skipFiles []string
+	if cmd.Bool("skip-variables") {
+		if _, err := os.Stat("variables.tf"); err != nil {
+			return fmt.Errorf("-skip-variables keeps an existing variables.tf, but it could not be read: %w\nGenerate once without -skip-variables", err)
+		}
+		skipFiles = append(skipFiles, "variables.tf", ".env.example")
+	}
+	if cmd.Bool("skip-locals") {
+		bodyLocal := localName
+		if bodyLocal == "" {
+			bodyLocal = "resource_body"
+		}
+		if err := requireExistingLocal(".", bodyLocal); err != nil {
+			return err
+		}
+		skipFiles = append(skipFiles, "locals.tf")
+	}
+	if cmd.Bool("skip-outputs") {
+		skipFiles = append(skipFiles, "outputs.tf")
+	}
+	if cmd.Bool("skip-terraform") {
+		skipFiles = append(skipFiles, "terraform.tf")
+	}
+
+	var dataPlane bool
+	switch mode {
+	case "arm":
+		if endpoint != "" {
+			return fmt.Errorf("-endpoint is only valid with -mode data-plane")
+		}
+	case "data-plane":
+		// Data-plane specs don't follow the ARM instance path shape, so the body schema
+		// must be chosen explicitly rather than inferred from the PUT operation.
+		if schemaDefinition == "" {
+			return fmt.Errorf("-mode data-plane requires -schema-definition")
+		}
+		if !schemaValidation {
+			return fmt.Errorf("-azapi-schema-validation=false is only valid with -mode arm")
+		}
+		dataPlane = true
+	default:
+		return fmt.Errorf("unsupported mode %q: expected arm or data-plane", mode)
+	}
+
+	extraOpts := []terraform.GeneratorOption{
+		terraform.WithResourceName(resourceName),
+		terraform.WithMulti(multi),
+		terraform.WithBackend(backend),
+		terraform.WithProviderSource(providerSource),
+		terraform.WithRequiredVersion(requiredVersion),
+		terraform.WithProviderVersion(providerVersion),
+		terraform.WithLocalsStyle(localsStyle),
+		terraform.WithInlineSmallObjects(inlineSmallObjects),
+		terraform.WithSortVariables(sortVariables),
+		terraform.WithNameValidationSource(nameValidationSource),
+		terraform.WithResourceIDNameHeuristic(resourceIDHeuristic),
+		terraform.WithRangeValidations(rangeValidations),
+		terraform.WithIntRangeChecks(intRangeChecks),
+		terraform.WithCompactValidations(compactValidations),
+		terraform.WithOpenEnumValidation(openEnumValidation),
+		terraform.WithErrorMessagePrefix(errorMessagePrefix),
+		terraform.WithValidateIdentityIDs(validateIdentityIDs),
+		terraform.WithParentIDValidation(parentIDValidation),
+		terraform.WithFailOnEmptyBody(failOnEmptyBody),
+		terraform.WithAsDataSource(asDataSource),
+		terraform.WithValidateAfter(validateAfter),
+		terraform.WithRequiredOnly(requiredOnly),
+		terraform.WithHeuristicSecrets(heuristicSecrets),
+		terraform.WithNestedSecrets(noFlattenSecrets),
+		terraform.WithDropEmpty(dropEmpty),
+		terraform.WithSchemaValidation(schemaValidation),
+		terraform.WithIgnoreNullProperty(ignoreNullProperty),
+		terraform.WithTimeouts(timeouts),
+		terraform.WithStrictOutputs(strictOutputs),
+		terraform.WithIdentifierMaps(identifierMaps),
+		terraform.WithDisambiguateCollisions(disambiguateCollisions),
+		terraform.WithDefaultTags(defaultTags),
+		terraform.WithSkipFiles(skipFiles...),
+		terraform.WithAcronyms(acronyms),
+		terraform.WithCommentSource(commentSource),
+		terraform.WithPreserveUnknownExtensions(preserveUnknownExtensions),
+		terraform.WithCommentGenerated(commentGenerated),
+		terraform.WithStripDescriptionHTML(stripDescriptionHTML),
+		terraform.WithDescriptionMaxLength(descriptionMax),
+		terraform.WithActions(actions),
+		terraform.WithPreflight(preflight),
+		terraform.WithResourceGroupOutputs(resourceGroupOutputs),
+		terraform.WithEnvPrefix(envPrefix),
+		terraform.WithVariableDefaultsFile(variableDefaultsFile),
+		terraform.WithTelemetry(!noTelemetry),
+		terraform.WithDataPlane(dataPlane),
+		terraform.WithEndpoint(endpoint),
+		terraform.WithMaxDepth(maxDepth),
+	}
+	if apiVersion != "" {
+		extraOpts = append(extraOpts, terraform.WithAPIVersion(apiVersion))
+	}
+	if title != "" || description != "" {
+		extraOpts = append(extraOpts, terraform.WithMetadata(title, description))
+	}
 
-	return generateBaseModule(ctx, specs, resourceType, localName)
+	if len(armSchemas) > 0 {
+		result, err := terraform.LoadARMSchemaResource(ctx, armSchemas, resourceType)
+		if err != nil {
+			return fmt.Errorf("failed to load resource: %w", err)
+		}
+		return generateLoadedModule(ctx, result, resourceType, localName, extraOpts...)
+	}
+	return generateBaseModule(ctx, specs, resourceType, schemaDefinition, localName, extraOpts...)
 }
 
 func runAddChild(ctx context.Context, cmd *cli.Command) error {
@@ -219,8 +715,9 @@ func runAddChild(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed to wire child module: %w", err)
 	}
 
-	fmt.Printf("Successfully created child module at: %s\n", modulePath)
-	fmt.Println("Successfully generated submodule wrapper files")
+	logger := loggerFromContext(ctx)
+	logger.Info("Successfully created child module at: " + modulePath)
+	logger.Info("Successfully generated submodule wrapper files")
 	return nil
 }
 
@@ -232,11 +729,29 @@ func runGenAVM(ctx context.Context, cmd *cli.Command) error {
 	resourceType := cmd.String("resource")
 	localName := cmd.String("local-name")
 	moduleDir := cmd.String("module-dir")
+	resourcePrefix := cmd.String("resource-prefix")
+	moduleName := cmd.String("module-name")
+	previousManifest := cmd.String("previous-manifest")
+	noWrappers := cmd.Bool("no-wrappers")
+	versionSelect := cmd.String("spec-version-select")
+	apiVersion := cmd.String("api-version")
 	dryRun := cmd.Bool("dry-run")
 
 	if len(specs) == 0 && specRoot == "" {
 		return fmt.Errorf("at least one -spec or -spec-root is required")
 	}
+	if versionSelect == openapi.VersionSelectExact && apiVersion == "" {
+		return fmt.Errorf("-spec-version-select exact requires -api-version")
+	}
+	if moduleName == "" {
+		moduleName = deriveModuleName(resourceType)
+	} else if !hclsyntax.ValidIdentifier(moduleName) {
+		return fmt.Errorf("-module-name %q is not a valid Terraform identifier", moduleName)
+	}
+	if noWrappers && previousManifest != "" {
+		// Moved blocks address the wrapper module calls, which -no-wrappers leaves to the user.
+		return fmt.Errorf("-previous-manifest cannot be used with -no-wrappers")
+	}
 
 	githubToken := specpkg.GithubTokenFromEnv()
 	includeGlobs := defaultDiscoveryGlobsForParent(resourceType)
@@ -275,17 +790,31 @@ func runGenAVM(ctx context.Context, cmd *cli.Command) error {
 		fmt.Println("DRY RUN: Would execute the following steps:")
 		fmt.Printf("1. Generate base module for resource: %s\n", resourceType)
 		fmt.Printf("2. Discover children under parent: %s\n", resourceType)
-		fmt.Printf("3. Generate submodule for each discovered child in: %s/\n", moduleDir)
+		if noWrappers {
+			fmt.Printf("3. Generate submodule for each discovered child in: %s/ (without root wrappers)\n", moduleDir)
+		} else {
+			fmt.Printf("3. Generate submodule for each discovered child in: %s/\n", moduleDir)
+		}
 		fmt.Printf("4. Generate main.interfaces.tf\n")
+		fmt.Printf("5. Generate %s/main.tf calling module %q, and README.md if missing\n", terraform.DefaultExampleDir, moduleName)
 		fmt.Printf("Using %d resolved spec(s)\n", len(specSources))
 		return nil
 	}
 
-	if err := orchestrateAVMGeneration(ctx, specSources, resourceType, localName, moduleDir); err != nil {
+	// Read the previous manifest up front, as this run overwrites avm.manifest.json.
+	var previous *avmManifest
+	if previousManifest != "" {
+		previous, err = readAVMManifest(previousManifest)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := orchestrateAVMGeneration(ctx, specSources, resourceType, localName, moduleDir, resourcePrefix, moduleName, noWrappers, versionSelect, apiVersion, previous); err != nil {
 		return fmt.Errorf("failed to generate AVM module: %w", err)
 	}
 
-	fmt.Println("Successfully generated AVM module with child submodules and interfaces")
+	loggerFromContext(ctx).Info("Successfully generated AVM module with child submodules and interfaces")
 	return nil
 }
 
@@ -318,6 +847,7 @@ func generateChildModule(ctx context.Context, specs []string, childType, moduleP
 		terraform.WithLocalName(localName),
 		terraform.WithModuleNamePrefix(moduleName),
 		terraform.WithOutputDir(modulePath),
+		terraform.WithLogger(loggerFromContext(ctx).With("module", modulePath)),
 	); err != nil {
 		return fmt.Errorf("failed to generate terraform files: %w", err)
 	}
@@ -325,62 +855,85 @@ func generateChildModule(ctx context.Context, specs []string, childType, moduleP
 	return nil
 }
 
-// orchestrateAVMGeneration performs the full AVM generation workflow
-func orchestrateAVMGeneration(ctx context.Context, specSources []string, resourceType, localName, moduleDir string) error {
+// orchestrateAVMGeneration performs the full AVM generation workflow.
+// moduleName labels the root module in the generated example and titles the README.
+// When resourcePrefix is set, it is prepended to each child module name. When noWrappers is
+// set, child modules are not wired into the root module, leaving composition to the user. The
+// child modules are recorded in avm.manifest.json; when previous is set, children whose module
+// name changed since that run get moved blocks in moved.tf. versionSelect and apiVersion choose
+// the API version of each child, as in openapi.DiscoverChildrenOptions.
+func orchestrateAVMGeneration(ctx context.Context, specSources []string, resourceType, localName, moduleDir, resourcePrefix, moduleName string, noWrappers bool, versionSelect, apiVersion string, previous *avmManifest) error {
+	logger := loggerFromContext(ctx)
+
 	// Step 1: Generate base module
-	fmt.Println("Step 1/4: Generating base module...")
-	if err := generateBaseModule(ctx, specSources, resourceType, localName); err != nil {
+	logger.Info("Step 1/5: Generating base module...")
+	loaded, err := terraform.LoadResource(ctx, specSources, resourceType)
+	if err != nil {
+		return fmt.Errorf("failed to generate base module: failed to load resource: %w", err)
+	}
+	if err := generateLoadedModule(ctx, loaded, resourceType, localName); err != nil {
 		return fmt.Errorf("failed to generate base module: %w", err)
 	}
 
 	// Step 2: Discover children
-	fmt.Println("Step 2/4: Discovering child resources...")
+	logger.Info("Step 2/5: Discovering child resources...")
 	opts := openapi.DiscoverChildrenOptions{
-		Specs:  specSources,
-		Parent: resourceType,
-		Depth:  1,
+		Specs:         specSources,
+		Parent:        resourceType,
+		Depth:         1,
+		APIVersion:    apiVersion,
+		VersionSelect: versionSelect,
 	}
 	result, err := openapi.DiscoverChildren(opts)
 	if err != nil {
 		return fmt.Errorf("failed to discover children: %w", err)
 	}
 
-	fmt.Printf("Found %d deployable child resource type(s)\n", len(result.Deployable))
+	logger.Info(fmt.Sprintf("Found %d deployable child resource type(s)", len(result.Deployable)))
+	specVersions := loadSpecVersions(specSources)
+
+	manifest := &avmManifest{ResourceType: resourceType, Children: []avmManifestChild{}}
 
 	// Step 3: Generate submodule for each child
 	if len(result.Deployable) > 0 {
-		fmt.Println("Step 3/4: Generating child submodules...")
+		logger.Info("Step 3/5: Generating child submodules...")
 		for i, child := range result.Deployable {
 			// Some child resource types are managed via AVM interfaces on the parent module.
 			// For example, private endpoints are configured through the interfaces module and
 			// should not be generated as a standalone child submodule.
 			if isInterfaceManagedChild(child.ResourceType) {
-				fmt.Printf("  [%d/%d] Skipping interface-managed child %s\n", i+1, len(result.Deployable), child.ResourceType)
+				logger.Info(fmt.Sprintf("  [%d/%d] Skipping interface-managed child %s", i+1, len(result.Deployable), child.ResourceType))
 				continue
 			}
 
-			fmt.Printf("  [%d/%d] Generating submodule for %s...\n", i+1, len(result.Deployable), child.ResourceType)
+			logger.Info(fmt.Sprintf("  [%d/%d] Generating submodule for %s...", i+1, len(result.Deployable), child.ResourceType))
 
 			// Derive module name from child type
 			moduleName := deriveModuleName(child.ResourceType)
+			if resourcePrefix != "" {
+				moduleName = resourcePrefix + "_" + moduleName
+			}
 			modulePath := filepath.Join(moduleDir, moduleName)
 
 			// Generate child module
-			if err := generateChildModule(ctx, specSources, child.ResourceType, modulePath); err != nil {
+			if err := generateChildModule(ctx, specsPreferringVersion(specSources, specVersions, child.APIVersion), child.ResourceType, modulePath); err != nil {
 				return fmt.Errorf("failed to generate child module for %s: %w", child.ResourceType, err)
 			}
 
 			// Wire child module into parent
-			if err := submodule.Generate(modulePath); err != nil {
-				return fmt.Errorf("failed to wire child module for %s: %w", child.ResourceType, err)
+			if !noWrappers {
+				if err := submodule.Generate(modulePath); err != nil {
+					return fmt.Errorf("failed to wire child module for %s: %w", child.ResourceType, err)
+				}
 			}
+			manifest.Children = append(manifest.Children, avmManifestChild{ResourceType: child.ResourceType, ModuleName: moduleName})
 		}
 	} else {
-		fmt.Println("Step 3/4: No child resources found, skipping submodule generation")
+		logger.Info("Step 3/5: No child resources found, skipping submodule generation")
 	}
 
 	// Step 4: Generate AVM interfaces
-	fmt.Println("Step 4/4: Generating AVM interfaces...")
+	logger.Info("Step 4/5: Generating AVM interfaces...")
 	// Load the spec for capability detection (reuse the logic from generateBaseModule)
 	var doc *openapi3.T
 	for _, specPath := range specSources {
@@ -398,9 +951,60 @@ func orchestrateAVMGeneration(ctx context.Context, specSources []string, resourc
 		return fmt.Errorf("failed to generate AVM interfaces: %w", err)
 	}
 
+	// Step 5: Generate the example and README
+	logger.Info("Step 5/5: Generating example and README...")
+	if err := terraform.GenerateExampleFile(moduleName, loaded, terraform.WithOutputDir(".")); err != nil {
+		return fmt.Errorf("failed to generate example: %w", err)
+	}
+	if err := terraform.GenerateReadmeFile(moduleName, resourceType, "."); err != nil {
+		return fmt.Errorf("failed to generate README.md: %w", err)
+	}
+
+	if err := writeAVMManifest(manifest); err != nil {
+		return fmt.Errorf("failed to write %s: %w", avmManifestFile, err)
+	}
+	if previous != nil {
+		if moves := movedChildModules(previous, manifest); len(moves) > 0 {
+			if err := writeMovedFile(moves); err != nil {
+				return fmt.Errorf("failed to write moved.tf: %w", err)
+			}
+			logger.Info(fmt.Sprintf("Wrote %d moved block(s) to moved.tf for renamed child modules", len(moves)))
+		}
+	}
+
 	return nil
 }
 
+// loadSpecVersions returns the info.version of each spec that loads, keyed by spec source.
+func loadSpecVersions(specSources []string) map[string]string {
+	versions := make(map[string]string, len(specSources))
+	for _, specPath := range specSources {
+		doc, err := openapi.LoadSpec(specPath)
+		if err != nil || doc.Info == nil {
+			continue
+		}
+		versions[specPath] = doc.Info.Version
+	}
+	return versions
+}
+
+// specsPreferringVersion orders specSources so the specs of apiVersion come first, so a child
+// module is generated from the API version child discovery selected for it.
+func specsPreferringVersion(specSources []string, specVersions map[string]string, apiVersion string) []string {
+	ordered := make([]string, 0, len(specSources))
+	for _, specPath := range specSources {
+		if specVersions[specPath] == apiVersion {
+			ordered = append(ordered, specPath)
+		}
+	}
+	for _, specPath := range specSources {
+		if specVersions[specPath] != apiVersion {
+			ordered = append(ordered, specPath)
+		}
+	}
+	return ordered
+}
+
 func isInterfaceManagedChild(childResourceType string) bool {
 	// Today, the only known interface-managed child we want to suppress is Private Endpoint Connections.
 	// The interfaces module handles private endpoints through the  input.
@@ -411,14 +1015,28 @@ func isInterfaceManagedChild(childResourceType string) bool {
 	return strings.EqualFold(last, "privateEndpointConnections")
 }
 
-// generateBaseModule generates the base module files in the current directory
-func generateBaseModule(ctx context.Context, specSources []string, resourceType, localName string) error {
+// generateBaseModule generates the base module files in the current directory.
+// When schemaDefinition is set, the named definition is used as the body schema instead of
+// the one inferred from the resource's PUT operation.
+// Additional generator options are applied after the loaded resource options.
+func generateBaseModule(ctx context.Context, specSources []string, resourceType, schemaDefinition, localName string, extraOpts ...terraform.GeneratorOption) error {
 	// Load the resource from specs
-	result, err := terraform.LoadResource(ctx, specSources, resourceType)
+	var result terraform.GeneratorOption
+	var err error
+	if schemaDefinition != "" {
+		result, err = terraform.LoadSchemaDefinition(ctx, specSources, schemaDefinition)
+	} else {
+		result, err = terraform.LoadResource(ctx, specSources, resourceType)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to load resource: %w", err)
 	}
+	return generateLoadedModule(ctx, result, resourceType, localName, extraOpts...)
+}
 
+// generateLoadedModule generates the base module files in the current directory from the
+// loaded resource options, applying the additional generator options after them.
+func generateLoadedModule(ctx context.Context, result terraform.GeneratorOption, resourceType, localName string, extraOpts ...terraform.GeneratorOption) error {
 	// Determine local name
 	finalLocalName := "resource_body"
 	if localName != "" {
@@ -426,8 +1044,6 @@ func generateBaseModule(ctx context.Context, specSources []string, resourceType,
 	}
 
 	// Generate Terraform files
-	return terraform.Generate(resourceType,
-		result,
-		terraform.WithLocalName(finalLocalName),
-	)
+	opts := append([]terraform.GeneratorOption{result, terraform.WithLocalName(finalLocalName), terraform.WithLogger(loggerFromContext(ctx))}, extraOpts...)
+	return terraform.Generate(resourceType, opts...)
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v3"
+)
+
+// setupLogging is a cli.BeforeFunc that stores a logger at the level chosen by -verbose or
+// -quiet in the command context. Flags are inherited by subcommands, so it also covers
+// gen avm and gen submodule.
+func setupLogging(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	verbose := cmd.Bool("verbose")
+	quiet := cmd.Bool("quiet")
+	if verbose && quiet {
+		return ctx, fmt.Errorf("-verbose and -quiet are mutually exclusive")
+	}
+
+	level := slog.LevelInfo
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case quiet:
+		level = slog.LevelError
+	}
+	return context.WithValue(ctx, loggerKey{}, newCLILogger(os.Stderr, level)), nil
+}
+
+type loggerKey struct{}
+
+// loggerFromContext returns the logger stored by setupLogging, or an info-level logger for
+// commands without the logging flags.
+func loggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return newCLILogger(os.Stderr, slog.LevelInfo)
+}
+
+func newCLILogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(&cliHandler{w: w, level: level, mu: &sync.Mutex{}})
+}
+
+// cliHandler writes each record as a plain "message key=value ..." line, without the time and
+// level prefixes of slog's built-in handlers, as the output is read by people at a terminal.
+type cliHandler struct {
+	w     io.Writer
+	level slog.Level
+	attrs []slog.Attr
+	mu    *sync.Mutex
+}
+
+func (h *cliHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *cliHandler) Handle(_ context.Context, record slog.Record) error {
+	var sb strings.Builder
+	if record.Level >= slog.LevelWarn {
+		sb.WriteString(strings.ToLower(record.Level.String()))
+		sb.WriteString(": ")
+	}
+	sb.WriteString(record.Message)
+	writeAttr := func(attr slog.Attr) bool {
+		fmt.Fprintf(&sb, " %s=%v", attr.Key, attr.Value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		writeAttr(attr)
+	}
+	record.Attrs(writeAttr)
+	sb.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+func (h *cliHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup is a no-op: the CLI doesn't group attributes.
+func (h *cliHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/matt-FFFFFF/tfmodmake/naming"
 )
 
@@ -45,9 +48,42 @@ func deriveModuleName(childType string) string {
 	return naming.ToSnakeCase(segment)
 }
 
-// inferResourceTypeFromMainTf attempts to read the resource type from an existing main.tf file.
-func inferResourceTypeFromMainTf() (string, error) {
-	data, err := os.ReadFile("main.tf")
+// readAcronymsFile reads the acronyms listed in the file given to -acronyms, if any.
+func readAcronymsFile(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open acronyms file: %w", err)
+	}
+	defer f.Close()
+	acronyms, err := naming.ReadAcronyms(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read acronyms file %s: %w", path, err)
+	}
+	return acronyms, nil
+}
+
+// parseDefaultTags parses the key=value pairs given to -default-tags.
+func parseDefaultTags(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	tags := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid -default-tags entry %q: expected key=value", pair)
+		}
+		tags[strings.TrimSpace(key)] = value
+	}
+	return tags, nil
+}
+
+// inferResourceTypeFromMainTf attempts to read the resource type from an existing main.tf file in dir.
+func inferResourceTypeFromMainTf(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "main.tf"))
 	if err != nil {
 		return "", fmt.Errorf("could not read main.tf: %w", err)
 	}
@@ -63,7 +99,8 @@ func inferResourceTypeFromMainTf() (string, error) {
 			// Extract the value between quotes
 			parts := strings.Split(trimmed, "\"")
 			if len(parts) >= 2 {
-				resourceType := parts[1]
+				// Drop the "@<apiVersion>" suffix used by azapi_resource.
+				resourceType, _, _ := strings.Cut(parts[1], "@")
 				if strings.Contains(resourceType, "Microsoft.") {
 					return resourceType, nil
 				}
@@ -73,3 +110,25 @@ func inferResourceTypeFromMainTf() (string, error) {
 
 	return "", fmt.Errorf("could not find resource type in main.tf")
 }
+
+// requireExistingLocal returns an error unless locals.tf in dir defines local.<name>, so a run
+// that skips locals.tf leaves main.tf referring to a local that exists.
+func requireExistingLocal(dir, name string) error {
+	data, err := os.ReadFile(filepath.Join(dir, "locals.tf"))
+	if err != nil {
+		return fmt.Errorf("-skip-locals keeps an existing locals.tf, but it could not be read: %w\nGenerate once without -skip-locals, or use add locals to refresh only locals.tf", err)
+	}
+	file, diags := hclsyntax.ParseConfig(data, "locals.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse locals.tf: %s", diags.Error())
+	}
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		if _, ok := block.Body.Attributes[name]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("-skip-locals keeps the existing locals.tf, but it does not define local.%s, which main.tf uses as the resource body", name)
+}
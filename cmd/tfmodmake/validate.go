@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+	"github.com/urfave/cli/v3"
+)
+
+func ValidateSpecCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "validate-spec",
+		Usage: "Check a spec for issues that block or degrade module generation",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:     "spec",
+				Usage:    "Path or URL to OpenAPI spec",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "resource",
+				Usage:    "Resource type to check",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output the report as JSON",
+			},
+		},
+		Action: runValidateSpec,
+	}
+}
+
+func runValidateSpec(ctx context.Context, cmd *cli.Command) error {
+	specs := cmd.StringSlice("spec")
+	resourceType := cmd.String("resource")
+	jsonOutput := cmd.Bool("json")
+
+	report, err := terraform.AnalyzeResource(ctx, specs, resourceType)
+	if err != nil {
+		return fmt.Errorf("failed to analyze spec: %w", err)
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format as JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printSpecReport(report)
+	}
+
+	if report.HasErrors() {
+		return fmt.Errorf("spec validation failed for %s", resourceType)
+	}
+	return nil
+}
+
+func printSpecReport(report *terraform.SpecReport) {
+	if len(report.Issues) == 0 {
+		fmt.Printf("No issues found for %s\n", report.ResourceType)
+		return
+	}
+	fmt.Printf("Found %d issue(s) for %s:\n", len(report.Issues), report.ResourceType)
+	for _, issue := range report.Issues {
+		if issue.Path != "" {
+			fmt.Printf("  [%s] %s %s: %s\n", issue.Severity, issue.Code, issue.Path, issue.Message)
+		} else {
+			fmt.Printf("  [%s] %s: %s\n", issue.Severity, issue.Code, issue.Message)
+		}
+	}
+}
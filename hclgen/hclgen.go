@@ -106,6 +106,70 @@ func NullEqualityTernary(conditionExpr hclwrite.Tokens, trueExpr hclwrite.Tokens
 	return t
 }
 
+// NullOrEmptyTernary returns tokens for a ternary expression that also treats an empty
+// collection as null: try(length(condition), 0) == 0 ? null : trueExpr
+func NullOrEmptyTernary(conditionExpr hclwrite.Tokens, trueExpr hclwrite.Tokens) hclwrite.Tokens {
+	var t hclwrite.Tokens
+	t = append(t, hclwrite.TokensForFunctionCall("try", hclwrite.TokensForFunctionCall("length", conditionExpr), hclwrite.TokensForValue(cty.NumberIntVal(0)))...)
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte("==")})
+	t = append(t, hclwrite.TokensForValue(cty.NumberIntVal(0))...)
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte("?")})
+	t = append(t, hclwrite.TokensForIdentifier("null")...)
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	t = append(t, trueExpr...)
+	return t
+}
+
+// NullOrAllNullTernary returns tokens for a ternary expression that also treats an object whose
+// attributes are all null as null, since it is sent as {} once null attributes are dropped:
+// try(alltrue([for v in values(condition) : v == null]), true) ? null : trueExpr
+func NullOrAllNullTernary(conditionExpr hclwrite.Tokens, trueExpr hclwrite.Tokens) hclwrite.Tokens {
+	var allNull hclwrite.Tokens
+	allNull = append(allNull, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+	allNull = append(allNull, hclwrite.TokensForIdentifier("for")...)
+	allNull = append(allNull, hclwrite.TokensForIdentifier("v")...)
+	allNull = append(allNull, hclwrite.TokensForIdentifier("in")...)
+	allNull = append(allNull, hclwrite.TokensForFunctionCall("values", conditionExpr)...)
+	allNull = append(allNull, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	allNull = append(allNull, hclwrite.TokensForIdentifier("v")...)
+	allNull = append(allNull, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte("==")})
+	allNull = append(allNull, hclwrite.TokensForIdentifier("null")...)
+	allNull = append(allNull, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+	var t hclwrite.Tokens
+	t = append(t, hclwrite.TokensForFunctionCall("try", hclwrite.TokensForFunctionCall("alltrue", allNull), hclwrite.TokensForIdentifier("true"))...)
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte("?")})
+	t = append(t, hclwrite.TokensForIdentifier("null")...)
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	t = append(t, trueExpr...)
+	return t
+}
+
+// NonNullAttributes returns tokens for an object expression keeping only the attributes of
+// objectExpr that are not null: { for k, v in objectExpr : k => v if v != null }
+func NonNullAttributes(objectExpr hclwrite.Tokens) hclwrite.Tokens {
+	ident := func(name string) *hclwrite.Token {
+		return &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte(name), SpacesBefore: 1}
+	}
+	t := hclwrite.Tokens{
+		{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")},
+		ident("for"), ident("k"),
+		{Type: hclsyntax.TokenComma, Bytes: []byte(",")},
+		ident("v"), ident("in"),
+	}
+	t = append(t, objectExpr...)
+	t = append(t,
+		&hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":"), SpacesBefore: 1},
+		ident("k"),
+		&hclwrite.Token{Type: hclsyntax.TokenFatArrow, Bytes: []byte("=>"), SpacesBefore: 1},
+		ident("v"), ident("if"), ident("v"),
+		&hclwrite.Token{Type: hclsyntax.TokenNotEqual, Bytes: []byte("!="), SpacesBefore: 1},
+		ident("null"),
+		&hclwrite.Token{Type: hclsyntax.TokenCBrace, Bytes: []byte("}"), SpacesBefore: 1},
+	)
+	return t
+}
+
 // SetDescriptionAttribute sets the description attribute on a body using a heredoc.
 func SetDescriptionAttribute(body *hclwrite.Body, description string) {
 	body.SetAttributeRaw("description", TokensForHeredoc(description))
@@ -170,13 +234,12 @@ func TokensForMultilineStringListWithValueComments(values []string) hclwrite.Tok
 	return tokens
 }
 
-// WriteFile writes an HCL file to disk.
+// WriteFile writes an HCL file to disk, formatted as terraform fmt would.
 func WriteFile(path string, file *hclwrite.File) error {
-	return os.WriteFile(path, file.Bytes(), 0o644)
+	return os.WriteFile(path, hclwrite.Format(file.Bytes()), 0o644)
 }
 
-// WriteFileToDir writes an HCL file to a specified directory.
+// WriteFileToDir writes an HCL file to a specified directory, formatted as terraform fmt would.
 func WriteFileToDir(outputDir string, filename string, file *hclwrite.File) error {
-	path := filepath.Join(outputDir, filename)
-	return os.WriteFile(path, file.Bytes(), 0o644)
+	return WriteFile(filepath.Join(outputDir, filename), file)
 }
@@ -1,6 +1,9 @@
 package naming
 
 import (
+	"bufio"
+	"io"
+	"sort"
 	"strings"
 	"unicode"
 )
@@ -90,3 +93,95 @@ func ToSnakeCase(input string) string {
 	}
 	return out
 }
+
+// Namer converts names to snake_case like ToSnakeCase, additionally keeping each of its
+// acronyms together as one word, so with VNet, vNetId becomes vnet_id rather than v_net_id.
+// A nil *Namer behaves exactly like ToSnakeCase.
+type Namer struct {
+	acronyms [][]rune
+}
+
+// NewNamer returns a Namer for the given acronyms. Matching is case-insensitive; the longest
+// acronym wins where several match.
+func NewNamer(acronyms ...string) *Namer {
+	n := &Namer{}
+	for _, acronym := range acronyms {
+		if acronym = strings.TrimSpace(acronym); acronym != "" {
+			n.acronyms = append(n.acronyms, []rune(acronym))
+		}
+	}
+	sort.SliceStable(n.acronyms, func(i, j int) bool {
+		return len(n.acronyms[i]) > len(n.acronyms[j])
+	})
+	return n
+}
+
+// ReadAcronyms reads acronyms one per line, ignoring blank lines and lines starting with #.
+func ReadAcronyms(r io.Reader) ([]string, error) {
+	var acronyms []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		acronyms = append(acronyms, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return acronyms, nil
+}
+
+// ToSnakeCase converts input to snake_case. Each acronym found at a word boundary is first
+// rewritten as a single capitalised word (vNetId -> vnetId, SubnetVNet -> SubnetVnet), which
+// ToSnakeCase then keeps together.
+func (n *Namer) ToSnakeCase(input string) string {
+	if n == nil || len(n.acronyms) == 0 {
+		return ToSnakeCase(input)
+	}
+
+	runes := []rune(input)
+	var sb strings.Builder
+	for i := 0; i < len(runes); {
+		length := n.acronymAt(runes, i)
+		if length == 0 {
+			sb.WriteRune(runes[i])
+			i++
+			continue
+		}
+		for j, r := range runes[i : i+length] {
+			if j == 0 && unicode.IsUpper(r) {
+				sb.WriteRune(r)
+				continue
+			}
+			sb.WriteRune(unicode.ToLower(r))
+		}
+		i += length
+	}
+	return ToSnakeCase(sb.String())
+}
+
+// acronymAt returns the length of the acronym starting a word at runes[i], or 0 if none does.
+func (n *Namer) acronymAt(runes []rune, i int) int {
+	if i > 0 {
+		prev := runes[i-1]
+		startsWord := !unicode.IsLetter(prev) && !unicode.IsDigit(prev) ||
+			unicode.IsUpper(runes[i]) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+		if !startsWord {
+			return 0
+		}
+	}
+	for _, acronym := range n.acronyms {
+		end := i + len(acronym)
+		if end > len(runes) || !strings.EqualFold(string(runes[i:end]), string(acronym)) {
+			continue
+		}
+		// A following lower-case letter means the match is only the start of a longer word.
+		if end < len(runes) && unicode.IsLower(runes[end]) {
+			continue
+		}
+		return len(acronym)
+	}
+	return 0
+}
@@ -1,6 +1,7 @@
 package openapi
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -8,6 +9,10 @@ import (
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
+// ErrAllOfCycle is returned, wrapped, when an allOf chain refers back to a schema that is
+// already being merged.
+var ErrAllOfCycle = errors.New("circular reference detected in allOf chain")
+
 // GetEffectiveProperties returns the effective properties map for a schema,
 // merging properties from allOf components if present.
 // This is used for shape generation (types/locals) but preserves the original schema
@@ -34,7 +39,7 @@ func getEffectivePropertiesRecursive(schema *openapi3.Schema, cache map[*openapi
 
 	// Check for cycles
 	if _, active := inProgress[schema]; active {
-		return nil, fmt.Errorf("circular reference detected in allOf chain while getting effective properties")
+		return nil, fmt.Errorf("%w while getting effective properties", ErrAllOfCycle)
 	}
 
 	// Mark as in-progress
@@ -135,7 +140,7 @@ func getEffectiveRequiredRecursive(schema *openapi3.Schema, cache map[*openapi3.
 
 	// Check for cycles
 	if _, active := inProgress[schema]; active {
-		return nil, fmt.Errorf("circular reference detected in allOf chain while getting effective required fields")
+		return nil, fmt.Errorf("%w while getting effective required fields", ErrAllOfCycle)
 	}
 
 	// Mark as in-progress
@@ -186,6 +191,64 @@ func getEffectiveRequiredRecursive(schema *openapi3.Schema, cache map[*openapi3.
 	return result, nil
 }
 
+// GetEffectiveTypes returns the types of schema (see EffectiveTypes) or, for a typeless schema
+// composed with allOf, those of its first allOf component with a type. A component may declare
+// only type: object, or only additionalProperties, leaving the composed schema untyped.
+func GetEffectiveTypes(schema *openapi3.Schema) []string {
+	return getEffectiveTypesRecursive(schema, make(map[*openapi3.Schema]struct{}))
+}
+
+func getEffectiveTypesRecursive(schema *openapi3.Schema, inProgress map[*openapi3.Schema]struct{}) []string {
+	if types := EffectiveTypes(schema); types != nil || schema == nil {
+		return types
+	}
+	if _, active := inProgress[schema]; active {
+		return nil
+	}
+	inProgress[schema] = struct{}{}
+	defer delete(inProgress, schema)
+
+	for _, componentRef := range schema.AllOf {
+		if componentRef == nil {
+			continue
+		}
+		if types := getEffectiveTypesRecursive(componentRef.Value, inProgress); types != nil {
+			return types
+		}
+	}
+	return nil
+}
+
+// GetEffectiveAdditionalProperties returns the additionalProperties schema of schema or, when it
+// declares none, that of its first allOf component declaring one, or nil.
+func GetEffectiveAdditionalProperties(schema *openapi3.Schema) *openapi3.Schema {
+	return getEffectiveAdditionalPropertiesRecursive(schema, make(map[*openapi3.Schema]struct{}))
+}
+
+func getEffectiveAdditionalPropertiesRecursive(schema *openapi3.Schema, inProgress map[*openapi3.Schema]struct{}) *openapi3.Schema {
+	if schema == nil {
+		return nil
+	}
+	if schema.AdditionalProperties.Schema != nil && schema.AdditionalProperties.Schema.Value != nil {
+		return schema.AdditionalProperties.Schema.Value
+	}
+	if _, active := inProgress[schema]; active {
+		return nil
+	}
+	inProgress[schema] = struct{}{}
+	defer delete(inProgress, schema)
+
+	for _, componentRef := range schema.AllOf {
+		if componentRef == nil {
+			continue
+		}
+		if additional := getEffectiveAdditionalPropertiesRecursive(componentRef.Value, inProgress); additional != nil {
+			return additional
+		}
+	}
+	return nil
+}
+
 // schemasEquivalent checks if two schemas are equivalent for the purposes of allOf merging.
 // It's tolerant of differences in documentation and extension fields.
 func schemasEquivalent(a, b *openapi3.Schema) bool {
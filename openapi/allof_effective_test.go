@@ -107,6 +107,7 @@ func TestGetEffectiveProperties_CycleDetection(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, props)
 	assert.Contains(t, err.Error(), "circular reference")
+	assert.ErrorIs(t, err, ErrAllOfCycle)
 }
 
 func TestGetEffectiveProperties_NestedAllOf(t *testing.T) {
@@ -215,6 +216,7 @@ func TestGetEffectiveRequired_CycleDetection(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, required)
 	assert.Contains(t, err.Error(), "circular reference")
+	assert.ErrorIs(t, err, ErrAllOfCycle)
 }
 
 func TestGetEffectiveRequired_DuplicatesHandled(t *testing.T) {
@@ -14,6 +14,10 @@ type InterfaceCapabilities struct {
 	SupportsDiagnostics        bool
 	SupportsCustomerManagedKey bool
 	SupportsManagedIdentity    bool
+	// DiagnosticLogCategories and DiagnosticLogGroups are the log categories and category groups
+	// the resource's diagnostic settings accept, when the spec defines them.
+	DiagnosticLogCategories []string
+	DiagnosticLogGroups     []string
 	// Lock and RoleAssignments are ARM-level capabilities not detectable from individual resource specs
 }
 
@@ -33,6 +37,9 @@ func DetectInterfaceCapabilities(spec *openapi3.T, resourceType string) Interfac
 	// declare it in their own spec; it's a generic Microsoft.Insights capability on most ARM resources
 	// For now, we'll assume most resources support diagnostics unless we have specific evidence otherwise
 	caps.SupportsDiagnostics = detectDiagnosticSupport(spec, resourceType)
+	if caps.SupportsDiagnostics {
+		caps.DiagnosticLogCategories, caps.DiagnosticLogGroups = detectDiagnosticCategories(spec, resourceType)
+	}
 
 	// Check for customer-managed key support by looking for encryption properties in the schema
 	caps.SupportsCustomerManagedKey = detectCustomerManagedKeySupport(spec, resourceType)
@@ -75,58 +82,212 @@ func detectPrivateEndpointSupport(spec *openapi3.T, resourceType string) bool {
 	return false
 }
 
+// diagnosticSettingsExtensionSegment is the extension path segment used to manage diagnostic
+// settings on a resource, e.g. <resourceId>/providers/Microsoft.Insights/diagnosticSettings.
+const diagnosticSettingsExtensionSegment = "/providers/microsoft.insights/diagnosticsettings"
+
+// knownDiagnosticsResourceTypes lists resource types known to support diagnostic settings whose
+// specs don't declare a diagnosticSettings extension path. Keys are lower case.
+var knownDiagnosticsResourceTypes = map[string]struct{}{
+	"microsoft.app/managedenvironments":          {},
+	"microsoft.cognitiveservices/accounts":       {},
+	"microsoft.containerregistry/registries":     {},
+	"microsoft.containerservice/managedclusters": {},
+	"microsoft.eventhub/namespaces":              {},
+	"microsoft.keyvault/vaults":                  {},
+	"microsoft.servicebus/namespaces":            {},
+	"microsoft.storage/storageaccounts":          {},
+	"microsoft.web/sites":                        {},
+}
+
+// diagnosticSettingsCategoriesExtensionSegment is the extension path segment listing the
+// diagnostic categories of a resource, e.g.
+// <resourceId>/providers/Microsoft.Insights/diagnosticSettingsCategories/{name}.
+const diagnosticSettingsCategoriesExtensionSegment = "/providers/microsoft.insights/diagnosticsettingscategories/"
+
 // detectDiagnosticSupport checks if the resource supports diagnostic settings.
-// Currently returns false by default since diagnostic settings are a generic ARM capability
-// managed via Microsoft.Insights, not declared in individual resource provider specs.
+// Diagnostic settings are a Microsoft.Insights extension resource, so support is only reported
+// when the spec exposes a diagnosticSettings extension path under the resource, or when the
+// resource type is on the known-support list.
 func detectDiagnosticSupport(spec *openapi3.T, resourceType string) bool {
-	// Diagnostic settings are managed via Microsoft.Insights provider and work on most ARM resources.
-	// Individual resource provider specs don't typically declare diagnostic settings support.
-	// We should NOT auto-generate this unless there's explicit evidence.
-	return false
-}
+	normalized := normalizeDiagnosticsResourceType(resourceType)
+	if _, ok := knownDiagnosticsResourceTypes[normalized]; ok {
+		return true
+	}
 
-// detectCustomerManagedKeySupport checks if the schema includes encryption/customerManagedKey properties
-func detectCustomerManagedKeySupport(spec *openapi3.T, resourceType string) bool {
-	if spec.Components == nil || spec.Components.Schemas == nil {
+	if spec.Paths == nil {
 		return false
 	}
+	for path := range spec.Paths.Map() {
+		if isInstanceExtensionPath(strings.ToLower(path), normalized, diagnosticSettingsExtensionSegment) {
+			return true
+		}
+	}
+	return false
+}
 
-	// Check PUT operation request body schema for encryption properties
+// detectDiagnosticCategories returns the log categories and category groups of the resource from
+// a Microsoft.Insights diagnosticSettingsCategories definition under the resource instance. The
+// categories are the enum of the path's category name parameter, and the groups the enum of the
+// categoryGroups items in the GET response. Either is nil when the spec doesn't enumerate it.
+func detectDiagnosticCategories(spec *openapi3.T, resourceType string) (categories, groups []string) {
+	if spec.Paths == nil {
+		return nil, nil
+	}
+	normalized := normalizeDiagnosticsResourceType(resourceType)
 	for path, pathItem := range spec.Paths.Map() {
-		if pathItem == nil {
+		pathLower := strings.ToLower(path)
+		if pathItem == nil || pathItem.Get == nil || !isInstanceExtensionPath(pathLower, normalized, diagnosticSettingsCategoriesExtensionSegment) {
 			continue
 		}
+		nameSegment := path[strings.Index(pathLower, diagnosticSettingsCategoriesExtensionSegment)+len(diagnosticSettingsCategoriesExtensionSegment):]
+		if !isPathParam(nameSegment) {
+			continue
+		}
+		paramName := strings.Trim(nameSegment, "{}")
+		params := append(append(openapi3.Parameters(nil), pathItem.Parameters...), pathItem.Get.Parameters...)
+		for _, paramRef := range params {
+			if paramRef != nil && paramRef.Value != nil && paramRef.Value.In == "path" && paramRef.Value.Name == paramName && paramRef.Value.Schema != nil {
+				categories = enumStrings(paramRef.Value.Schema.Value)
+			}
+		}
+		groups = categoryGroupsEnum(pathItem.Get)
+		if categories != nil || groups != nil {
+			return categories, groups
+		}
+	}
+	return nil, nil
+}
 
-		// Only check PUT operations (create/update)
-		if pathItem.Put == nil {
+// categoryGroupsEnum returns the enum of properties.categoryGroups items in the 200 response of
+// a diagnosticSettingsCategories GET.
+func categoryGroupsEnum(op *openapi3.Operation) []string {
+	if op.Responses == nil {
+		return nil
+	}
+	response := op.Responses.Status(200)
+	if response == nil || response.Value == nil {
+		return nil
+	}
+	for _, content := range response.Value.Content {
+		if content.Schema == nil || content.Schema.Value == nil {
+			continue
+		}
+		props, err := GetEffectiveProperties(content.Schema.Value)
+		if err != nil || props["properties"] == nil || props["properties"].Value == nil {
 			continue
 		}
+		inner, err := GetEffectiveProperties(props["properties"].Value)
+		if err != nil || inner["categoryGroups"] == nil || inner["categoryGroups"].Value == nil {
+			continue
+		}
+		if items := inner["categoryGroups"].Value.Items; items != nil {
+			return enumStrings(items.Value)
+		}
+	}
+	return nil
+}
 
-		// Check if this path matches the resource type
-		if !strings.Contains(strings.ToLower(path), strings.ToLower(resourceType)) {
+// enumStrings returns the string values of schema's enum, sorted, or nil when it has none.
+func enumStrings(schema *openapi3.Schema) []string {
+	if schema == nil {
+		return nil
+	}
+	var values []string
+	for _, v := range schema.Enum {
+		if s, ok := v.(string); ok && !slices.Contains(values, s) {
+			values = append(values, s)
+		}
+	}
+	slices.Sort(values)
+	return values
+}
+
+// normalizeDiagnosticsResourceType lower-cases resourceType and drops a trailing instance name
+// parameter, e.g. Microsoft.Test/widgets/{widgetName} becomes microsoft.test/widgets.
+func normalizeDiagnosticsResourceType(resourceType string) string {
+	normalized := strings.ToLower(resourceType)
+	if strings.HasSuffix(normalized, "}") {
+		if idx := strings.LastIndex(normalized, "/{"); idx != -1 {
+			normalized = normalized[:idx]
+		}
+	}
+	return normalized
+}
+
+// isInstanceExtensionPath reports whether the lower-cased pathLower is an extension at segment
+// scoped to an instance of the normalized resource type, e.g.
+// .../providers/Microsoft.Test/widgets/{name}/providers/Microsoft.Insights/diagnosticSettings.
+func isInstanceExtensionPath(pathLower, normalized, segment string) bool {
+	idx := strings.Index(pathLower, segment)
+	if idx == -1 {
+		return false
+	}
+	scope := pathLower[:idx]
+	typeIdx := strings.LastIndex(scope, "/providers/"+normalized+"/")
+	if typeIdx == -1 {
+		return false
+	}
+	// Only the instance name may follow the type, so child resources don't count.
+	instance := scope[typeIdx+len("/providers/"+normalized+"/"):]
+	return instance != "" && !strings.Contains(instance, "/")
+}
+
+// detectCustomerManagedKeySupport reports whether the resource's PUT request body has a writable
+// encryption, customerManagedKey or keyVaultProperties object, either at the root or in the
+// top-level properties bag (e.g. properties.encryption).
+func detectCustomerManagedKeySupport(spec *openapi3.T, resourceType string) bool {
+	if spec.Paths == nil {
+		return false
+	}
+
+	for path, pathItem := range spec.Paths.Map() {
+		if pathItem == nil || pathItem.Put == nil {
 			continue
 		}
 
-		// Check request body schema
-		if pathItem.Put.RequestBody == nil || pathItem.Put.RequestBody.Value == nil {
+		// Only the resource's own instance path counts; child resources may have their own encryption.
+		pathType, _, ok := azureARMInstancePathInfo(path)
+		if !ok || !strings.EqualFold(pathType, resourceType) {
 			continue
 		}
 
-		for _, content := range pathItem.Put.RequestBody.Value.Content {
-			if content.Schema == nil || content.Schema.Value == nil {
-				continue
-			}
+		if hasEncryptionProperty(putRequestBodySchema(pathItem.Put)) {
+			return true
+		}
+	}
 
-			if hasEncryptionProperty(content.Schema.Value) {
-				return true
+	return false
+}
+
+// putRequestBodySchema returns the request body schema of op, from either the OpenAPI 3
+// requestBody or a Swagger/OpenAPI v2 body parameter.
+func putRequestBodySchema(op *openapi3.Operation) *openapi3.Schema {
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		for _, content := range op.RequestBody.Value.Content {
+			if content.Schema != nil && content.Schema.Value != nil {
+				return content.Schema.Value
 			}
 		}
 	}
+	for _, paramRef := range op.Parameters {
+		if paramRef.Value != nil && paramRef.Value.In == "body" && paramRef.Value.Schema != nil {
+			return paramRef.Value.Schema.Value
+		}
+	}
+	return nil
+}
 
-	return false
+// encryptionPropertyNames are the lowercased property names that indicate customer-managed key
+// configuration.
+var encryptionPropertyNames = map[string]struct{}{
+	"encryption":         {},
+	"customermanagedkey": {},
+	"keyvaultproperties": {},
 }
 
-// hasEncryptionProperty recursively checks if a schema has encryption/customerManagedKey properties
+// hasEncryptionProperty checks if a schema, or its top-level properties bag, has a writable
+// encryption/customerManagedKey/keyVaultProperties object.
 func hasEncryptionProperty(schema *openapi3.Schema) bool {
 	if schema == nil {
 		return false
@@ -139,21 +300,16 @@ func hasEncryptionProperty(schema *openapi3.Schema) bool {
 	}
 
 	for propName, propRef := range props {
-		if propRef == nil || propRef.Value == nil {
+		if propRef == nil || propRef.Value == nil || !isWritableSchema(propRef.Value) {
 			continue
 		}
 
-		propNameLower := strings.ToLower(propName)
-
-		// Check for encryption-related property names
-		if propNameLower == "encryption" ||
-			propNameLower == "customermanagedkey" ||
-			strings.Contains(propNameLower, "encryptionkey") {
+		if _, ok := encryptionPropertyNames[strings.ToLower(propName)]; ok && isObjectSchema(propRef.Value) {
 			return true
 		}
 
 		// Check nested properties object
-		if propName == "properties" && propRef.Value.Type != nil && slices.Contains(*propRef.Value.Type, "object") {
+		if propName == "properties" && isObjectSchema(propRef.Value) {
 			if hasEncryptionProperty(propRef.Value) {
 				return true
 			}
@@ -163,6 +319,66 @@ func hasEncryptionProperty(schema *openapi3.Schema) bool {
 	return false
 }
 
+// isObjectSchema reports whether schema describes an object, either by type or by declaring
+// properties directly or through allOf.
+func isObjectSchema(schema *openapi3.Schema) bool {
+	if slices.Contains(EffectiveTypes(schema), "object") {
+		return true
+	}
+	return schema.Type == nil && len(schema.AllOf) > 0
+}
+
+// EffectiveTypes returns the types declared by schema. Some specs omit type on object schemas,
+// so a typeless schema declaring properties, additionalProperties or patternProperties is
+// treated as an object.
+func EffectiveTypes(schema *openapi3.Schema) []string {
+	if schema == nil {
+		return nil
+	}
+	if schema.Type != nil {
+		return *schema.Type
+	}
+	if len(schema.Properties) > 0 || schema.AdditionalProperties.Schema != nil || (schema.AdditionalProperties.Has != nil && *schema.AdditionalProperties.Has) {
+		return []string{"object"}
+	}
+	if _, ok := schema.Extensions["patternProperties"]; ok {
+		return []string{"object"}
+	}
+	return nil
+}
+
+// isWritableSchema reports whether a property can be set by the user: it is not readOnly and,
+// when x-ms-mutability is declared, it includes create or update.
+func isWritableSchema(schema *openapi3.Schema) bool {
+	if schema.ReadOnly {
+		return false
+	}
+	raw, ok := schema.Extensions["x-ms-mutability"]
+	if !ok {
+		return true
+	}
+	var mutabilities []string
+	switch v := raw.(type) {
+	case []string:
+		mutabilities = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				mutabilities = append(mutabilities, s)
+			}
+		}
+	}
+	if len(mutabilities) == 0 {
+		return true
+	}
+	for _, m := range mutabilities {
+		if m := strings.ToLower(strings.TrimSpace(m)); m == "create" || m == "update" {
+			return true
+		}
+	}
+	return false
+}
+
 // detectManagedIdentitySupport checks if the schema includes identity property.
 // Most Azure resources that support managed identity have an "identity" property in their schema.
 func detectManagedIdentitySupport(spec *openapi3.T, resourceType string) bool {
@@ -241,7 +457,7 @@ func hasIdentityProperty(schema *openapi3.Schema) bool {
 		}
 
 		// Check nested properties object
-		if propName == "properties" && propRef.Value.Type != nil && slices.Contains(*propRef.Value.Type, "object") {
+		if propName == "properties" && isObjectSchema(propRef.Value) {
 			if hasIdentityProperty(propRef.Value) {
 				return true
 			}
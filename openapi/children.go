@@ -3,6 +3,7 @@ package openapi
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -18,19 +19,64 @@ type ChildResource struct {
 	IsDeployable        bool     // Whether this resource can be deployed
 	DeployabilityReason string   // Reason if not deployable
 	APIVersion          string   // API version where this was found
+	// Schema is the writable request body schema (see WritableSchema), only set for deployable
+	// children when DiscoverChildrenOptions.IncludeSchemas is set.
+	Schema *openapi3.Schema `json:"schema,omitempty"`
+	// ParentIDTemplate is how the child's ID extends the parent's, e.g.
+	// "<parent_id>/certificates" for Microsoft.App/managedEnvironments/certificates, only set
+	// for deployable children when DiscoverChildrenOptions.IncludeParentIDTemplates is set.
+	ParentIDTemplate string `json:",omitempty"`
+}
+
+// ChildAction represents a POST action on the parent or a child resource instance
+// (e.g. start, listKeys). Actions are never deployable.
+type ChildAction struct {
+	ResourceType string // Resource type the action is invoked on
+	Name         string // Action name, e.g. "listKeys"
+	Path         string // Example action path
+	Method       string // HTTP method, e.g. "POST"
+	HasBody      bool   // Whether the action takes a request body
+	APIVersion   string // API version where this was found
 }
 
 // ChildrenResult holds the result of child resource discovery.
 type ChildrenResult struct {
 	Deployable  []ChildResource // Resources that can be deployed
 	FilteredOut []ChildResource // Resources that were filtered out with reasons
+	Actions     []ChildAction   // Actions, only populated when IncludeActions is set
 }
 
+// API version selection policies for DiscoverChildrenOptions.VersionSelect.
+const (
+	// VersionSelectLatestStable prefers the latest stable API version, so a newer -preview
+	// version is ignored. Children only found in preview versions keep their latest preview.
+	VersionSelectLatestStable = "latest-stable"
+	// VersionSelectLatestAny prefers the latest API version, preview or not.
+	VersionSelectLatestAny = "latest-any"
+	// VersionSelectExact only reads specs of DiscoverChildrenOptions.APIVersion, which must be set.
+	VersionSelectExact = "exact"
+)
+
 // DiscoverChildrenOptions holds options for child discovery.
 type DiscoverChildrenOptions struct {
 	Specs  []string // Paths or URLs to OpenAPI specs
 	Parent string   // Parent resource type (e.g. "Microsoft.App/managedEnvironments")
 	Depth  int      // How many levels deep to search (default 1 for direct children)
+	// APIVersion, when set, restricts discovery to specs of exactly this API version
+	// (e.g. "2024-01-01"). It is an error if none of the specs match.
+	APIVersion string
+	// VersionSelect is the policy choosing which API version of a child is reported when specs
+	// of several versions are given: one of the VersionSelect constants, latest-stable when
+	// empty.
+	VersionSelect string
+	// IncludeActions also collects POST actions on the parent and its children into
+	// ChildrenResult.Actions.
+	IncludeActions bool
+	// IncludeSchemas attaches each deployable child's request body schema, from the spec of
+	// its latest API version, to ChildResource.Schema.
+	IncludeSchemas bool
+	// IncludeParentIDTemplates sets ChildResource.ParentIDTemplate on each deployable child.
+	IncludeParentIDTemplates bool
 }
 
 // DiscoverChildren discovers child resources under a parent resource type from OpenAPI specs.
@@ -45,6 +91,17 @@ func DiscoverChildren(opts DiscoverChildrenOptions) (*ChildrenResult, error) {
 	if opts.Depth <= 0 {
 		opts.Depth = 1 // Default to direct children only
 	}
+	switch opts.VersionSelect {
+	case "":
+		opts.VersionSelect = VersionSelectLatestStable
+	case VersionSelectLatestStable, VersionSelectLatestAny:
+	case VersionSelectExact:
+		if opts.APIVersion == "" {
+			return nil, fmt.Errorf("%s version selection requires an api version", VersionSelectExact)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported version selection %q: expected %s, %s or %s", opts.VersionSelect, VersionSelectLatestStable, VersionSelectLatestAny, VersionSelectExact)
+	}
 
 	// Normalize parent type
 	parentType := opts.Parent
@@ -57,7 +114,13 @@ func DiscoverChildren(opts DiscoverChildrenOptions) (*ChildrenResult, error) {
 	// Map to collect unique children across all specs
 	// Key: resource type, Value: ChildResource
 	childrenMap := make(map[string]*ChildResource)
+	actionsMap := make(map[string]*ChildAction)
+	// API version each child's Schema was read from, so a later spec can replace it.
+	schemaVersions := make(map[string]string)
 
+	// Track spec versions so a pinned APIVersion that matches nothing can be reported.
+	var availableVersions []string
+	versionMatched := false
 	for _, specPath := range opts.Specs {
 		doc, err := LoadSpec(specPath)
 		if err != nil {
@@ -65,11 +128,31 @@ func DiscoverChildren(opts DiscoverChildrenOptions) (*ChildrenResult, error) {
 		}
 
 		apiVersion := extractAPIVersion(doc, specPath)
+		if opts.APIVersion != "" && apiVersion != opts.APIVersion {
+			if apiVersion != "" && !slices.Contains(availableVersions, apiVersion) {
+				availableVersions = append(availableVersions, apiVersion)
+			}
+			continue
+		}
+		versionMatched = true
 
 		// Discover children in this spec
-		if err := discoverChildrenInSpec(doc, parentType, opts.Depth, apiVersion, childrenMap); err != nil {
+		if err := discoverChildrenInSpec(doc, parentType, opts.Depth, apiVersion, opts.VersionSelect, childrenMap); err != nil {
 			return nil, fmt.Errorf("failed to discover children in spec %s: %w", specPath, err)
 		}
+		if opts.IncludeActions {
+			discoverActionsInSpec(doc, parentType, opts.Depth, apiVersion, opts.VersionSelect, actionsMap)
+		}
+		if opts.IncludeSchemas {
+			if err := attachChildSchemas(doc, specPath, apiVersion, childrenMap, schemaVersions); err != nil {
+				return nil, fmt.Errorf("failed to read child schemas in spec %s: %w", specPath, err)
+			}
+		}
+	}
+
+	if opts.APIVersion != "" && !versionMatched {
+		slices.Sort(availableVersions)
+		return nil, fmt.Errorf("api version %s not found in provided specs (available: %s)", opts.APIVersion, strings.Join(availableVersions, ", "))
 	}
 
 	// Split into deployable and filtered-out
@@ -80,15 +163,79 @@ func DiscoverChildren(opts DiscoverChildrenOptions) (*ChildrenResult, error) {
 
 	for _, child := range childrenMap {
 		if child.IsDeployable {
+			if opts.IncludeParentIDTemplates && len(child.ExamplePaths) > 0 {
+				child.ParentIDTemplate = parentIDTemplate(slices.Min(child.ExamplePaths), parentType)
+			}
 			result.Deployable = append(result.Deployable, *child)
 		} else {
 			result.FilteredOut = append(result.FilteredOut, *child)
 		}
 	}
 
+	if opts.IncludeActions {
+		result.Actions = make([]ChildAction, 0, len(actionsMap))
+		for _, action := range actionsMap {
+			result.Actions = append(result.Actions, *action)
+		}
+	}
+
 	return result, nil
 }
 
+// parentIDTemplate returns how the ID of the resource at the instance path extends the ID of
+// its ancestor of type parentType: "<parent_id>" followed by the path segments between the
+// two, without the resource's own name, e.g. "<parent_id>/certificates" or, for a grandchild,
+// "<parent_id>/daprComponents/{componentName}/resiliencyPolicies". Path parameters of
+// intermediate resources are kept as placeholders.
+func parentIDTemplate(path, parentType string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	providersIdx := slices.IndexFunc(segments, func(seg string) bool { return strings.EqualFold(seg, "providers") })
+	if providersIdx == -1 {
+		return ""
+	}
+	// After the provider namespace, each type of the parent is followed by its name.
+	start := providersIdx + 2 + 2*(len(strings.Split(parentType, "/"))-1)
+	if start >= len(segments)-1 {
+		return ""
+	}
+	return "<parent_id>/" + strings.Join(segments[start:len(segments)-1], "/")
+}
+
+// attachChildSchemas sets Schema on the deployable children whose latest API version is the one
+// of doc. Children without a PUT body in doc, e.g. PATCH-only ones, are left without a schema.
+func attachChildSchemas(doc *openapi3.T, specPath, apiVersion string, childrenMap map[string]*ChildResource, schemaVersions map[string]string) error {
+	var pending []string
+	for resourceType, child := range childrenMap {
+		if !child.IsDeployable || child.APIVersion != apiVersion || schemaVersions[resourceType] == apiVersion {
+			continue
+		}
+		pending = append(pending, resourceType)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// As in terraform.LoadResource, writability overrides are best effort.
+	resolver, _ := NewPropertyWritabilityResolver(specPath)
+	for _, resourceType := range pending {
+		schema, err := FindResource(doc, resourceType)
+		if err != nil {
+			continue
+		}
+		AnnotateSchemaRefOrigins(schema)
+		if resolver != nil {
+			ApplyPropertyWritabilityOverrides(schema, resolver)
+		}
+		writable, err := WritableSchema(schema)
+		if err != nil {
+			return fmt.Errorf("%s: %w", resourceType, err)
+		}
+		childrenMap[resourceType].Schema = writable
+		schemaVersions[resourceType] = apiVersion
+	}
+	return nil
+}
+
 // extractAPIVersion extracts the API version from the OpenAPI document with fallback strategies.
 // 1. Try doc.Info.Version (most common in Azure specs)
 // 2. Try to extract from spec path/URL (e.g., .../2024-01-01/... or .../stable/2024-01-01/...)
@@ -136,7 +283,7 @@ func extractAPIVersion(doc *openapi3.T, specPath string) string {
 	return ""
 }
 
-func discoverChildrenInSpec(doc *openapi3.T, parentType string, depth int, apiVersion string, childrenMap map[string]*ChildResource) error {
+func discoverChildrenInSpec(doc *openapi3.T, parentType string, depth int, apiVersion, versionSelect string, childrenMap map[string]*ChildResource) error {
 	if doc == nil || doc.Paths == nil {
 		return nil
 	}
@@ -172,10 +319,7 @@ func discoverChildrenInSpec(doc *openapi3.T, parentType string, depth int, apiVe
 			if !contains(child.ExamplePaths, path) {
 				child.ExamplePaths = append(child.ExamplePaths, path)
 			}
-			// Prefer later API version. Azure API versions use YYYY-MM-DD format (with optional
-			// -preview suffix), so simple string comparison is sufficient and correct.
-			// Examples: "2024-01-01" < "2024-03-01", "2024-01-01" < "2025-10-02-preview"
-			if apiVersion > child.APIVersion {
+			if isPreferredVersion(apiVersion, child.APIVersion, versionSelect) {
 				child.APIVersion = apiVersion
 			}
 		}
@@ -230,6 +374,111 @@ func discoverChildrenInSpec(doc *openapi3.T, parentType string, depth int, apiVe
 	return nil
 }
 
+// discoverActionsInSpec collects POST actions whose path is an instance path of the parent, or
+// of a child within depth, followed by a single action segment
+// (e.g. .../managedEnvironments/{environmentName}/listKeys).
+func discoverActionsInSpec(doc *openapi3.T, parentType string, depth int, apiVersion, versionSelect string, actionsMap map[string]*ChildAction) {
+	if doc == nil || doc.Paths == nil {
+		return
+	}
+
+	for path, pathItem := range doc.Paths.Map() {
+		if pathItem == nil || pathItem.Post == nil {
+			continue
+		}
+
+		idx := strings.LastIndex(path, "/")
+		if idx <= 0 {
+			continue
+		}
+		name := path[idx+1:]
+		if name == "" || isPathParam(name) {
+			continue
+		}
+
+		resourceType, _, ok := azureARMInstancePathInfo(path[:idx])
+		if !ok {
+			continue
+		}
+		if !strings.EqualFold(resourceType, parentType) && !isChildOf(resourceType, parentType, depth) {
+			continue
+		}
+
+		key := resourceType + "/" + name
+		if existing, exists := actionsMap[key]; exists {
+			if !isPreferredVersion(apiVersion, existing.APIVersion, versionSelect) {
+				continue
+			}
+		}
+		actionsMap[key] = &ChildAction{
+			ResourceType: resourceType,
+			Name:         name,
+			Path:         path,
+			Method:       "POST",
+			HasBody:      hasRequestBodySchema(pathItem.Post),
+			APIVersion:   apiVersion,
+		}
+	}
+}
+
+// FindResourceActions returns the POST actions invoked on instances of resourceType in doc
+// (e.g. regenerateKey, listKeys), sorted by name. Actions on its children are not included.
+func FindResourceActions(doc *openapi3.T, resourceType string) []ChildAction {
+	actionsMap := make(map[string]*ChildAction)
+	discoverActionsInSpec(doc, resourceType, 0, "", VersionSelectLatestAny, actionsMap)
+	actions := make([]ChildAction, 0, len(actionsMap))
+	for _, action := range actionsMap {
+		actions = append(actions, *action)
+	}
+	slices.SortFunc(actions, func(a, b ChildAction) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return actions
+}
+
+// isPreferredVersion reports whether apiVersion should replace current under the versionSelect
+// policy, ordering versions as CompareAPIVersions does.
+func isPreferredVersion(apiVersion, current, versionSelect string) bool {
+	if versionSelect == VersionSelectLatestStable {
+		if stable, currentStable := !isPreviewVersion(apiVersion), !isPreviewVersion(current); stable != currentStable {
+			return stable
+		}
+	}
+	return CompareAPIVersions(apiVersion, current) > 0
+}
+
+// CompareAPIVersions orders Azure API versions, YYYY-MM-DD with an optional suffix such as
+// -preview, by date, and a stable version above a preview of the same date. Plain string
+// comparison would rank 2024-01-01-preview above 2024-01-01. It returns -1, 0 or +1 as
+// strings.Compare does, so slices.MaxFunc(versions, CompareAPIVersions) is the latest.
+// Examples: "2024-01-01" < "2024-03-01-preview" < "2024-03-01"
+func CompareAPIVersions(a, b string) int {
+	if c := strings.Compare(apiVersionDate(a), apiVersionDate(b)); c != 0 {
+		return c
+	}
+	if previewA, previewB := isPreviewVersion(a), isPreviewVersion(b); previewA != previewB {
+		if previewA {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// apiVersionDate returns the YYYY-MM-DD date an API version starts with, or the whole version
+// when it is shorter.
+func apiVersionDate(apiVersion string) string {
+	if len(apiVersion) < len("2006-01-02") {
+		return apiVersion
+	}
+	return apiVersion[:len("2006-01-02")]
+}
+
+// isPreviewVersion reports whether apiVersion is a preview version, e.g. 2025-10-02-preview.
+func isPreviewVersion(apiVersion string) bool {
+	return strings.Contains(strings.ToLower(apiVersion), "-preview")
+}
+
 // isChildOf checks if childType is a child of parentType.
 func isChildOf(childType, parentType string, maxDepth int) bool {
 	if !strings.HasPrefix(childType, parentType+"/") {
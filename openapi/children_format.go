@@ -37,7 +37,11 @@ func FormatChildrenAsText(result *ChildrenResult) string {
 			if apiVersion == "" {
 				apiVersion = "(unknown)"
 			}
-			sb.WriteString("- " + apiVersion + "\t" + child.ResourceType + "\n")
+			line := "- " + apiVersion + "\t" + child.ResourceType
+			if child.ParentIDTemplate != "" {
+				line += "\t" + child.ParentIDTemplate
+			}
+			sb.WriteString(line + "\n")
 		}
 	}
 	sb.WriteString("\n")
@@ -60,9 +64,38 @@ func FormatChildrenAsText(result *ChildrenResult) string {
 	}
 	sb.WriteString("\n")
 
+	if result.Actions != nil {
+		sb.WriteString("Actions (not deployable)\n")
+		actions := sortedActions(result.Actions)
+		if len(actions) == 0 {
+			sb.WriteString("(none)\n")
+		}
+		for _, action := range actions {
+			body := "no body"
+			if action.HasBody {
+				body = "body"
+			}
+			sb.WriteString("- " + action.Method + "\t" + action.ResourceType + "/" + action.Name + "\t" + body + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	return sb.String()
 }
 
+// sortedActions returns a copy of actions sorted by resource type and action name.
+func sortedActions(actions []ChildAction) []ChildAction {
+	sorted := make([]ChildAction, len(actions))
+	copy(sorted, actions)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].ResourceType != sorted[j].ResourceType {
+			return sorted[i].ResourceType < sorted[j].ResourceType
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
 // FormatChildrenAsMarkdown is kept for backwards compatibility.
 // It currently emits plain text (not markdown tables).
 func FormatChildrenAsMarkdown(result *ChildrenResult) string {
@@ -91,10 +124,14 @@ func FormatChildrenAsJSON(result *ChildrenResult) (string, error) {
 	output := struct {
 		Deployable  []ChildResource `json:"deployable"`
 		FilteredOut []ChildResource `json:"filtered_out"`
+		Actions     []ChildAction   `json:"actions,omitempty"`
 	}{
 		Deployable:  deployable,
 		FilteredOut: filteredOut,
 	}
+	if result.Actions != nil {
+		output.Actions = sortedActions(result.Actions)
+	}
 
 	data, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
@@ -103,3 +140,78 @@ func FormatChildrenAsJSON(result *ChildrenResult) (string, error) {
 
 	return string(data), nil
 }
+
+// FormatChildrenAsTree formats the children result as an indented tree rooted at parent.
+// Each child is nested under its closest discovered ancestor and marked ✓ when deployable or
+// ✗ with the reason when filtered out. Actions are not included.
+func FormatChildrenAsTree(parent string, result *ChildrenResult) string {
+	if result == nil {
+		return "No results\n"
+	}
+	if strings.HasSuffix(parent, "}") {
+		if idx := strings.LastIndex(parent, "/{"); idx != -1 {
+			parent = parent[:idx]
+		}
+	}
+
+	nodes := make(map[string]ChildResource, len(result.Deployable)+len(result.FilteredOut))
+	for _, child := range result.Deployable {
+		nodes[strings.ToLower(child.ResourceType)] = child
+	}
+	for _, child := range result.FilteredOut {
+		nodes[strings.ToLower(child.ResourceType)] = child
+	}
+
+	// Attach each child to its closest discovered ancestor, falling back to the parent.
+	root := strings.ToLower(parent)
+	children := make(map[string][]ChildResource)
+	for key, child := range nodes {
+		ancestor := root
+		for candidate := key; ; {
+			idx := strings.LastIndex(candidate, "/")
+			if idx == -1 {
+				break
+			}
+			candidate = candidate[:idx]
+			if _, ok := nodes[candidate]; ok || candidate == root {
+				ancestor = candidate
+				break
+			}
+		}
+		children[ancestor] = append(children[ancestor], child)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(parent + "\n")
+	var render func(key, typ, indent string)
+	render = func(key, typ, indent string) {
+		kids := children[key]
+		sort.Slice(kids, func(i, j int) bool {
+			return kids[i].ResourceType < kids[j].ResourceType
+		})
+		for i, child := range kids {
+			branch, nextIndent := "├── ", indent+"│   "
+			if i == len(kids)-1 {
+				branch, nextIndent = "└── ", indent+"    "
+			}
+			name := strings.TrimPrefix(child.ResourceType[len(typ):], "/")
+			apiVersion := child.APIVersion
+			if apiVersion == "" {
+				apiVersion = "(unknown)"
+			}
+			if child.IsDeployable {
+				sb.WriteString(indent + branch + "✓ " + name + " (" + apiVersion + ")\n")
+			} else {
+				reason := child.DeployabilityReason
+				if reason == "" {
+					reason = "(no reason)"
+				}
+				sb.WriteString(indent + branch + "✗ " + name + " (" + apiVersion + "): " + reason + "\n")
+			}
+			render(strings.ToLower(child.ResourceType), child.ResourceType, nextIndent)
+		}
+	}
+	render(root, parent, "")
+
+	return sb.String()
+}
@@ -58,6 +58,22 @@ func TestFormatChildrenAsText(t *testing.T) {
 		assert.Equal(t, "No results\n", text)
 	})
 
+	t.Run("lists actions when present", func(t *testing.T) {
+		result := &ChildrenResult{
+			Deployable:  []ChildResource{},
+			FilteredOut: []ChildResource{},
+			Actions: []ChildAction{
+				{ResourceType: "Microsoft.App/managedEnvironments", Name: "listKeys", Method: "POST"},
+				{ResourceType: "Microsoft.App/managedEnvironments", Name: "start", Method: "POST", HasBody: true},
+			},
+		}
+
+		text := FormatChildrenAsText(result)
+
+		assert.Contains(t, text, "Actions (not deployable)\n- POST\tMicrosoft.App/managedEnvironments/listKeys\tno body\n- POST\tMicrosoft.App/managedEnvironments/start\tbody\n")
+		assert.NotContains(t, FormatChildrenAsText(&ChildrenResult{}), "Actions")
+	})
+
 	t.Run("sorts by resource type", func(t *testing.T) {
 		result := &ChildrenResult{
 			Deployable: []ChildResource{
@@ -164,3 +180,39 @@ func TestFormatChildrenAsJSON(t *testing.T) {
 		assert.Equal(t, "Microsoft.App/managedEnvironments/storages", parsed.Deployable[1].ResourceType)
 	})
 }
+
+func TestFormatChildrenAsTree(t *testing.T) {
+	t.Run("nests children under their parent", func(t *testing.T) {
+		result := &ChildrenResult{
+			Deployable: []ChildResource{
+				{ResourceType: "Microsoft.Test/widgets/parts", APIVersion: "2024-01-01", IsDeployable: true},
+				{ResourceType: "Microsoft.Test/widgets/parts/bolts", APIVersion: "2024-01-01", IsDeployable: true},
+				{ResourceType: "Microsoft.Test/widgets/gears", APIVersion: "2024-01-01", IsDeployable: true},
+				// The intermediate "sprockets" type was not discovered, so "teeth" hangs off the parent.
+				{ResourceType: "Microsoft.Test/widgets/sprockets/teeth", APIVersion: "2024-01-01", IsDeployable: true},
+			},
+			FilteredOut: []ChildResource{
+				{ResourceType: "Microsoft.Test/widgets/parts/status", APIVersion: "2024-01-01", DeployabilityReason: "GET-only resource"},
+			},
+		}
+
+		tree := FormatChildrenAsTree("Microsoft.Test/widgets/{widgetName}", result)
+
+		expected := "Microsoft.Test/widgets\n" +
+			"├── ✓ gears (2024-01-01)\n" +
+			"├── ✓ parts (2024-01-01)\n" +
+			"│   ├── ✓ bolts (2024-01-01)\n" +
+			"│   └── ✗ status (2024-01-01): GET-only resource\n" +
+			"└── ✓ sprockets/teeth (2024-01-01)\n"
+		assert.Equal(t, expected, tree)
+	})
+
+	t.Run("handles empty results", func(t *testing.T) {
+		tree := FormatChildrenAsTree("Microsoft.Test/widgets", &ChildrenResult{})
+		assert.Equal(t, "Microsoft.Test/widgets\n", tree)
+	})
+
+	t.Run("handles nil result", func(t *testing.T) {
+		assert.Equal(t, "No results\n", FormatChildrenAsTree("Microsoft.Test/widgets", nil))
+	})
+}
@@ -1,6 +1,11 @@
 package openapi
 
 import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -293,7 +298,7 @@ func TestDiscoverChildrenInSpec(t *testing.T) {
 		})
 
 		childrenMap := make(map[string]*ChildResource)
-		err := discoverChildrenInSpec(doc, "Microsoft.App/managedEnvironments", 1, "2024-01-01", childrenMap)
+		err := discoverChildrenInSpec(doc, "Microsoft.App/managedEnvironments", 1, "2024-01-01", VersionSelectLatestStable, childrenMap)
 		require.NoError(t, err)
 
 		require.Len(t, childrenMap, 1)
@@ -324,7 +329,7 @@ func TestDiscoverChildrenInSpec(t *testing.T) {
 		})
 
 		childrenMap := make(map[string]*ChildResource)
-		err := discoverChildrenInSpec(doc, "Microsoft.App/managedEnvironments", 1, "2024-01-01", childrenMap)
+		err := discoverChildrenInSpec(doc, "Microsoft.App/managedEnvironments", 1, "2024-01-01", VersionSelectLatestStable, childrenMap)
 		require.NoError(t, err)
 
 		require.Len(t, childrenMap, 1)
@@ -351,7 +356,7 @@ func TestDiscoverChildrenInSpec(t *testing.T) {
 		})
 
 		childrenMap := make(map[string]*ChildResource)
-		err := discoverChildrenInSpec(doc, "Microsoft.App/managedEnvironments", 1, "2024-01-01", childrenMap)
+		err := discoverChildrenInSpec(doc, "Microsoft.App/managedEnvironments", 1, "2024-01-01", VersionSelectLatestStable, childrenMap)
 		require.NoError(t, err)
 
 		require.Len(t, childrenMap, 1)
@@ -388,7 +393,7 @@ func TestDiscoverChildrenInSpec(t *testing.T) {
 		})
 
 		childrenMap := make(map[string]*ChildResource)
-		err := discoverChildrenInSpec(doc, "Microsoft.App/managedEnvironments", 1, "2024-01-01", childrenMap)
+		err := discoverChildrenInSpec(doc, "Microsoft.App/managedEnvironments", 1, "2024-01-01", VersionSelectLatestStable, childrenMap)
 		require.NoError(t, err)
 
 		assert.Len(t, childrenMap, 0, "grandchildren should be excluded when depth=1")
@@ -429,14 +434,14 @@ func TestDiscoverChildrenInSpec(t *testing.T) {
 		childrenMap := make(map[string]*ChildResource)
 
 		// Process older version first
-		err := discoverChildrenInSpec(doc1, "Microsoft.App/managedEnvironments", 1, "2023-01-01", childrenMap)
+		err := discoverChildrenInSpec(doc1, "Microsoft.App/managedEnvironments", 1, "2023-01-01", VersionSelectLatestStable, childrenMap)
 		require.NoError(t, err)
 
 		child := childrenMap["Microsoft.App/managedEnvironments/certificates"]
 		assert.Equal(t, "2023-01-01", child.APIVersion)
 
 		// Process newer version
-		err = discoverChildrenInSpec(doc2, "Microsoft.App/managedEnvironments", 1, "2024-01-01", childrenMap)
+		err = discoverChildrenInSpec(doc2, "Microsoft.App/managedEnvironments", 1, "2024-01-01", VersionSelectLatestStable, childrenMap)
 		require.NoError(t, err)
 
 		child = childrenMap["Microsoft.App/managedEnvironments/certificates"]
@@ -466,4 +471,325 @@ func TestDiscoverChildren(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "parent resource type must be provided")
 	})
+	t.Run("parent version pins discovery to one spec version", func(t *testing.T) {
+		dir := t.TempDir()
+		older := writeChildrenTestSpec(t, dir, "2023-01-01", "certificates")
+		newer := writeChildrenTestSpec(t, dir, "2024-01-01", "certificates", "storages")
+
+		result, err := DiscoverChildren(DiscoverChildrenOptions{
+			Specs:  []string{older, newer},
+			Parent: "Microsoft.App/managedEnvironments",
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			"Microsoft.App/managedEnvironments/certificates",
+			"Microsoft.App/managedEnvironments/storages",
+		}, deployableTypes(result))
+
+		result, err = DiscoverChildren(DiscoverChildrenOptions{
+			Specs:      []string{older, newer},
+			Parent:     "Microsoft.App/managedEnvironments",
+			APIVersion: "2023-01-01",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Microsoft.App/managedEnvironments/certificates"}, deployableTypes(result))
+		assert.Equal(t, "2023-01-01", result.Deployable[0].APIVersion)
+
+		_, err = DiscoverChildren(DiscoverChildrenOptions{
+			Specs:      []string{older, newer},
+			Parent:     "Microsoft.App/managedEnvironments",
+			APIVersion: "2022-01-01",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "api version 2022-01-01 not found")
+		assert.Contains(t, err.Error(), "2023-01-01, 2024-01-01")
+	})
+
+	t.Run("version selection policy", func(t *testing.T) {
+		dir := t.TempDir()
+		stable := writeChildrenTestSpec(t, dir, "2024-01-01", "certificates")
+		preview := writeChildrenTestSpec(t, dir, "2025-06-01-preview", "certificates", "storages")
+		certificateVersion := func(result *ChildrenResult) string {
+			for _, child := range result.Deployable {
+				if child.ResourceType == "Microsoft.App/managedEnvironments/certificates" {
+					return child.APIVersion
+				}
+			}
+			return ""
+		}
+
+		// latest-stable is the default and skips the newer preview.
+		for _, versionSelect := range []string{"", VersionSelectLatestStable} {
+			result, err := DiscoverChildren(DiscoverChildrenOptions{
+				Specs:         []string{stable, preview},
+				Parent:        "Microsoft.App/managedEnvironments",
+				VersionSelect: versionSelect,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "2024-01-01", certificateVersion(result))
+			// Children only found in a preview version are still discovered.
+			assert.Contains(t, deployableTypes(result), "Microsoft.App/managedEnvironments/storages")
+		}
+
+		result, err := DiscoverChildren(DiscoverChildrenOptions{
+			Specs:         []string{stable, preview},
+			Parent:        "Microsoft.App/managedEnvironments",
+			VersionSelect: VersionSelectLatestAny,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "2025-06-01-preview", certificateVersion(result))
+
+		result, err = DiscoverChildren(DiscoverChildrenOptions{
+			Specs:         []string{stable, preview},
+			Parent:        "Microsoft.App/managedEnvironments",
+			VersionSelect: VersionSelectExact,
+			APIVersion:    "2025-06-01-preview",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "2025-06-01-preview", certificateVersion(result))
+
+		_, err = DiscoverChildren(DiscoverChildrenOptions{
+			Specs:         []string{stable, preview},
+			Parent:        "Microsoft.App/managedEnvironments",
+			VersionSelect: VersionSelectExact,
+		})
+		require.ErrorContains(t, err, "exact version selection requires an api version")
+
+		_, err = DiscoverChildren(DiscoverChildrenOptions{
+			Specs:         []string{stable, preview},
+			Parent:        "Microsoft.App/managedEnvironments",
+			VersionSelect: "newest",
+		})
+		require.ErrorContains(t, err, `unsupported version selection "newest"`)
+	})
+
+	t.Run("include actions lists POST actions separately", func(t *testing.T) {
+		dir := t.TempDir()
+		base := "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.App/managedEnvironments/{environmentName}"
+		putWithBody := map[string]any{
+			"put": map[string]any{
+				"parameters": []any{
+					map[string]any{"name": "body", "in": "body", "schema": map[string]any{"$ref": "#/definitions/Resource"}},
+				},
+				"responses": map[string]any{"200": map[string]any{"description": "OK"}},
+			},
+		}
+		spec := map[string]any{
+			"swagger": "2.0",
+			"info":    map[string]any{"title": "test", "version": "2024-01-01"},
+			"paths": map[string]any{
+				base:                      putWithBody,
+				base + "/storages/{name}": putWithBody,
+				base + "/listKeys": map[string]any{
+					"post": map[string]any{"responses": map[string]any{"200": map[string]any{"description": "OK"}}},
+				},
+				base + "/storages/{name}/start": map[string]any{
+					"post": map[string]any{
+						"parameters": []any{
+							map[string]any{"name": "body", "in": "body", "schema": map[string]any{"$ref": "#/definitions/Resource"}},
+						},
+						"responses": map[string]any{"200": map[string]any{"description": "OK"}},
+					},
+				},
+			},
+			"definitions": map[string]any{
+				"Resource": map[string]any{"type": "object"},
+			},
+		}
+		data, err := json.Marshal(spec)
+		require.NoError(t, err)
+		specPath := filepath.Join(dir, "spec.json")
+		require.NoError(t, os.WriteFile(specPath, data, 0o644))
+
+		result, err := DiscoverChildren(DiscoverChildrenOptions{
+			Specs:  []string{specPath},
+			Parent: "Microsoft.App/managedEnvironments",
+		})
+		require.NoError(t, err)
+		assert.Nil(t, result.Actions)
+
+		result, err = DiscoverChildren(DiscoverChildrenOptions{
+			Specs:          []string{specPath},
+			Parent:         "Microsoft.App/managedEnvironments",
+			IncludeActions: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Microsoft.App/managedEnvironments/storages"}, deployableTypes(result))
+		assert.Empty(t, result.FilteredOut)
+		assert.ElementsMatch(t, []ChildAction{
+			{ResourceType: "Microsoft.App/managedEnvironments", Name: "listKeys", Path: base + "/listKeys", Method: "POST", HasBody: false, APIVersion: "2024-01-01"},
+			{ResourceType: "Microsoft.App/managedEnvironments/storages", Name: "start", Path: base + "/storages/{name}/start", Method: "POST", HasBody: true, APIVersion: "2024-01-01"},
+		}, result.Actions)
+	})
+
+	t.Run("include schemas attaches the writable body schema", func(t *testing.T) {
+		dir := t.TempDir()
+		base := "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.App/managedEnvironments/{environmentName}"
+		putWith := func(ref string) map[string]any {
+			return map[string]any{
+				"put": map[string]any{
+					"parameters": []any{
+						map[string]any{"name": "body", "in": "body", "schema": map[string]any{"$ref": ref}},
+					},
+					"responses": map[string]any{"200": map[string]any{"description": "OK"}},
+				},
+			}
+		}
+		spec := map[string]any{
+			"swagger": "2.0",
+			"info":    map[string]any{"title": "test", "version": "2024-01-01"},
+			"paths": map[string]any{
+				base:                      putWith("#/definitions/ProxyResource"),
+				base + "/storages/{name}": putWith("#/definitions/Storage"),
+			},
+			"definitions": map[string]any{
+				"ProxyResource": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"id":   map[string]any{"type": "string", "readOnly": true},
+						"name": map[string]any{"type": "string", "readOnly": true},
+					},
+				},
+				"Storage": map[string]any{
+					"allOf": []any{map[string]any{"$ref": "#/definitions/ProxyResource"}},
+					"properties": map[string]any{
+						"properties": map[string]any{"$ref": "#/definitions/StorageProperties"},
+					},
+				},
+				"StorageProperties": map[string]any{
+					"type":     "object",
+					"required": []any{"shareName"},
+					"properties": map[string]any{
+						"shareName":         map[string]any{"type": "string"},
+						"provisioningState": map[string]any{"type": "string", "readOnly": true},
+					},
+				},
+			},
+		}
+		data, err := json.Marshal(spec)
+		require.NoError(t, err)
+		specPath := filepath.Join(dir, "spec.json")
+		require.NoError(t, os.WriteFile(specPath, data, 0o644))
+
+		result, err := DiscoverChildren(DiscoverChildrenOptions{
+			Specs:  []string{specPath},
+			Parent: "Microsoft.App/managedEnvironments",
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Deployable, 1)
+		assert.Nil(t, result.Deployable[0].Schema)
+
+		result, err = DiscoverChildren(DiscoverChildrenOptions{
+			Specs:          []string{specPath},
+			Parent:         "Microsoft.App/managedEnvironments",
+			IncludeSchemas: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Deployable, 1)
+		schema := result.Deployable[0].Schema
+		require.NotNil(t, schema)
+		assert.Empty(t, schema.AllOf)
+		assert.Equal(t, []string{"properties"}, slices.Sorted(maps.Keys(schema.Properties)))
+		properties := schema.Properties["properties"]
+		assert.Empty(t, properties.Ref)
+		assert.Equal(t, []string{"shareName"}, slices.Sorted(maps.Keys(properties.Value.Properties)))
+		assert.Equal(t, []string{"shareName"}, properties.Value.Required)
+
+		output, err := FormatChildrenAsJSON(result)
+		require.NoError(t, err)
+		assert.Contains(t, output, `"schema": {`)
+		assert.Contains(t, output, `"shareName"`)
+		assert.NotContains(t, output, "$ref")
+		assert.NotContains(t, output, "provisioningState")
+	})
+
+	t.Run("parent id templates", func(t *testing.T) {
+		dir := t.TempDir()
+		specPath := writeChildrenTestSpec(t, dir, "2024-01-01", "certificates", "daprComponents", "daprComponents/{componentName}/resiliencyPolicies")
+
+		result, err := DiscoverChildren(DiscoverChildrenOptions{
+			Specs:  []string{specPath},
+			Parent: "Microsoft.App/managedEnvironments",
+			Depth:  2,
+		})
+		require.NoError(t, err)
+		for _, child := range result.Deployable {
+			assert.Empty(t, child.ParentIDTemplate)
+		}
+
+		result, err = DiscoverChildren(DiscoverChildrenOptions{
+			Specs:                    []string{specPath},
+			Parent:                   "Microsoft.App/managedEnvironments",
+			Depth:                    2,
+			IncludeParentIDTemplates: true,
+		})
+		require.NoError(t, err)
+		templates := make(map[string]string)
+		for _, child := range result.Deployable {
+			templates[child.ResourceType] = child.ParentIDTemplate
+		}
+		assert.Equal(t, map[string]string{
+			"Microsoft.App/managedEnvironments/certificates":                      "<parent_id>/certificates",
+			"Microsoft.App/managedEnvironments/daprComponents":                    "<parent_id>/daprComponents",
+			"Microsoft.App/managedEnvironments/daprComponents/resiliencyPolicies": "<parent_id>/daprComponents/{componentName}/resiliencyPolicies",
+		}, templates)
+
+		assert.Contains(t, FormatChildrenAsText(result), "- 2024-01-01\tMicrosoft.App/managedEnvironments/certificates\t<parent_id>/certificates\n")
+		output, err := FormatChildrenAsJSON(result)
+		require.NoError(t, err)
+		var decoded struct {
+			Deployable []struct{ ResourceType, ParentIDTemplate string } `json:"deployable"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(output), &decoded))
+		require.NotEmpty(t, decoded.Deployable)
+		assert.Equal(t, "Microsoft.App/managedEnvironments/certificates", decoded.Deployable[0].ResourceType)
+		assert.Equal(t, "<parent_id>/certificates", decoded.Deployable[0].ParentIDTemplate)
+	})
+}
+
+func deployableTypes(result *ChildrenResult) []string {
+	var types []string
+	for _, child := range result.Deployable {
+		types = append(types, child.ResourceType)
+	}
+	return types
+}
+
+// writeChildrenTestSpec writes a minimal Swagger 2.0 spec with the given API version that declares
+// Microsoft.App/managedEnvironments and a deployable child for each of the given child names.
+func writeChildrenTestSpec(t *testing.T, dir, apiVersion string, children ...string) string {
+	t.Helper()
+
+	putWithBody := map[string]any{
+		"put": map[string]any{
+			"parameters": []any{
+				map[string]any{"name": "body", "in": "body", "schema": map[string]any{"$ref": "#/definitions/Resource"}},
+			},
+			"responses": map[string]any{"200": map[string]any{"description": "OK"}},
+		},
+	}
+
+	base := "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.App/managedEnvironments/{environmentName}"
+	paths := map[string]any{base: putWithBody}
+	for _, child := range children {
+		paths[base+"/"+child+"/{name}"] = putWithBody
+	}
+
+	spec := map[string]any{
+		"swagger": "2.0",
+		"info":    map[string]any{"title": "test", "version": apiVersion},
+		"paths":   paths,
+		"definitions": map[string]any{
+			"Resource": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"properties": map[string]any{"type": "object"}},
+			},
+		},
+	}
+
+	data, err := json.Marshal(spec)
+	require.NoError(t, err)
+	path := filepath.Join(dir, apiVersion+".json")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
 }
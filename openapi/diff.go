@@ -0,0 +1,354 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SpecDiff describes how a resource type changed between two API versions: its properties,
+// keyed by dotted path with [] marking array items, and its deployable child resource types.
+type SpecDiff struct {
+	ResourceType       string            `json:"resource_type"`
+	OldAPIVersion      string            `json:"old_api_version"`
+	NewAPIVersion      string            `json:"new_api_version"`
+	AddedProperties    []PropertySummary `json:"added_properties"`
+	RemovedProperties  []PropertySummary `json:"removed_properties"`
+	ChangedProperties  []PropertyChange  `json:"changed_properties"`  // Type changes
+	ChangedConstraints []PropertyChange  `json:"changed_constraints"` // Required, read-only, enum, pattern, length, range and format changes
+	AddedChildren      []string          `json:"added_children"`
+	RemovedChildren    []string          `json:"removed_children"`
+}
+
+// PropertySummary is a property path with its type, e.g. properties.sku.name (string).
+type PropertySummary struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// PropertyChange is a property whose type or constraints differ between the two versions.
+type PropertyChange struct {
+	Path string `json:"path"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// HasChanges reports whether the diff found any difference.
+func (d *SpecDiff) HasChanges() bool {
+	return len(d.AddedProperties) > 0 || len(d.RemovedProperties) > 0 || len(d.ChangedProperties) > 0 ||
+		len(d.ChangedConstraints) > 0 || len(d.AddedChildren) > 0 || len(d.RemovedChildren) > 0
+}
+
+// propertyShape is the part of a property the diff compares.
+type propertyShape struct {
+	typ         string
+	constraints string
+}
+
+// DiffSpecs compares resourceType in the spec at oldSpec with the one at newSpec, using the
+// effective (allOf-merged) properties of each PUT request body and the deployable children
+// found in each spec.
+func DiffSpecs(oldSpec, newSpec, resourceType string) (*SpecDiff, error) {
+	oldDoc, oldShapes, oldChildren, err := loadDiffSurface(oldSpec, resourceType)
+	if err != nil {
+		return nil, err
+	}
+	newDoc, newShapes, newChildren, err := loadDiffSurface(newSpec, resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &SpecDiff{
+		ResourceType:       resourceType,
+		OldAPIVersion:      extractAPIVersion(oldDoc, oldSpec),
+		NewAPIVersion:      extractAPIVersion(newDoc, newSpec),
+		AddedProperties:    []PropertySummary{},
+		RemovedProperties:  []PropertySummary{},
+		ChangedProperties:  []PropertyChange{},
+		ChangedConstraints: []PropertyChange{},
+		AddedChildren:      []string{},
+		RemovedChildren:    []string{},
+	}
+
+	for _, path := range sortedPropertyPaths(newShapes) {
+		newShape := newShapes[path]
+		oldShape, ok := oldShapes[path]
+		if !ok {
+			diff.AddedProperties = append(diff.AddedProperties, PropertySummary{Path: path, Type: newShape.typ})
+			continue
+		}
+		if oldShape.typ != newShape.typ {
+			diff.ChangedProperties = append(diff.ChangedProperties, PropertyChange{Path: path, Old: oldShape.typ, New: newShape.typ})
+		}
+		if oldShape.constraints != newShape.constraints {
+			diff.ChangedConstraints = append(diff.ChangedConstraints, PropertyChange{Path: path, Old: oldShape.constraints, New: newShape.constraints})
+		}
+	}
+	for _, path := range sortedPropertyPaths(oldShapes) {
+		if _, ok := newShapes[path]; !ok {
+			diff.RemovedProperties = append(diff.RemovedProperties, PropertySummary{Path: path, Type: oldShapes[path].typ})
+		}
+	}
+
+	for _, child := range newChildren {
+		if !slices.Contains(oldChildren, child) {
+			diff.AddedChildren = append(diff.AddedChildren, child)
+		}
+	}
+	for _, child := range oldChildren {
+		if !slices.Contains(newChildren, child) {
+			diff.RemovedChildren = append(diff.RemovedChildren, child)
+		}
+	}
+
+	return diff, nil
+}
+
+// loadDiffSurface loads specPath and returns the property shapes of resourceType and its
+// sorted deployable child resource types.
+func loadDiffSurface(specPath, resourceType string) (*openapi3.T, map[string]propertyShape, []string, error) {
+	doc, err := LoadSpec(specPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load spec %s: %w", specPath, err)
+	}
+	schema, err := FindResource(doc, resourceType)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to find resource in spec %s: %w", specPath, err)
+	}
+
+	shapes := make(map[string]propertyShape)
+	if err := collectPropertyShapes(schema, "", shapes, make(map[*openapi3.Schema]struct{})); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read properties in spec %s: %w", specPath, err)
+	}
+
+	parentType := resourceType
+	if strings.HasSuffix(parentType, "}") {
+		if idx := strings.LastIndex(parentType, "/{"); idx != -1 {
+			parentType = parentType[:idx]
+		}
+	}
+	childrenMap := make(map[string]*ChildResource)
+	if err := discoverChildrenInSpec(doc, parentType, 1, "", VersionSelectLatestAny, childrenMap); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to discover children in spec %s: %w", specPath, err)
+	}
+	var children []string
+	for childType, child := range childrenMap {
+		if child.IsDeployable {
+			children = append(children, childType)
+		}
+	}
+	slices.Sort(children)
+
+	return doc, shapes, children, nil
+}
+
+// collectPropertyShapes records the shape of every property below schema, descending into
+// nested objects and array items. Schemas already being visited are skipped, so recursive
+// definitions are only expanded once per path.
+func collectPropertyShapes(schema *openapi3.Schema, prefix string, shapes map[string]propertyShape, visiting map[*openapi3.Schema]struct{}) error {
+	schema, _ = UnwrapNullable(schema)
+	if schema == nil {
+		return nil
+	}
+	if _, ok := visiting[schema]; ok {
+		return nil
+	}
+	visiting[schema] = struct{}{}
+	defer delete(visiting, schema)
+
+	if slices.Contains(EffectiveTypes(schema), "array") {
+		if schema.Items == nil || schema.Items.Value == nil {
+			return nil
+		}
+		return collectPropertyShapes(schema.Items.Value, prefix+"[]", shapes, visiting)
+	}
+
+	props, err := GetEffectiveProperties(schema)
+	if err != nil {
+		return err
+	}
+	required, err := GetEffectiveRequired(schema)
+	if err != nil {
+		return err
+	}
+	for name, ref := range props {
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		prop, _ := UnwrapNullable(ref.Value)
+		shapes[path] = propertyShape{
+			typ:         describeSchemaType(prop),
+			constraints: describeSchemaConstraints(prop, slices.Contains(required, name)),
+		}
+		if err := collectPropertyShapes(prop, path, shapes, visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeSchemaType returns the type of schema, with the item type of arrays, e.g.
+// array<string>. Schemas without a type are described as any.
+func describeSchemaType(schema *openapi3.Schema) string {
+	types := EffectiveTypes(schema)
+	if len(types) == 0 {
+		return "any"
+	}
+	typ := strings.Join(types, "|")
+	if slices.Contains(types, "array") && schema.Items != nil && schema.Items.Value != nil {
+		items, _ := UnwrapNullable(schema.Items.Value)
+		typ += "<" + describeSchemaType(items) + ">"
+	}
+	return typ
+}
+
+// describeSchemaConstraints summarises the constraints of a property, e.g.
+// "required; maxLength 24". It returns an empty string for an unconstrained property.
+func describeSchemaConstraints(schema *openapi3.Schema, required bool) string {
+	var parts []string
+	if required {
+		parts = append(parts, "required")
+	}
+	if schema.ReadOnly {
+		parts = append(parts, "readOnly")
+	}
+	if schema.Format != "" {
+		parts = append(parts, "format "+schema.Format)
+	}
+	if len(schema.Enum) > 0 {
+		values := make([]string, 0, len(schema.Enum))
+		for _, v := range schema.Enum {
+			values = append(values, fmt.Sprint(v))
+		}
+		sort.Strings(values)
+		parts = append(parts, "enum ["+strings.Join(values, ", ")+"]")
+	}
+	if schema.Pattern != "" {
+		parts = append(parts, "pattern "+schema.Pattern)
+	}
+	if schema.MinLength > 0 {
+		parts = append(parts, fmt.Sprintf("minLength %d", schema.MinLength))
+	}
+	if schema.MaxLength != nil {
+		parts = append(parts, fmt.Sprintf("maxLength %d", *schema.MaxLength))
+	}
+	if schema.Min != nil {
+		op := ">="
+		if schema.ExclusiveMin {
+			op = ">"
+		}
+		parts = append(parts, fmt.Sprintf("%s %v", op, *schema.Min))
+	}
+	if schema.Max != nil {
+		op := "<="
+		if schema.ExclusiveMax {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %v", op, *schema.Max))
+	}
+	if schema.MinItems > 0 {
+		parts = append(parts, fmt.Sprintf("minItems %d", schema.MinItems))
+	}
+	if schema.MaxItems != nil {
+		parts = append(parts, fmt.Sprintf("maxItems %d", *schema.MaxItems))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func sortedPropertyPaths(shapes map[string]propertyShape) []string {
+	keys := make([]string, 0, len(shapes))
+	for key := range shapes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FormatSpecDiffAsMarkdown formats the diff as a markdown report with a section per kind of
+// change. Empty sections are omitted.
+func FormatSpecDiffAsMarkdown(diff *SpecDiff) string {
+	if diff == nil {
+		return "No results\n"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s: %s → %s\n\n", diff.ResourceType, versionOrUnknown(diff.OldAPIVersion), versionOrUnknown(diff.NewAPIVersion))
+	if !diff.HasChanges() {
+		sb.WriteString("No changes.\n")
+		return sb.String()
+	}
+
+	writeSummaries := func(title string, props []PropertySummary) {
+		if len(props) == 0 {
+			return
+		}
+		sb.WriteString("## " + title + "\n\n")
+		for _, prop := range props {
+			sb.WriteString("- `" + prop.Path + "` (" + prop.Type + ")\n")
+		}
+		sb.WriteString("\n")
+	}
+	writeChanges := func(title string, changes []PropertyChange) {
+		if len(changes) == 0 {
+			return
+		}
+		sb.WriteString("## " + title + "\n\n")
+		for _, change := range changes {
+			sb.WriteString("- `" + change.Path + "`: " + valueOrNone(change.Old) + " → " + valueOrNone(change.New) + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	writeTypes := func(title string, types []string) {
+		if len(types) == 0 {
+			return
+		}
+		sb.WriteString("## " + title + "\n\n")
+		for _, typ := range types {
+			sb.WriteString("- " + typ + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	writeSummaries("Added properties", diff.AddedProperties)
+	writeSummaries("Removed properties", diff.RemovedProperties)
+	writeChanges("Changed properties", diff.ChangedProperties)
+	writeChanges("Changed constraints", diff.ChangedConstraints)
+	writeTypes("Added child resource types", diff.AddedChildren)
+	writeTypes("Removed child resource types", diff.RemovedChildren)
+
+	return sb.String()
+}
+
+// FormatSpecDiffAsJSON formats the diff as JSON.
+func FormatSpecDiffAsJSON(diff *SpecDiff) (string, error) {
+	if diff == nil {
+		return "{}", nil
+	}
+	data, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func versionOrUnknown(apiVersion string) string {
+	if apiVersion == "" {
+		return "(unknown)"
+	}
+	return apiVersion
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
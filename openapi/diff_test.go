@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSpecs(t *testing.T) {
+	dir := t.TempDir()
+	oldSpec := writeDiffTestSpec(t, dir, "2024-01-01", map[string]any{
+		"name":   map[string]any{"type": "string", "maxLength": 10},
+		"count":  map[string]any{"type": "integer"},
+		"legacy": map[string]any{"type": "boolean"},
+		"rules": map[string]any{
+			"type":  "array",
+			"items": map[string]any{"type": "object", "properties": map[string]any{"port": map[string]any{"type": "integer"}}},
+		},
+	}, "certificates")
+	newSpec := writeDiffTestSpec(t, dir, "2025-01-01", map[string]any{
+		"name":  map[string]any{"type": "string", "maxLength": 20},
+		"count": map[string]any{"type": "string"},
+		"zone":  map[string]any{"type": "string", "enum": []any{"1", "2", "3"}},
+		"rules": map[string]any{
+			"type": "array",
+			"items": map[string]any{"type": "object", "properties": map[string]any{
+				"port":     map[string]any{"type": "integer"},
+				"protocol": map[string]any{"type": "string"},
+			}},
+		},
+	}, "certificates", "widgets")
+
+	diff, err := DiffSpecs(oldSpec, newSpec, "Microsoft.App/managedEnvironments")
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024-01-01", diff.OldAPIVersion)
+	assert.Equal(t, "2025-01-01", diff.NewAPIVersion)
+	assert.Equal(t, []PropertySummary{
+		{Path: "properties.rules[].protocol", Type: "string"},
+		{Path: "properties.zone", Type: "string"},
+	}, diff.AddedProperties)
+	assert.Equal(t, []PropertySummary{{Path: "properties.legacy", Type: "boolean"}}, diff.RemovedProperties)
+	assert.Equal(t, []PropertyChange{{Path: "properties.count", Old: "integer", New: "string"}}, diff.ChangedProperties)
+	assert.Equal(t, []PropertyChange{{Path: "properties.name", Old: "maxLength 10", New: "maxLength 20"}}, diff.ChangedConstraints)
+	assert.Equal(t, []string{"Microsoft.App/managedEnvironments/widgets"}, diff.AddedChildren)
+	assert.Empty(t, diff.RemovedChildren)
+
+	markdown := FormatSpecDiffAsMarkdown(diff)
+	assert.Contains(t, markdown, "# Microsoft.App/managedEnvironments: 2024-01-01 → 2025-01-01")
+	assert.Contains(t, markdown, "## Added properties\n\n- `properties.rules[].protocol` (string)\n- `properties.zone` (string)\n")
+	assert.Contains(t, markdown, "- `properties.name`: maxLength 10 → maxLength 20\n")
+	assert.Contains(t, markdown, "## Added child resource types\n\n- Microsoft.App/managedEnvironments/widgets\n")
+	assert.NotContains(t, markdown, "## Removed child resource types")
+
+	t.Run("no changes", func(t *testing.T) {
+		diff, err := DiffSpecs(oldSpec, oldSpec, "Microsoft.App/managedEnvironments")
+		require.NoError(t, err)
+		assert.False(t, diff.HasChanges())
+		assert.Contains(t, FormatSpecDiffAsMarkdown(diff), "No changes.")
+
+		output, err := FormatSpecDiffAsJSON(diff)
+		require.NoError(t, err)
+		assert.Contains(t, output, `"added_properties": []`)
+	})
+
+	t.Run("missing resource", func(t *testing.T) {
+		_, err := DiffSpecs(oldSpec, newSpec, "Microsoft.App/containerApps")
+		assert.Error(t, err)
+	})
+}
+
+// writeDiffTestSpec writes a Swagger 2.0 spec with the given API version declaring
+// Microsoft.App/managedEnvironments, whose properties bag has the given properties, and a
+// deployable child for each of the given child names.
+func writeDiffTestSpec(t *testing.T, dir, apiVersion string, properties map[string]any, children ...string) string {
+	t.Helper()
+
+	putWithBody := func(definition string) map[string]any {
+		return map[string]any{
+			"put": map[string]any{
+				"parameters": []any{
+					map[string]any{"name": "body", "in": "body", "schema": map[string]any{"$ref": "#/definitions/" + definition}},
+				},
+				"responses": map[string]any{"200": map[string]any{"description": "OK"}},
+			},
+		}
+	}
+
+	base := "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.App/managedEnvironments/{environmentName}"
+	paths := map[string]any{base: putWithBody("Environment")}
+	for _, child := range children {
+		paths[base+"/"+child+"/{name}"] = putWithBody("Child")
+	}
+
+	spec := map[string]any{
+		"swagger": "2.0",
+		"info":    map[string]any{"title": "test", "version": apiVersion},
+		"paths":   paths,
+		"definitions": map[string]any{
+			"Environment": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"properties": map[string]any{"type": "object", "properties": properties}},
+			},
+			"Child": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"properties": map[string]any{"type": "object"}},
+			},
+		},
+	}
+
+	data, err := json.Marshal(spec)
+	require.NoError(t, err)
+	path := filepath.Join(dir, apiVersion+".json")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
+}
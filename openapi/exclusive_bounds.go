@@ -0,0 +1,73 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// readNormalizedSpec reads a spec document and rewrites the JSON Schema constructs kin-openapi
+// does not understand into ones it does: OpenAPI 3.1 style numeric exclusiveMinimum/
+// exclusiveMaximum values into the 3.0 minimum/maximum + boolean flag form, and tuple items
+// (see normalizeTupleItems). Documents that are not JSON are returned unchanged.
+func readNormalizedSpec(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+	data, err := openapi3.DefaultReadFromURI(loader, location)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return data, nil
+	}
+	boundsChanged := normalizeExclusiveBounds(raw)
+	tuplesChanged := normalizeTupleItems(raw)
+	if !boundsChanged && !tuplesChanged {
+		return data, nil
+	}
+	return json.Marshal(raw)
+}
+
+// normalizeExclusiveBounds walks a decoded JSON document and converts numeric exclusive bounds
+// in place. It reports whether anything was changed.
+func normalizeExclusiveBounds(node any) bool {
+	changed := false
+	switch v := node.(type) {
+	case map[string]any:
+		if normalizeExclusiveBound(v, "exclusiveMinimum", "minimum", func(inclusive, exclusive float64) bool { return inclusive > exclusive }) {
+			changed = true
+		}
+		if normalizeExclusiveBound(v, "exclusiveMaximum", "maximum", func(inclusive, exclusive float64) bool { return inclusive < exclusive }) {
+			changed = true
+		}
+		for _, child := range v {
+			if normalizeExclusiveBounds(child) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if normalizeExclusiveBounds(child) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// normalizeExclusiveBound rewrites a numeric exclusive bound on a single schema object. When an
+// inclusive bound is also present, whichever is stricter is kept.
+func normalizeExclusiveBound(schema map[string]any, exclusiveKey, inclusiveKey string, inclusiveIsStricter func(inclusive, exclusive float64) bool) bool {
+	exclusive, ok := schema[exclusiveKey].(float64)
+	if !ok {
+		return false
+	}
+	if inclusive, ok := schema[inclusiveKey].(float64); ok && inclusiveIsStricter(inclusive, exclusive) {
+		delete(schema, exclusiveKey)
+		return true
+	}
+	schema[inclusiveKey] = exclusive
+	schema[exclusiveKey] = true
+	return true
+}
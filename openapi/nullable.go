@@ -0,0 +1,44 @@
+package openapi
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// UnwrapNullable returns the real schema of an OpenAPI 3.1 style nullable wrapper,
+// anyOf: [{type: "null"}, {...}], in either order, and reports whether schema was one. Any
+// other schema, including a wrapper that also declares a type or properties of its own, is
+// returned as is. Terraform variables are nullable already, so the wrapper adds nothing to the
+// type.
+func UnwrapNullable(schema *openapi3.Schema) (*openapi3.Schema, bool) {
+	if schema == nil || len(schema.AnyOf) != 2 || EffectiveTypes(schema) != nil || len(schema.AllOf) > 0 || len(schema.OneOf) > 0 {
+		return schema, false
+	}
+	for i, ref := range schema.AnyOf {
+		if ref == nil || ref.Value == nil || !isNullSchema(ref.Value) {
+			continue
+		}
+		inner := schema.AnyOf[1-i]
+		if inner == nil || inner.Value == nil || isNullSchema(inner.Value) {
+			return schema, false
+		}
+		return inner.Value, true
+	}
+	return schema, false
+}
+
+// IsNullable reports whether schema allows null: it is marked nullable (OpenAPI 3.0) or is an
+// OpenAPI 3.1 style nullable wrapper (see UnwrapNullable).
+func IsNullable(schema *openapi3.Schema) bool {
+	if schema == nil {
+		return false
+	}
+	if schema.Nullable {
+		return true
+	}
+	_, ok := UnwrapNullable(schema)
+	return ok
+}
+
+func isNullSchema(schema *openapi3.Schema) bool {
+	return schema.Type != nil && len(*schema.Type) == 1 && (*schema.Type)[0] == "null"
+}
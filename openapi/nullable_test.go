@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnwrapNullable(t *testing.T) {
+	tests := []struct {
+		name      string
+		schema    string
+		wantType  string
+		unwrapped bool
+	}{
+		{name: "null first", schema: `{"anyOf": [{"type": "null"}, {"type": "string"}]}`, wantType: "string", unwrapped: true},
+		{name: "null last", schema: `{"anyOf": [{"type": "integer"}, {"type": "null"}]}`, wantType: "integer", unwrapped: true},
+		{name: "union", schema: `{"anyOf": [{"type": "integer"}, {"type": "string"}]}`},
+		{name: "three branches", schema: `{"anyOf": [{"type": "null"}, {"type": "integer"}, {"type": "string"}]}`},
+		{name: "only null", schema: `{"anyOf": [{"type": "null"}, {"type": "null"}]}`},
+		{name: "typed wrapper", schema: `{"type": "string", "anyOf": [{"type": "null"}, {"type": "string"}]}`, wantType: "string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema openapi3.Schema
+			require.NoError(t, json.Unmarshal([]byte(tt.schema), &schema))
+
+			got, ok := UnwrapNullable(&schema)
+			assert.Equal(t, tt.unwrapped, ok)
+			if !tt.unwrapped {
+				assert.Same(t, &schema, got)
+			}
+			if tt.wantType == "" {
+				assert.Nil(t, got.Type)
+			} else {
+				assert.Equal(t, &openapi3.Types{tt.wantType}, got.Type)
+			}
+		})
+	}
+}
@@ -3,16 +3,26 @@ package openapi
 
 import (
 	"fmt"
+	"maps"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
-// LoadSpec loads the OpenAPI specification from a file path or URL.
-func LoadSpec(path string) (*openapi3.T, error) {
+// newSpecLoader returns a loader following external refs and reading documents through
+// readNormalizedSpec.
+func newSpecLoader() *openapi3.Loader {
 	loader := openapi3.NewLoader()
 	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = readNormalizedSpec
+	return loader
+}
+
+// LoadSpec loads the OpenAPI specification from a file path or URL.
+func LoadSpec(path string) (*openapi3.T, error) {
+	loader := newSpecLoader()
 
 	u, err := url.Parse(path)
 	var doc *openapi3.T
@@ -56,7 +66,8 @@ func FindResource(doc *openapi3.T, resourceType string) (*openapi3.Schema, error
 
 	var bestMatchSchema *openapi3.Schema
 
-	for path, pathItem := range doc.Paths.Map() {
+	for _, path := range sortedPaths(doc) {
+		pathItem := doc.Paths.Value(path)
 		if pathItem == nil || pathItem.Put == nil {
 			continue
 		}
@@ -150,7 +161,8 @@ func FindResource(doc *openapi3.T, resourceType string) (*openapi3.Schema, error
 		candidates := []string{name, strings.TrimSuffix(name, "s")}
 
 		if doc.Components != nil && doc.Components.Schemas != nil {
-			for schemaName, schemaRef := range doc.Components.Schemas {
+			for _, schemaName := range slices.Sorted(maps.Keys(doc.Components.Schemas)) {
+				schemaRef := doc.Components.Schemas[schemaName]
 				for _, candidate := range candidates {
 					if strings.EqualFold(schemaName, candidate) {
 						if schemaRef.Value != nil {
@@ -165,6 +177,47 @@ func FindResource(doc *openapi3.T, resourceType string) (*openapi3.Schema, error
 	return nil, fmt.Errorf("resource type %s not found in spec", resourceType)
 }
 
+// FindSchemaDefinition returns the named schema from components.schemas (OpenAPI 3) or from
+// definitions (Swagger 2). Swagger 2 definitions are not modelled by kin-openapi, so the named
+// definition is resolved on demand relative to specPath, including any $refs it contains.
+func FindSchemaDefinition(doc *openapi3.T, specPath, name string) (*openapi3.Schema, error) {
+	if doc == nil {
+		return nil, fmt.Errorf("schema definition %s not found: no spec loaded", name)
+	}
+
+	if doc.Components != nil {
+		if ref, ok := doc.Components.Schemas[name]; ok && ref != nil && ref.Value != nil {
+			return ref.Value, nil
+		}
+	}
+
+	definitions, _ := doc.Extensions["definitions"].(map[string]any)
+	if _, ok := definitions[name]; !ok {
+		return nil, fmt.Errorf("schema definition %s not found in spec", name)
+	}
+
+	location, err := url.Parse(specPath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing spec location %s: %w", specPath, err)
+	}
+
+	// Resolve a synthetic reference on a shallow copy so the caller's document is left untouched.
+	resolveDoc := *doc
+	resolveDoc.Components = &openapi3.Components{
+		Schemas: openapi3.Schemas{name: &openapi3.SchemaRef{Ref: "#/definitions/" + name}},
+	}
+	loader := newSpecLoader()
+	if err := loader.ResolveRefsIn(&resolveDoc, location); err != nil {
+		return nil, fmt.Errorf("resolving schema definition %s: %w", name, err)
+	}
+
+	schema := resolveDoc.Components.Schemas[name].Value
+	if schema == nil {
+		return nil, fmt.Errorf("schema definition %s could not be resolved", name)
+	}
+	return schema, nil
+}
+
 // FindResourceNameSchema identifies the schema for the resource name path parameter for the specified resource type.
 //
 // Azure specs typically express resource naming rules (pattern/minLength/maxLength) on the final path parameter of the PUT
@@ -184,7 +237,8 @@ func FindResourceNameSchema(doc *openapi3.T, resourceType string) (*openapi3.Sch
 		}
 	}
 
-	for path, pathItem := range doc.Paths.Map() {
+	for _, path := range sortedPaths(doc) {
+		pathItem := doc.Paths.Value(path)
 		if pathItem == nil || pathItem.Put == nil {
 			continue
 		}
@@ -209,6 +263,154 @@ func FindResourceNameSchema(doc *openapi3.T, resourceType string) (*openapi3.Sch
 	return nil, nil
 }
 
+// RequestParameter is a header or query parameter of a resource's PUT operation.
+type RequestParameter struct {
+	Name        string // Parameter name as sent, e.g. "If-Match"
+	In          string // "header" or "query"
+	Description string
+	// Schema holds the string constraints of the parameter, if any, normalized as for
+	// FindResourceNameSchema.
+	Schema *openapi3.Schema
+}
+
+// FindResourceRequiredParameters returns the required header and query parameters of the PUT
+// operation for the specified resource type, other than api-version, which azapi sets itself.
+// Operation-level parameters override path-level ones of the same name. The result is sorted
+// by location, then name.
+func FindResourceRequiredParameters(doc *openapi3.T, resourceType string) []RequestParameter {
+	if doc == nil || doc.Paths == nil {
+		return nil
+	}
+
+	searchType := resourceType
+	if strings.HasSuffix(searchType, "}") {
+		if idx := strings.LastIndex(searchType, "/{"); idx != -1 {
+			searchType = searchType[:idx]
+		}
+	}
+
+	for _, path := range sortedPaths(doc) {
+		pathItem := doc.Paths.Value(path)
+		if pathItem == nil || pathItem.Put == nil {
+			continue
+		}
+		parsedType, _, ok := azureARMInstancePathInfo(path)
+		if !ok || !strings.EqualFold(parsedType, searchType) {
+			continue
+		}
+
+		byKey := make(map[string]RequestParameter)
+		for _, params := range []openapi3.Parameters{pathItem.Parameters, pathItem.Put.Parameters} {
+			for _, paramRef := range params {
+				if paramRef == nil || paramRef.Value == nil {
+					continue
+				}
+				p := paramRef.Value
+				if p.In != "header" && p.In != "query" {
+					continue
+				}
+				key := p.In + "/" + strings.ToLower(p.Name)
+				if !p.Required || strings.EqualFold(p.Name, "api-version") {
+					delete(byKey, key)
+					continue
+				}
+				schema := schemaFromParameterExtensions(p)
+				if p.Schema != nil && p.Schema.Value != nil {
+					schema = p.Schema.Value
+				}
+				byKey[key] = RequestParameter{
+					Name:        p.Name,
+					In:          p.In,
+					Description: p.Description,
+					Schema:      normalizeStringSchemaForValidation(schema),
+				}
+			}
+		}
+
+		var result []RequestParameter
+		for _, key := range slices.Sorted(maps.Keys(byKey)) {
+			result = append(result, byKey[key])
+		}
+		return result
+	}
+
+	return nil
+}
+
+// IsLongRunningResource reports whether the PUT operation for the specified resource type is
+// marked with x-ms-long-running-operation, i.e. the service creates and updates the resource
+// asynchronously and the operation can outlast the client's default timeouts.
+func IsLongRunningResource(doc *openapi3.T, resourceType string) bool {
+	if doc == nil || doc.Paths == nil {
+		return false
+	}
+
+	searchType := resourceType
+	if strings.HasSuffix(searchType, "}") {
+		if idx := strings.LastIndex(searchType, "/{"); idx != -1 {
+			searchType = searchType[:idx]
+		}
+	}
+
+	for _, path := range sortedPaths(doc) {
+		pathItem := doc.Paths.Value(path)
+		if pathItem == nil || pathItem.Put == nil {
+			continue
+		}
+		parsedType, _, ok := azureARMInstancePathInfo(path)
+		if !ok || !strings.EqualFold(parsedType, searchType) {
+			continue
+		}
+		longRunning, _ := pathItem.Put.Extensions["x-ms-long-running-operation"].(bool)
+		return longRunning
+	}
+
+	return false
+}
+
+// IsDeprecatedResource reports whether the API for the specified resource type is marked
+// deprecated: its PUT or PATCH operation, or its whole path, which OpenAPI has no field for
+// but specs mark with a path-level deprecated all the same.
+func IsDeprecatedResource(doc *openapi3.T, resourceType string) bool {
+	if doc == nil || doc.Paths == nil {
+		return false
+	}
+
+	searchType := resourceType
+	if strings.HasSuffix(searchType, "}") {
+		if idx := strings.LastIndex(searchType, "/{"); idx != -1 {
+			searchType = searchType[:idx]
+		}
+	}
+
+	for _, path := range sortedPaths(doc) {
+		pathItem := doc.Paths.Value(path)
+		if pathItem == nil || pathItem.Put == nil {
+			continue
+		}
+		parsedType, _, ok := azureARMInstancePathInfo(path)
+		if !ok || !strings.EqualFold(parsedType, searchType) {
+			continue
+		}
+		if deprecated, _ := pathItem.Extensions["deprecated"].(bool); deprecated {
+			return true
+		}
+		return pathItem.Put.Deprecated || (pathItem.Patch != nil && pathItem.Patch.Deprecated)
+	}
+
+	return false
+}
+
+// sortedPaths returns the spec's path keys in lexical order. Specs commonly declare the same
+// resource at several scopes; iterating in a fixed order keeps the selected path, and so the
+// generated output, stable across runs.
+func sortedPaths(doc *openapi3.T) []string {
+	if doc == nil || doc.Paths == nil {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(doc.Paths.Map()))
+}
+
 func findPathParameterSchema(params openapi3.Parameters, name string) *openapi3.Schema {
 	for _, paramRef := range params {
 		if paramRef == nil || paramRef.Value == nil {
@@ -1,6 +1,8 @@
 package openapi
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -74,6 +76,66 @@ func TestFindResource(t *testing.T) {
 	}
 }
 
+func TestFindSchemaDefinition_Components(t *testing.T) {
+	t.Parallel()
+
+	profile := &openapi3.Schema{Type: &openapi3.Types{"object"}}
+	doc := &openapi3.T{Components: &openapi3.Components{
+		Schemas: openapi3.Schemas{"NetworkProfile": &openapi3.SchemaRef{Value: profile}},
+	}}
+
+	schema, err := FindSchemaDefinition(doc, "spec.json", "NetworkProfile")
+	require.NoError(t, err)
+	assert.Same(t, profile, schema)
+
+	_, err = FindSchemaDefinition(doc, "spec.json", "Missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "schema definition Missing not found")
+}
+
+func TestFindSchemaDefinition_Swagger2Normalized(t *testing.T) {
+	t.Parallel()
+
+	// Definitions resolved on demand read the documents they refer to with the same
+	// normalization as LoadSpec.
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "spec.json")
+	require.NoError(t, os.WriteFile(specPath, []byte(`{
+  "swagger": "2.0",
+  "info": {"title": "Test", "version": "2024-01-01"},
+  "paths": {},
+  "definitions": {
+    "Scale": {
+      "type": "object",
+      "properties": {
+        "capacity": {"$ref": "common.json#/definitions/Capacity"},
+        "range": {"$ref": "common.json#/definitions/Range"}
+      }
+    }
+  }
+}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "common.json"), []byte(`{
+  "swagger": "2.0",
+  "info": {"title": "Common", "version": "2024-01-01"},
+  "paths": {},
+  "definitions": {
+    "Capacity": {"type": "integer", "exclusiveMinimum": 0},
+    "Range": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}]}
+  }
+}`), 0o644))
+
+	doc, err := LoadSpec(specPath)
+	require.NoError(t, err)
+	schema, err := FindSchemaDefinition(doc, specPath, "Scale")
+	require.NoError(t, err)
+
+	capacity := schema.Properties["capacity"].Value
+	require.NotNil(t, capacity.Min)
+	assert.Equal(t, 0.0, *capacity.Min)
+	assert.True(t, capacity.ExclusiveMin)
+	assert.Len(t, TupleItems(schema.Properties["range"].Value), 2)
+}
+
 func TestFindResourceNameSchema(t *testing.T) {
 	t.Parallel()
 
@@ -110,6 +172,36 @@ func TestFindResourceNameSchema(t *testing.T) {
 	assert.Equal(t, maxLen, *schema.MaxLength)
 }
 
+func TestFindResourceNameSchema_MultipleScopesIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	pathItemWithPattern := func(pattern string) *openapi3.PathItem {
+		return &openapi3.PathItem{
+			Put: &openapi3.Operation{
+				Parameters: openapi3.Parameters{
+					&openapi3.ParameterRef{Value: &openapi3.Parameter{
+						Name:   "widgetName",
+						In:     "path",
+						Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Pattern: pattern}},
+					}},
+				},
+			},
+		}
+	}
+
+	doc := &openapi3.T{Paths: &openapi3.Paths{}}
+	doc.Paths.Set("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}", pathItemWithPattern("^rg$"))
+	doc.Paths.Set("/subscriptions/{subscriptionId}/providers/Microsoft.Test/widgets/{widgetName}", pathItemWithPattern("^sub$"))
+
+	// Paths are visited in lexical order, so the same scope wins on every run.
+	for range 20 {
+		schema, err := FindResourceNameSchema(doc, "Microsoft.Test/widgets")
+		require.NoError(t, err)
+		require.NotNil(t, schema)
+		assert.Equal(t, "^sub$", schema.Pattern)
+	}
+}
+
 func TestFindResourceNameSchema_SwaggerV2ParameterExtensions(t *testing.T) {
 	t.Parallel()
 
@@ -148,6 +240,109 @@ func TestFindResourceNameSchema_SwaggerV2ParameterExtensions(t *testing.T) {
 	assert.Equal(t, uint64(63), *schema.MaxLength)
 }
 
+func TestFindResourceRequiredParameters(t *testing.T) {
+	t.Parallel()
+
+	parameter := func(name, in string, required bool) *openapi3.ParameterRef {
+		return &openapi3.ParameterRef{Value: &openapi3.Parameter{Name: name, In: in, Required: required}}
+	}
+	ifMatch := parameter("If-Match", "header", true)
+	ifMatch.Value.Description = "The ETag of the resource."
+	ifMatch.Value.Extensions = map[string]interface{}{"type": "string", "maxLength": float64(64)}
+
+	doc := &openapi3.T{Paths: &openapi3.Paths{}}
+	doc.Paths.Set("/subscriptions/{subscriptionId}/providers/Microsoft.Test/widgets/{widgetName}", &openapi3.PathItem{
+		Parameters: openapi3.Parameters{
+			parameter("mode", "query", true),
+			parameter("region", "query", true),
+		},
+		Put: &openapi3.Operation{Parameters: openapi3.Parameters{
+			parameter("widgetName", "path", true),
+			parameter("api-version", "query", true),
+			// An operation-level parameter overrides the path-level one.
+			parameter("region", "query", false),
+			parameter("x-ms-client-request-id", "header", false),
+			ifMatch,
+		}},
+	})
+
+	params := FindResourceRequiredParameters(doc, "Microsoft.Test/widgets")
+	require.Len(t, params, 2)
+	assert.Equal(t, "If-Match", params[0].Name)
+	assert.Equal(t, "header", params[0].In)
+	assert.Equal(t, "The ETag of the resource.", params[0].Description)
+	require.NotNil(t, params[0].Schema)
+	require.NotNil(t, params[0].Schema.MaxLength)
+	assert.Equal(t, uint64(64), *params[0].Schema.MaxLength)
+	assert.Equal(t, "mode", params[1].Name)
+	assert.Equal(t, "query", params[1].In)
+	assert.Nil(t, params[1].Schema)
+
+	assert.Empty(t, FindResourceRequiredParameters(doc, "Microsoft.Test/gadgets"))
+}
+
+func TestIsLongRunningResource(t *testing.T) {
+	t.Parallel()
+
+	spec := `{
+		"swagger": "2.0",
+		"info": {"title": "test", "version": "2024-01-01"},
+		"paths": {
+			"/subscriptions/{subscriptionId}/providers/Microsoft.Test/widgets/{widgetName}": {
+				"put": {"x-ms-long-running-operation": true, "responses": {"200": {"description": "OK"}}}
+			},
+			"/subscriptions/{subscriptionId}/providers/Microsoft.Test/gadgets/{gadgetName}": {
+				"put": {"responses": {"200": {"description": "OK"}}}
+			}
+		}
+	}`
+	specPath := filepath.Join(t.TempDir(), "spec.json")
+	require.NoError(t, os.WriteFile(specPath, []byte(spec), 0o644))
+	doc, err := LoadSpec(specPath)
+	require.NoError(t, err)
+
+	assert.True(t, IsLongRunningResource(doc, "Microsoft.Test/widgets"))
+	assert.False(t, IsLongRunningResource(doc, "Microsoft.Test/gadgets"))
+	assert.False(t, IsLongRunningResource(doc, "Microsoft.Test/unknown"))
+	assert.False(t, IsLongRunningResource(nil, "Microsoft.Test/widgets"))
+}
+
+func TestIsDeprecatedResource(t *testing.T) {
+	t.Parallel()
+
+	spec := `{
+		"swagger": "2.0",
+		"info": {"title": "test", "version": "2024-01-01"},
+		"paths": {
+			"/subscriptions/{subscriptionId}/providers/Microsoft.Test/widgets/{widgetName}": {
+				"put": {"deprecated": true, "responses": {"200": {"description": "OK"}}}
+			},
+			"/subscriptions/{subscriptionId}/providers/Microsoft.Test/gizmos/{gizmoName}": {
+				"put": {"responses": {"200": {"description": "OK"}}},
+				"patch": {"deprecated": true, "responses": {"200": {"description": "OK"}}}
+			},
+			"/subscriptions/{subscriptionId}/providers/Microsoft.Test/doohickeys/{doohickeyName}": {
+				"deprecated": true,
+				"put": {"responses": {"200": {"description": "OK"}}}
+			},
+			"/subscriptions/{subscriptionId}/providers/Microsoft.Test/gadgets/{gadgetName}": {
+				"put": {"responses": {"200": {"description": "OK"}}}
+			}
+		}
+	}`
+	specPath := filepath.Join(t.TempDir(), "spec.json")
+	require.NoError(t, os.WriteFile(specPath, []byte(spec), 0o644))
+	doc, err := LoadSpec(specPath)
+	require.NoError(t, err)
+
+	assert.True(t, IsDeprecatedResource(doc, "Microsoft.Test/widgets"))
+	assert.True(t, IsDeprecatedResource(doc, "Microsoft.Test/gizmos"))
+	assert.True(t, IsDeprecatedResource(doc, "Microsoft.Test/doohickeys"))
+	assert.False(t, IsDeprecatedResource(doc, "Microsoft.Test/gadgets"))
+	assert.False(t, IsDeprecatedResource(doc, "Microsoft.Test/unknown"))
+	assert.False(t, IsDeprecatedResource(nil, "Microsoft.Test/widgets"))
+}
+
 func TestAzureARMInstancePathInfo(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,74 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// PatternProperties is a map declared with JSON Schema patternProperties: every key matches
+// KeyPattern and every value follows Value.
+type PatternProperties struct {
+	KeyPattern string
+	// Value is nil when the value schemas differ between patterns or are unresolved $refs.
+	Value *openapi3.Schema
+}
+
+// GetPatternProperties returns the patternProperties map declared by schema, or nil when it
+// declares none. kin-openapi has no field for the keyword, so it is read from the raw value kept
+// in Extensions. Several patterns are combined into a single alternation.
+func GetPatternProperties(schema *openapi3.Schema) (*PatternProperties, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	raw, ok := schema.Extensions["patternProperties"]
+	if !ok {
+		return nil, nil
+	}
+	patterns, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("patternProperties must be an object, got %T", raw)
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(patterns))
+	for pattern := range patterns {
+		keys = append(keys, pattern)
+	}
+	sort.Strings(keys)
+
+	result := &PatternProperties{KeyPattern: keys[0]}
+	if len(keys) > 1 {
+		alternatives := make([]string, len(keys))
+		for i, pattern := range keys {
+			alternatives[i] = "(?:" + pattern + ")"
+		}
+		result.KeyPattern = strings.Join(alternatives, "|")
+	}
+
+	first := patterns[keys[0]]
+	for _, pattern := range keys[1:] {
+		if !reflect.DeepEqual(patterns[pattern], first) {
+			return result, nil
+		}
+	}
+
+	data, err := json.Marshal(first)
+	if err != nil {
+		return nil, fmt.Errorf("reading patternProperties value schema: %w", err)
+	}
+	var value openapi3.SchemaRef
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("reading patternProperties value schema: %w", err)
+	}
+	if value.Ref == "" {
+		result.Value = value.Value
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPatternProperties(t *testing.T) {
+	parse := func(t *testing.T, raw string) *openapi3.Schema {
+		t.Helper()
+		var schema openapi3.Schema
+		require.NoError(t, json.Unmarshal([]byte(raw), &schema))
+		return &schema
+	}
+
+	t.Run("none", func(t *testing.T) {
+		pattern, err := GetPatternProperties(parse(t, `{"type": "object"}`))
+		require.NoError(t, err)
+		assert.Nil(t, pattern)
+	})
+
+	t.Run("single pattern", func(t *testing.T) {
+		schema := parse(t, `{"patternProperties": {"^x-": {"type": "integer"}}}`)
+		assert.Equal(t, []string{"object"}, EffectiveTypes(schema))
+
+		pattern, err := GetPatternProperties(schema)
+		require.NoError(t, err)
+		require.NotNil(t, pattern)
+		assert.Equal(t, "^x-", pattern.KeyPattern)
+		require.NotNil(t, pattern.Value)
+		assert.Equal(t, &openapi3.Types{"integer"}, pattern.Value.Type)
+	})
+
+	t.Run("patterns sharing a value schema", func(t *testing.T) {
+		pattern, err := GetPatternProperties(parse(t, `{"patternProperties": {"^b": {"type": "string"}, "^a": {"type": "string"}}}`))
+		require.NoError(t, err)
+		assert.Equal(t, "(?:^a)|(?:^b)", pattern.KeyPattern)
+		require.NotNil(t, pattern.Value)
+		assert.Equal(t, &openapi3.Types{"string"}, pattern.Value.Type)
+	})
+
+	t.Run("patterns with different value schemas", func(t *testing.T) {
+		pattern, err := GetPatternProperties(parse(t, `{"patternProperties": {"^a": {"type": "string"}, "^b": {"type": "integer"}}}`))
+		require.NoError(t, err)
+		assert.Equal(t, "(?:^a)|(?:^b)", pattern.KeyPattern)
+		assert.Nil(t, pattern.Value)
+	})
+
+	t.Run("unresolved ref", func(t *testing.T) {
+		pattern, err := GetPatternProperties(parse(t, `{"patternProperties": {"^a": {"$ref": "#/definitions/Label"}}}`))
+		require.NoError(t, err)
+		assert.Nil(t, pattern.Value)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := GetPatternProperties(parse(t, `{"patternProperties": ["^a"]}`))
+		require.Error(t, err)
+	})
+}
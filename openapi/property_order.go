@@ -0,0 +1,212 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const tfmodmakePropertyOrderKey = "x-tfmodmake-property-order"
+
+// AnnotatePropertyOrder records, on each schema below schema that came from a definition or
+// component (see AnnotateSchemaRefOrigins), the order its properties are declared in the spec
+// at specPath. Parsed schemas keep properties in a map, so the order is read from the raw spec.
+//
+// This mutates the schema graph rooted at schema.
+func AnnotatePropertyOrder(schema *openapi3.Schema, specPath string) error {
+	data, err := readSpecBytes(specPath)
+	if err != nil {
+		return err
+	}
+	orders, err := declaredPropertyOrders(data)
+	if err != nil {
+		return err
+	}
+	annotatePropertyOrder(schema, orders, make(map[*openapi3.Schema]struct{}))
+	return nil
+}
+
+func annotatePropertyOrder(schema *openapi3.Schema, orders map[string][]string, visited map[*openapi3.Schema]struct{}) {
+	if schema == nil {
+		return
+	}
+	if _, seen := visited[schema]; seen {
+		return
+	}
+	visited[schema] = struct{}{}
+
+	if ref, _ := schema.Extensions[tfmodmakeSchemaRefKey].(string); ref != "" {
+		if order, ok := orders[ref]; ok {
+			schema.Extensions[tfmodmakePropertyOrderKey] = order
+		}
+	}
+
+	for _, ref := range schema.AllOf {
+		if ref != nil {
+			annotatePropertyOrder(ref.Value, orders, visited)
+		}
+	}
+	for _, propRef := range schema.Properties {
+		if propRef != nil {
+			annotatePropertyOrder(propRef.Value, orders, visited)
+		}
+	}
+	if schema.Items != nil {
+		annotatePropertyOrder(schema.Items.Value, orders, visited)
+	}
+	if schema.AdditionalProperties.Schema != nil {
+		annotatePropertyOrder(schema.AdditionalProperties.Schema.Value, orders, visited)
+	}
+}
+
+// PropertyOrder returns the names of the effective properties of schema (see
+// GetEffectiveProperties) in declaration order: those of its allOf components first, in order,
+// then its own. Properties whose order was not recorded by AnnotatePropertyOrder follow in
+// alphabetical order.
+func PropertyOrder(schema *openapi3.Schema) ([]string, error) {
+	props, err := GetEffectiveProperties(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]string, 0, len(props))
+	seen := make(map[string]struct{}, len(props))
+	inProgress := make(map[*openapi3.Schema]struct{})
+	var visit func(s *openapi3.Schema)
+	visit = func(s *openapi3.Schema) {
+		if s == nil {
+			return
+		}
+		if _, ok := inProgress[s]; ok {
+			return
+		}
+		inProgress[s] = struct{}{}
+		defer delete(inProgress, s)
+
+		for _, ref := range s.AllOf {
+			if ref != nil {
+				visit(ref.Value)
+			}
+		}
+		declared, _ := s.Extensions[tfmodmakePropertyOrderKey].([]string)
+		for _, name := range declared {
+			if _, ok := props[name]; !ok {
+				continue
+			}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			ordered = append(ordered, name)
+		}
+	}
+	visit(schema)
+
+	var rest []string
+	for name := range props {
+		if _, ok := seen[name]; !ok {
+			rest = append(rest, name)
+		}
+	}
+	slices.Sort(rest)
+	return append(ordered, rest...), nil
+}
+
+// declaredPropertyOrders returns the property names of each definition (Swagger 2) and
+// component schema (OpenAPI 3) of a raw spec, in declaration order, keyed by their $ref.
+func declaredPropertyOrders(data []byte) (map[string][]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	orders := make(map[string][]string)
+	err := walkJSONObject(dec, func(key string) error {
+		switch key {
+		case "definitions":
+			return readSchemaPropertyOrders(dec, "#/definitions/", orders)
+		case "components":
+			return walkJSONObject(dec, func(key string) error {
+				if key == "schemas" {
+					return readSchemaPropertyOrders(dec, "#/components/schemas/", orders)
+				}
+				return skipJSONValue(dec)
+			})
+		}
+		return skipJSONValue(dec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// readSchemaPropertyOrders reads an object of named schemas, recording the property order of
+// each under prefix followed by its name.
+func readSchemaPropertyOrders(dec *json.Decoder, prefix string, orders map[string][]string) error {
+	return walkJSONObject(dec, func(name string) error {
+		return walkJSONObject(dec, func(key string) error {
+			if key != "properties" {
+				return skipJSONValue(dec)
+			}
+			var names []string
+			err := walkJSONObject(dec, func(prop string) error {
+				names = append(names, prop)
+				return skipJSONValue(dec)
+			})
+			orders[prefix+name] = names
+			return err
+		})
+	})
+}
+
+// walkJSONObject reads the next value from dec and, if it is an object, calls field with each
+// of its keys in order. field must consume the value of its key. Any other value is skipped.
+func walkJSONObject(dec *json.Decoder, field func(key string) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return skipJSONRest(dec, tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if err := field(key); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// skipJSONValue reads and discards the next value from dec.
+func skipJSONValue(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	return skipJSONRest(dec, tok)
+}
+
+// skipJSONRest discards the rest of the value tok starts, when tok opens an object or array.
+func skipJSONRest(dec *json.Decoder, tok json.Token) error {
+	if delim, ok := tok.(json.Delim); !ok || (delim != '{' && delim != '[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
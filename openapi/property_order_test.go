@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropertyOrder(t *testing.T) {
+	t.Parallel()
+
+	spec := `{
+		"swagger": "2.0",
+		"info": {"title": "test", "version": "2024-01-01"},
+		"paths": {
+			"/subscriptions/{subscriptionId}/providers/Microsoft.Test/widgets/{widgetName}": {
+				"put": {
+					"parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/Widget"}}],
+					"responses": {"200": {"description": "OK"}}
+				}
+			}
+		},
+		"definitions": {
+			"Widget": {
+				"allOf": [{"$ref": "#/definitions/Resource"}],
+				"properties": {
+					"sku": {"type": "string"},
+					"properties": {"$ref": "#/definitions/WidgetProperties"}
+				}
+			},
+			"Resource": {
+				"properties": {
+					"name": {"type": "string"},
+					"location": {"type": "string"},
+					"id": {"type": "string", "readOnly": true}
+				}
+			},
+			"WidgetProperties": {
+				"properties": {
+					"zone": {"type": "string"},
+					"alias": {"type": "string"},
+					"settings": {"type": "object", "properties": {"b": {"type": "string"}, "a": {"type": "string"}}},
+					"mode": {"type": "string"}
+				}
+			}
+		}
+	}`
+	specPath := filepath.Join(t.TempDir(), "spec.json")
+	require.NoError(t, os.WriteFile(specPath, []byte(spec), 0o644))
+
+	doc, err := LoadSpec(specPath)
+	require.NoError(t, err)
+	schema, err := FindResource(doc, "Microsoft.Test/widgets")
+	require.NoError(t, err)
+
+	// Without annotations every property is unordered.
+	order, err := PropertyOrder(schema)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "location", "name", "properties", "sku"}, order)
+
+	AnnotateSchemaRefOrigins(schema)
+	require.NoError(t, AnnotatePropertyOrder(schema, specPath))
+
+	// allOf components come first. FindResource returns the resource schema without its $ref,
+	// so its own properties stay alphabetical.
+	order, err = PropertyOrder(schema)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "location", "id", "properties", "sku"}, order)
+
+	bag := schema.Properties["properties"].Value
+	order, err = PropertyOrder(bag)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zone", "alias", "settings", "mode"}, order)
+
+	// Inline schemas have no definition to read the order from.
+	order, err = PropertyOrder(bag.Properties["settings"].Value)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, order)
+}
@@ -0,0 +1,60 @@
+package openapi
+
+import (
+	"encoding/json"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// tupleItemsKey marks an array schema whose items were a list of schemas, one per position.
+const tupleItemsKey = "x-tfmodmake-tuple-items"
+
+// normalizeTupleItems walks a decoded JSON document and rewrites each JSON Schema tuple, an
+// "items" holding a list of schemas, into the single items schema kin-openapi understands: an
+// anyOf of the positional schemas, with the array marked by tupleItemsKey so TupleItems can
+// recover them. It reports whether anything was changed.
+func normalizeTupleItems(node any) bool {
+	changed := false
+	switch v := node.(type) {
+	case map[string]any:
+		if items, ok := v["items"].([]any); ok {
+			v["items"] = map[string]any{"anyOf": items}
+			v[tupleItemsKey] = true
+			changed = true
+		}
+		for _, child := range v {
+			if normalizeTupleItems(child) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if normalizeTupleItems(child) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// TupleItems returns the positional item schemas of an array schema whose items were a tuple,
+// a list of schemas (see normalizeTupleItems), or nil for any other schema.
+func TupleItems(schema *openapi3.Schema) []*openapi3.SchemaRef {
+	if schema == nil || schema.Items == nil || schema.Items.Value == nil {
+		return nil
+	}
+	switch marker := schema.Extensions[tupleItemsKey].(type) {
+	case bool:
+		if !marker {
+			return nil
+		}
+	case json.RawMessage:
+		var tuple bool
+		if err := json.Unmarshal(marker, &tuple); err != nil || !tuple {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return schema.Items.Value.AnyOf
+}
@@ -0,0 +1,51 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTupleItems(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "spec.json")
+	require.NoError(t, os.WriteFile(specPath, []byte(`{
+  "swagger": "2.0",
+  "info": {"title": "Test", "version": "2024-01-01"},
+  "paths": {
+    "/subscriptions/{subscriptionId}/providers/Microsoft.Test/widgets/{widgetName}": {
+      "put": {
+        "parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/Widget"}}],
+        "responses": {"200": {"description": "OK"}}
+      }
+    }
+  },
+  "definitions": {
+    "Widget": {
+      "type": "object",
+      "properties": {
+        "range": {"type": "array", "items": [{"type": "string"}, {"$ref": "#/definitions/Weight"}]},
+        "labels": {"type": "array", "items": {"type": "string"}}
+      }
+    },
+    "Weight": {"type": "object", "properties": {"value": {"type": "integer"}}}
+  }
+}`), 0o644))
+
+	doc, err := LoadSpec(specPath)
+	require.NoError(t, err)
+	schema, err := FindResource(doc, "Microsoft.Test/widgets")
+	require.NoError(t, err)
+
+	items := TupleItems(schema.Properties["range"].Value)
+	require.Len(t, items, 2)
+	assert.True(t, items[0].Value.Type.Is(openapi3.TypeString))
+	require.NotNil(t, items[1].Value)
+	assert.Contains(t, items[1].Value.Properties, "value")
+
+	assert.Nil(t, TupleItems(schema.Properties["labels"].Value))
+	assert.Nil(t, TupleItems(schema))
+}
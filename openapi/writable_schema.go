@@ -0,0 +1,106 @@
+package openapi
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// WritableSchema returns a self-contained copy of a request body schema holding only what a
+// caller can set: $refs are inlined, allOf components are merged into the properties they
+// contribute, and read-only properties are dropped. Vendor extensions are left out, apart from
+// patternProperties which kin-openapi keeps among them. A schema that refers back to itself is
+// cut off with an empty schema at the point of recursion.
+func WritableSchema(schema *openapi3.Schema) (*openapi3.Schema, error) {
+	return writableSchemaCopy(schema, make(map[*openapi3.Schema]struct{}))
+}
+
+func writableSchemaCopy(schema *openapi3.Schema, visiting map[*openapi3.Schema]struct{}) (*openapi3.Schema, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	if _, ok := visiting[schema]; ok {
+		return &openapi3.Schema{}, nil
+	}
+	visiting[schema] = struct{}{}
+	defer delete(visiting, schema)
+
+	copyRef := func(ref *openapi3.SchemaRef) (*openapi3.SchemaRef, error) {
+		if ref == nil || ref.Value == nil {
+			return nil, nil
+		}
+		value, err := writableSchemaCopy(ref.Value, visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &openapi3.SchemaRef{Value: value}, nil
+	}
+	copyRefs := func(refs openapi3.SchemaRefs) (openapi3.SchemaRefs, error) {
+		var out openapi3.SchemaRefs
+		for _, ref := range refs {
+			copied, err := copyRef(ref)
+			if err != nil {
+				return nil, err
+			}
+			if copied != nil {
+				out = append(out, copied)
+			}
+		}
+		return out, nil
+	}
+
+	out := *schema
+	out.Extensions = nil
+	if raw, ok := schema.Extensions["patternProperties"]; ok {
+		out.Extensions = map[string]any{"patternProperties": raw}
+	}
+	out.Origin = nil
+	out.AllOf = nil
+
+	props, err := GetEffectiveProperties(schema)
+	if err != nil {
+		return nil, err
+	}
+	required, err := GetEffectiveRequired(schema)
+	if err != nil {
+		return nil, err
+	}
+	out.Properties = nil
+	out.Required = nil
+	for name, ref := range props {
+		if ref == nil || ref.Value == nil || !isWritableSchema(ref.Value) {
+			continue
+		}
+		copied, err := copyRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		if out.Properties == nil {
+			out.Properties = make(openapi3.Schemas, len(props))
+		}
+		out.Properties[name] = copied
+	}
+	for _, name := range required {
+		if _, ok := out.Properties[name]; ok {
+			out.Required = append(out.Required, name)
+		}
+	}
+	if out.Type == nil && len(out.Properties) > 0 {
+		out.Type = &openapi3.Types{"object"}
+	}
+
+	if out.Items, err = copyRef(schema.Items); err != nil {
+		return nil, err
+	}
+	if out.AdditionalProperties.Schema, err = copyRef(schema.AdditionalProperties.Schema); err != nil {
+		return nil, err
+	}
+	if out.Not, err = copyRef(schema.Not); err != nil {
+		return nil, err
+	}
+	if out.OneOf, err = copyRefs(schema.OneOf); err != nil {
+		return nil, err
+	}
+	if out.AnyOf, err = copyRefs(schema.AnyOf); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
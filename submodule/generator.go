@@ -58,11 +58,17 @@ func Generate(modulePath string) error {
 func buildDescription(module *tfconfig.Module) string {
 	sb := strings.Builder{}
 	sb.WriteString("Map of instances for the submodule with the following attributes:\n\n")
-	for k, v := range module.Variables {
-		if k == "parent_id" {
+	var variableNames []string
+	for name := range module.Variables {
+		if name == "parent_id" {
 			continue
 		}
-		sb.WriteString(fmt.Sprintf("**%s**\n%s\n", k, v.Description))
+		variableNames = append(variableNames, name)
+	}
+	sort.Strings(variableNames)
+
+	for _, name := range variableNames {
+		sb.WriteString(fmt.Sprintf("**%s**\n%s\n", name, module.Variables[name].Description))
 	}
 	return sb.String()
 }
@@ -114,7 +120,7 @@ func writeVariablesFile(moduleName string, typeTokens hclwrite.Tokens, descripti
 	blockBody.SetAttributeValue("default", cty.MapValEmpty(cty.DynamicPseudoType))
 
 	filename := fmt.Sprintf("variables.%s.tf", moduleName)
-	return os.WriteFile(filename, file.Bytes(), 0o644)
+	return hclgen.WriteFile(filename, file)
 }
 
 func writeMainFile(moduleName, sourcePath string, module *tfconfig.Module) error {
@@ -150,7 +156,7 @@ func writeMainFile(moduleName, sourcePath string, module *tfconfig.Module) error
 	}
 
 	filename := fmt.Sprintf("main.%s.tf", moduleName)
-	return os.WriteFile(filename, file.Bytes(), 0o644)
+	return hclgen.WriteFile(filename, file)
 }
 
 func parseExpressionTokens(expr string) (hclwrite.Tokens, error) {
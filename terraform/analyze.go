@@ -0,0 +1,153 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/matt-FFFFFF/tfmodmake/openapi"
+)
+
+// Severity levels for SpecIssue.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// SpecIssue is a single finding from AnalyzeResource.
+type SpecIssue struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
+}
+
+// SpecReport lists the issues that would affect generating a module for a resource.
+type SpecReport struct {
+	ResourceType string      `json:"resource_type"`
+	Issues       []SpecIssue `json:"issues"`
+}
+
+// HasErrors reports whether any issue would block generation.
+func (r *SpecReport) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeResource runs the generation pipeline for resourceType in analysis-only mode and
+// reports issues that block generation or degrade the generated module. Nothing is written
+// to disk. The returned error is only non-nil if ctx is cancelled.
+func AnalyzeResource(ctx context.Context, specs []string, resourceType string, opts ...GeneratorOption) (*SpecReport, error) {
+	report := &SpecReport{ResourceType: resourceType, Issues: []SpecIssue{}}
+
+	loaded, err := LoadResource(ctx, specs, resourceType)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		report.add(SeverityError, "resource-not-found", "", err.Error())
+		return report, nil
+	}
+
+	o := &generatorOptions{}
+	loaded(o)
+	analyzeSchema(report, o.schema, "", make(map[*openapi3.Schema]struct{}))
+
+	// Only run generation when the schema walk found nothing blocking, so the same problem
+	// is not reported twice.
+	if !report.HasErrors() {
+		genOpts := append([]GeneratorOption{loaded}, opts...)
+		if _, err := GenerateFiles(resourceType, genOpts...); err != nil {
+			code := "generation-failed"
+			if strings.Contains(err.Error(), "name collision") {
+				code = "name-collision"
+			}
+			report.add(SeverityError, code, "", err.Error())
+		}
+	}
+
+	return report, nil
+}
+
+func (r *SpecReport) add(severity, code, path, message string) {
+	r.Issues = append(r.Issues, SpecIssue{Severity: severity, Code: code, Path: path, Message: message})
+}
+
+// analyzeSchema walks the writable properties of schema, recording issues against their
+// dotted paths (e.g. properties.networkProfile.subnets[]).
+func analyzeSchema(report *SpecReport, schema *openapi3.Schema, path string, visited map[*openapi3.Schema]struct{}) {
+	if schema == nil {
+		return
+	}
+	// A nullable wrapper is generated as the schema it wraps.
+	schema, _ = openapi.UnwrapNullable(schema)
+	if _, seen := visited[schema]; seen {
+		return
+	}
+	visited[schema] = struct{}{}
+
+	if path != "" {
+		if len(schema.OneOf) > 0 || len(schema.AnyOf) > 0 {
+			report.add(SeverityWarning, "unsupported-oneof", path, "oneOf/anyOf variants are not modelled; only the base schema is generated")
+		} else if isUntyped(schema) {
+			report.add(SeverityWarning, "untyped-property", path, "property has no type and will be generated as any")
+		}
+	}
+
+	if items := openapi.TupleItems(schema); items != nil {
+		for i, item := range items {
+			if item != nil {
+				analyzeSchema(report, item.Value, fmt.Sprintf("%s[%d]", path, i), visited)
+			}
+		}
+	} else if schema.Items != nil && schema.Items.Value != nil {
+		analyzeSchema(report, schema.Items.Value, path+"[]", visited)
+	}
+	if schema.AdditionalProperties.Schema != nil && schema.AdditionalProperties.Schema.Value != nil {
+		analyzeSchema(report, schema.AdditionalProperties.Schema.Value, path+".*", visited)
+	}
+
+	props, err := openapi.GetEffectiveProperties(schema)
+	if err != nil {
+		code := "allof-conflict"
+		if errors.Is(err, openapi.ErrAllOfCycle) {
+			code = "allof-cycle"
+		}
+		report.add(SeverityError, code, path, err.Error())
+		return
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propRef := props[name]
+		if propRef == nil || propRef.Value == nil || !isWritableProperty(propRef.Value) {
+			continue
+		}
+		childPath := name
+		if path != "" {
+			childPath = path + "." + name
+		}
+		analyzeSchema(report, propRef.Value, childPath, visited)
+	}
+}
+
+// isUntyped reports whether a schema gives the generator nothing to derive a type from.
+func isUntyped(schema *openapi3.Schema) bool {
+	if schema.Type != nil && len(*schema.Type) > 0 {
+		return false
+	}
+	return len(schema.AllOf) == 0 && len(schema.Properties) == 0 &&
+		schema.Items == nil && schema.AdditionalProperties.Schema == nil
+}
@@ -0,0 +1,47 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeSchema(t *testing.T) {
+	cyclic := &openapi3.Schema{Type: &openapi3.Types{"object"}}
+	cyclic.AllOf = openapi3.SchemaRefs{{Value: cyclic}}
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"loop":    {Value: cyclic},
+					"variant": {Value: &openapi3.Schema{OneOf: openapi3.SchemaRefs{{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}}}}},
+					"items": {Value: &openapi3.Schema{
+						Type:  &openapi3.Types{"array"},
+						Items: &openapi3.SchemaRef{Value: &openapi3.Schema{}},
+					}},
+					// A nullable wrapper is generated as the schema it wraps, so it isn't reported.
+					"nullable": {Value: &openapi3.Schema{AnyOf: openapi3.SchemaRefs{
+						{Value: &openapi3.Schema{Type: &openapi3.Types{"null"}}},
+						{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					}}},
+					"status": {Value: &openapi3.Schema{ReadOnly: true}},
+					"value":  {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+				},
+			}},
+		},
+	}
+
+	report := &SpecReport{}
+	analyzeSchema(report, schema, "", make(map[*openapi3.Schema]struct{}))
+
+	assert.Equal(t, []SpecIssue{
+		{Severity: SeverityWarning, Code: "untyped-property", Path: "properties.items[]", Message: "property has no type and will be generated as any"},
+		{Severity: SeverityError, Code: "allof-cycle", Path: "properties.loop", Message: "getting properties from allOf component 0: circular reference detected in allOf chain while getting effective properties"},
+		{Severity: SeverityWarning, Code: "unsupported-oneof", Path: "properties.variant", Message: "oneOf/anyOf variants are not modelled; only the base schema is generated"},
+	}, report.Issues)
+	assert.True(t, report.HasErrors())
+}
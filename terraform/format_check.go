@@ -0,0 +1,18 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// checkParses checks that src, the content of the Terraform file filename, is valid HCL. It is a
+// cheap gate against files assembled from tokens that don't parse the way they were meant to,
+// needing neither the terraform binary nor `terraform init`.
+func checkParses(filename string, src []byte) error {
+	if _, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos); diags.HasErrors() {
+		return fmt.Errorf("%s is not valid HCL: %w", filename, diags)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/openapi"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// actionVariableNames returns the toggle variable enabling an action and the variable holding
+// its request body, e.g. regenerate_key_enabled and regenerate_key_body.
+func actionVariableNames(action openapi.ChildAction, namer *naming.Namer) (enabled, body string) {
+	name := namer.ToSnakeCase(action.Name)
+	return name + "_enabled", name + "_body"
+}
+
+// emitActionVars generates the toggle variable of each action, and a body variable for those
+// that take a request body.
+func emitActionVars(body *hclwrite.Body, actions []openapi.ChildAction, namer *naming.Namer, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
+	for _, action := range actions {
+		enabledName, bodyName := actionVariableNames(action, namer)
+		enabledBody := appendVariable(
+			enabledName,
+			fmt.Sprintf("Whether to invoke the %s action on the resource once it is created.", action.Name),
+			hclwrite.TokensForIdentifier("bool"),
+		)
+		enabledBody.SetAttributeValue("default", cty.False)
+		enabledBody.SetAttributeValue("nullable", cty.False)
+		body.AppendNewline()
+
+		if !action.HasBody {
+			continue
+		}
+		bodyBody := appendVariable(
+			bodyName,
+			fmt.Sprintf("The request body of the %s action, used when %s is true.", action.Name, enabledName),
+			hclwrite.TokensForIdentifier("any"),
+		)
+		bodyBody.SetAttributeRaw("default", hclwrite.TokensForIdentifier("null"))
+		body.AppendNewline()
+	}
+}
+
+// generateActions creates main.actions.tf with an azapi_resource_action per POST action of the
+// resource, each invoked on azapi_resource.<resourceName> only when its toggle variable is set.
+func generateActions(actions []openapi.ChildAction, resourceType, apiVersion, resourceName string, namer *naming.Namer, write fileWriter) error {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+
+	apiVersion = strings.TrimSpace(apiVersion)
+	if apiVersion == "" {
+		apiVersion = "apiVersion"
+	}
+	resourceTypeWithAPIVersion := fmt.Sprintf("%s@%s", cleanTypeString(resourceType), apiVersion)
+
+	for i, action := range actions {
+		if i > 0 {
+			body.AppendNewline()
+		}
+		enabledName, bodyName := actionVariableNames(action, namer)
+		actionBlock := body.AppendNewBlock("resource", []string{"azapi_resource_action", namer.ToSnakeCase(action.Name)})
+		actionBody := actionBlock.Body()
+		actionBody.SetAttributeRaw("count", tokensForToggleCount(hclgen.TokensForTraversal("var", enabledName)))
+		actionBody.SetAttributeValue("type", cty.StringVal(resourceTypeWithAPIVersion))
+		actionBody.SetAttributeRaw("resource_id", hclgen.TokensForTraversal("azapi_resource", resourceName, "id"))
+		actionBody.SetAttributeValue("action", cty.StringVal(action.Name))
+		actionBody.SetAttributeValue("method", cty.StringVal(action.Method))
+		if action.HasBody {
+			actionBody.SetAttributeRaw("body", hclgen.TokensForTraversal("var", bodyName))
+		}
+	}
+
+	return write("main.actions.tf", file.Bytes())
+}
+
+// tokensForToggleCount builds `<condition> ? 1 : 0`.
+func tokensForToggleCount(condition hclwrite.Tokens) hclwrite.Tokens {
+	tokens := append(hclwrite.Tokens(nil), condition...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte("?")})
+	tokens = append(tokens, hclwrite.TokensForValue(cty.NumberIntVal(1))...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	tokens = append(tokens, hclwrite.TokensForValue(cty.NumberIntVal(0))...)
+	return tokens
+}
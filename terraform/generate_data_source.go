@@ -0,0 +1,101 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/openapi"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// isReadOnlyBody reports whether nothing in the request body schema can be set: the body itself
+// is read-only, or it declares properties and every one of them, down to the leaves of nested
+// objects, is read-only. Such a resource is fully computed by the service, so a module creating
+// it would send an empty body.
+func isReadOnlyBody(schema *openapi3.Schema) bool {
+	if schema == nil {
+		return false
+	}
+	props, err := openapi.GetEffectiveProperties(schema)
+	if err != nil || (len(props) == 0 && isWritableProperty(schema)) {
+		return false
+	}
+	return !hasWritableContent(schema, make(map[*openapi3.Schema]struct{}))
+}
+
+// hasWritableContent reports whether schema is writable and, when it declares properties, at
+// least one of them has writable content.
+func hasWritableContent(schema *openapi3.Schema, visiting map[*openapi3.Schema]struct{}) bool {
+	if !isWritableProperty(schema) {
+		return false
+	}
+	if _, ok := visiting[schema]; ok {
+		return true
+	}
+	visiting[schema] = struct{}{}
+	defer delete(visiting, schema)
+
+	props, err := openapi.GetEffectiveProperties(schema)
+	if err != nil || len(props) == 0 {
+		return true
+	}
+	for _, prop := range props {
+		if prop != nil && prop.Value != nil && hasWritableContent(prop.Value, visiting) {
+			return true
+		}
+	}
+	return false
+}
+
+// generateDataSource generates a module reading an existing resource through the azapi_resource
+// data source, for fully computed resources or as a read-only companion of a module managing
+// the resource: name and parent_id variables, the data source exporting the computed values of
+// the response, and outputs for them.
+func generateDataSource(o *generatorOptions, nameSchema *openapi3.Schema, write fileWriter) error {
+	variables := hclwrite.NewEmptyFile()
+	body := variables.Body()
+	nameBody := body.AppendNewBlock("variable", []string{"name"}).Body()
+	hclgen.SetDescriptionAttribute(nameBody, "The name of the resource to read.")
+	nameBody.SetAttributeRaw("type", hclwrite.TokensForIdentifier("string"))
+	if nameSchema != nil {
+		generateValidations(nameBody, "name", nameSchema, true, o.openEnumValidation)
+	}
+	body.AppendNewline()
+	parentIDBody := body.AppendNewBlock("variable", []string{"parent_id"}).Body()
+	hclgen.SetDescriptionAttribute(parentIDBody, "The parent resource ID of the resource to read.")
+	parentIDBody.SetAttributeRaw("type", hclwrite.TokensForIdentifier("string"))
+	if o.parentIDValidation {
+		condition := hclwrite.TokensForFunctionCall(
+			"can",
+			hclwrite.TokensForFunctionCall("regex", hclwrite.TokensForValue(cty.StringVal(parentIDPattern)), hclgen.TokensForTraversal("var", "parent_id")),
+		)
+		appendValidation(parentIDBody, condition, "parent_id must be an Azure resource ID starting with /subscriptions/ or /providers/, e.g. /subscriptions/.../resourceGroups/my-rg.")
+	}
+	if err := write("variables.tf", variables.Bytes()); err != nil {
+		return err
+	}
+
+	if err := generateTerraform(o.backend, o.providerSource, o.requiredVersion, o.providerVersion, write); err != nil {
+		return err
+	}
+
+	apiVersion := strings.TrimSpace(o.apiVersion)
+	if apiVersion == "" {
+		apiVersion = "apiVersion"
+	}
+	main := hclwrite.NewEmptyFile()
+	dataBody := main.Body().AppendNewBlock("data", []string{"azapi_resource", o.resourceBlockName()}).Body()
+	dataBody.SetAttributeValue("type", cty.StringVal(fmt.Sprintf("%s@%s", cleanTypeString(o.resourceType), apiVersion)))
+	dataBody.SetAttributeRaw("name", hclgen.TokensForTraversal("var", "name"))
+	dataBody.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("var", "parent_id"))
+	dataBody.SetAttributeRaw("response_export_values", hclgen.TokensForMultilineStringList(extractComputedPaths(o.schema)))
+	if err := write("main.tf", main.Bytes()); err != nil {
+		return err
+	}
+
+	// The outputs refer to the data source by its address, data.azapi_resource.<name>.
+	return generateOutputs(o.schema, "data.azapi_resource", o.resourceBlockName(), o.strictOutputs, o.resourceGroupOutputsEnabled(), o.namer, write)
+}
@@ -0,0 +1,172 @@
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/openapi"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// DefaultExampleDir is where GenerateExampleFile writes the example calling the module.
+const DefaultExampleDir = "examples/default"
+
+// GenerateExampleFile writes examples/default/main.tf below the output directory: a module
+// block labelled moduleName that calls the module there, assigning each required variable of
+// its variables.tf. Variables set from a property of the schema, at the root of the body or in
+// its properties bag, are assigned the property's example (its example value, else the first
+// of its examples) when that converts to the variable's type; the others an empty value of
+// their type to fill in. Of the generator options, only WithOutputDir, WithSchema,
+// WithModuleNamePrefix and WithAcronyms apply.
+func GenerateExampleFile(moduleName string, opts ...GeneratorOption) error {
+	o := newGeneratorOptions("", opts...)
+	if !hclsyntax.ValidIdentifier(moduleName) {
+		return fmt.Errorf("invalid module name %q: must be a valid Terraform identifier", moduleName)
+	}
+	variablesSrc, err := os.ReadFile(filepath.Join(o.outputDir, "variables.tf"))
+	if err != nil {
+		return err
+	}
+	variables, diags := hclsyntax.ParseConfig(variablesSrc, "variables.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return diags
+	}
+	examples, err := propertyExamples(o)
+	if err != nil {
+		return err
+	}
+
+	file := hclwrite.NewEmptyFile()
+	moduleBody := file.Body().AppendNewBlock("module", []string{moduleName}).Body()
+	moduleBody.SetAttributeValue("source", cty.StringVal("../../"))
+	first := true
+	for _, block := range variables.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "variable" || len(block.Labels) != 1 {
+			continue
+		}
+		if _, ok := block.Body.Attributes["default"]; ok {
+			continue
+		}
+		if first {
+			moduleBody.AppendNewline()
+			first = false
+		}
+		name := block.Labels[0]
+		typeAttr, hasType := block.Body.Attributes["type"]
+		if example, ok := examples[name]; ok && hasType {
+			if ty, diags := typeexpr.TypeConstraint(typeAttr.Expr); !diags.HasErrors() {
+				if value, err := ctyjson.Unmarshal(example, ty); err == nil {
+					moduleBody.SetAttributeValue(name, value)
+					continue
+				}
+			}
+		}
+		typeExpr := ""
+		if hasType {
+			typeExpr = string(typeAttr.Expr.Range().SliceBytes(variablesSrc))
+		}
+		moduleBody.SetAttributeRaw(name, hclwrite.Tokens{
+			{Type: hclsyntax.TokenIdent, Bytes: []byte(placeholderForType(typeExpr))},
+		})
+	}
+
+	exampleDir := filepath.Join(o.outputDir, filepath.FromSlash(DefaultExampleDir))
+	if err := os.MkdirAll(exampleDir, 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", exampleDir, err)
+	}
+	return os.WriteFile(filepath.Join(exampleDir, "main.tf"), hclwrite.Format(file.Bytes()), 0o644)
+}
+
+// propertyExamples returns the spec examples, as JSON, of the writable root properties of
+// o.schema and the children of its properties bag, keyed by the name of the variable they set.
+func propertyExamples(o *generatorOptions) (map[string][]byte, error) {
+	examples := make(map[string][]byte)
+	if o.schema == nil {
+		return examples, nil
+	}
+	add := func(name string, schema *openapi3.Schema) error {
+		// Secrets are better left for the caller to supply than seeded from the spec.
+		if schema == nil || !isWritableProperty(schema) || isSecretField(schema) {
+			return nil
+		}
+		tfName := o.namer.ToSnakeCase(name)
+		if o.moduleNamePrefix != "" && tfName == "version" {
+			tfName = o.moduleNamePrefix + "_version"
+		}
+		example, ok := schemaExample(schema)
+		if _, taken := examples[tfName]; !ok || taken {
+			return nil
+		}
+		data, err := json.Marshal(example)
+		if err != nil {
+			return fmt.Errorf("encoding the example of %s: %w", name, err)
+		}
+		examples[tfName] = data
+		return nil
+	}
+
+	rootProps, err := openapi.GetEffectiveProperties(o.schema)
+	if err != nil {
+		return nil, fmt.Errorf("getting effective properties: %w", err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(rootProps)) {
+		ref := rootProps[name]
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		if name == "properties" {
+			childProps, err := openapi.GetEffectiveProperties(ref.Value)
+			if err != nil {
+				return nil, fmt.Errorf("getting effective properties for root properties bag: %w", err)
+			}
+			for _, childName := range slices.Sorted(maps.Keys(childProps)) {
+				if childRef := childProps[childName]; childRef != nil {
+					if err := add(childName, childRef.Value); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		if err := add(name, ref.Value); err != nil {
+			return nil, err
+		}
+	}
+	return examples, nil
+}
+
+// schemaExample returns the example of schema: its example value, else the first of its
+// JSON Schema examples.
+func schemaExample(schema *openapi3.Schema) (any, bool) {
+	if schema.Example != nil {
+		return schema.Example, true
+	}
+	if examples, ok := schema.Extensions["examples"].([]any); ok && len(examples) > 0 {
+		return examples[0], true
+	}
+	return nil, false
+}
+
+// GenerateReadmeFile writes a starter README.md to outputDir, titled moduleName, unless one
+// already exists: READMEs are edited by hand, so regenerating must not overwrite them.
+func GenerateReadmeFile(moduleName, resourceType, outputDir string) error {
+	path := filepath.Join(outputDir, "README.md")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	readme := fmt.Sprintf("# %s\n\nTerraform module for `%s`.\n\nSee [%s](%s) for how to call it.\n", moduleName, resourceType, DefaultExampleDir, DefaultExampleDir)
+	return os.WriteFile(path, []byte(readme), 0o644)
+}
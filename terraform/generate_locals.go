@@ -2,20 +2,25 @@ package terraform
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 	"sort"
+	"strconv"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/matt-FFFFFF/tfmodmake/hclgen"
-	"github.com/matt-FFFFFF/tfmodmake/naming"
 	"github.com/matt-FFFFFF/tfmodmake/openapi"
 	"github.com/zclconf/go-cty/cty"
 )
 
-func generateLocals(schema *openapi3.Schema, localName string, supportsIdentity bool, secrets []secretField, resourceType string, caps openapi.InterfaceCapabilities, moduleNamePrefix string, outputDir string) error {
-	if schema == nil {
+// generateLocals writes locals.tf with the request body built from o.schema. varNames holds
+// the variables renamed to resolve a name collision, keyed by property path.
+func generateLocals(o *generatorOptions, supportsIdentity bool, secrets []secretField, caps openapi.InterfaceCapabilities, varNames map[string]string, write fileWriter) error {
+	if o.schema == nil {
 		return nil
 	}
 
@@ -25,12 +30,16 @@ func generateLocals(schema *openapi3.Schema, localName string, supportsIdentity
 	locals := body.AppendNewBlock("locals", nil)
 	localBody := locals.Body()
 
-	secretPaths := newSecretPathSet(secrets)
-	valueExpression, err := constructValue(schema, hclwrite.TokensForIdentifier("var"), true, secretPaths, "", supportsIdentity, moduleNamePrefix)
+	b := &bodyBuilder{o: o, secretPaths: newSecretPathSet(secrets), varNames: varNames}
+	valueExpression, err := b.constructValue(o.schema, hclwrite.TokensForIdentifier("var"), true, "", supportsIdentity, o.flattenProperties(), o.maxDepth)
 	if err != nil {
 		return err
 	}
-	localBody.SetAttributeRaw(localName, valueExpression)
+	localBody.SetAttributeRaw(o.localName, valueExpression)
+
+	if len(o.defaultTags) > 0 {
+		localBody.SetAttributeRaw("tags", tokensForDefaultTagsLocal(o.defaultTags))
+	}
 
 	// Managed identity scaffolding (only when the resource schema supports configuring identity).
 	if supportsIdentity {
@@ -40,13 +49,260 @@ func generateLocals(schema *openapi3.Schema, localName string, supportsIdentity
 	// Private endpoints local with opinionated defaults for subresource_name
 	// Only generate when swagger indicates private endpoint support
 	if caps.SupportsPrivateEndpoints {
-		localBody.SetAttributeRaw("private_endpoints", tokensForPrivateEndpointsLocal(resourceType))
+		localBody.SetAttributeRaw("private_endpoints", tokensForPrivateEndpointsLocal(o.resourceType))
 	}
 
-	return hclgen.WriteFileToDir(outputDir, "locals.tf", file)
+	return write("locals.tf", file.Bytes())
 }
 
-func constructFlattenedRootPropertiesValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, secretPaths map[string]struct{}, moduleNamePrefix string) (hclwrite.Tokens, error) {
+// GenerateLocalsFile regenerates only locals.tf in the output directory, leaving the other
+// module files untouched, e.g. to refresh the body after a spec change when variables.tf has
+// been edited by hand. It reconciles with the variables declared in variables.tf: the nested
+// locals style is used when a properties variable is declared, and writable properties without
+// a declared variable are left out of the body. Their variable names are returned, sorted, so
+// callers can report them.
+//
+// WithModuleNamePrefix is honoured only when variables.tf declares the renamed
+// <prefix>_version variable.
+// Multi-instance modules (see WithMulti), whose variables.tf declares var.instances, are
+// rejected rather than given a single-instance body.
+func GenerateLocalsFile(resourceType string, opts ...GeneratorOption) ([]string, error) {
+	o := newGeneratorOptions(resourceType, opts...)
+	if o.schema == nil {
+		return nil, fmt.Errorf("no schema to generate locals from")
+	}
+
+	path := filepath.Join(o.outputDir, "variables.tf")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	existing, diags := hclwrite.ParseConfig(data, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing %s: %w", path, diags)
+	}
+	declared := make(map[string]struct{})
+	for _, block := range existing.Body().Blocks() {
+		if block.Type() == "variable" && len(block.Labels()) == 1 {
+			declared[block.Labels()[0]] = struct{}{}
+		}
+	}
+
+	// A multi-instance module builds its locals per entry of var.instances, which the body
+	// locals here are not.
+	if _, ok := declared[instancesVariable]; ok {
+		return nil, fmt.Errorf("%s declares var.%s: multi-instance modules are not supported, regenerate them with gen -multi", path, instancesVariable)
+	}
+
+	if o.heuristicSecrets {
+		o.schema, err = markHeuristicSecrets(o.schema, o.maxDepth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, nested := declared["properties"]
+	if _, ok := declared[o.moduleNamePrefix+"_version"]; !ok {
+		o.moduleNamePrefix = ""
+	}
+	o.localsStyle = LocalsStyleFlat
+	o.inlineSmallObjects = 0
+	if nested {
+		o.localsStyle = LocalsStyleNested
+	}
+
+	var renamed map[string]string
+	if o.disambiguateCollisions {
+		renamed, err = collisionRenames(o)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	schema, missing, err := pruneUndeclaredProperties(o, declared, renamed)
+	if err != nil {
+		return nil, err
+	}
+	o.schema = schema
+
+	var secrets []secretField
+	if !o.dataPlane {
+		secrets, err = collectSecretFields(schema, "", o.maxDepth, o.namer)
+		if err != nil {
+			return nil, fmt.Errorf("collecting secret fields: %w", err)
+		}
+	}
+	var caps openapi.InterfaceCapabilities
+	if o.spec != nil && !o.dataPlane {
+		caps = openapi.DetectInterfaceCapabilities(o.spec, o.resourceType)
+	}
+	supportsIdentity := !o.dataPlane && SupportsIdentity(schema)
+	// Default tags are merged only when the module declares the tags variable to merge them into.
+	if _, ok := declared["tags"]; !ok {
+		o.defaultTags = nil
+	}
+
+	if err := generateLocals(o, supportsIdentity, secrets, caps, renamed, dirWriter(o.outputDir)); err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
+
+// collisionRenames returns the variables gen renames to resolve a name collision (see
+// WithDisambiguateCollisions), keyed by property path, by building variables.tf for o without
+// writing it.
+func collisionRenames(o *generatorOptions) (map[string]string, error) {
+	var secrets []secretField
+	if !o.dataPlane {
+		var err error
+		secrets, err = collectSecretFields(o.schema, "", o.maxDepth, o.namer)
+		if err != nil {
+			return nil, fmt.Errorf("collecting secret fields: %w", err)
+		}
+	}
+	supportsIdentity := !o.dataPlane && SupportsIdentity(o.schema)
+	longRunning := o.timeouts && o.spec != nil && !o.dataPlane && openapi.IsLongRunningResource(o.spec, o.resourceType)
+
+	renamed := make(map[string]string)
+	discard := func(string, []byte) error { return nil }
+	if err := generateVariables(o, supportsIdentity, longRunning, secrets, nil, openapi.InterfaceCapabilities{}, nil, nil, renamed, discard); err != nil {
+		return nil, err
+	}
+	return renamed, nil
+}
+
+// pruneUndeclaredProperties returns a copy of o.schema without the writable root properties, or
+// children of the root properties bag when the properties are flattened, whose Terraform
+// variable is not declared. renamed holds the variables renamed to resolve a name collision,
+// keyed by property path. It also returns the missing variable names, sorted.
+func pruneUndeclaredProperties(o *generatorOptions, declared map[string]struct{}, renamed map[string]string) (*openapi3.Schema, []string, error) {
+	schema := o.schema
+	flattenProperties := o.flattenProperties()
+	var missing []string
+	// declaredOrReadOnly reports whether the property at path has a declared variable, recording
+	// it as missing otherwise. Read-only properties are kept, as the body locals skip them anyway.
+	declaredOrReadOnly := func(name, path string, ref *openapi3.SchemaRef) bool {
+		if ref == nil || ref.Value == nil || !isWritableProperty(ref.Value) {
+			return true
+		}
+		tfName := o.namer.ToSnakeCase(name)
+		if o.moduleNamePrefix != "" && tfName == "version" {
+			tfName = o.moduleNamePrefix + "_version"
+		}
+		if to, ok := renamed[path]; ok {
+			tfName = to
+		}
+		if _, ok := declared[tfName]; ok {
+			return true
+		}
+		missing = append(missing, tfName)
+		return false
+	}
+
+	rootProps, err := openapi.GetEffectiveProperties(schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting effective properties: %w", err)
+	}
+	pruned := make(map[string]*openapi3.SchemaRef, len(rootProps))
+	for name, ref := range rootProps {
+		switch {
+		case name == "identity" || name == "location":
+			// Configured through dedicated azapi_resource arguments rather than variables of
+			// the same name, and never part of the body locals.
+			pruned[name] = ref
+		case name == "properties" && flattenProperties && ref != nil && ref.Value != nil && slices.Contains(openapi.EffectiveTypes(ref.Value), "object"):
+			childProps, err := openapi.GetEffectiveProperties(ref.Value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("getting effective properties for root properties bag: %w", err)
+			}
+			children := make(map[string]*openapi3.SchemaRef, len(childProps))
+			for childName, childRef := range childProps {
+				if declaredOrReadOnly(childName, "properties."+childName, childRef) {
+					children[childName] = childRef
+				}
+			}
+			if len(children) == 0 {
+				continue
+			}
+			bag := *ref.Value
+			bag.AllOf = nil
+			bag.Properties = children
+			pruned[name] = &openapi3.SchemaRef{Value: &bag}
+		case declaredOrReadOnly(name, name, ref):
+			pruned[name] = ref
+		}
+	}
+
+	root := *schema
+	root.AllOf = nil
+	root.Properties = pruned
+	sort.Strings(missing)
+	return &root, missing, nil
+}
+
+// pruneOptionalProperties returns a copy of schema keeping only the required writable root
+// properties and required children of the root properties bag, so variables and locals are
+// generated for them alone. The bag is kept when any of its children are required, whether or
+// not the bag itself is. Read-only properties are kept for outputs, and identity and location
+// for their dedicated azapi_resource arguments.
+func pruneOptionalProperties(schema *openapi3.Schema) (*openapi3.Schema, error) {
+	rootProps, err := openapi.GetEffectiveProperties(schema)
+	if err != nil {
+		return nil, fmt.Errorf("getting effective properties: %w", err)
+	}
+	rootRequired, err := openapi.GetEffectiveRequired(schema)
+	if err != nil {
+		return nil, fmt.Errorf("getting effective required: %w", err)
+	}
+
+	pruned := make(map[string]*openapi3.SchemaRef, len(rootProps))
+	for name, ref := range rootProps {
+		switch {
+		case ref == nil || ref.Value == nil || !isWritableProperty(ref.Value):
+			pruned[name] = ref
+		case name == "identity" || name == "location":
+			pruned[name] = ref
+		case name == "properties" && slices.Contains(openapi.EffectiveTypes(ref.Value), "object"):
+			childProps, err := openapi.GetEffectiveProperties(ref.Value)
+			if err != nil {
+				return nil, fmt.Errorf("getting effective properties for root properties bag: %w", err)
+			}
+			childRequired, err := openapi.GetEffectiveRequired(ref.Value)
+			if err != nil {
+				return nil, fmt.Errorf("getting effective required for root properties bag: %w", err)
+			}
+			children := make(map[string]*openapi3.SchemaRef, len(childProps))
+			hasRequired := false
+			for childName, childRef := range childProps {
+				switch {
+				case childRef == nil || childRef.Value == nil || !isWritableProperty(childRef.Value):
+					children[childName] = childRef
+				case slices.Contains(childRequired, childName):
+					children[childName] = childRef
+					hasRequired = true
+				}
+			}
+			if !hasRequired {
+				continue
+			}
+			bag := *ref.Value
+			bag.AllOf = nil
+			bag.Properties = children
+			bag.Required = childRequired
+			pruned[name] = &openapi3.SchemaRef{Value: &bag}
+		case slices.Contains(rootRequired, name):
+			pruned[name] = ref
+		}
+	}
+
+	root := *schema
+	root.AllOf = nil
+	root.Properties = pruned
+	root.Required = rootRequired
+	return &root, nil
+}
+
+func (b *bodyBuilder) constructFlattenedRootPropertiesValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, depth int) (hclwrite.Tokens, error) {
 	// schema represents the OpenAPI schema at root.properties.
 	// The Terraform variables are flattened to var.<child> rather than var.properties.<child>.
 
@@ -78,23 +334,26 @@ func constructFlattenedRootPropertiesValue(schema *openapi3.Schema, accessPath h
 			continue
 		}
 
-		if secretPaths != nil {
-			if _, ok := secretPaths["properties."+k]; ok {
+		if b.secretPaths != nil {
+			if _, ok := b.secretPaths["properties."+k]; ok {
 				continue
 			}
 		}
 
-		snakeName := naming.ToSnakeCase(k)
+		snakeName := b.o.namer.ToSnakeCase(k)
 		// Rename variables that conflict with Terraform module meta-arguments
-		if moduleNamePrefix != "" && snakeName == "version" {
-			snakeName = moduleNamePrefix + "_version"
+		if b.o.moduleNamePrefix != "" && snakeName == "version" {
+			snakeName = b.o.moduleNamePrefix + "_version"
+		}
+		if renamed, ok := b.varNames["properties."+k]; ok {
+			snakeName = renamed
 		}
 		var childAccess hclwrite.Tokens
 		childAccess = append(childAccess, accessPath...)
 		childAccess = append(childAccess, &hclwrite.Token{Type: hclsyntax.TokenDot, Bytes: []byte(".")})
 		childAccess = append(childAccess, hclwrite.TokensForIdentifier(snakeName)...)
 
-		childValue, err := constructValue(prop.Value, childAccess, false, secretPaths, "properties."+k, false, moduleNamePrefix)
+		childValue, err := b.constructValue(prop.Value, childAccess, false, "properties."+k, false, false, depth)
 		if err != nil {
 			return nil, err
 		}
@@ -104,20 +363,57 @@ func constructFlattenedRootPropertiesValue(schema *openapi3.Schema, accessPath h
 		})
 	}
 
-	return hclwrite.TokensForObject(attrs), nil
+	return b.withoutNulls(hclwrite.TokensForObject(attrs)), nil
 }
 
-func constructValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, isRoot bool, secretPaths map[string]struct{}, pathPrefix string, omitRootIdentity bool, moduleNamePrefix string) (hclwrite.Tokens, error) {
-	if schema.Type == nil {
-		return accessPath, nil
+// bodyBuilder builds the body locals expression for the generator options o. Properties at
+// secretPaths are left out, as they are sent through sensitive_body, and varNames holds the
+// variables renamed to resolve a name collision, keyed by property path (see
+// WithDisambiguateCollisions).
+type bodyBuilder struct {
+	o           *generatorOptions
+	secretPaths map[string]struct{}
+	varNames    map[string]string
+}
+
+// withoutNulls drops the null attributes of the object built by objTokens, so optional
+// variables left unset are not sent as explicit nulls. With ignore_null_property set, azapi
+// drops them itself and the object is kept as is.
+func (b *bodyBuilder) withoutNulls(objTokens hclwrite.Tokens) hclwrite.Tokens {
+	if b.o.ignoreNullProperty {
+		return objTokens
 	}
+	return hclgen.NonNullAttributes(objTokens)
+}
 
-	types := *schema.Type
+// constructValue builds the body expression for schema from accessPath. Nesting beyond depth
+// levels is passed through as-is, matching the any type used for it in variables.tf.
+// When flattenRootProperties is set, the root "properties" bag is built from one variable per
+// property rather than from var.properties.
+func (b *bodyBuilder) constructValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, isRoot bool, pathPrefix string, omitRootIdentity, flattenRootProperties bool, depth int) (hclwrite.Tokens, error) {
+	schema, _ = openapi.UnwrapNullable(schema)
+	types := openapi.EffectiveTypes(schema)
+	if types == nil || depth <= 0 {
+		return accessPath, nil
+	}
 
 	if slices.Contains(types, "object") {
 		if len(schema.Properties) == 0 {
-			if schema.AdditionalProperties.Schema != nil && schema.AdditionalProperties.Schema.Value != nil {
-				mappedValue, err := constructValue(schema.AdditionalProperties.Schema.Value, hclwrite.TokensForIdentifier("value"), false, secretPaths, pathPrefix, false, moduleNamePrefix)
+			var valueSchema *openapi3.Schema
+			if schema.AdditionalProperties.Schema != nil {
+				valueSchema = schema.AdditionalProperties.Schema.Value
+			}
+			if valueSchema == nil {
+				pattern, err := openapi.GetPatternProperties(schema)
+				if err != nil {
+					return nil, fmt.Errorf("getting pattern properties at %s: %w", pathPrefix, err)
+				}
+				if pattern != nil {
+					valueSchema = pattern.Value
+				}
+			}
+			if valueSchema != nil {
+				mappedValue, err := b.constructValue(valueSchema, hclwrite.TokensForIdentifier("value"), false, pathPrefix, false, false, depth-1)
 				if err != nil {
 					return nil, err
 				}
@@ -137,7 +433,7 @@ func constructValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, isRoot
 				tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrace, Bytes: []byte("}")})
 
 				if !isRoot {
-					return hclgen.NullEqualityTernary(accessPath, tokens), nil
+					return collectionNullGuard(accessPath, tokens, b.o.dropEmpty), nil
 				}
 				return tokens, nil
 			}
@@ -147,7 +443,11 @@ func constructValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, isRoot
 		// Get effective properties for allOf handling
 		effectiveProps, err := openapi.GetEffectiveProperties(schema)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get effective properties in constructValue: %w", err)
+			location := pathPrefix
+			if location == "" {
+				location = "the resource body"
+			}
+			return nil, fmt.Errorf("getting effective properties at %s: %w", location, err)
 		}
 
 		var attrs []hclwrite.ObjectAttrTokens
@@ -179,19 +479,16 @@ func constructValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, isRoot
 				continue
 			}
 
-			childPath := k
-			if pathPrefix != "" {
-				childPath = pathPrefix + "." + k
-			}
-			if secretPaths != nil {
-				if _, ok := secretPaths[childPath]; ok {
+			childPath := joinSchemaPath(pathPrefix, k)
+			if b.secretPaths != nil {
+				if _, ok := b.secretPaths[childPath]; ok {
 					continue
 				}
 			}
 
 			// Flatten the top-level "properties" bag into separate variables.
-			if isRoot && k == "properties" && prop.Value.Type != nil && slices.Contains(*prop.Value.Type, "object") && len(prop.Value.Properties) > 0 {
-				childValue, err := constructFlattenedRootPropertiesValue(prop.Value, accessPath, secretPaths, moduleNamePrefix)
+			if isRoot && flattenRootProperties && k == "properties" && slices.Contains(openapi.EffectiveTypes(prop.Value), "object") && len(prop.Value.Properties) > 0 {
+				childValue, err := b.constructFlattenedRootPropertiesValue(prop.Value, accessPath, depth)
 				if err != nil {
 					return nil, err
 				}
@@ -202,13 +499,26 @@ func constructValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, isRoot
 				continue
 			}
 
-			snakeName := naming.ToSnakeCase(k)
+			// Below the root, the value is an attribute of the variable's object type.
+			snakeName := objectAttributeName(b.o.namer, k)
+			if isRoot {
+				snakeName = b.o.namer.ToSnakeCase(k)
+				if renamed, ok := b.varNames[childPath]; ok {
+					snakeName = renamed
+				}
+			}
 			var childAccess hclwrite.Tokens
 			childAccess = append(childAccess, accessPath...)
 			childAccess = append(childAccess, &hclwrite.Token{Type: hclsyntax.TokenDot, Bytes: []byte(".")})
 			childAccess = append(childAccess, hclwrite.TokensForIdentifier(snakeName)...)
 
-			childValue, err := constructValue(prop.Value, childAccess, false, secretPaths, childPath, false, moduleNamePrefix)
+			// The root body is not a variable, so its properties start with the full depth
+			// budget, matching the nesting depth of their types in variables.tf.
+			childDepth := depth - 1
+			if isRoot {
+				childDepth = depth
+			}
+			childValue, err := b.constructValue(prop.Value, childAccess, false, childPath, false, false, childDepth)
 			if err != nil {
 				return nil, err
 			}
@@ -218,16 +528,78 @@ func constructValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, isRoot
 			})
 		}
 
-		objTokens := hclwrite.TokensForObject(attrs)
+		objTokens := b.withoutNulls(hclwrite.TokensForObject(attrs))
 		if !isRoot {
+			if b.o.dropEmpty {
+				return hclgen.NullOrAllNullTernary(accessPath, objTokens), nil
+			}
 			return hclgen.NullEqualityTernary(accessPath, objTokens), nil
 		}
 		return objTokens, nil
 	}
 
 	if slices.Contains(types, "array") {
+		if items := openapi.TupleItems(schema); items != nil {
+			// A tuple is rebuilt position by position.
+			elems := make([]hclwrite.Tokens, len(items))
+			for i, item := range items {
+				elemAccess := cloneTokens(accessPath)
+				elemAccess = append(elemAccess,
+					&hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+					&hclwrite.Token{Type: hclsyntax.TokenNumberLit, Bytes: []byte(strconv.Itoa(i))},
+					&hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")},
+				)
+				elems[i] = elemAccess
+				if item == nil || item.Value == nil {
+					continue
+				}
+				childValue, err := b.constructValue(item.Value, elemAccess, false, fmt.Sprintf("%s[%d]", pathPrefix, i), false, false, depth-1)
+				if err != nil {
+					return nil, err
+				}
+				elems[i] = childValue
+			}
+			tokens := hclwrite.TokensForTuple(elems)
+			if !isRoot {
+				return collectionNullGuard(accessPath, tokens, b.o.dropEmpty), nil
+			}
+			return tokens, nil
+		}
+		if key, ok := identifierMapKey(schema); b.o.identifierMaps && ok {
+			valueSchema, err := identifierMapValueSchema(schema.Items.Value, key)
+			if err != nil {
+				return nil, fmt.Errorf("getting effective properties at %s[]: %w", pathPrefix, err)
+			}
+			childValue, err := b.constructValue(valueSchema, hclwrite.TokensForIdentifier("value"), false, pathPrefix+"[]", false, false, depth-1)
+			if err != nil {
+				return nil, err
+			}
+			// Each item gets its identifier back from the map key; merge skips a null value.
+			keyAttr := hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{{
+				Name:  tokensForObjectKey(key),
+				Value: hclwrite.TokensForIdentifier("k"),
+			}})
+			item := hclwrite.TokensForFunctionCall("merge", keyAttr, childValue)
+
+			var tokens hclwrite.Tokens
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")})
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("k")})
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")})
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("value")})
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")})
+			tokens = append(tokens, accessPath...)
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+			tokens = append(tokens, item...)
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+			if !isRoot {
+				return collectionNullGuard(accessPath, tokens, b.o.dropEmpty), nil
+			}
+			return tokens, nil
+		}
 		if schema.Items != nil && schema.Items.Value != nil {
-			childValue, err := constructValue(schema.Items.Value, hclwrite.TokensForIdentifier("item"), false, secretPaths, pathPrefix+"[]", false, moduleNamePrefix)
+			childValue, err := b.constructValue(schema.Items.Value, hclwrite.TokensForIdentifier("item"), false, pathPrefix+"[]", false, false, depth-1)
 			if err != nil {
 				return nil, err
 			}
@@ -243,7 +615,7 @@ func constructValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, isRoot
 			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
 
 			if !isRoot {
-				return hclgen.NullEqualityTernary(accessPath, tokens), nil
+				return collectionNullGuard(accessPath, tokens, b.o.dropEmpty), nil
 			}
 			return tokens, nil
 		}
@@ -253,6 +625,33 @@ func constructValue(schema *openapi3.Schema, accessPath hclwrite.Tokens, isRoot
 	return accessPath, nil
 }
 
+// collectionNullGuard makes the map or list built by tokens from accessPath null when
+// accessPath is null or, with dropEmpty, empty, so that azapi does not send {} or [].
+func collectionNullGuard(accessPath, tokens hclwrite.Tokens, dropEmpty bool) hclwrite.Tokens {
+	if dropEmpty {
+		return hclgen.NullOrEmptyTernary(accessPath, tokens)
+	}
+	return hclgen.NullEqualityTernary(accessPath, tokens)
+}
+
+// tokensForDefaultTagsLocal builds merge(var.tags, { ... }) with the keys sorted. The defaults
+// come last so module-injected tags can't be overridden by the caller.
+func tokensForDefaultTagsLocal(defaultTags map[string]string) hclwrite.Tokens {
+	keys := make([]string, 0, len(defaultTags))
+	for k := range defaultTags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]hclwrite.ObjectAttrTokens, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, hclwrite.ObjectAttrTokens{
+			Name:  tokensForObjectKey(k),
+			Value: hclwrite.TokensForValue(cty.StringVal(defaultTags[k])),
+		})
+	}
+	return hclwrite.TokensForFunctionCall("merge", hclgen.TokensForTraversal("var", "tags"), hclwrite.TokensForObject(attrs))
+}
+
 func tokensForManagedIdentitiesLocal() hclwrite.Tokens {
 	varManaged := hclgen.TokensForTraversal("var", "managed_identities")
 	userAssigned := append(hclwrite.Tokens(nil), varManaged...)
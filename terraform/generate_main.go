@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/matt-FFFFFF/tfmodmake/hclgen"
 	"github.com/zclconf/go-cty/cty"
@@ -22,42 +21,69 @@ func cleanTypeString(typeStr string) string {
 	return strings.Join(cleaned, "/")
 }
 
-func generateMain(schema *openapi3.Schema, resourceType, apiVersion, localName string, supportsTags, supportsLocation, supportsIdentity, hasSchema bool, secrets []secretField, outputDir string) error {
+// deployedToResourceGroup reports whether resourceType is a top-level ARM resource type, e.g.
+// Microsoft.App/managedEnvironments, whose parent_id is the resource group it is deployed to.
+func deployedToResourceGroup(resourceType string) bool {
+	return len(strings.Split(cleanTypeString(resourceType), "/")) == 2
+}
+
+// generateMain writes main.tf with the module's resource block.
+func generateMain(o *generatorOptions, supportsIdentity, longRunning bool, secrets []secretField, requestParams []requestParameterVariable, write fileWriter) error {
 	file := hclwrite.NewEmptyFile()
 	body := file.Body()
 
-	apiVersion = strings.TrimSpace(apiVersion)
+	apiVersion := strings.TrimSpace(o.apiVersion)
 	if apiVersion == "" {
 		apiVersion = "apiVersion"
 	}
-	resourceTypeWithAPIVersion := fmt.Sprintf("%s@%s", cleanTypeString(resourceType), apiVersion)
+	resourceTypeWithAPIVersion := fmt.Sprintf("%s@%s", cleanTypeString(o.resourceType), apiVersion)
 
-	resourceBlock := body.AppendNewBlock("resource", []string{"azapi_resource", "this"})
+	resourceBlock := body.AppendNewBlock("resource", []string{o.resourceBlockType(), o.resourceBlockName()})
 	resourceBody := resourceBlock.Body()
 	resourceBody.SetAttributeValue("type", cty.StringVal(resourceTypeWithAPIVersion))
 	resourceBody.SetAttributeRaw("name", hclgen.TokensForTraversal("var", "name"))
-	resourceBody.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("var", "parent_id"))
+	if o.dataPlane {
+		// Data-plane resources are parented by their service endpoint rather than an ARM ID.
+		resourceBody.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("var", "endpoint"))
+	} else {
+		resourceBody.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("var", "parent_id"))
+	}
 
-	if supportsLocation {
+	if o.supportsLocation {
 		resourceBody.SetAttributeRaw("location", hclgen.TokensForTraversal("var", "location"))
 	}
 
 	resourceBody.SetAttributeValue("body", cty.EmptyObjectVal)
-	if hasSchema {
-		resourceBody.SetAttributeRaw("body", hclgen.TokensForTraversal("local", localName))
+	if o.schema != nil {
+		resourceBody.SetAttributeRaw("body", hclgen.TokensForTraversal("local", o.localName))
+	}
+	// azapi validates the body by default, so the attribute is only written to disable it.
+	if !o.schemaValidation {
+		resourceBody.SetAttributeValue("schema_validation_enabled", cty.False)
+	}
+	if o.ignoreNullProperty {
+		resourceBody.SetAttributeValue("ignore_null_property", cty.True)
 	}
 
-	// Add sensitive_body if there are secrets
+	// Add sensitive_body if there are secrets. Nested secrets are already shaped like the body
+	// and share a version.
 	if len(secrets) > 0 {
-		sensitiveBodyTokens := tokensForSensitiveBody(secrets, func(secret secretField) hclwrite.Tokens {
-			return hclgen.TokensForTraversal("var", secret.varName)
-		})
-		resourceBody.SetAttributeRaw("sensitive_body", sensitiveBodyTokens)
+		if o.nestSecrets {
+			resourceBody.SetAttributeRaw("sensitive_body", hclgen.TokensForTraversal("var", sensitiveBodyVariable))
+		} else {
+			sensitiveBodyTokens := tokensForSensitiveBody(secrets, func(secret secretField) hclwrite.Tokens {
+				return hclgen.TokensForTraversal("var", secret.varName)
+			})
+			resourceBody.SetAttributeRaw("sensitive_body", sensitiveBodyTokens)
+		}
 
 		// Add sensitive_body_version map
 		var versionAttrs []hclwrite.ObjectAttrTokens
 		for _, secret := range secrets {
 			versionVarName := secret.varName + "_version"
+			if o.nestSecrets {
+				versionVarName = sensitiveBodyVariable + "_version"
+			}
 			key := secret.path
 			versionAttrs = append(versionAttrs, hclwrite.ObjectAttrTokens{
 				Name:  hclwrite.TokensForValue(cty.StringVal(key)),
@@ -67,8 +93,14 @@ func generateMain(schema *openapi3.Schema, resourceType, apiVersion, localName s
 		resourceBody.SetAttributeRaw("sensitive_body_version", hclwrite.TokensForObject(versionAttrs))
 	}
 
-	if supportsTags {
-		resourceBody.SetAttributeRaw("tags", hclgen.TokensForTraversal("var", "tags"))
+	setRequestParameterAttributes(resourceBody, requestParams)
+
+	if o.supportsTags {
+		if len(o.defaultTags) > 0 {
+			resourceBody.SetAttributeRaw("tags", hclgen.TokensForTraversal("local", "tags"))
+		} else {
+			resourceBody.SetAttributeRaw("tags", hclgen.TokensForTraversal("var", "tags"))
+		}
 	}
 
 	if supportsIdentity {
@@ -82,9 +114,16 @@ func generateMain(schema *openapi3.Schema, resourceType, apiVersion, localName s
 		contentBody.SetAttributeRaw("identity_ids", hclgen.TokensForTraversal("identity", "value", "user_assigned_resource_ids"))
 	}
 
+	if longRunning {
+		timeoutsBody := resourceBody.AppendNewBlock("timeouts", nil).Body()
+		for _, operation := range []string{"create", "update", "delete"} {
+			timeoutsBody.SetAttributeRaw(operation, hclgen.TokensForTraversal("var", "timeouts", operation))
+		}
+	}
+
 	// Generate response_export_values from computed (non-writable) fields in the schema
-	exportPaths := extractComputedPaths(schema)
+	exportPaths := extractComputedPaths(o.schema)
 	resourceBody.SetAttributeRaw("response_export_values", hclgen.TokensForMultilineStringList(exportPaths))
 
-	return hclgen.WriteFileToDir(outputDir, "main.tf", file)
+	return write("main.tf", file.Bytes())
 }
@@ -0,0 +1,34 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// moduleMetadata is the manifest written to metadata.json for registry publishing workflows.
+type moduleMetadata struct {
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	ResourceType string `json:"resource_type"`
+	APIVersion   string `json:"api_version,omitempty"`
+	Provider     string `json:"provider"`
+	GeneratedAt  string `json:"generated_at"`
+}
+
+// generateMetadata creates the metadata.json module manifest.
+func generateMetadata(o *generatorOptions, write fileWriter) error {
+	manifest := moduleMetadata{
+		Title:        o.metadataTitle,
+		Description:  o.metadataDescription,
+		ResourceType: cleanTypeString(o.resourceType),
+		APIVersion:   o.apiVersion,
+		Provider:     o.providerSource,
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling module metadata: %w", err)
+	}
+	return write("metadata.json", append(data, '\n'))
+}
@@ -1,10 +1,15 @@
 package terraform
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/matt-FFFFFF/tfmodmake/hclgen"
 	"github.com/matt-FFFFFF/tfmodmake/naming"
@@ -12,31 +17,112 @@ import (
 )
 
 // generateOutputs creates the outputs.tf file with AVM-compliant outputs.
+func generateOutputs(schema *openapi3.Schema, resourceBlockType, resourceName string, strictOutputs, resourceGroupOutputs bool, namer *naming.Namer, write fileWriter) error {
+	return write("outputs.tf", buildOutputsFile(schema, resourceBlockType, resourceName, strictOutputs, resourceGroupOutputs, namer).Bytes())
+}
+
+// GenerateOutputsFile (re)generates outputs.tf for an existing module.
+//
+// Outputs already declared in outputs.tf are left untouched, including hand-written ones,
+// and only missing outputs are appended. Running it repeatedly is therefore idempotent.
+func GenerateOutputsFile(opts ...GeneratorOption) error {
+	o := newGeneratorOptions("", opts...)
+	if !hclsyntax.ValidIdentifier(o.resourceBlockName()) {
+		return fmt.Errorf("invalid resource name %q: expected a Terraform identifier", o.resourceName)
+	}
+
+	generated := buildOutputsFile(o.schema, o.resourceBlockType(), o.resourceBlockName(), o.strictOutputs, o.resourceGroupOutputsEnabled(), o.namer)
+
+	path := filepath.Join(o.outputDir, "outputs.tf")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return hclgen.WriteFileToDir(o.outputDir, "outputs.tf", generated)
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	existing, diags := hclwrite.ParseConfig(data, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("parsing %s: %w", path, diags)
+	}
+
+	declared := make(map[string]struct{})
+	for _, block := range existing.Body().Blocks() {
+		if block.Type() == "output" && len(block.Labels()) == 1 {
+			declared[block.Labels()[0]] = struct{}{}
+		}
+	}
+
+	body := existing.Body()
+	for _, block := range generated.Body().Blocks() {
+		if _, ok := declared[block.Labels()[0]]; ok {
+			continue
+		}
+		if len(body.Blocks()) > 0 {
+			body.AppendNewline()
+		}
+		body.AppendBlock(block)
+	}
+
+	return hclgen.WriteFileToDir(o.outputDir, "outputs.tf", existing)
+}
+
+// buildOutputsFile builds the outputs.tf content.
 // Always includes the mandatory AVM outputs: resource_id and name.
 // Also includes outputs for computed/readOnly exported attributes when schema is available.
-func generateOutputs(schema *openapi3.Schema, outputDir string) error {
+// These fall back to an empty value with try() unless strictOutputs is set, in which case a
+// precondition fails the apply when the attribute is missing from the response.
+// With resourceGroupOutputs, the name and ID of the resource group in the parent_id of the
+// resource are output too.
+func buildOutputsFile(schema *openapi3.Schema, resourceBlockType, resourceName string, strictOutputs, resourceGroupOutputs bool, namer *naming.Namer) *hclwrite.File {
 	file := hclwrite.NewEmptyFile()
 	body := file.Body()
 
+	// A data source module reads the resource rather than creating it.
+	subject := "created resource"
+	if strings.HasPrefix(resourceBlockType, "data.") {
+		subject = "resource read"
+	}
+
 	// AVM mandatory output: resource_id
 	resourceID := body.AppendNewBlock("output", []string{"resource_id"})
 	resourceIDBody := resourceID.Body()
-	resourceIDBody.SetAttributeValue("description", cty.StringVal("The ID of the created resource."))
-	resourceIDBody.SetAttributeRaw("value", hclgen.TokensForTraversal("azapi_resource", "this", "id"))
+	resourceIDBody.SetAttributeValue("description", cty.StringVal("The ID of the "+subject+"."))
+	resourceIDBody.SetAttributeRaw("value", hclgen.TokensForTraversal(resourceBlockType, resourceName, "id"))
 	body.AppendNewline()
 
 	// AVM mandatory output: name
 	name := body.AppendNewBlock("output", []string{"name"})
 	nameBody := name.Body()
-	nameBody.SetAttributeValue("description", cty.StringVal("The name of the created resource."))
-	nameBody.SetAttributeRaw("value", hclgen.TokensForTraversal("azapi_resource", "this", "name"))
+	nameBody.SetAttributeValue("description", cty.StringVal("The name of the "+subject+"."))
+	nameBody.SetAttributeRaw("value", hclgen.TokensForTraversal(resourceBlockType, resourceName, "name"))
 	body.AppendNewline()
 
+	if resourceGroupOutputs {
+		parentID := hclgen.TokensForTraversal(resourceBlockType, resourceName, "parent_id")
+
+		// split("/", parent_id)[4] is the name in /subscriptions/{id}/resourceGroups/{name}.
+		rgName := body.AppendNewBlock("output", []string{"resource_group_name"}).Body()
+		rgName.SetAttributeValue("description", cty.StringVal("The name of the resource group the resource is deployed to."))
+		nameExpr := hclwrite.TokensForFunctionCall("split", hclwrite.TokensForValue(cty.StringVal("/")), parentID)
+		nameExpr = append(nameExpr, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+		nameExpr = append(nameExpr, hclwrite.TokensForValue(cty.NumberIntVal(4))...)
+		nameExpr = append(nameExpr, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+		rgName.SetAttributeRaw("value", nameExpr)
+		body.AppendNewline()
+
+		rgID := body.AppendNewBlock("output", []string{"resource_group_id"}).Body()
+		rgID.SetAttributeValue("description", cty.StringVal("The ID of the resource group the resource is deployed to."))
+		rgID.SetAttributeRaw("value", cloneTokens(parentID))
+		body.AppendNewline()
+	}
+
 	if schema != nil {
 		exportPaths := extractComputedPaths(schema)
 		usedNames := make(map[string]int)
 		for _, exportPath := range exportPaths {
-			outputName := outputNameForExportPath(exportPath)
+			outputName := outputNameForExportPath(exportPath, namer)
 			if outputName == "" {
 				continue
 			}
@@ -61,15 +147,23 @@ func generateOutputs(schema *openapi3.Schema, outputDir string) error {
 
 			segments := strings.Split(exportPath, ".")
 			valueParts := make([]string, 0, 3+len(segments))
-			valueParts = append(valueParts, "azapi_resource", "this", "output")
+			valueParts = append(valueParts, resourceBlockType, resourceName, "output")
 			valueParts = append(valueParts, segments...)
 			expr := hclgen.TokensForTraversalOrIndex(valueParts...)
-			outBody.SetAttributeRaw("value", hclwrite.TokensForFunctionCall("try", expr, defaultTokensForSchema(propSchema)))
+			if !strictOutputs {
+				outBody.SetAttributeRaw("value", hclwrite.TokensForFunctionCall("try", expr, defaultTokensForSchema(propSchema)))
+				body.AppendNewline()
+				continue
+			}
+			outBody.SetAttributeRaw("value", expr)
+			precondition := outBody.AppendNewBlock("precondition", nil).Body()
+			precondition.SetAttributeRaw("condition", hclwrite.TokensForFunctionCall("can", cloneTokens(expr)))
+			precondition.SetAttributeValue("error_message", cty.StringVal(fmt.Sprintf("The API response for %s has no %s.", resourceBlockType+"."+resourceName, exportPath)))
 			body.AppendNewline()
 		}
 	}
 
-	return hclgen.WriteFileToDir(outputDir, "outputs.tf", file)
+	return file
 }
 
 func schemaForExportPath(schema *openapi3.Schema, exportPath string) *openapi3.Schema {
@@ -133,7 +227,7 @@ func defaultTokensForSchema(schema *openapi3.Schema) hclwrite.Tokens {
 	return hclwrite.TokensForIdentifier("null")
 }
 
-func outputNameForExportPath(path string) string {
+func outputNameForExportPath(path string, namer *naming.Namer) string {
 	path = strings.TrimSpace(path)
 	if path == "" {
 		return ""
@@ -151,7 +245,7 @@ func outputNameForExportPath(path string) string {
 		if seg == "" {
 			continue
 		}
-		nameSegments = append(nameSegments, naming.ToSnakeCase(seg))
+		nameSegments = append(nameSegments, namer.ToSnakeCase(seg))
 	}
 	if len(nameSegments) == 0 {
 		return ""
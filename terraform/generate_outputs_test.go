@@ -1,10 +1,20 @@
 package terraform
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
 )
 
 func TestOutputNameForExportPath(t *testing.T) {
@@ -25,7 +35,7 @@ func TestOutputNameForExportPath(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.expected, outputNameForExportPath(tt.path))
+			assert.Equal(t, tt.expected, outputNameForExportPath(tt.path, nil))
 		})
 	}
 }
@@ -90,3 +100,114 @@ func TestSchemaForExportPath_CircularDoesNotLoop(t *testing.T) {
 		assert.Nil(t, got)
 	})
 }
+
+func TestGenerateOutputsFile_PreservesExistingOutputs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	existing := `output "resource_id" {
+  description = "Hand-written description."
+  value       = azapi_resource.this.id
+}
+
+output "custom" {
+  value = "kept"
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "outputs.tf"), []byte(existing), 0o644))
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"fqdn": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, ReadOnly: true}},
+				},
+			}},
+		},
+	}
+
+	require.NoError(t, GenerateOutputsFile(WithSchema(schema), WithOutputDir(tmpDir)))
+	first, err := os.ReadFile(filepath.Join(tmpDir, "outputs.tf"))
+	require.NoError(t, err)
+
+	content := string(first)
+	assert.Contains(t, content, "Hand-written description.")
+	assert.Contains(t, content, `output "custom"`)
+	assert.Contains(t, content, `output "name"`)
+	assert.Contains(t, content, `output "fqdn"`)
+	assert.Equal(t, 1, strings.Count(content, `output "resource_id"`))
+
+	require.NoError(t, GenerateOutputsFile(WithSchema(schema), WithOutputDir(tmpDir)))
+	second, err := os.ReadFile(filepath.Join(tmpDir, "outputs.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, string(first), string(second))
+}
+
+func TestBuildOutputsFile_StrictOutputs(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"fqdn": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, ReadOnly: true}},
+				},
+			}},
+		},
+	}
+
+	lenient := string(buildOutputsFile(schema, "azapi_resource", "this", false, false, nil).Bytes())
+	assert.Contains(t, lenient, "try(azapi_resource.this.output.properties.fqdn, null)")
+	assert.NotContains(t, lenient, "precondition")
+
+	strict := string(hclwrite.Format(buildOutputsFile(schema, "azapi_resource", "this", true, false, nil).Bytes()))
+	assert.NotContains(t, strict, "try(")
+	assert.Contains(t, strict, `output "fqdn" {
+  description = "Computed value exported from the Azure API response."
+  value       = azapi_resource.this.output.properties.fqdn
+  precondition {
+    condition     = can(azapi_resource.this.output.properties.fqdn)
+    error_message = "The API response for azapi_resource.this has no properties.fqdn."
+  }
+}`)
+}
+
+func TestGenerate_WithResourceGroupOutputs(t *testing.T) {
+	files, err := GenerateFiles("Microsoft.Test/widgets")
+	require.NoError(t, err)
+	assert.NotContains(t, string(files["outputs.tf"]), "resource_group_name")
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithResourceGroupOutputs(true))
+	require.NoError(t, err)
+	src := files["outputs.tf"]
+	outputs, diags := hclsyntax.ParseConfig(src, "outputs.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"azapi_resource": cty.ObjectVal(map[string]cty.Value{
+				"this": cty.ObjectVal(map[string]cty.Value{
+					"parent_id": cty.StringVal("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg-example"),
+				}),
+			}),
+		},
+		Functions: map[string]function.Function{"split": stdlib.SplitFunc},
+	}
+	value := func(name string) cty.Value {
+		t.Helper()
+		block := requireBlock(t, outputs.Body.(*hclsyntax.Body), "output", name)
+		require.Contains(t, block.Body.Attributes, "value")
+		v, diags := block.Body.Attributes["value"].Expr.Value(ctx)
+		require.False(t, diags.HasErrors(), diags.Error())
+		return v
+	}
+	assert.Equal(t, cty.StringVal("rg-example"), value("resource_group_name"))
+	assert.Equal(t, cty.StringVal("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg-example"), value("resource_group_id"))
+
+	t.Run("child resource", func(t *testing.T) {
+		files, err := GenerateFiles("Microsoft.Test/widgets/gadgets", WithResourceGroupOutputs(true))
+		require.NoError(t, err)
+		assert.NotContains(t, string(files["outputs.tf"]), "resource_group_name")
+	})
+}
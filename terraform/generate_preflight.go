@@ -0,0 +1,108 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// preflightDeploymentsAPIVersion is the version of the ARM deployments API whose validate action
+// runs the preflight.
+const preflightDeploymentsAPIVersion = "2021-04-01"
+
+// generatePreflight creates main.preflight.tf with a check block validating a template
+// deployment of the resource body to the resource group in var.parent_id, ARM's preflight. The
+// scoped azapi_resource_action data source runs on every plan; a rejected deployment, e.g. for
+// an exhausted quota or a policy denial, is reported as a check warning rather than failing the
+// plan. Secrets and the identity are not part of the validated body.
+func generatePreflight(resourceType, apiVersion, localName string, hasSchema, supportsTags, supportsLocation bool, defaultTags map[string]string, write fileWriter) error {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+
+	apiVersion = strings.TrimSpace(apiVersion)
+	if apiVersion == "" {
+		apiVersion = "apiVersion"
+	}
+
+	resourceAttrs := []hclwrite.ObjectAttrTokens{
+		{Name: hclwrite.TokensForIdentifier("type"), Value: hclwrite.TokensForValue(cty.StringVal(cleanTypeString(resourceType)))},
+		{Name: hclwrite.TokensForIdentifier("apiVersion"), Value: hclwrite.TokensForValue(cty.StringVal(apiVersion))},
+		{Name: hclwrite.TokensForIdentifier("name"), Value: hclgen.TokensForTraversal("var", "name")},
+	}
+	if supportsLocation {
+		resourceAttrs = append(resourceAttrs, hclwrite.ObjectAttrTokens{Name: hclwrite.TokensForIdentifier("location"), Value: hclgen.TokensForTraversal("var", "location")})
+	}
+	if supportsTags {
+		tags := hclgen.TokensForTraversal("var", "tags")
+		if len(defaultTags) > 0 {
+			tags = hclgen.TokensForTraversal("local", "tags")
+		}
+		resourceAttrs = append(resourceAttrs, hclwrite.ObjectAttrTokens{Name: hclwrite.TokensForIdentifier("tags"), Value: tags})
+	}
+	resource := hclwrite.TokensForObject(resourceAttrs)
+	if hasSchema {
+		resource = hclwrite.TokensForFunctionCall("merge", hclgen.TokensForTraversal("local", localName), resource)
+	}
+
+	template := hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+		{Name: hclwrite.TokensForValue(cty.StringVal("$schema")), Value: hclwrite.TokensForValue(cty.StringVal("https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#"))},
+		{Name: hclwrite.TokensForIdentifier("contentVersion"), Value: hclwrite.TokensForValue(cty.StringVal("1.0.0.0"))},
+		{Name: hclwrite.TokensForIdentifier("resources"), Value: hclwrite.TokensForTuple([]hclwrite.Tokens{resource})},
+	})
+	requestBody := hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{{
+		Name: hclwrite.TokensForIdentifier("properties"),
+		Value: hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+			{Name: hclwrite.TokensForIdentifier("mode"), Value: hclwrite.TokensForValue(cty.StringVal("Incremental"))},
+			{Name: hclwrite.TokensForIdentifier("template"), Value: template},
+		}),
+	}})
+
+	checkBlock := body.AppendNewBlock("check", []string{"preflight"})
+	checkBody := checkBlock.Body()
+
+	dataBlock := checkBody.AppendNewBlock("data", []string{"azapi_resource_action", "preflight"})
+	dataBody := dataBlock.Body()
+	dataBody.SetAttributeValue("type", cty.StringVal("Microsoft.Resources/deployments@"+preflightDeploymentsAPIVersion))
+	dataBody.SetAttributeRaw("resource_id", tokensForPreflightDeploymentID())
+	dataBody.SetAttributeValue("action", cty.StringVal("validate"))
+	dataBody.SetAttributeValue("method", cty.StringVal("POST"))
+	dataBody.SetAttributeRaw("body", requestBody)
+	dataBody.SetAttributeRaw("response_export_values", hclgen.TokensForMultilineStringList([]string{"properties.provisioningState"}))
+
+	checkBody.AppendNewline()
+	assertBody := checkBody.AppendNewBlock("assert", nil).Body()
+	provisioningState := hclgen.TokensForTraversal("data", "azapi_resource_action", "preflight", "output", "properties", "provisioningState")
+	condition := hclwrite.TokensForFunctionCall("try", provisioningState, hclwrite.TokensForIdentifier("null"))
+	condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte("==")})
+	condition = append(condition, hclwrite.TokensForValue(cty.StringVal("Succeeded"))...)
+	assertBody.SetAttributeRaw("condition", condition)
+	assertBody.SetAttributeValue("error_message", cty.StringVal(fmt.Sprintf("The preflight validation of %s did not succeed; the deployment is likely to be rejected on apply.", cleanTypeString(resourceType))))
+
+	return write("main.preflight.tf", file.Bytes())
+}
+
+// tokensForPreflightDeploymentID builds
+// "${var.parent_id}/providers/Microsoft.Resources/deployments/${var.name}-preflight".
+func tokensForPreflightDeploymentID() hclwrite.Tokens {
+	tokens := hclwrite.Tokens{
+		{Type: hclsyntax.TokenOQuote, Bytes: []byte(`"`)},
+		{Type: hclsyntax.TokenTemplateInterp, Bytes: []byte("${")},
+	}
+	tokens = append(tokens, hclgen.TokensForTraversal("var", "parent_id")...)
+	tokens = append(tokens,
+		&hclwrite.Token{Type: hclsyntax.TokenTemplateSeqEnd, Bytes: []byte("}")},
+		&hclwrite.Token{Type: hclsyntax.TokenQuotedLit, Bytes: []byte("/providers/Microsoft.Resources/deployments/")},
+		&hclwrite.Token{Type: hclsyntax.TokenTemplateInterp, Bytes: []byte("${")},
+	)
+	tokens = append(tokens, hclgen.TokensForTraversal("var", "name")...)
+	tokens = append(tokens,
+		&hclwrite.Token{Type: hclsyntax.TokenTemplateSeqEnd, Bytes: []byte("}")},
+		&hclwrite.Token{Type: hclsyntax.TokenQuotedLit, Bytes: []byte("-preflight")},
+		&hclwrite.Token{Type: hclsyntax.TokenCQuote, Bytes: []byte(`"`)},
+	)
+	return tokens
+}
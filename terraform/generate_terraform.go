@@ -1,24 +1,85 @@
 package terraform
 
 import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
-	"github.com/matt-FFFFFF/tfmodmake/hclgen"
 	"github.com/zclconf/go-cty/cty"
 )
 
-func generateTerraform(outputDir string) error {
+// providerSourcePattern matches a provider address of the form [hostname/]namespace/type.
+var providerSourcePattern = regexp.MustCompile(`^(?:[a-zA-Z0-9-]+(?:\.[a-zA-Z0-9-]+)*(?::[0-9]+)?/)?[a-zA-Z0-9][a-zA-Z0-9-]*/[a-zA-Z0-9][a-zA-Z0-9-]*$`)
+
+func generateTerraform(backend, providerSource, requiredVersion, providerVersion string, write fileWriter) error {
+	if !providerSourcePattern.MatchString(providerSource) {
+		return fmt.Errorf("invalid provider source %q: expected [hostname/]namespace/type", providerSource)
+	}
+	if strings.TrimSpace(requiredVersion) == "" {
+		return fmt.Errorf("invalid required version: expected a version constraint")
+	}
+	if strings.TrimSpace(providerVersion) == "" {
+		return fmt.Errorf("invalid provider version: expected a version constraint")
+	}
+
 	file := hclwrite.NewEmptyFile()
 	body := file.Body()
 
 	tfBlock := body.AppendNewBlock("terraform", nil)
 	tfBody := tfBlock.Body()
-	tfBody.SetAttributeValue("required_version", cty.StringVal("~> 1.12"))
+	tfBody.SetAttributeValue("required_version", cty.StringVal(requiredVersion))
 
 	providers := tfBody.AppendNewBlock("required_providers", nil)
 	providers.Body().SetAttributeValue("azapi", cty.ObjectVal(map[string]cty.Value{
-		"source":  cty.StringVal("azure/azapi"),
-		"version": cty.StringVal("~> 2.7"),
+		"source":  cty.StringVal(providerSource),
+		"version": cty.StringVal(providerVersion),
 	}))
 
-	return hclgen.WriteFileToDir(outputDir, "terraform.tf", file)
+	if backend != "" {
+		stub, err := backendStubTokens(backend)
+		if err != nil {
+			return err
+		}
+		tfBody.AppendNewline()
+		tfBody.AppendUnstructuredTokens(stub)
+	}
+
+	return write("terraform.tf", file.Bytes())
+}
+
+// backendStubTokens renders a commented-out backend block for the given backend type.
+// The stub is commented so generated modules never force a state backend; users uncomment
+// and fill in the settings when bootstrapping a root module.
+func backendStubTokens(backend string) (hclwrite.Tokens, error) {
+	stub := hclwrite.NewEmptyFile()
+	blockBody := stub.Body().AppendNewBlock("backend", []string{backend}).Body()
+
+	switch backend {
+	case "azurerm":
+		blockBody.SetAttributeValue("resource_group_name", cty.StringVal(""))
+		blockBody.SetAttributeValue("storage_account_name", cty.StringVal(""))
+		blockBody.SetAttributeValue("container_name", cty.StringVal("tfstate"))
+		blockBody.SetAttributeValue("key", cty.StringVal("terraform.tfstate"))
+		blockBody.SetAttributeValue("use_azuread_auth", cty.True)
+	case "s3":
+		blockBody.SetAttributeValue("bucket", cty.StringVal(""))
+		blockBody.SetAttributeValue("key", cty.StringVal("terraform.tfstate"))
+		blockBody.SetAttributeValue("region", cty.StringVal(""))
+	case "local":
+		blockBody.SetAttributeValue("path", cty.StringVal("terraform.tfstate"))
+	default:
+		return nil, fmt.Errorf("unsupported backend %q: expected one of azurerm, s3, local", backend)
+	}
+
+	var tokens hclwrite.Tokens
+	for line := range bytes.Lines(hclwrite.Format(stub.Bytes())) {
+		tokens = append(tokens, &hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: append([]byte("# "), line...),
+		})
+	}
+	return tokens, nil
 }
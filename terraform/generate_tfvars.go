@@ -0,0 +1,78 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// DefaultVariableDefaultsFile is the conventional name of the starter tfvars file.
+const DefaultVariableDefaultsFile = "terraform.tfvars.example"
+
+// generateVariableDefaults writes filename, a starter tfvars file assigning every variable of
+// variablesSrc, the generated variables.tf: optional variables their default and required ones
+// an empty value of their type to fill in. Each assignment is preceded by the first line of the
+// variable's description.
+func generateVariableDefaults(variablesSrc []byte, filename string, write fileWriter) error {
+	file, diags := hclsyntax.ParseConfig(variablesSrc, "variables.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	var sb strings.Builder
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "variable" || len(block.Labels) != 1 {
+			continue
+		}
+		name := block.Labels[0]
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+
+		comment := name
+		var value string
+		if attr, ok := block.Body.Attributes["default"]; ok {
+			value = string(attr.Expr.Range().SliceBytes(variablesSrc))
+		} else {
+			comment += " (required)"
+			typeExpr := ""
+			if attr, ok := block.Body.Attributes["type"]; ok {
+				typeExpr = string(attr.Expr.Range().SliceBytes(variablesSrc))
+			}
+			value = placeholderForType(typeExpr)
+		}
+		if attr, ok := block.Body.Attributes["description"]; ok {
+			if description, diags := attr.Expr.Value(nil); !diags.HasErrors() && description.Type() == cty.String && !description.IsNull() {
+				if line, _, _ := strings.Cut(strings.TrimSpace(description.AsString()), "\n"); line != "" {
+					comment += ": " + line
+				}
+			}
+		}
+		sb.WriteString("# " + comment + "\n")
+		sb.WriteString(name + " = " + value + "\n")
+	}
+
+	return write(filename, hclwrite.Format([]byte(sb.String())))
+}
+
+// placeholderForType returns an empty value of the Terraform type expression typeExpr, e.g. ""
+// for string and {} for an object, or null when the type is unknown.
+func placeholderForType(typeExpr string) string {
+	switch name, _, _ := strings.Cut(strings.TrimSpace(typeExpr), "("); name {
+	case "string":
+		return `""`
+	case "number":
+		return "0"
+	case "bool":
+		return "false"
+	case "list", "set", "tuple":
+		return "[]"
+	case "map", "object":
+		return "{}"
+	default:
+		return "null"
+	}
+}
@@ -1,10 +1,16 @@
 package terraform
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"html"
+	"maps"
+	"regexp"
 	"slices"
 	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
@@ -15,9 +21,17 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
-func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation, supportsIdentity bool, secrets []secretField, nameSchema *openapi3.Schema, caps openapi.InterfaceCapabilities, moduleNamePrefix string, outputDir string) error {
+// generateVariables writes variables.tf. Variables renamed to resolve a name collision are
+// recorded in renamed, keyed by property path, for the locals to refer to.
+func generateVariables(o *generatorOptions, supportsIdentity, longRunning bool, secrets []secretField, nameSchema *openapi3.Schema, caps openapi.InterfaceCapabilities, actions []openapi.ChildAction, requestParams []requestParameterVariable, renamed map[string]string, write fileWriter) error {
+	schema := o.schema
+	supportsTags := o.supportsTags
+	supportsLocation := o.supportsLocation
+	moduleNamePrefix := o.moduleNamePrefix
+
 	file := hclwrite.NewEmptyFile()
 	body := file.Body()
+	types := newTypeMapper(o.maxDepth, o.identifierMaps, o.namer)
 
 	arrayItemsContainSecret := func(schema *openapi3.Schema) (bool, error) {
 		if schema == nil || schema.Type == nil {
@@ -58,10 +72,41 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 		})
 	}
 
-	// Build a set of secret field variable names for quick lookup.
+	// appendSourceComment records the originating OpenAPI path above the next variable, when enabled.
+	appendSourceComment := func(path string) {
+		if !o.commentSource {
+			return
+		}
+		body.AppendUnstructuredTokens(hclwrite.Tokens{
+			&hclwrite.Token{Type: hclsyntax.TokenComment, Bytes: []byte("# source: " + path)},
+			&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+		})
+	}
+
+	// appendExtensionsComment lists the x-ms-* extensions of schema the generator doesn't act on
+	// above the next variable, when enabled.
+	appendExtensionsComment := func(schema *openapi3.Schema) {
+		if !o.preserveUnknownExtensions {
+			return
+		}
+		for _, line := range unhandledExtensionLines(schema) {
+			body.AppendUnstructuredTokens(hclwrite.Tokens{
+				&hclwrite.Token{Type: hclsyntax.TokenComment, Bytes: []byte("# " + line)},
+				&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+			})
+		}
+	}
+
+	// Build a set of secret field variable names for quick lookup. Nested secrets all live in
+	// var.sensitive_body instead, so the properties holding them get no variable of their own.
 	secretVarNames := make(map[string]struct{}, len(secrets))
-	for _, secret := range secrets {
-		secretVarNames[secret.varName] = struct{}{}
+	var nestedSecretPaths map[string]struct{}
+	if o.nestSecrets {
+		nestedSecretPaths = newSecretPathSet(secrets)
+	} else {
+		for _, secret := range secrets {
+			secretVarNames[secret.varName] = struct{}{}
+		}
 	}
 
 	appendVariable := func(name, description string, typeTokens hclwrite.Tokens) *hclwrite.Body {
@@ -72,18 +117,35 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 		return varBody
 	}
 
-	appendSchemaVariable := func(tfName, originalName string, propSchema *openapi3.Schema, required []string) (*hclwrite.Body, error) {
+	appendSchemaVariable := func(tfName, originalName, path string, propSchema *openapi3.Schema, required []string) (*hclwrite.Body, error) {
 		if propSchema == nil {
 			return nil, nil
 		}
+		nullable := openapi.IsNullable(propSchema)
+		// A nullable wrapper is typed, documented and validated as the schema it wraps.
+		if inner, ok := openapi.UnwrapNullable(propSchema); ok {
+			if inner.Description == "" {
+				described := *inner
+				described.Description = propSchema.Description
+				inner = &described
+			}
+			propSchema = inner
+		}
 
-		tfType, err := mapType(propSchema)
+		types.truncated = false
+		types.path = path
+		tfType, err := types.mapType(propSchema)
 		if err != nil {
 			return nil, err
 		}
+		depthNote := ""
+		if types.truncated {
+			depthNote = fmt.Sprintf("Fields nested more than %d levels deep are typed as `any` and passed through as-is.", o.maxDepth)
+		}
 
 		var nestedDocSchema *openapi3.Schema
-		if propSchema.Type != nil && slices.Contains(*propSchema.Type, "object") {
+		nestedDocHeading := ""
+		if slices.Contains(openapi.EffectiveTypes(propSchema), "object") {
 			switch {
 			case len(propSchema.Properties) > 0:
 				nestedDocSchema = propSchema
@@ -91,16 +153,39 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 				apSchema := propSchema.AdditionalProperties.Schema.Value
 				if apSchema.Type != nil && slices.Contains(*apSchema.Type, "object") && len(apSchema.Properties) > 0 {
 					nestedDocSchema = apSchema
+					nestedDocHeading = "Map values:\n"
 				}
 			}
 		}
+		if propSchema.Type != nil && slices.Contains(*propSchema.Type, "array") && propSchema.Items != nil && propSchema.Items.Value != nil {
+			itemSchema := propSchema.Items.Value
+			if itemSchema.Type != nil && slices.Contains(*itemSchema.Type, "object") && len(itemSchema.Properties) > 0 {
+				nestedDocSchema = itemSchema
+				nestedDocHeading = "List item fields:\n"
+			}
+		}
+		// An identifier map's values are the list items without their identifier, and since
+		// its keys are unique, uniqueItems needs no validation.
+		validationSchema := propSchema
+		if key, ok := identifierMapKey(propSchema); o.identifierMaps && ok {
+			valueSchema, err := identifierMapValueSchema(propSchema.Items.Value, key)
+			if err != nil {
+				return nil, err
+			}
+			nestedDocSchema = valueSchema
+			nestedDocHeading = fmt.Sprintf("Map values, keyed by `%s`:\n", key)
+			withoutUnique := *propSchema
+			withoutUnique.UniqueItems = false
+			validationSchema = &withoutUnique
+		}
 		isNestedObject := nestedDocSchema != nil
 
 		varBody := appendVariable(tfName, "", tfType)
+		isResourceID := false
 
 		if isNestedObject {
 			var sb strings.Builder
-			desc := propSchema.Description
+			desc := cleanDescription(propSchema.Description, o.stripDescriptionHTML, o.descriptionMaxLength)
 			if desc == "" {
 				if originalName != "" {
 					desc = fmt.Sprintf("The %s of the resource.", originalName)
@@ -110,19 +195,26 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 			}
 			sb.WriteString(desc)
 			sb.WriteString("\n\n")
-
-			if nestedDocSchema != propSchema {
-				sb.WriteString("Map values:\n")
+			if caveat := notConstraintDescription(propSchema); caveat != "" {
+				sb.WriteString(caveat)
+				sb.WriteString("\n\n")
 			}
 
-			nested, err := buildNestedDescription(nestedDocSchema, "")
+			sb.WriteString(nestedDocHeading)
+
+			nested, err := buildNestedDescription(nestedDocSchema, "", o.maxDepth, o.stripDescriptionHTML, o.descriptionMaxLength, o.namer)
 			if err != nil {
 				return nil, err
 			}
 			sb.WriteString(nested)
+			if depthNote != "" {
+				sb.WriteString("\n")
+				sb.WriteString(depthNote)
+				sb.WriteString("\n")
+			}
 			hclgen.SetDescriptionAttribute(varBody, sb.String())
 		} else {
-			description := propSchema.Description
+			description := cleanDescription(propSchema.Description, o.stripDescriptionHTML, o.descriptionMaxLength)
 			if description == "" {
 				if originalName != "" {
 					description = fmt.Sprintf("The %s of the resource.", originalName)
@@ -130,6 +222,16 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 					description = fmt.Sprintf("The %s of the resource.", tfName)
 				}
 			}
+			if caveat := notConstraintDescription(propSchema); caveat != "" {
+				description = strings.TrimSpace(description) + "\n\n" + caveat
+			}
+			isResourceID = isARMResourceIDField(originalName, propSchema, o.resourceIDNameHeuristic)
+			if isResourceID {
+				description = strings.TrimSpace(description) + "\n\nExpected to be an ARM resource ID, e.g. `/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/{resourceProviderNamespace}/{resourceType}/{resourceName}`."
+			}
+			if depthNote != "" {
+				description = strings.TrimSpace(description) + "\n\n" + depthNote
+			}
 			hclgen.SetDescriptionAttribute(varBody, description)
 		}
 
@@ -154,32 +256,108 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 			varBody.SetAttributeValue("ephemeral", cty.True)
 		}
 
-		// Generate validations for this variable
-		generateValidations(varBody, tfName, propSchema, isRequired)
-		if propSchema.Type != nil && slices.Contains(*propSchema.Type, "object") && len(propSchema.Properties) > 0 {
-			if err := generateNestedObjectValidations(varBody, tfName, propSchema); err != nil {
+		// Generate validations for this variable. A required property the spec allows to be
+		// null must still be set, but may be set to null, so its validations stay null-guarded.
+		nonNull := isRequired && !nullable
+		generateValidations(varBody, tfName, validationSchema, nonNull, o.openEnumValidation)
+		generateArrayIdentifierUniqueValidation(varBody, tfName, validationSchema, nonNull, o.namer)
+		if o.intRangeChecks {
+			generateIntegerRangeValidation(varBody, tfName, propSchema, nonNull)
+		}
+		// ARM ID formats are already validated by generateValidations.
+		if isResourceID && !isARMResourceIDFormat(resolveSchemaForValidation(propSchema).Format) {
+			generateResourceIDValidation(varBody, tfName, nonNull)
+		}
+		if slices.Contains(openapi.EffectiveTypes(propSchema), "object") && len(propSchema.Properties) > 0 {
+			if err := generateNestedObjectValidations(varBody, tfName, propSchema, o.namer, o.openEnumValidation); err != nil {
 				return nil, err
 			}
 		}
+		o.logger.Debug("generated variable", "variable", tfName, "required", isRequired)
 
 		return varBody, nil
 	}
 
+	// generatedVariable records a variable emitted for a schema property, keyed by property name,
+	// so validations spanning sibling properties can be added once all siblings exist. required
+	// is set for a required property that may not be null.
+	type generatedVariable struct {
+		tfName   string
+		body     *hclwrite.Body
+		required bool
+	}
+
+	appendRangeValidations := func(props map[string]*openapi3.SchemaRef, generated map[string]generatedVariable) {
+		if !o.rangeValidations {
+			return
+		}
+		for _, pair := range findRangePairs(props) {
+			lower, lowerOK := generated[pair.lower]
+			upper, upperOK := generated[pair.upper]
+			if !lowerOK || !upperOK {
+				continue
+			}
+			generateRangeOrderValidation(upper.body, lower.tfName, upper.tfName, lower.required, upper.required)
+		}
+	}
+
+	// requirePropertiesBag keeps a properties bag the body requires from being sent with every
+	// field null. Its required children are made non-nullable; when it has none, the first
+	// variable checks that at least one of them is set.
+	requirePropertiesBag := func(keys []string, generated map[string]generatedVariable) {
+		var names []string
+		var first *hclwrite.Body
+		hasRequired := false
+		for _, key := range keys {
+			child, ok := generated[key]
+			if !ok {
+				continue
+			}
+			if child.required {
+				child.body.SetAttributeValue("nullable", cty.False)
+				hasRequired = true
+			}
+			if first == nil {
+				first = child.body
+			}
+			names = append(names, child.tfName)
+		}
+		if hasRequired || first == nil {
+			return
+		}
+		generateAnyOfSetValidation(first, names)
+	}
+
 	nameVarBody := appendVariable("name", "The name of the resource.", hclwrite.TokensForIdentifier("string"))
 	// The resource name constraints usually come from the operation path parameter schema (not the request body schema).
 	// When available, apply them as validations to var.name.
 	if nameSchema != nil {
-		generateValidations(nameVarBody, "name", nameSchema, true)
+		generateValidations(nameVarBody, "name", nameSchema, true, o.openEnumValidation)
 	}
 	body.AppendNewline()
 
-	appendVariable("parent_id", "The parent resource ID for this resource.", hclwrite.TokensForIdentifier("string"))
-	body.AppendNewline()
+	if o.dataPlane {
+		endpointBody := appendVariable("endpoint", "The data-plane endpoint host the resource is created under, e.g. `myvault.vault.azure.net`.", hclwrite.TokensForIdentifier("string"))
+		if o.endpoint != "" {
+			endpointBody.SetAttributeValue("default", cty.StringVal(o.endpoint))
+		}
+		body.AppendNewline()
+	} else {
+		parentIDBody := appendVariable("parent_id", "The parent resource ID for this resource.", hclwrite.TokensForIdentifier("string"))
+		if o.parentIDValidation {
+			condition := hclwrite.TokensForFunctionCall(
+				"can",
+				hclwrite.TokensForFunctionCall("regex", hclwrite.TokensForValue(cty.StringVal(parentIDPattern)), hclgen.TokensForTraversal("var", "parent_id")),
+			)
+			appendValidation(parentIDBody, condition, "parent_id must be an Azure resource ID starting with /subscriptions/ or /providers/, e.g. /subscriptions/.../resourceGroups/my-rg.")
+		}
+		body.AppendNewline()
 
-	// AVM standard variables (declared up-front; may be unused depending on resource capabilities)
-	// location
-	appendVariable("location", "The location of the resource.", hclwrite.TokensForIdentifier("string"))
-	body.AppendNewline()
+		// AVM standard variables (declared up-front; may be unused depending on resource capabilities)
+		// location
+		appendVariable("location", "The location of the resource.", hclwrite.TokensForIdentifier("string"))
+		body.AppendNewline()
+	}
 
 	// tags (only when the resource supports tags)
 	if supportsTags {
@@ -195,16 +373,13 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 		miBody := appendVariable(
 			"managed_identities",
 			"Controls the Managed Identity configuration on this resource.",
-			hclwrite.TokensForFunctionCall(
-				"object",
-				hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-					{Name: hclwrite.TokensForIdentifier("system_assigned"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("bool"), hclwrite.TokensForIdentifier("false"))},
-					{Name: hclwrite.TokensForIdentifier("user_assigned_resource_ids"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.ListValEmpty(cty.String)))},
-				}),
-			),
+			managedIdentitiesType(),
 		)
 		miBody.SetAttributeRaw("default", hclwrite.TokensForObject(nil))
 		miBody.SetAttributeValue("nullable", cty.False)
+		if o.validateIdentityIDs {
+			appendValidation(miBody, tokensForIdentityIDsCondition(), "Each of managed_identities.user_assigned_resource_ids must be the resource ID of a user-assigned identity, e.g. /subscriptions/.../userAssignedIdentities/my-identity.")
+		}
 		body.AppendNewline()
 	}
 
@@ -220,18 +395,53 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 		"private_endpoints":    {},
 		"private_endpoints_manage_dns_zone_group": {},
 	}
+	if !o.telemetry {
+		delete(reservedNames, "enable_telemetry")
+	}
+	if o.dataPlane {
+		reservedNames["endpoint"] = struct{}{}
+	}
 	if supportsTags {
 		reservedNames["tags"] = struct{}{}
 	}
 	if supportsIdentity {
 		reservedNames["managed_identities"] = struct{}{}
 	}
+	if longRunning {
+		reservedNames["timeouts"] = struct{}{}
+	}
 
 	seenNames := map[string]struct{}{}
 	for k := range reservedNames {
 		seenNames[k] = struct{}{}
 	}
 
+	// claimName takes tfName for the property at path. A name another property already took is
+	// an error unless collisions are disambiguated, in which case the first free _2, _3, ...
+	// suffix is used and the rename is noted above the variable.
+	claimName := func(tfName, path string) (string, error) {
+		if _, exists := seenNames[tfName]; exists {
+			if !o.disambiguateCollisions {
+				return "", fmt.Errorf("terraform variable name collision: %q (from %s)", tfName, path)
+			}
+			taken := tfName
+			for i := 2; ; i++ {
+				tfName = fmt.Sprintf("%s_%d", taken, i)
+				if _, exists := seenNames[tfName]; !exists {
+					break
+				}
+			}
+			renamed[path] = tfName
+			o.logger.Debug("renamed variable", "property", path, "variable", tfName, "reason", "name collision with "+taken)
+			body.AppendUnstructuredTokens(hclwrite.Tokens{
+				&hclwrite.Token{Type: hclsyntax.TokenComment, Bytes: []byte(fmt.Sprintf("# %s sets %s, as %s is taken by another property.", tfName, path, taken))},
+				&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+			})
+		}
+		seenNames[tfName] = struct{}{}
+		return tfName, nil
+	}
+
 	// Get effective properties and required (handling allOf)
 	var keys []string
 	var effectiveProps map[string]*openapi3.SchemaRef
@@ -250,9 +460,15 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 		for k := range effectiveProps {
 			keys = append(keys, k)
 		}
-		sort.Strings(keys)
+		keys, err = sortVariableKeys(o.sortVariables, schema, keys, effectiveRequired)
+		if err != nil {
+			return fmt.Errorf("ordering variables: %w", err)
+		}
 	}
 
+	// bodyVariables counts variables generated from writable body properties.
+	bodyVariables := 0
+	generatedProps := make(map[string]generatedVariable, len(keys))
 	for i, name := range keys {
 		prop := effectiveProps[name]
 		if prop == nil || prop.Value == nil {
@@ -263,19 +479,24 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 		// When not supported, we avoid generating an input for identity, since many specs
 		// only expose identity as read-only metadata.
 		if name == "identity" {
+			o.logger.Debug("skipped property", "property", name, "reason", "configured through managed_identities when supported")
 			continue
 		}
 		if supportsTags && name == "tags" {
+			o.logger.Debug("skipped property", "property", name, "reason", "covered by the standard tags variable")
 			continue
 		}
 		if supportsLocation && name == "location" {
+			o.logger.Debug("skipped property", "property", name, "reason", "covered by the standard location variable")
 			continue
 		}
 		propSchema := prop.Value
 
 		// Flatten the standard ARM top-level "properties" bag into individual Terraform variables.
 		// This is the default module shape for full-schema generation (no -root), per DESIGN.md.
-		if name == "properties" && propSchema.Type != nil && slices.Contains(*propSchema.Type, "object") {
+		// The nested locals style, or a bag smaller than WithInlineSmallObjects, keeps it as a
+		// single properties variable instead.
+		if name == "properties" && o.flattenProperties() && slices.Contains(openapi.EffectiveTypes(propSchema), "object") {
 			propsSchema := propSchema
 
 			childProps, err := openapi.GetEffectiveProperties(propsSchema)
@@ -294,8 +515,12 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 			for k := range childProps {
 				childKeys = append(childKeys, k)
 			}
-			sort.Strings(childKeys)
+			childKeys, err = sortVariableKeys(o.sortVariables, propsSchema, childKeys, childRequired)
+			if err != nil {
+				return fmt.Errorf("ordering variables of root properties bag: %w", err)
+			}
 
+			generatedChildren := make(map[string]generatedVariable, len(childKeys))
 			for _, childName := range childKeys {
 				childRef := childProps[childName]
 				if childRef == nil || childRef.Value == nil {
@@ -303,10 +528,15 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 				}
 				childSchema := childRef.Value
 				if !isWritableProperty(childSchema) {
+					o.logger.Debug("skipped property", "property", "properties."+childName, "reason", "read-only")
+					continue
+				}
+				if _, ok := nestedSecretPaths["properties."+childName]; ok {
+					o.logger.Debug("skipped property", "property", "properties."+childName, "reason", "secret nested in var."+sensitiveBodyVariable)
 					continue
 				}
 
-				tfName := naming.ToSnakeCase(childName)
+				tfName := o.namer.ToSnakeCase(childName)
 				if tfName == "" {
 					return fmt.Errorf("could not derive terraform variable name for %s", childName)
 				}
@@ -320,84 +550,146 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 				if _, reserved := reservedNames[tfName]; reserved {
 					return fmt.Errorf("terraform variable name collision: %q (from properties.%s)", tfName, childName)
 				}
-				if _, exists := seenNames[tfName]; exists {
-					return fmt.Errorf("terraform variable name collision: %q (from properties.%s)", tfName, childName)
+				tfName, err = claimName(tfName, "properties."+childName)
+				if err != nil {
+					return err
 				}
-				seenNames[tfName] = struct{}{}
 
-				if _, err := appendSchemaVariable(tfName, childName, childSchema, childRequired); err != nil {
+				appendSourceComment("properties." + childName)
+				appendExtensionsComment(childSchema)
+				varBody, err := appendSchemaVariable(tfName, childName, "properties."+childName, childSchema, childRequired)
+				if err != nil {
 					return err
 				}
+				generatedChildren[childName] = generatedVariable{tfName: tfName, body: varBody, required: slices.Contains(childRequired, childName) && !openapi.IsNullable(childSchema)}
+				bodyVariables++
 
 				body.AppendNewline()
 			}
+			appendRangeValidations(childProps, generatedChildren)
+			if slices.Contains(effectiveRequired, "properties") {
+				requirePropertiesBag(childKeys, generatedChildren)
+			}
 
 			continue
 		}
 
 		if !isWritableProperty(propSchema) {
+			o.logger.Debug("skipped property", "property", name, "reason", "read-only")
+			continue
+		}
+		if _, ok := nestedSecretPaths[name]; ok {
+			o.logger.Debug("skipped property", "property", name, "reason", "secret nested in var."+sensitiveBodyVariable)
 			continue
 		}
 
-		tfName := naming.ToSnakeCase(name)
+		tfName := o.namer.ToSnakeCase(name)
 		if tfName == "" {
 			return fmt.Errorf("could not derive terraform variable name for %s", name)
 		}
 		if _, reserved := reservedNames[tfName]; reserved {
+			o.logger.Debug("skipped property", "property", name, "reason", "reserved variable name "+tfName)
 			continue
 		}
 		// Rename variables that conflict with Terraform module meta-arguments
 		if moduleNamePrefix != "" && tfName == "version" {
 			tfName = moduleNamePrefix + "_version"
 		}
-		if _, exists := seenNames[tfName]; exists {
-			return fmt.Errorf("terraform variable name collision: %q (from %s)", tfName, name)
+		tfName, err := claimName(tfName, name)
+		if err != nil {
+			return err
 		}
-		seenNames[tfName] = struct{}{}
-		if _, err := appendSchemaVariable(tfName, name, propSchema, effectiveRequired); err != nil {
+		appendSourceComment(name)
+		appendExtensionsComment(propSchema)
+		varBody, err := appendSchemaVariable(tfName, name, name, propSchema, effectiveRequired)
+		if err != nil {
 			return err
 		}
+		generatedProps[name] = generatedVariable{tfName: tfName, body: varBody, required: slices.Contains(effectiveRequired, name) && !openapi.IsNullable(propSchema)}
+		bodyVariables++
 
 		if i < len(keys)-1 {
 			body.AppendNewline()
 		}
 	}
+	appendRangeValidations(effectiveProps, generatedProps)
 
-	// Add secret field variables (extracted from nested structures)
-	secretBlockAdded := false
-	for _, secret := range secrets {
-		// If the variable already exists (e.g., flattened root properties), don't redeclare it.
-		// The existing variable will already be marked ephemeral via secretVarNames.
-		if _, exists := seenNames[secret.varName]; exists {
-			continue
+	if o.failOnEmptyBody && bodyVariables == 0 {
+		if isReadOnlyBody(o.schema) {
+			return fmt.Errorf("resource type %s has no writable body properties: its body is entirely read-only, generate it as a data source instead", o.resourceType)
 		}
-		if !secretBlockAdded && len(keys) > 0 {
+		return fmt.Errorf("resource type %s has no writable body properties", o.resourceType)
+	}
+
+	// Add secret field variables (extracted from nested structures), or the single variable
+	// nesting them all
+	if o.nestSecrets && len(secrets) > 0 {
+		if _, exists := seenNames[sensitiveBodyVariable]; exists {
+			return fmt.Errorf("terraform variable name collision: %q (from nested secrets)", sensitiveBodyVariable)
+		}
+		if len(keys) > 0 {
 			body.AppendNewline()
-			secretBlockAdded = true
 		}
-
-		tfType, err := mapType(secret.schema)
+		typeTokens, err := tokensForSensitiveBodyType(secrets, func(secret secretField) (hclwrite.Tokens, error) {
+			types.path = secret.path
+			return types.mapType(secret.schema)
+		})
 		if err != nil {
 			return err
 		}
+		paths := make([]string, 0, len(secrets))
+		for _, secret := range secrets {
+			paths = append(paths, "- `"+secret.path+"`")
+		}
 		secretVarBody := appendVariable(
-			secret.varName,
-			secret.schema.Description,
-			tfType,
+			sensitiveBodyVariable,
+			"The secret properties of the request body, nested as in the body and sent through sensitive_body so they are never stored in state:\n\n"+strings.Join(paths, "\n"),
+			typeTokens,
 		)
-
-		seenNames[secret.varName] = struct{}{}
+		seenNames[sensitiveBodyVariable] = struct{}{}
 		secretVarBody.SetAttributeRaw("default", hclwrite.TokensForIdentifier("null"))
 		secretVarBody.SetAttributeValue("ephemeral", cty.True)
-
 		body.AppendNewline()
-	}
+	} else {
+		secretBlockAdded := false
+		for _, secret := range secrets {
+			// If the variable already exists (e.g., flattened root properties), don't redeclare it.
+			// The existing variable will already be marked ephemeral via secretVarNames.
+			if _, exists := seenNames[secret.varName]; exists {
+				continue
+			}
+			if !secretBlockAdded && len(keys) > 0 {
+				body.AppendNewline()
+				secretBlockAdded = true
+			}
+
+			types.path = secret.path
+			tfType, err := types.mapType(secret.schema)
+			if err != nil {
+				return err
+			}
+			appendSourceComment(secret.path)
+			appendExtensionsComment(secret.schema)
+			secretVarBody := appendVariable(
+				secret.varName,
+				cleanDescription(secret.schema.Description, o.stripDescriptionHTML, o.descriptionMaxLength),
+				tfType,
+			)
+
+			seenNames[secret.varName] = struct{}{}
+			secretVarBody.SetAttributeRaw("default", hclwrite.TokensForIdentifier("null"))
+			secretVarBody.SetAttributeValue("ephemeral", cty.True)
 
-	// Add secret version variables
-	for i, secret := range secrets {
-		if i == 0 && len(keys) > 0 {
 			body.AppendNewline()
 		}
+	}
+
+	// Add secret version variables
+	secretVars := secretVariables(secrets, o.nestSecrets)
+	if len(secretVars) > 0 {
+		separateBlocks(body)
+	}
+	for i, secret := range secretVars {
 		versionVarName := secret.varName + "_version"
 		if _, exists := seenNames[versionVarName]; exists {
 			return fmt.Errorf("terraform variable name collision: %q (from secret version var)", versionVarName)
@@ -434,22 +726,43 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 			cty.StringVal(fmt.Sprintf("When %s is set, %s must also be set.", secret.varName, versionVarName)),
 		)
 
-		if i < len(secrets)-1 {
+		if i < len(secretVars)-1 {
 			body.AppendNewline()
 		}
 	}
 
+	// Required header and query parameters of the PUT request
+	if len(requestParams) > 0 {
+		separateBlocks(body)
+	}
+	taken := func(name string) bool {
+		_, exists := seenNames[name]
+		return exists
+	}
+	if err := emitRequestParameterVars(body, requestParams, taken, claimName, appendVariable, o.openEnumValidation); err != nil {
+		return err
+	}
+
+	// Timeouts of the long-running create, update and delete operations
+	if longRunning {
+		separateBlocks(body)
+		emitTimeoutsVar(body, appendVariable)
+	}
+
 	// Add AVM interface variables
 	// Only generate these when capabilities indicate support from REST spec
-	if len(secrets) > 0 || len(keys) > 0 {
-		body.AppendNewline()
+	hasAVMVars := o.telemetry || caps.SupportsCustomerManagedKey || caps.SupportsDiagnostics || caps.SupportsPrivateEndpoints
+	if hasAVMVars {
+		separateBlocks(body)
 	}
 
 	// customer_managed_key (only if supported based on encryption properties in schema)
 	emitCustomerManagedKeyVar(body, caps, appendVariable, appendTFLintIgnoreUnused)
 
-	// enable_telemetry (always included for AVM compliance)
-	emitEnableTelemetryVar(body, appendVariable)
+	// enable_telemetry (included by default for AVM compliance)
+	if o.telemetry {
+		emitEnableTelemetryVar(body, appendVariable)
+	}
 
 	// diagnostic_settings (only if swagger indicates support)
 	emitDiagnosticSettingsVar(body, caps, appendVariable)
@@ -466,15 +779,185 @@ func generateVariables(schema *openapi3.Schema, supportsTags, supportsLocation,
 	// private_endpoints (only if swagger indicates Private Link/Private Endpoint support)
 	emitPrivateEndpointsVars(body, caps, appendVariable)
 
-	return hclgen.WriteFileToDir(outputDir, "variables.tf", file)
+	// Toggles for the azapi_resource_action blocks in main.actions.tf
+	emitActionVars(body, actions, o.namer, appendVariable)
+
+	if o.compactValidations {
+		if err := compactValidations(body); err != nil {
+			return err
+		}
+	}
+	if o.errorMessagePrefix != "" {
+		if err := prefixValidationMessages(body, o.errorMessagePrefix); err != nil {
+			return err
+		}
+	}
+
+	if o.envPrefix == "" {
+		return write("variables.tf", file.Bytes())
+	}
+
+	// Variables without a default must be supplied by the caller; in pipelines that is usually
+	// through the environment.
+	var required []string
+	for _, block := range body.Blocks() {
+		if block.Type() == "variable" && len(block.Labels()) == 1 && block.Body().GetAttribute("default") == nil {
+			required = append(required, block.Labels()[0])
+		}
+	}
+	if err := write("variables.tf", annotateEnvVariables(file.Bytes(), required, o.envPrefix)); err != nil {
+		return err
+	}
+	return write(".env.example", envExample(required, o.envPrefix))
+}
+
+// handledExtensions are the x-ms-* extensions the generated variables always act on: enums and
+// resource IDs are validated and secrets become ephemeral variables.
+var handledExtensions = map[string]struct{}{
+	"x-ms-azure-resource": {},
+	"x-ms-enum":           {},
+	"x-ms-secret":         {},
+}
+
+// unhandledExtensionLines returns a "name: value" line, in name order, for each x-ms-*
+// extension of schema not in handledExtensions, with the value as JSON.
+func unhandledExtensionLines(schema *openapi3.Schema) []string {
+	if schema == nil {
+		return nil
+	}
+	var lines []string
+	for _, name := range slices.Sorted(maps.Keys(schema.Extensions)) {
+		if !strings.HasPrefix(name, "x-ms-") {
+			continue
+		}
+		if _, ok := handledExtensions[name]; ok {
+			continue
+		}
+		value, err := json.Marshal(schema.Extensions[name])
+		if err != nil {
+			value = []byte(fmt.Sprint(schema.Extensions[name]))
+		}
+		lines = append(lines, name+": "+string(value))
+	}
+	return lines
+}
+
+// sortVariableKeys orders keys, the effective property names of schema, as WithSortVariables
+// sets. required lists the required ones.
+func sortVariableKeys(order string, schema *openapi3.Schema, keys, required []string) ([]string, error) {
+	if order == SortVariablesSpec {
+		return openapi.PropertyOrder(schema)
+	}
+	sort.Strings(keys)
+	if order == SortVariablesRequiredFirst {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return slices.Contains(required, keys[i]) && !slices.Contains(required, keys[j])
+		})
+	}
+	return keys, nil
+}
+
+// annotateEnvVariables precedes each named variable block in src with a comment naming the
+// environment variable expected to set it.
+func annotateEnvVariables(src []byte, names []string, prefix string) []byte {
+	for _, name := range names {
+		header := fmt.Sprintf("\nvariable %q {", name)
+		if bytes.HasPrefix(src, []byte(header[1:])) {
+			src = append([]byte("# env: "+prefix+name+"\n"), src...)
+			continue
+		}
+		src = bytes.Replace(src, []byte(header), []byte("\n# env: "+prefix+name+header), 1)
+	}
+	return src
+}
+
+// envExample renders a .env.example listing an empty assignment per variable.
+func envExample(names []string, prefix string) []byte {
+	var sb strings.Builder
+	sb.WriteString("# Required module inputs (variables without a default).\n")
+	for _, name := range names {
+		sb.WriteString(prefix + name + "=\n")
+	}
+	return []byte(sb.String())
+}
+
+// typeMapper converts OpenAPI schemas into Terraform type expressions.
+//
+// Large resources commonly reuse the same definition (via $ref) for many nested
+// properties. Results are memoized per schema pointer so each shape is computed
+// once and every occurrence renders identically.
+//
+// Nesting deeper than maxDepth is typed as any so self-referential schemas terminate.
+// A shape cut short by the limit depends on the depth it was built at, so those are
+// cached per depth; truncated records whether any limit was hit since it was last reset.
+// path is the schema path being mapped, e.g. "properties.sku", used to locate errors.
+// namer converts property names to object attribute names.
+type typeMapper struct {
+	cache          map[*openapi3.Schema]hclwrite.Tokens
+	truncatedCache map[typeCacheKey]hclwrite.Tokens
+	maxDepth       int
+	depth          int
+	truncated      bool
+	path           string
+	identifierMaps bool
+	namer          *naming.Namer
+}
+
+type typeCacheKey struct {
+	schema *openapi3.Schema
+	depth  int
+}
+
+func newTypeMapper(maxDepth int, identifierMaps bool, namer *naming.Namer) *typeMapper {
+	return &typeMapper{
+		cache:          make(map[*openapi3.Schema]hclwrite.Tokens),
+		truncatedCache: make(map[typeCacheKey]hclwrite.Tokens),
+		maxDepth:       maxDepth,
+		identifierMaps: identifierMaps,
+		namer:          namer,
+	}
 }
 
-func mapType(schema *openapi3.Schema) (hclwrite.Tokens, error) {
-	if schema.Type == nil {
+// mapType returns the Terraform type expression for schema. The returned tokens are
+// a private copy; hclwrite formatting mutates tokens in place, so cached tokens must
+// never be shared between attributes.
+func (m *typeMapper) mapType(schema *openapi3.Schema) (hclwrite.Tokens, error) {
+	schema, _ = openapi.UnwrapNullable(schema)
+	if cached, ok := m.cache[schema]; ok {
+		return cloneTokens(cached), nil
+	}
+	key := typeCacheKey{schema: schema, depth: m.depth}
+	if cached, ok := m.truncatedCache[key]; ok {
+		m.truncated = true
+		return cloneTokens(cached), nil
+	}
+	if m.depth >= m.maxDepth {
+		m.truncated = true
 		return hclwrite.TokensForIdentifier("any"), nil
 	}
 
-	types := *schema.Type
+	outerTruncated := m.truncated
+	m.truncated = false
+	m.depth++
+	tokens, err := m.buildType(schema)
+	m.depth--
+	if err != nil {
+		return nil, err
+	}
+	if m.truncated {
+		m.truncatedCache[key] = tokens
+	} else {
+		m.cache[schema] = tokens
+	}
+	m.truncated = m.truncated || outerTruncated
+	return cloneTokens(tokens), nil
+}
+
+func (m *typeMapper) buildType(schema *openapi3.Schema) (hclwrite.Tokens, error) {
+	types := openapi.GetEffectiveTypes(schema)
+	if types == nil {
+		return hclwrite.TokensForIdentifier("any"), nil
+	}
 
 	if slices.Contains(types, "string") {
 		return hclwrite.TokensForIdentifier("string"), nil
@@ -486,10 +969,45 @@ func mapType(schema *openapi3.Schema) (hclwrite.Tokens, error) {
 		return hclwrite.TokensForIdentifier("bool"), nil
 	}
 	if slices.Contains(types, "array") {
+		if items := openapi.TupleItems(schema); items != nil {
+			elemTypes := make([]hclwrite.Tokens, len(items))
+			for i, item := range items {
+				elemTypes[i] = hclwrite.TokensForIdentifier("any")
+				if item == nil || item.Value == nil {
+					continue
+				}
+				var err error
+				outerPath := m.path
+				m.path += fmt.Sprintf("[%d]", i)
+				elemTypes[i], err = m.mapType(item.Value)
+				m.path = outerPath
+				if err != nil {
+					return nil, err
+				}
+			}
+			return hclwrite.TokensForFunctionCall("tuple", hclwrite.TokensForTuple(elemTypes)), nil
+		}
+		if key, ok := identifierMapKey(schema); m.identifierMaps && ok {
+			valueSchema, err := identifierMapValueSchema(schema.Items.Value, key)
+			if err != nil {
+				return nil, fmt.Errorf("getting effective properties at %s[]: %w", m.path, err)
+			}
+			outerPath := m.path
+			m.path += "[]"
+			valueType, err := m.mapType(valueSchema)
+			m.path = outerPath
+			if err != nil {
+				return nil, err
+			}
+			return hclwrite.TokensForFunctionCall("map", valueType), nil
+		}
 		elemType := hclwrite.TokensForIdentifier("any")
 		if schema.Items != nil && schema.Items.Value != nil {
 			var err error
-			elemType, err = mapType(schema.Items.Value)
+			outerPath := m.path
+			m.path += "[]"
+			elemType, err = m.mapType(schema.Items.Value)
+			m.path = outerPath
 			if err != nil {
 				return nil, err
 			}
@@ -500,21 +1018,38 @@ func mapType(schema *openapi3.Schema) (hclwrite.Tokens, error) {
 		// Get effective properties and required for allOf handling
 		effectiveProps, err := openapi.GetEffectiveProperties(schema)
 		if err != nil {
-			return nil, fmt.Errorf("getting effective properties: %w", err)
+			return nil, fmt.Errorf("getting effective properties at %s: %w", m.path, err)
 		}
 		effectiveRequired, err := openapi.GetEffectiveRequired(schema)
 		if err != nil {
-			return nil, fmt.Errorf("getting effective required: %w", err)
+			return nil, fmt.Errorf("getting effective required at %s: %w", m.path, err)
 		}
 
+		// Explicit properties, of the schema or any allOf component, take precedence over
+		// additionalProperties: a Terraform object type has no catch-all attribute, so the schema
+		// is a map only when it declares no properties at all.
 		if len(effectiveProps) == 0 {
-			if schema.AdditionalProperties.Schema != nil && schema.AdditionalProperties.Schema.Value != nil {
-				valueType, err := mapType(schema.AdditionalProperties.Schema.Value)
+			if additional := openapi.GetEffectiveAdditionalProperties(schema); additional != nil {
+				valueType, err := m.mapType(additional)
 				if err != nil {
 					return nil, err
 				}
 				return hclwrite.TokensForFunctionCall("map", valueType), nil
 			}
+			pattern, err := openapi.GetPatternProperties(schema)
+			if err != nil {
+				return nil, fmt.Errorf("getting pattern properties at %s: %w", m.path, err)
+			}
+			if pattern != nil {
+				valueType := hclwrite.TokensForIdentifier("any")
+				if pattern.Value != nil {
+					valueType, err = m.mapType(pattern.Value)
+					if err != nil {
+						return nil, err
+					}
+				}
+				return hclwrite.TokensForFunctionCall("map", valueType), nil
+			}
 			return hclwrite.TokensForFunctionCall("map", hclwrite.TokensForIdentifier("string")), nil
 		}
 		var attrs []hclwrite.ObjectAttrTokens
@@ -534,7 +1069,10 @@ func mapType(schema *openapi3.Schema) (hclwrite.Tokens, error) {
 			if !isWritableProperty(prop.Value) {
 				continue
 			}
-			fieldType, err := mapType(prop.Value)
+			outerPath := m.path
+			m.path = joinSchemaPath(m.path, k)
+			fieldType, err := m.mapType(prop.Value)
+			m.path = outerPath
 			if err != nil {
 				return nil, err
 			}
@@ -546,10 +1084,14 @@ func mapType(schema *openapi3.Schema) (hclwrite.Tokens, error) {
 			}
 
 			if isOptional {
-				fieldType = hclwrite.TokensForFunctionCall("optional", fieldType)
+				if defaultValue, ok := schemaDefaultTokens(prop.Value); ok {
+					fieldType = hclwrite.TokensForFunctionCall("optional", fieldType, defaultValue)
+				} else {
+					fieldType = hclwrite.TokensForFunctionCall("optional", fieldType)
+				}
 			}
 			attrs = append(attrs, hclwrite.ObjectAttrTokens{
-				Name:  hclwrite.TokensForIdentifier(naming.ToSnakeCase(k)),
+				Name:  hclwrite.TokensForIdentifier(objectAttributeName(m.namer, k)),
 				Value: fieldType,
 			})
 		}
@@ -559,8 +1101,101 @@ func mapType(schema *openapi3.Schema) (hclwrite.Tokens, error) {
 	return hclwrite.TokensForIdentifier("any"), nil
 }
 
-func buildNestedDescription(schema *openapi3.Schema, indent string) (string, error) {
+// joinSchemaPath appends a property name to a dotted schema path.
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// schemaDefaultTokens returns the schema's default as a Terraform literal for use as an
+// optional() object attribute default. Only scalar defaults matching the schema type are
+// supported; anything else is left to the API to default.
+func schemaDefaultTokens(schema *openapi3.Schema) (hclwrite.Tokens, bool) {
+	if schema == nil || schema.Default == nil || schema.Type == nil {
+		return nil, false
+	}
+	types := *schema.Type
+	switch def := schema.Default.(type) {
+	case string:
+		if slices.Contains(types, "string") {
+			return hclwrite.TokensForValue(cty.StringVal(def)), true
+		}
+	case float64:
+		if slices.Contains(types, "integer") || slices.Contains(types, "number") {
+			return hclwrite.TokensForValue(cty.NumberFloatVal(def)), true
+		}
+	case int:
+		if slices.Contains(types, "integer") || slices.Contains(types, "number") {
+			return hclwrite.TokensForValue(cty.NumberIntVal(int64(def))), true
+		}
+	case bool:
+		if slices.Contains(types, "boolean") {
+			return hclwrite.TokensForValue(cty.BoolVal(def)), true
+		}
+	}
+	return nil, false
+}
+
+func cloneTokens(tokens hclwrite.Tokens) hclwrite.Tokens {
+	out := make(hclwrite.Tokens, len(tokens))
+	for i, tok := range tokens {
+		clone := *tok
+		out[i] = &clone
+	}
+	return out
+}
+
+// HTML tag patterns stripped from spec descriptions by cleanDescription.
+var (
+	// htmlBreakTagPattern matches HTML tags that separate text, such as <br> or </li>.
+	htmlBreakTagPattern = regexp.MustCompile(`(?i)</?(?:br|p|div|ul|ol|li|tr|td|th|h[1-6])(?:\s[^<>]*)?/?>`)
+	// htmlInlineTagPattern matches inline HTML tags, such as <a href="..."> or <code>. Only
+	// known tags are matched, so placeholders like <resourceName> are left alone.
+	htmlInlineTagPattern = regexp.MustCompile(`(?i)</?(?:a|b|i|u|em|strong|code|pre|span|sup|sub|table|thead|tbody)(?:\s[^<>]*)?/?>`)
+)
+
+// cleanDescription returns a spec description as written to variables.tf. With stripHTML, HTML
+// tags are removed, entities such as &amp; are unescaped and runs of whitespace collapse to a
+// single space, as Azure descriptions are often written for a rendered HTML page. A positive
+// maxLength then truncates the description to at most that many characters (see
+// truncateDescription).
+func cleanDescription(description string, stripHTML bool, maxLength int) string {
+	if stripHTML {
+		description = htmlBreakTagPattern.ReplaceAllString(description, " ")
+		description = htmlInlineTagPattern.ReplaceAllString(description, "")
+		description = html.UnescapeString(description)
+		description = strings.Join(strings.Fields(description), " ")
+	}
+	return truncateDescription(description, maxLength)
+}
+
+// truncateDescription cuts description to at most maxLength characters, ending it with an
+// ellipsis. The cut is made at the last word boundary that fits, unless the first word alone is
+// too long. A maxLength of 0 or less leaves the description as is.
+func truncateDescription(description string, maxLength int) string {
+	runes := []rune(strings.TrimSpace(description))
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return description
+	}
+	const ellipsis = "…"
+	cut := runes[:max(maxLength-1, 0)]
+	if i := strings.LastIndexFunc(string(cut), unicode.IsSpace); i > 0 {
+		cut = []rune(string(cut)[:i])
+	}
+	return strings.TrimRightFunc(string(cut), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + ellipsis
+}
+
+// buildNestedDescription renders a markdown list of the writable nested fields of schema.
+// Fields nested more than depth levels deep are omitted.
+func buildNestedDescription(schema *openapi3.Schema, indent string, depth int, stripHTML bool, maxLength int, namer *naming.Namer) (string, error) {
 	var sb strings.Builder
+	if depth <= 0 {
+		return "", nil
+	}
 
 	// Get effective properties for allOf handling
 	effectiveProps, err := openapi.GetEffectiveProperties(schema)
@@ -574,7 +1209,7 @@ func buildNestedDescription(schema *openapi3.Schema, indent string) (string, err
 	}
 	var childKeys []keyPair
 	for k := range effectiveProps {
-		childKeys = append(childKeys, keyPair{original: k, snake: naming.ToSnakeCase(k)})
+		childKeys = append(childKeys, keyPair{original: k, snake: objectAttributeName(namer, k)})
 	}
 	sort.Slice(childKeys, func(i, j int) bool {
 		return childKeys[i].snake < childKeys[j].snake
@@ -592,7 +1227,11 @@ func buildNestedDescription(schema *openapi3.Schema, indent string) (string, err
 			continue
 		}
 
-		childDesc := val.Description
+		childDesc := cleanDescription(val.Description, stripHTML, maxLength)
+		val, _ = openapi.UnwrapNullable(val)
+		if childDesc == "" {
+			childDesc = cleanDescription(val.Description, stripHTML, maxLength)
+		}
 		if childDesc == "" {
 			childDesc = fmt.Sprintf("The %s property.", k)
 		}
@@ -613,7 +1252,7 @@ func buildNestedDescription(schema *openapi3.Schema, indent string) (string, err
 			return "", fmt.Errorf("getting effective properties for nested object: %w", err)
 		}
 		if isNested && len(nestedProps) > 0 {
-			nested, err := buildNestedDescription(val, indent+"  ")
+			nested, err := buildNestedDescription(val, indent+"  ", depth-1, stripHTML, maxLength, namer)
 			if err != nil {
 				return "", err
 			}
@@ -622,3 +1261,32 @@ func buildNestedDescription(schema *openapi3.Schema, indent string) (string, err
 	}
 	return sb.String(), nil
 }
+
+// parentIDPattern loosely matches an ARM resource ID: one scoped to a subscription, or to the
+// tenant through a provider such as Microsoft.Management/managementGroups.
+const parentIDPattern = "^/(subscriptions|providers)/.+"
+
+// userAssignedIdentityIDPattern loosely matches the ARM resource ID of a user-assigned identity.
+const userAssignedIdentityIDPattern = "^/subscriptions/.+/userAssignedIdentities/.+"
+
+// tokensForIdentityIDsCondition builds
+// `alltrue([for id in var.managed_identities.user_assigned_resource_ids : can(regex("...", id))])`.
+func tokensForIdentityIDsCondition() hclwrite.Tokens {
+	matches := hclwrite.TokensForFunctionCall(
+		"can",
+		hclwrite.TokensForFunctionCall("regex", hclwrite.TokensForValue(cty.StringVal(userAssignedIdentityIDPattern)), hclwrite.TokensForIdentifier("id")),
+	)
+
+	listComp := hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("id")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")},
+	}
+	listComp = append(listComp, hclgen.TokensForTraversal("var", "managed_identities", "user_assigned_resource_ids")...)
+	listComp = append(listComp, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	listComp = append(listComp, matches...)
+	listComp = append(listComp, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+	return hclwrite.TokensForFunctionCall("alltrue", listComp)
+}
@@ -1,6 +1,9 @@
 package terraform
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/matt-FFFFFF/tfmodmake/hclgen"
@@ -12,6 +15,116 @@ import (
 // These are standard variables that follow AVM patterns for customer_managed_key,
 // diagnostic_settings, private_endpoints, etc.
 
+// requiredAttr is an attribute of an object type constraint that callers must set.
+func requiredAttr(name string, typeTokens hclwrite.Tokens) hclwrite.ObjectAttrTokens {
+	return hclwrite.ObjectAttrTokens{Name: hclwrite.TokensForIdentifier(name), Value: typeTokens}
+}
+
+// optionalAttr is an optional attribute of an object type constraint, optional(type, default),
+// or optional(type) when defaultTokens is nil.
+func optionalAttr(name string, typeTokens, defaultTokens hclwrite.Tokens) hclwrite.ObjectAttrTokens {
+	if defaultTokens == nil {
+		return hclwrite.ObjectAttrTokens{Name: hclwrite.TokensForIdentifier(name), Value: hclwrite.TokensForFunctionCall("optional", typeTokens)}
+	}
+	return hclwrite.ObjectAttrTokens{Name: hclwrite.TokensForIdentifier(name), Value: hclwrite.TokensForFunctionCall("optional", typeTokens, defaultTokens)}
+}
+
+// objectType builds the type constraint object({...}) with attrs.
+func objectType(attrs ...hclwrite.ObjectAttrTokens) hclwrite.Tokens {
+	return hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject(attrs))
+}
+
+// collectionType builds the type constraint kind(element), e.g. map(string).
+func collectionType(kind string, element hclwrite.Tokens) hclwrite.Tokens {
+	return hclwrite.TokensForFunctionCall(kind, element)
+}
+
+// customerManagedKeyType is the type of the AVM customer_managed_key variable.
+func customerManagedKeyType() hclwrite.Tokens {
+	id := hclwrite.TokensForIdentifier
+	return objectType(
+		requiredAttr("key_vault_resource_id", id("string")),
+		requiredAttr("key_name", id("string")),
+		optionalAttr("key_version", id("string"), id("null")),
+		optionalAttr("user_assigned_identity", objectType(
+			requiredAttr("resource_id", id("string")),
+		), id("null")),
+	)
+}
+
+// managedIdentitiesType is the type of the AVM managed_identities variable.
+func managedIdentitiesType() hclwrite.Tokens {
+	id := hclwrite.TokensForIdentifier
+	return objectType(
+		optionalAttr("system_assigned", id("bool"), id("false")),
+		optionalAttr("user_assigned_resource_ids", collectionType("set", id("string")), hclwrite.TokensForValue(cty.ListValEmpty(cty.String))),
+	)
+}
+
+// diagnosticSettingsType is the type of the AVM diagnostic_settings variable. Each setting
+// collects all logs and metrics by default.
+func diagnosticSettingsType() hclwrite.Tokens {
+	id := hclwrite.TokensForIdentifier
+	return collectionType("map", objectType(
+		optionalAttr("name", id("string"), id("null")),
+		optionalAttr("log_categories", collectionType("set", id("string")), hclwrite.TokensForValue(cty.ListValEmpty(cty.String))),
+		optionalAttr("log_groups", collectionType("set", id("string")), hclwrite.TokensForValue(cty.ListVal([]cty.Value{cty.StringVal("allLogs")}))),
+		optionalAttr("metric_categories", collectionType("set", id("string")), hclwrite.TokensForValue(cty.ListVal([]cty.Value{cty.StringVal("AllMetrics")}))),
+		optionalAttr("log_analytics_destination_type", id("string"), hclwrite.TokensForValue(cty.StringVal("Dedicated"))),
+		optionalAttr("workspace_resource_id", id("string"), id("null")),
+		optionalAttr("storage_account_resource_id", id("string"), id("null")),
+		optionalAttr("event_hub_authorization_rule_resource_id", id("string"), id("null")),
+		optionalAttr("event_hub_name", id("string"), id("null")),
+		optionalAttr("marketplace_partner_resource_id", id("string"), id("null")),
+	))
+}
+
+// privateEndpointsType is the type of the AVM private_endpoints variable.
+func privateEndpointsType() hclwrite.Tokens {
+	id := hclwrite.TokensForIdentifier
+	return collectionType("map", objectType(
+		optionalAttr("name", id("string"), id("null")),
+		optionalAttr("role_assignments", collectionType("map", objectType(
+			requiredAttr("role_definition_id_or_name", id("string")),
+			requiredAttr("principal_id", id("string")),
+			optionalAttr("description", id("string"), id("null")),
+			optionalAttr("skip_service_principal_aad_check", id("bool"), id("false")),
+			optionalAttr("condition", id("string"), id("null")),
+			optionalAttr("condition_version", id("string"), id("null")),
+			optionalAttr("delegated_managed_identity_resource_id", id("string"), id("null")),
+			optionalAttr("principal_type", id("string"), id("null")),
+		)), hclwrite.TokensForObject(nil)),
+		optionalAttr("lock", objectType(
+			requiredAttr("kind", id("string")),
+			optionalAttr("name", id("string"), id("null")),
+		), id("null")),
+		optionalAttr("tags", collectionType("map", id("string")), id("null")),
+		requiredAttr("subnet_resource_id", id("string")),
+		optionalAttr("subresource_name", id("string"), id("null")),
+		optionalAttr("private_dns_zone_group_name", id("string"), hclwrite.TokensForValue(cty.StringVal("default"))),
+		optionalAttr("private_dns_zone_resource_ids", collectionType("set", id("string")), hclwrite.TokensForValue(cty.ListValEmpty(cty.String))),
+		optionalAttr("application_security_group_associations", collectionType("map", id("string")), hclwrite.TokensForValue(cty.MapValEmpty(cty.String))),
+		optionalAttr("private_service_connection_name", id("string"), nil),
+		optionalAttr("network_interface_name", id("string"), nil),
+		optionalAttr("location", id("string"), nil),
+		optionalAttr("resource_group_name", id("string"), nil),
+		optionalAttr("ip_configurations", collectionType("map", objectType(
+			requiredAttr("name", id("string")),
+			requiredAttr("private_ip_address", id("string")),
+		)), hclwrite.TokensForObject(nil)),
+	))
+}
+
+// timeoutsType is the type of the timeouts variable, each operation defaulting to
+// defaultLongRunningTimeout.
+func timeoutsType() hclwrite.Tokens {
+	var attrs []hclwrite.ObjectAttrTokens
+	for _, operation := range []string{"create", "update", "delete"} {
+		attrs = append(attrs, optionalAttr(operation, hclwrite.TokensForIdentifier("string"), hclwrite.TokensForValue(cty.StringVal(defaultLongRunningTimeout))))
+	}
+	return objectType(attrs...)
+}
+
 // emitCustomerManagedKeyVar generates the customer_managed_key variable if supported.
 func emitCustomerManagedKeyVar(body *hclwrite.Body, caps openapi.InterfaceCapabilities, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body, appendTFLintIgnoreUnused func()) {
 	if !caps.SupportsCustomerManagedKey {
@@ -22,30 +135,13 @@ func emitCustomerManagedKeyVar(body *hclwrite.Body, caps openapi.InterfaceCapabi
 	cmkBody := appendVariable(
 		"customer_managed_key",
 		"A map describing customer-managed keys to associate with the resource.",
-		hclwrite.TokensForFunctionCall(
-			"object",
-			hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-				{Name: hclwrite.TokensForIdentifier("key_vault_resource_id"), Value: hclwrite.TokensForIdentifier("string")},
-				{Name: hclwrite.TokensForIdentifier("key_name"), Value: hclwrite.TokensForIdentifier("string")},
-				{Name: hclwrite.TokensForIdentifier("key_version"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-				{Name: hclwrite.TokensForIdentifier("user_assigned_identity"), Value: hclwrite.TokensForFunctionCall(
-					"optional",
-					hclwrite.TokensForFunctionCall(
-						"object",
-						hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-							{Name: hclwrite.TokensForIdentifier("resource_id"), Value: hclwrite.TokensForIdentifier("string")},
-						}),
-					),
-					hclwrite.TokensForIdentifier("null"),
-				)},
-			}),
-		),
+		customerManagedKeyType(),
 	)
 	cmkBody.SetAttributeRaw("default", hclwrite.TokensForIdentifier("null"))
 	body.AppendNewline()
 }
 
-// emitEnableTelemetryVar generates the enable_telemetry variable (always included for AVM compliance).
+// emitEnableTelemetryVar generates the enable_telemetry variable required for AVM compliance.
 func emitEnableTelemetryVar(body *hclwrite.Body, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
 	telemetryBody := appendVariable(
 		"enable_telemetry",
@@ -57,6 +153,23 @@ func emitEnableTelemetryVar(body *hclwrite.Body, appendVariable func(string, str
 	body.AppendNewline()
 }
 
+// defaultLongRunningTimeout is the default timeout of each operation of a resource with a
+// long-running PUT, twice the azapi provider's own 30 minutes.
+const defaultLongRunningTimeout = "60m"
+
+// emitTimeoutsVar generates the timeouts variable setting the timeouts block of a resource
+// with a long-running PUT.
+func emitTimeoutsVar(body *hclwrite.Body, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
+	timeoutsBody := appendVariable(
+		"timeouts",
+		fmt.Sprintf("The timeouts of creating, updating and deleting the resource, as durations such as `30m` or `2h`. The service runs these operations asynchronously, so each defaults to %s.", defaultLongRunningTimeout),
+		timeoutsType(),
+	)
+	timeoutsBody.SetAttributeRaw("default", hclwrite.TokensForObject(nil))
+	timeoutsBody.SetAttributeValue("nullable", cty.False)
+	body.AppendNewline()
+}
+
 // emitDiagnosticSettingsVar generates the diagnostic_settings variable with validations if supported.
 func emitDiagnosticSettingsVar(body *hclwrite.Body, caps openapi.InterfaceCapabilities, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
 	if !caps.SupportsDiagnostics {
@@ -66,24 +179,15 @@ func emitDiagnosticSettingsVar(body *hclwrite.Body, caps openapi.InterfaceCapabi
 	diagBody := appendVariable(
 		"diagnostic_settings",
 		"A map of diagnostic settings to create on the resource.",
-		hclwrite.TokensForFunctionCall("map", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-			{Name: hclwrite.TokensForIdentifier("name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			{Name: hclwrite.TokensForIdentifier("log_categories"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.ListValEmpty(cty.String)))},
-			{Name: hclwrite.TokensForIdentifier("log_groups"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.ListVal([]cty.Value{cty.StringVal("allLogs")})))},
-			{Name: hclwrite.TokensForIdentifier("metric_categories"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.ListVal([]cty.Value{cty.StringVal("AllMetrics")})))},
-			{Name: hclwrite.TokensForIdentifier("log_analytics_destination_type"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForValue(cty.StringVal("Dedicated")))},
-			{Name: hclwrite.TokensForIdentifier("workspace_resource_id"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			{Name: hclwrite.TokensForIdentifier("storage_account_resource_id"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			{Name: hclwrite.TokensForIdentifier("event_hub_authorization_rule_resource_id"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			{Name: hclwrite.TokensForIdentifier("event_hub_name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			{Name: hclwrite.TokensForIdentifier("marketplace_partner_resource_id"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-		}))),
+		diagnosticSettingsType(),
 	)
 	diagBody.SetAttributeValue("default", cty.MapValEmpty(cty.DynamicPseudoType))
 	diagBody.SetAttributeValue("nullable", cty.False)
 
 	// Add validations
 	addDiagnosticSettingsValidations(diagBody)
+	addDiagnosticCategoryValidation(diagBody, "log_categories", caps.DiagnosticLogCategories)
+	addDiagnosticCategoryValidation(diagBody, "log_groups", caps.DiagnosticLogGroups)
 	body.AppendNewline()
 }
 
@@ -160,6 +264,54 @@ func addDiagnosticSettingsValidations(diagBody *hclwrite.Body) {
 	}
 }
 
+// addDiagnosticCategoryValidation adds a validation that every entry of the field of each
+// diagnostic setting, e.g. log_categories, is one of allowed. Nothing is added when the allowed
+// set is unknown.
+func addDiagnosticCategoryValidation(diagBody *hclwrite.Body, field string, allowed []string) {
+	if len(allowed) == 0 {
+		return
+	}
+	values := make([]cty.Value, 0, len(allowed))
+	quoted := make([]string, 0, len(allowed))
+	for _, value := range allowed {
+		values = append(values, cty.StringVal(value))
+		quoted = append(quoted, "'"+value+"'")
+	}
+	containsCall := hclwrite.TokensForFunctionCall(
+		"contains",
+		hclwrite.TokensForValue(cty.ListVal(values)),
+		hclwrite.TokensForIdentifier("c"),
+	)
+
+	// alltrue([for c in v.<field> : contains([...], c)])
+	innerComp := hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("c")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")},
+	}
+	innerComp = append(innerComp, hclgen.TokensForTraversal("v", field)...)
+	innerComp = append(innerComp, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	innerComp = append(innerComp, containsCall...)
+	innerComp = append(innerComp, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+	// alltrue([for _, v in var.diagnostic_settings : alltrue([...])])
+	listComp := hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("_")},
+		&hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("v")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")},
+	}
+	listComp = append(listComp, hclgen.TokensForTraversal("var", "diagnostic_settings")...)
+	listComp = append(listComp, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	listComp = append(listComp, hclwrite.TokensForFunctionCall("alltrue", innerComp)...)
+	listComp = append(listComp, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+	appendValidation(diagBody, hclwrite.TokensForFunctionCall("alltrue", listComp), fmt.Sprintf("Each of `%s` must be one of: %s.", field, strings.Join(quoted, ", ")))
+}
+
 // emitPrivateEndpointsVars generates both private_endpoints and private_endpoints_manage_dns_zone_group variables if supported.
 func emitPrivateEndpointsVars(body *hclwrite.Body, caps openapi.InterfaceCapabilities, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
 	if !caps.SupportsPrivateEndpoints {
@@ -169,37 +321,7 @@ func emitPrivateEndpointsVars(body *hclwrite.Body, caps openapi.InterfaceCapabil
 	peBody := appendVariable(
 		"private_endpoints",
 		"A map of private endpoints to create on this resource.",
-		hclwrite.TokensForFunctionCall("map", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-			{Name: hclwrite.TokensForIdentifier("name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			{Name: hclwrite.TokensForIdentifier("role_assignments"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("map", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-				{Name: hclwrite.TokensForIdentifier("role_definition_id_or_name"), Value: hclwrite.TokensForIdentifier("string")},
-				{Name: hclwrite.TokensForIdentifier("principal_id"), Value: hclwrite.TokensForIdentifier("string")},
-				{Name: hclwrite.TokensForIdentifier("description"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-				{Name: hclwrite.TokensForIdentifier("skip_service_principal_aad_check"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("bool"), hclwrite.TokensForIdentifier("false"))},
-				{Name: hclwrite.TokensForIdentifier("condition"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-				{Name: hclwrite.TokensForIdentifier("condition_version"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-				{Name: hclwrite.TokensForIdentifier("delegated_managed_identity_resource_id"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-				{Name: hclwrite.TokensForIdentifier("principal_type"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			}))), hclwrite.TokensForObject(nil))},
-			{Name: hclwrite.TokensForIdentifier("lock"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-				{Name: hclwrite.TokensForIdentifier("kind"), Value: hclwrite.TokensForIdentifier("string")},
-				{Name: hclwrite.TokensForIdentifier("name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			})), hclwrite.TokensForIdentifier("null"))},
-			{Name: hclwrite.TokensForIdentifier("tags"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("map", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForIdentifier("null"))},
-			{Name: hclwrite.TokensForIdentifier("subnet_resource_id"), Value: hclwrite.TokensForIdentifier("string")},
-			{Name: hclwrite.TokensForIdentifier("subresource_name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			{Name: hclwrite.TokensForIdentifier("private_dns_zone_group_name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForValue(cty.StringVal("default")))},
-			{Name: hclwrite.TokensForIdentifier("private_dns_zone_resource_ids"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.ListValEmpty(cty.String)))},
-			{Name: hclwrite.TokensForIdentifier("application_security_group_associations"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("map", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.MapValEmpty(cty.String)))},
-			{Name: hclwrite.TokensForIdentifier("private_service_connection_name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"))},
-			{Name: hclwrite.TokensForIdentifier("network_interface_name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"))},
-			{Name: hclwrite.TokensForIdentifier("location"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"))},
-			{Name: hclwrite.TokensForIdentifier("resource_group_name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"))},
-			{Name: hclwrite.TokensForIdentifier("ip_configurations"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("map", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-				{Name: hclwrite.TokensForIdentifier("name"), Value: hclwrite.TokensForIdentifier("string")},
-				{Name: hclwrite.TokensForIdentifier("private_ip_address"), Value: hclwrite.TokensForIdentifier("string")},
-			}))), hclwrite.TokensForObject(nil))},
-		}))),
+		privateEndpointsType(),
 	)
 	peBody.SetAttributeValue("default", cty.MapValEmpty(cty.DynamicPseudoType))
 	peBody.SetAttributeValue("nullable", cty.False)
@@ -0,0 +1,140 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// parseTypeConstraint parses tokens as a variable type constraint, returning the type and the
+// defaults of its optional attributes.
+func parseTypeConstraint(t *testing.T, tokens hclwrite.Tokens) (cty.Type, *typeexpr.Defaults) {
+	t.Helper()
+	src := hclwrite.Format(tokens.Bytes())
+	expr, diags := hclsyntax.ParseExpression(src, "type.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	typ, defaults, diags := typeexpr.TypeConstraintWithDefaults(expr)
+	require.False(t, diags.HasErrors(), diags.Error())
+	return typ, defaults
+}
+
+// defaultValues renders the default of each optional attribute of defaults as HCL.
+func defaultValues(defaults *typeexpr.Defaults) map[string]string {
+	values := make(map[string]string, len(defaults.DefaultValues))
+	for name, value := range defaults.DefaultValues {
+		values[name] = string(hclwrite.TokensForValue(value).Bytes())
+	}
+	return values
+}
+
+func TestCustomerManagedKeyType(t *testing.T) {
+	typ, defaults := parseTypeConstraint(t, customerManagedKeyType())
+
+	require.True(t, typ.IsObjectType())
+	assert.False(t, typ.AttributeOptional("key_vault_resource_id"))
+	assert.False(t, typ.AttributeOptional("key_name"))
+	assert.True(t, typ.AttributeOptional("key_version"))
+	assert.True(t, typ.AttributeOptional("user_assigned_identity"))
+	assert.False(t, typ.AttributeType("user_assigned_identity").AttributeOptional("resource_id"))
+	require.NotNil(t, defaults)
+	assert.Equal(t, map[string]string{
+		"key_version":            "null",
+		"user_assigned_identity": "null",
+	}, defaultValues(defaults))
+}
+
+func TestManagedIdentitiesType(t *testing.T) {
+	typ, defaults := parseTypeConstraint(t, managedIdentitiesType())
+
+	require.True(t, typ.IsObjectType())
+	assert.Equal(t, cty.Bool, typ.AttributeType("system_assigned"))
+	assert.Equal(t, cty.Set(cty.String), typ.AttributeType("user_assigned_resource_ids"))
+	require.NotNil(t, defaults)
+	assert.Equal(t, map[string]string{
+		"system_assigned":            "false",
+		"user_assigned_resource_ids": "[]",
+	}, defaultValues(defaults))
+}
+
+func TestDiagnosticSettingsType(t *testing.T) {
+	typ, defaults := parseTypeConstraint(t, diagnosticSettingsType())
+
+	require.True(t, typ.IsMapType())
+	setting := typ.ElementType()
+	require.True(t, setting.IsObjectType())
+	for name := range setting.AttributeTypes() {
+		assert.True(t, setting.AttributeOptional(name), "%s should be optional", name)
+	}
+
+	require.NotNil(t, defaults)
+	require.Contains(t, defaults.Children, "")
+	assert.Equal(t, map[string]string{
+		"name":                                     "null",
+		"log_categories":                           "[]",
+		"log_groups":                               `["allLogs"]`,
+		"metric_categories":                        `["AllMetrics"]`,
+		"log_analytics_destination_type":           `"Dedicated"`,
+		"workspace_resource_id":                    "null",
+		"storage_account_resource_id":              "null",
+		"event_hub_authorization_rule_resource_id": "null",
+		"event_hub_name":                           "null",
+		"marketplace_partner_resource_id":          "null",
+	}, defaultValues(defaults.Children[""]))
+}
+
+func TestPrivateEndpointsType(t *testing.T) {
+	typ, defaults := parseTypeConstraint(t, privateEndpointsType())
+
+	require.True(t, typ.IsMapType())
+	endpoint := typ.ElementType()
+	require.True(t, endpoint.IsObjectType())
+	assert.False(t, endpoint.AttributeOptional("subnet_resource_id"))
+	assert.True(t, endpoint.AttributeOptional("private_dns_zone_group_name"))
+	assert.False(t, endpoint.AttributeType("role_assignments").ElementType().AttributeOptional("principal_id"))
+	assert.False(t, endpoint.AttributeType("lock").AttributeOptional("kind"))
+	assert.False(t, endpoint.AttributeType("ip_configurations").ElementType().AttributeOptional("private_ip_address"))
+
+	require.NotNil(t, defaults)
+	require.Contains(t, defaults.Children, "")
+	endpointDefaults := defaults.Children[""]
+	assert.Equal(t, map[string]string{
+		"name":                          "null",
+		"role_assignments":              "{}",
+		"lock":                          "null",
+		"tags":                          "null",
+		"subresource_name":              "null",
+		"private_dns_zone_group_name":   `"default"`,
+		"private_dns_zone_resource_ids": "[]",
+		"application_security_group_associations": "{}",
+		"ip_configurations":                       "{}",
+	}, defaultValues(endpointDefaults))
+
+	// Role assignments skip the AAD check unless asked to.
+	require.Contains(t, endpointDefaults.Children, "role_assignments")
+	require.Contains(t, endpointDefaults.Children["role_assignments"].Children, "")
+	assert.Equal(t, map[string]string{
+		"description":                            "null",
+		"skip_service_principal_aad_check":       "false",
+		"condition":                              "null",
+		"condition_version":                      "null",
+		"delegated_managed_identity_resource_id": "null",
+		"principal_type":                         "null",
+	}, defaultValues(endpointDefaults.Children["role_assignments"].Children[""]))
+}
+
+func TestTimeoutsType(t *testing.T) {
+	typ, defaults := parseTypeConstraint(t, timeoutsType())
+
+	require.True(t, typ.IsObjectType())
+	require.NotNil(t, defaults)
+	for _, operation := range []string{"create", "update", "delete"} {
+		assert.True(t, typ.AttributeOptional(operation))
+		assert.Equal(t, `"`+defaultLongRunningTimeout+`"`, defaultValues(defaults)[operation])
+	}
+}
@@ -3,8 +3,16 @@ package terraform
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
 	"github.com/matt-FFFFFF/tfmodmake/openapi"
 )
 
@@ -12,15 +20,214 @@ import (
 type GeneratorOption func(*generatorOptions)
 
 type generatorOptions struct {
-	schema           *openapi3.Schema
-	resourceType     string
-	localName        string
+	schema       *openapi3.Schema
+	resourceType string
+	localName    string
+	// resourceName is the name label of the main resource block; empty means "this".
+	resourceName string
+	// multi creates a resource per entry of a var.instances map instead of a single one.
+	multi            bool
 	apiVersion       string
 	supportsTags     bool
 	supportsLocation bool
 	spec             *openapi3.T
 	moduleNamePrefix string
 	outputDir        string
+	backend          string
+	// resourceIDNameHeuristic treats string fields named *ResourceId as ARM resource IDs.
+	resourceIDNameHeuristic bool
+	// stripDescriptionHTML removes HTML tags and entities from spec descriptions.
+	stripDescriptionHTML bool
+	// descriptionMaxLength, when positive, truncates spec descriptions to that many characters.
+	descriptionMaxLength int
+	// commentSource annotates each generated variable with its originating OpenAPI path.
+	commentSource bool
+	// preserveUnknownExtensions lists the unhandled x-ms-* extensions of each variable's schema
+	// in a comment above it.
+	preserveUnknownExtensions bool
+	// actions scaffolds an azapi_resource_action per POST action of the resource.
+	actions bool
+	// preflight generates a check block validating a deployment of the resource body.
+	preflight bool
+	// commentGenerated starts each .tf file with a banner naming the tool and resource.
+	commentGenerated bool
+	// telemetry controls whether the AVM enable_telemetry variable is generated.
+	telemetry bool
+	// schemaValidation leaves azapi's schema validation of the body enabled; when false the
+	// resource sets schema_validation_enabled = false.
+	schemaValidation bool
+	// ignoreNullProperty sets ignore_null_property = true so null body fields are not sent.
+	ignoreNullProperty bool
+	// strictOutputs replaces the try() fallback on computed outputs with a precondition.
+	strictOutputs bool
+	// timeouts adds a timeouts block and variable to resources with a long-running PUT.
+	timeouts bool
+	// resourceGroupOutputs adds resource_group_name and resource_group_id outputs.
+	resourceGroupOutputs bool
+	// skipFiles names generated files that are built but not written.
+	skipFiles []string
+	// disambiguateCollisions suffixes colliding variable names with _2, _3, ... instead of failing.
+	disambiguateCollisions bool
+	// identifierMaps types arrays keyed by a single x-ms-identifiers property as maps.
+	identifierMaps bool
+	// defaultTags are merged into var.tags through local.tags when the resource supports tags.
+	defaultTags map[string]string
+	// namer converts property names to Terraform names; nil uses naming.ToSnakeCase as is.
+	namer *naming.Namer
+	// dataPlane generates an azapi_data_plane_resource module instead of an ARM azapi_resource one.
+	dataPlane bool
+	// endpoint is the default data-plane endpoint host used as the resource parent.
+	endpoint string
+	// metadata enables metadata.json, seeded with metadataTitle and metadataDescription.
+	metadata            bool
+	metadataTitle       string
+	metadataDescription string
+	// maxDepth limits how deeply nested schemas are expanded; deeper levels degrade to any.
+	maxDepth int
+	// providerSource is the registry address of the azapi provider in required_providers.
+	providerSource string
+	// requiredVersion and providerVersion are the Terraform and azapi version constraints.
+	requiredVersion string
+	providerVersion string
+	// localsStyle is LocalsStyleFlat or LocalsStyleNested.
+	localsStyle string
+	// inlineSmallObjects keeps a properties bag with fewer writable properties than this inline
+	// under the flat locals style; 0 always flattens.
+	inlineSmallObjects int
+	// sortVariables is the order of the body variables (SortVariables*).
+	sortVariables string
+	// nameValidationSource selects where var.name validations come from (NameValidationSource*).
+	nameValidationSource string
+	// rangeValidations adds ordering validations between paired min/max numeric variables.
+	rangeValidations bool
+	// compactValidations merges each variable's validation blocks into one.
+	compactValidations bool
+	// openEnumValidation validates open enums (x-ms-enum modelAsString: true) like closed ones.
+	openEnumValidation bool
+	// errorMessagePrefix namespaces validation error messages as "[prefix] ...".
+	errorMessagePrefix string
+	// validateIdentityIDs validates the user-assigned identity IDs of managed_identities.
+	validateIdentityIDs bool
+	// parentIDValidation validates that parent_id looks like an ARM resource ID.
+	parentIDValidation bool
+	// intRangeChecks bounds int32 and int64 variables to the range of their format.
+	intRangeChecks bool
+	// failOnEmptyBody errors instead of generating a module with no body variables.
+	failOnEmptyBody bool
+	// validateAfter checks that each generated .tf file parses before it is written.
+	validateAfter bool
+	// asDataSource generates a module reading the resource through the azapi_resource data
+	// source instead of managing it.
+	asDataSource bool
+	// dropEmpty makes empty nested objects, maps and lists null in the body locals.
+	dropEmpty bool
+	// heuristicSecrets treats writable string properties named like secrets as secrets.
+	heuristicSecrets bool
+	// nestSecrets declares the secrets in a single variable nested as in the body.
+	nestSecrets bool
+	// requiredOnly limits the body variables and locals to required properties.
+	requiredOnly bool
+	// variableDefaultsFile, when set, is the name of a starter tfvars file to generate.
+	variableDefaultsFile string
+	// envPrefix, when set, annotates required variables with their environment variable name
+	// and lists them in .env.example.
+	envPrefix string
+	// logger receives debug-level notes on generation decisions and warnings, e.g. about a
+	// deprecated API or a read-only body; they are discarded by default.
+	logger *slog.Logger
+}
+
+// DefaultMaxDepth is the default limit on nested schema expansion. It keeps self-referential
+// schemas from recursing forever while leaving room for deeply nested real-world resources;
+// output grows exponentially with depth when a schema recurses through more than one property.
+const DefaultMaxDepth = 10
+
+// DefaultProviderSource is the public registry address of the azapi provider.
+const DefaultProviderSource = "azure/azapi"
+
+// DefaultRequiredVersion is the default Terraform version constraint of terraform.tf.
+const DefaultRequiredVersion = "~> 1.12"
+
+// DefaultProviderVersion is the default azapi provider version constraint of terraform.tf.
+const DefaultProviderVersion = "~> 2.7"
+
+// Locals styles control how the top-level "properties" bag of the request body is exposed.
+const (
+	// LocalsStyleFlat generates one variable per property and rebuilds the bag in locals.
+	LocalsStyleFlat = "flat"
+	// LocalsStyleNested generates a single properties object variable.
+	LocalsStyleNested = "nested"
+)
+
+// Variable orders control the order of the body variables in variables.tf.
+const (
+	// SortVariablesAlpha orders variables alphabetically by property name.
+	SortVariablesAlpha = "alpha"
+	// SortVariablesSpec orders variables as their properties are declared in the spec, where
+	// the loaded spec records it, and alphabetically otherwise.
+	SortVariablesSpec = "spec"
+	// SortVariablesRequiredFirst orders required variables ahead of optional ones, each
+	// alphabetically.
+	SortVariablesRequiredFirst = "required-first"
+)
+
+// Name validation sources select which schema constrains var.name.
+const (
+	// NameValidationSourcePath uses the resource name parameter of the instance path.
+	NameValidationSourcePath = "path"
+	// NameValidationSourceBody uses the name field of the request body (properties.name, else name).
+	NameValidationSourceBody = "body"
+	// NameValidationSourceBoth applies the most restrictive constraints of the two.
+	NameValidationSourceBoth = "both"
+)
+
+// resourceBlockName returns the name label of the module's main resource block.
+func (o *generatorOptions) resourceBlockName() string {
+	if o.resourceName == "" {
+		return "this"
+	}
+	return o.resourceName
+}
+
+// resourceBlockType returns the azapi resource type used for the module's main resource.
+func (o *generatorOptions) resourceBlockType() string {
+	if o.dataPlane {
+		return "azapi_data_plane_resource"
+	}
+	return "azapi_resource"
+}
+
+// resourceGroupOutputsEnabled reports whether the resource group outputs are generated: they
+// are requested and the parent_id of the resource is a resource group.
+func (o *generatorOptions) resourceGroupOutputsEnabled() bool {
+	return o.resourceGroupOutputs && !o.dataPlane && deployedToResourceGroup(o.resourceType)
+}
+
+// flattenProperties reports whether the top-level "properties" bag gets a variable per
+// property: the locals style is flat and the bag has at least inlineSmallObjects writable
+// properties.
+func (o *generatorOptions) flattenProperties() bool {
+	if o.localsStyle != LocalsStyleFlat {
+		return false
+	}
+	if o.inlineSmallObjects <= 0 || o.schema == nil {
+		return true
+	}
+	props, err := openapi.GetEffectiveProperties(o.schema)
+	if err != nil || props["properties"] == nil || props["properties"].Value == nil {
+		return true
+	}
+	childProps, err := openapi.GetEffectiveProperties(props["properties"].Value)
+	if err != nil {
+		return true
+	}
+	writable := 0
+	for _, child := range childProps {
+		if child != nil && isWritableProperty(child.Value) {
+			writable++
+		}
+	}
+	return writable >= o.inlineSmallObjects
 }
 
 // WithSchema sets the OpenAPI schema for the resource.
@@ -37,6 +244,25 @@ func WithLocalName(name string) GeneratorOption {
 	}
 }
 
+// WithResourceName sets the name label of the module's main resource block, which defaults to
+// "this", e.g. to compose several resources in one directory. The outputs, and the actions of
+// WithActions, refer to the resource by this name. It must be a valid Terraform identifier.
+func WithResourceName(name string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.resourceName = name
+	}
+}
+
+// WithMulti sets whether the module creates a resource per entry of var.instances, a map of
+// objects holding the per-resource inputs, instead of a single resource. Locals built from those
+// inputs and the outputs become maps keyed by instance, and secret inputs, which must stay
+// ephemeral, become maps keyed by instance alongside var.instances.
+func WithMulti(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.multi = enabled
+	}
+}
+
 // WithAPIVersion sets the API version for the resource.
 func WithAPIVersion(version string) GeneratorOption {
 	return func(o *generatorOptions) {
@@ -79,6 +305,444 @@ func WithOutputDir(dir string) GeneratorOption {
 	}
 }
 
+// WithBackend adds a commented-out backend stub of the given type (azurerm, s3, or local)
+// to the terraform block in terraform.tf.
+func WithBackend(backend string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.backend = backend
+	}
+}
+
+// WithResourceIDNameHeuristic sets whether string fields whose names end in "ResourceId" are
+// treated as ARM resource IDs, in addition to fields marked with x-ms-azure-resource.
+func WithResourceIDNameHeuristic(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.resourceIDNameHeuristic = enabled
+	}
+}
+
+// WithStripDescriptionHTML sets whether HTML is cleaned out of the spec descriptions used for
+// variables and their nested field docs: tags such as <br> and <a> are removed, entities such
+// as &amp; are unescaped and whitespace is collapsed.
+func WithStripDescriptionHTML(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.stripDescriptionHTML = enabled
+	}
+}
+
+// WithDescriptionMaxLength truncates the spec descriptions used for variables and their nested
+// field docs to at most maxLength characters, cutting at a word boundary and ending with an
+// ellipsis. Truncation applies after WithStripDescriptionHTML. Zero, the default, leaves
+// descriptions whole.
+func WithDescriptionMaxLength(maxLength int) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.descriptionMaxLength = maxLength
+	}
+}
+
+// WithCommentSource sets whether each generated variable is preceded by a "# source:" comment
+// naming the OpenAPI property path it was generated from.
+func WithCommentSource(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.commentSource = enabled
+	}
+}
+
+// WithPreserveUnknownExtensions sets whether each variable generated from a property is
+// preceded by a comment per x-ms-* extension of its schema the generator doesn't act on, such
+// as x-ms-mutability or x-ms-identifiers, with its value. This surfaces spec semantics the
+// module may need to handle by hand.
+func WithPreserveUnknownExtensions(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.preserveUnknownExtensions = enabled
+	}
+}
+
+// WithActions sets whether main.actions.tf is generated with an azapi_resource_action for each
+// POST action the spec declares on the resource, such as regenerateKey, invoked on
+// azapi_resource.this once created. Each action is off unless its <action>_enabled variable is
+// set, and actions taking a request body get an <action>_body variable. Data-plane resources
+// have no ARM actions, so the option is ignored for them.
+func WithActions(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.actions = enabled
+	}
+}
+
+// WithPreflight sets whether main.preflight.tf is generated with a check block that validates a
+// template deployment of the resource body to the resource group in parent_id through ARM's
+// deployment validate action on every plan, so that quota, policy and other preflight failures
+// show as warnings before apply. Only top-level ARM resource types are deployed to a resource
+// group, so the option is ignored for child and data-plane resources.
+func WithPreflight(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.preflight = enabled
+	}
+}
+
+// WithCommentGenerated sets whether each generated .tf file starts with a comment banner
+// marking it as generated by tfmodmake from the resource type and API version, so reviewers
+// know not to edit it by hand.
+func WithCommentGenerated(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.commentGenerated = enabled
+	}
+}
+
+// WithTelemetry sets whether the AVM enable_telemetry variable is generated. It is enabled by default;
+// disable it for internal, non-AVM modules.
+func WithTelemetry(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.telemetry = enabled
+	}
+}
+
+// WithSchemaValidation sets whether azapi validates the resource body against its bundled schema.
+// It is enabled by default; disable it for preview resource types missing from, or out of date
+// in, the provider's schemas.
+func WithSchemaValidation(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.schemaValidation = enabled
+	}
+}
+
+// WithIgnoreNullProperty sets whether the resource sets ignore_null_property = true, so azapi
+// drops null fields from the body rather than sending them as explicit nulls. It is disabled
+// by default, and the body locals then filter null attributes out of each object instead.
+// GenerateLocalsFile builds the same locals when given the option.
+func WithIgnoreNullProperty(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.ignoreNullProperty = enabled
+	}
+}
+
+// WithStrictOutputs sets whether computed outputs read the response attribute directly, with
+// a precondition that fails when it is missing, instead of falling back to an empty value with
+// try(). This surfaces drift between the spec and the API rather than hiding it.
+func WithStrictOutputs(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.strictOutputs = enabled
+	}
+}
+
+// WithTimeouts sets whether resources whose PUT operation is marked with
+// x-ms-long-running-operation get a timeouts block for create, update and delete, set from a
+// timeouts variable defaulting each to defaultLongRunningTimeout. It is enabled by default;
+// resources without the marker are unaffected either way.
+func WithTimeouts(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.timeouts = enabled
+	}
+}
+
+// WithResourceGroupOutputs sets whether outputs.tf gets resource_group_name and
+// resource_group_id outputs, derived from the parent_id of the resource. Only top-level ARM
+// resource types are deployed to a resource group, so the option is ignored for child and
+// data-plane resources.
+func WithResourceGroupOutputs(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.resourceGroupOutputs = enabled
+	}
+}
+
+// WithDisambiguateCollisions sets whether writable properties whose variable names collide,
+// such as ipAddress and IPAddress which both become ip_address, are told apart by suffixing the
+// later one, in sorted property order, with _2 (then _3, ...) rather than failing generation. A
+// comment above each renamed variable names the property it sets. GenerateLocalsFile computes
+// the same renames when given the option.
+func WithDisambiguateCollisions(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.disambiguateCollisions = enabled
+	}
+}
+
+// WithIdentifierMaps sets whether arrays of objects whose items are identified by a single
+// property through x-ms-identifiers become maps keyed by that property, which suits for_each
+// better than a list. locals.tf rebuilds the list the API expects from the map.
+func WithIdentifierMaps(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.identifierMaps = enabled
+	}
+}
+
+// WithSkipFiles leaves the named files, such as outputs.tf or terraform.tf, out of the
+// module for repos that manage them separately. The files are still built, so generation fails
+// the same way it would without the option. References into a skipped file, such as
+// local.resource_body from main.tf, are left for the caller to satisfy.
+func WithSkipFiles(filenames ...string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.skipFiles = append(o.skipFiles, filenames...)
+	}
+}
+
+// WithDefaultTags merges module-injected tags, such as managed-by = "tfmodmake", into var.tags
+// as local.tags, which the resource then uses. The defaults win over caller tags with the same key.
+// The option has no effect on resources that don't support tags.
+func WithDefaultTags(tags map[string]string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.defaultTags = tags
+	}
+}
+
+// WithAcronyms adds domain acronyms, such as AKS or VNet, that are kept together as one word
+// when property names are converted to snake_case, so vNetId becomes vnet_id rather than
+// v_net_id. Use the same acronyms whenever a module's files are regenerated.
+func WithAcronyms(acronyms []string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.namer = naming.NewNamer(acronyms...)
+	}
+}
+
+// WithDataPlane sets whether to generate a module for a data-plane (non-ARM) resource.
+// Data-plane modules use azapi_data_plane_resource with a var.endpoint parent and skip
+// ARM-specific scaffolding such as location, tags, managed identity and AVM interfaces.
+func WithDataPlane(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.dataPlane = enabled
+	}
+}
+
+// WithEndpoint sets the default data-plane endpoint host (e.g. myvault.vault.azure.net)
+// for data-plane modules.
+func WithEndpoint(endpoint string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithMaxDepth sets how many levels of nested schema are expanded into types, locals and
+// descriptions. Deeper levels are typed as any and passed through unchanged.
+func WithMaxDepth(depth int) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.maxDepth = depth
+	}
+}
+
+// WithProviderSource overrides the azapi provider source address in required_providers, for
+// example to use a mirror such as "registry.internal/azure/azapi".
+func WithProviderSource(source string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.providerSource = source
+	}
+}
+
+// WithRequiredVersion overrides the Terraform version constraint, required_version, of
+// terraform.tf.
+func WithRequiredVersion(constraint string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.requiredVersion = constraint
+	}
+}
+
+// WithProviderVersion overrides the azapi provider version constraint in required_providers.
+func WithProviderVersion(constraint string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.providerVersion = constraint
+	}
+}
+
+// WithLocalsStyle sets how the top-level "properties" bag is exposed: LocalsStyleFlat (the
+// default) generates a variable per property, LocalsStyleNested a single var.properties object.
+func WithLocalsStyle(style string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.localsStyle = style
+	}
+}
+
+// WithInlineSmallObjects keeps the top-level "properties" bag as a single var.properties object,
+// as with LocalsStyleNested, when it has fewer than n writable properties; larger bags are
+// flattened as usual. 0 (the default) always flattens.
+func WithInlineSmallObjects(n int) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.inlineSmallObjects = n
+	}
+}
+
+// WithSortVariables sets the order of the body variables: SortVariablesAlpha (the default),
+// SortVariablesSpec or SortVariablesRequiredFirst. The order applies to the top-level properties
+// and, separately, to those of the flattened properties bag.
+func WithSortVariables(order string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.sortVariables = order
+	}
+}
+
+// WithNameValidationSource sets where var.name validations are sourced from: the path
+// parameter (NameValidationSourcePath, the default), the body name field, or both.
+func WithNameValidationSource(source string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.nameValidationSource = source
+	}
+}
+
+// WithRangeValidations sets whether sibling numeric properties named as a min/max pair (e.g.
+// minReplicas and maxReplicas) get a validation requiring the minimum not to exceed the maximum.
+// Pairs are detected by name only, so it is off by default.
+func WithRangeValidations(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.rangeValidations = enabled
+	}
+}
+
+// WithCompactValidations sets whether a variable with several validations, e.g. an enum and a
+// length, gets a single validation block instead, whose condition ANDs theirs and whose error
+// message joins theirs. It cuts down the size of variables.tf at the cost of error messages
+// listing every constraint rather than the one that failed.
+func WithCompactValidations(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.compactValidations = enabled
+	}
+}
+
+// WithOpenEnumValidation sets whether open enums, whose x-ms-enum sets modelAsString: true, get
+// an enum validation. Their values are extensible, new ones coming with service releases
+// without a new API version, so by default only closed enums are validated.
+func WithOpenEnumValidation(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.openEnumValidation = enabled
+	}
+}
+
+// WithErrorMessagePrefix starts the error message of every variable validation with
+// "[prefix] ", e.g. "[storage] sku must be one of: ...", so failures of a module embedded in a
+// larger configuration name it. An empty prefix leaves the messages as they are.
+func WithErrorMessagePrefix(prefix string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.errorMessagePrefix = prefix
+	}
+}
+
+// WithValidateIdentityIDs sets whether the managed_identities variable gets a validation that
+// each of its user_assigned_resource_ids looks like the ARM resource ID of a user-assigned
+// identity, catching e.g. a client ID passed by mistake at plan time rather than at apply.
+func WithValidateIdentityIDs(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.validateIdentityIDs = enabled
+	}
+}
+
+// WithParentIDValidation sets whether the parent_id variable gets a validation that it looks
+// like an ARM resource ID, starting with /subscriptions/ or /providers/, so that wiring a name
+// or a bare GUID into a child module fails at plan time.
+func WithParentIDValidation(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.parentIDValidation = enabled
+	}
+}
+
+// WithIntRangeChecks sets whether integer fields with format int32 or int64 get a validation
+// keeping them within the range of that format.
+func WithIntRangeChecks(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.intRangeChecks = enabled
+	}
+}
+
+// WithValidateAfter sets whether each generated .tf file is checked to parse as HCL once
+// assembled, failing generation before any file is written when one does not. Files are always
+// formatted as terraform fmt would leave them, so only parsing is checked.
+func WithValidateAfter(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.validateAfter = enabled
+	}
+}
+
+// WithAsDataSource sets whether to generate a module that reads an existing resource through the
+// azapi_resource data source instead of one managing it: variables.tf with name and parent_id,
+// main.tf with the data source exporting the computed values of the response, outputs.tf and
+// terraform.tf. It suits fully computed resources, whose request body is entirely read-only and
+// for which a module managing them would have nothing to set, and makes a read-only companion
+// to a module managing the resource.
+func WithAsDataSource(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.asDataSource = enabled
+	}
+}
+
+// WithFailOnEmptyBody sets whether generation fails when the request body has no writable
+// properties, leaving the module with only name, parent_id and the standard scaffolding.
+func WithFailOnEmptyBody(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.failOnEmptyBody = enabled
+	}
+}
+
+// WithDropEmpty sets whether the body locals collapse empty nested values to null: maps and
+// lists with no elements, and objects whose attributes are all null. Even with
+// ignore_null_property, azapi sends these as {} or [], which some APIs treat differently from
+// an omitted property.
+func WithDropEmpty(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.dropEmpty = enabled
+	}
+}
+
+// WithHeuristicSecrets sets whether writable string properties whose name contains password,
+// secret, key, token or connectionString are treated as secrets, like those marked with
+// x-ms-secret, as a safety net for specs that forgot the marker.
+func WithHeuristicSecrets(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.heuristicSecrets = enabled
+	}
+}
+
+// WithNestedSecrets sets whether the secrets of the body, rather than each being extracted to
+// an ephemeral variable of its own, are declared together in a single ephemeral
+// sensitive_body object variable nesting them as the body does, which is sent as the
+// sensitive_body of the resource as is.
+func WithNestedSecrets(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.nestSecrets = enabled
+	}
+}
+
+// WithRequiredOnly sets whether only required body properties, along with the children of the
+// properties bag it requires, are generated as variables and referenced by the body locals.
+// Optional properties are left to the API to default, giving a minimal module surface.
+func WithRequiredOnly(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.requiredOnly = enabled
+	}
+}
+
+// WithVariableDefaultsFile generates a starter tfvars file with the given name, typically
+// DefaultVariableDefaultsFile, assigning each variable its default, or an empty value of its
+// type when it is required.
+func WithVariableDefaultsFile(filename string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.variableDefaultsFile = filename
+	}
+}
+
+// WithEnvPrefix annotates each required variable (one without a default) with a comment naming
+// the environment variable expected to set it, prefix followed by the variable name, and lists
+// them in a generated .env.example. Terraform itself reads the TF_VAR_ prefix.
+func WithEnvPrefix(prefix string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithLogger sets the logger that receives debug-level notes on generation decisions: which
+// files are written, which AVM interfaces are enabled and why properties are skipped. It also
+// receives warnings, e.g. when the spec marks the resource's API deprecated or its request body
+// is entirely read-only.
+func WithLogger(logger *slog.Logger) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.logger = logger
+	}
+}
+
+// WithMetadata enables generation of a metadata.json module manifest with the given title
+// and description, alongside the resource type and API version.
+func WithMetadata(title, description string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.metadata = true
+		o.metadataTitle = title
+		o.metadataDescription = description
+	}
+}
+
 // WithLoadResult sets multiple options from a ResourceLoadResult.
 func WithLoadResult(result *ResourceLoadResult) GeneratorOption {
 	return func(o *generatorOptions) {
@@ -90,62 +754,369 @@ func WithLoadResult(result *ResourceLoadResult) GeneratorOption {
 	}
 }
 
-// Generate generates variables.tf, locals.tf, main.tf, and outputs.tf based on the schema.
-func Generate(resourceType string, opts ...GeneratorOption) error {
+// newGeneratorOptions returns the generator options for resourceType with the defaults of
+// every Generate* entry point, then opts applied.
+func newGeneratorOptions(resourceType string, opts ...GeneratorOption) *generatorOptions {
 	o := &generatorOptions{
-		resourceType: resourceType,
-		outputDir:    ".",
-		localName:    "resource_body",
+		resourceType:         resourceType,
+		outputDir:            ".",
+		localName:            "resource_body",
+		telemetry:            true,
+		schemaValidation:     true,
+		timeouts:             true,
+		maxDepth:             DefaultMaxDepth,
+		providerSource:       DefaultProviderSource,
+		requiredVersion:      DefaultRequiredVersion,
+		providerVersion:      DefaultProviderVersion,
+		localsStyle:          LocalsStyleFlat,
+		sortVariables:        SortVariablesAlpha,
+		nameValidationSource: NameValidationSourcePath,
+		logger:               slog.New(slog.DiscardHandler),
 	}
 	for _, opt := range opts {
 		opt(o)
 	}
+	return o
+}
+
+// Generate generates variables.tf, locals.tf, main.tf, and outputs.tf based on the schema.
+func Generate(resourceType string, opts ...GeneratorOption) error {
+	o := newGeneratorOptions(resourceType, opts...)
+	write := dirWriter(o.outputDir)
+	if !o.validateAfter {
+		return generateWithOpts(o, write)
+	}
+
+	// Every file is checked before any is written, so a failing check leaves the output
+	// directory as it was rather than with the files generated before the failing one.
+	type stagedFile struct {
+		filename string
+		content  []byte
+	}
+	var staged []stagedFile
+	if err := generateWithOpts(o, func(filename string, content []byte) error {
+		staged = append(staged, stagedFile{filename, content})
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, file := range staged {
+		if err := write(file.filename, file.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateFiles generates the same files as Generate but returns their contents keyed by
+// file name (e.g. "variables.tf") instead of writing them to disk. WithOutputDir is ignored.
+func GenerateFiles(resourceType string, opts ...GeneratorOption) (map[string][]byte, error) {
+	o := newGeneratorOptions(resourceType, opts...)
+
+	files := make(map[string][]byte)
+	write := formatWriter(func(filename string, content []byte) error {
+		files[filename] = content
+		return nil
+	})
+	if err := generateWithOpts(o, write); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// GenerateProviderRequirements writes only terraform.tf, the Terraform and azapi provider
+// requirements, to bootstrap a new module directory. Of the generator options, only
+// WithOutputDir, WithBackend, WithProviderSource, WithRequiredVersion and WithProviderVersion
+// apply.
+func GenerateProviderRequirements(opts ...GeneratorOption) error {
+	o := newGeneratorOptions("", opts...)
+
+	return generateTerraform(o.backend, o.providerSource, o.requiredVersion, o.providerVersion, dirWriter(o.outputDir))
+}
+
+// fileWriter receives the contents of each generated file by name.
+type fileWriter func(filename string, content []byte) error
 
-	return generateWithOpts(o)
+// dirWriter returns a fileWriter that writes files into outputDir, formatted by formatWriter.
+func dirWriter(outputDir string) fileWriter {
+	return formatWriter(func(filename string, content []byte) error {
+		return os.WriteFile(filepath.Join(outputDir, filename), content, 0o644)
+	})
 }
 
-func generateWithOpts(o *generatorOptions) error {
+// formatWriter returns a fileWriter passing each .tf file through hclwrite.Format before write.
+// Files are assembled from raw tokens in places, and the pass leaves them as terraform fmt would.
+func formatWriter(write fileWriter) fileWriter {
+	return func(filename string, content []byte) error {
+		if strings.HasSuffix(filename, ".tf") {
+			content = hclwrite.Format(content)
+		}
+		return write(filename, content)
+	}
+}
+
+func generateWithOpts(o *generatorOptions, write fileWriter) error {
+	if o.localsStyle != LocalsStyleFlat && o.localsStyle != LocalsStyleNested {
+		return fmt.Errorf("unsupported locals style %q: expected flat or nested", o.localsStyle)
+	}
+	if o.inlineSmallObjects < 0 {
+		return fmt.Errorf("inline small objects threshold must not be negative, got %d", o.inlineSmallObjects)
+	}
+	switch o.sortVariables {
+	case SortVariablesAlpha, SortVariablesSpec, SortVariablesRequiredFirst:
+	default:
+		return fmt.Errorf("unsupported variable order %q: expected alpha, spec or required-first", o.sortVariables)
+	}
+	switch o.nameValidationSource {
+	case NameValidationSourcePath, NameValidationSourceBody, NameValidationSourceBoth:
+	default:
+		return fmt.Errorf("unsupported name validation source %q: expected path, body or both", o.nameValidationSource)
+	}
+	if !hclsyntax.ValidIdentifier(o.resourceBlockName()) {
+		return fmt.Errorf("invalid resource name %q: expected a Terraform identifier", o.resourceName)
+	}
+	if o.multi && o.actions {
+		return fmt.Errorf("actions are not supported with multiple instances")
+	}
+	if o.multi && o.preflight {
+		return fmt.Errorf("preflight is not supported with multiple instances")
+	}
+	if o.multi && o.envPrefix != "" {
+		return fmt.Errorf("environment variable annotations are not supported with multiple instances")
+	}
+	if o.asDataSource && o.dataPlane {
+		return fmt.Errorf("data source modules are not supported for data-plane resources")
+	}
+	if o.asDataSource && o.multi {
+		return fmt.Errorf("data source modules are not supported with multiple instances")
+	}
+	if !o.asDataSource && isReadOnlyBody(o.schema) {
+		o.logger.Warn("the request body of " + o.resourceType + " is entirely read-only, so the module has nothing to set; generate it as a data source to read the resource instead")
+	}
+
 	hasSchema := o.schema != nil
 	supportsIdentity := SupportsIdentity(o.schema)
 
 	// Detect interface capabilities from spec
 	var caps openapi.InterfaceCapabilities
 	var nameSchema *openapi3.Schema
-	if o.spec != nil {
+	if o.dataPlane {
+		// Data-plane resources have no ARM instance path, so ARM capability detection and
+		// name parameter lookup don't apply, and neither do location, tags or identity.
+		o.supportsTags = false
+		o.supportsLocation = false
+		supportsIdentity = false
+	} else if o.spec != nil {
 		caps = openapi.DetectInterfaceCapabilities(o.spec, o.resourceType)
 		nameSchema, _ = openapi.FindResourceNameSchema(o.spec, o.resourceType)
 	}
+	nameSchema, err := nameValidationSchema(o.nameValidationSource, nameSchema, o.schema)
+	if err != nil {
+		return err
+	}
+	logCapabilities(o, caps, supportsIdentity)
+
+	if hasSchema && o.requiredOnly {
+		o.schema, err = pruneOptionalProperties(o.schema)
+		if err != nil {
+			return err
+		}
+	}
+	if hasSchema && o.heuristicSecrets {
+		o.schema, err = markHeuristicSecrets(o.schema, o.maxDepth)
+		if err != nil {
+			return err
+		}
+	}
+
+	generated := write
+	var variablesSrc []byte
+	write = func(filename string, content []byte) error {
+		if filename == "variables.tf" {
+			variablesSrc = content
+		}
+		if slices.Contains(o.skipFiles, filename) {
+			o.logger.Debug("skipped file", "file", filename)
+			return nil
+		}
+		if strings.HasSuffix(filename, ".tf") {
+			if o.commentGenerated {
+				content = append(generatedBanner(o.resourceType, o.apiVersion), content...)
+			}
+			// The check runs before the file is written, and Generate holds back every file
+			// until all of them pass, so a file that doesn't parse is never left on disk.
+			if o.validateAfter {
+				if err := checkParses(filename, content); err != nil {
+					return fmt.Errorf("checking generated files: %w", err)
+				}
+			}
+		}
+		if err := generated(filename, content); err != nil {
+			return err
+		}
+		o.logger.Debug("generated file", "file", filename)
+		return nil
+	}
+
+	if o.asDataSource {
+		return generateDataSource(o, nameSchema, write)
+	}
 
-	// Collect secret fields from schema
+	// A multi-instance module is rewritten from the single-instance files, so those are held
+	// back until all of them are generated.
+	flush := write
+	pending := make(map[string][]byte)
+	if o.multi {
+		write = func(filename string, content []byte) error {
+			if slices.Contains(multiInstanceFiles, filename) {
+				pending[filename] = content
+				return nil
+			}
+			return flush(filename, content)
+		}
+	}
+
+	// Collect secret fields from schema. azapi_data_plane_resource has no sensitive_body,
+	// so data-plane secrets stay in the regular body.
 	var secrets []secretField
-	if hasSchema {
+	if hasSchema && !o.dataPlane {
 		var err error
-		secrets, err = collectSecretFields(o.schema, "")
+		secrets, err = collectSecretFields(o.schema, "", o.maxDepth, o.namer)
 		if err != nil {
 			return fmt.Errorf("collecting secret fields: %w", err)
 		}
 	}
 
-	if err := generateTerraform(o.outputDir); err != nil {
+	var requestParams []requestParameterVariable
+	if o.spec != nil && !o.dataPlane {
+		requestParams = newRequestParameterVariables(openapi.FindResourceRequiredParameters(o.spec, o.resourceType), o.namer)
+	}
+
+	if o.spec != nil && openapi.IsDeprecatedResource(o.spec, o.resourceType) {
+		o.logger.Warn("generating against a deprecated API: the spec marks the operations on "+o.resourceType+" deprecated", "api_version", o.apiVersion)
+	}
+
+	longRunning := o.timeouts && o.spec != nil && !o.dataPlane && openapi.IsLongRunningResource(o.spec, o.resourceType)
+	if longRunning {
+		o.logger.Debug("timeouts enabled", "reason", "PUT is a long-running operation")
+	}
+
+	var actions []openapi.ChildAction
+	if o.actions && o.spec != nil && !o.dataPlane {
+		actions = openapi.FindResourceActions(o.spec, o.resourceType)
+		if len(actions) == 0 {
+			o.logger.Debug("actions disabled", "reason", "spec has no POST actions on the resource")
+		}
+	}
+
+	// Variables are generated first as they reject unusable schemas, e.g. with
+	// WithFailOnEmptyBody, before any other file is written.
+	renamed := make(map[string]string)
+	if err := generateVariables(o, supportsIdentity, longRunning, secrets, nameSchema, caps, actions, requestParams, renamed, write); err != nil {
 		return err
 	}
-	if err := generateVariables(o.schema, o.supportsTags, o.supportsLocation, supportsIdentity, secrets, nameSchema, caps, o.moduleNamePrefix, o.outputDir); err != nil {
+	if err := generateTerraform(o.backend, o.providerSource, o.requiredVersion, o.providerVersion, write); err != nil {
 		return err
 	}
-	if hasSchema {
-		if err := generateLocals(o.schema, o.localName, supportsIdentity, secrets, o.resourceType, caps, o.moduleNamePrefix, o.outputDir); err != nil {
-			return err
-		}
+	// local.tags lives in locals.tf, which is only generated alongside a body.
+	if !hasSchema || !o.supportsTags {
+		o.defaultTags = nil
+	}
+	if err := generateLocals(o, supportsIdentity, secrets, caps, renamed, write); err != nil {
+		return err
 	}
-	if err := generateMain(o.schema, o.resourceType, o.apiVersion, o.localName, o.supportsTags, o.supportsLocation, supportsIdentity, hasSchema, secrets, o.outputDir); err != nil {
+	if err := generateMain(o, supportsIdentity, longRunning, secrets, requestParams, write); err != nil {
 		return err
 	}
-	if err := generateOutputs(o.schema, o.outputDir); err != nil {
+	if o.resourceGroupOutputs && !o.resourceGroupOutputsEnabled() {
+		o.logger.Debug("resource group outputs disabled", "reason", "parent_id is not a resource group")
+	}
+	if err := generateOutputs(o.schema, o.resourceBlockType(), o.resourceBlockName(), o.strictOutputs, o.resourceGroupOutputsEnabled(), o.namer, write); err != nil {
 		return err
 	}
+	if len(actions) > 0 {
+		if err := generateActions(actions, o.resourceType, o.apiVersion, o.resourceBlockName(), o.namer, write); err != nil {
+			return err
+		}
+	}
+	if o.preflight {
+		switch {
+		case o.dataPlane:
+			o.logger.Debug("preflight disabled", "reason", "data-plane resource")
+		case !deployedToResourceGroup(o.resourceType):
+			o.logger.Debug("preflight disabled", "reason", "child resources are not deployed to a resource group")
+		default:
+			if err := generatePreflight(o.resourceType, o.apiVersion, o.localName, hasSchema, o.supportsTags, o.supportsLocation, o.defaultTags, write); err != nil {
+				return err
+			}
+		}
+	}
+	if o.multi {
+		if err := toMultiInstance(pending, o.resourceBlockType(), o.resourceBlockName(), o.errorMessagePrefix, secretVariables(secrets, o.nestSecrets)); err != nil {
+			return err
+		}
+		for _, filename := range multiInstanceFiles {
+			if content, ok := pending[filename]; ok {
+				if err := flush(filename, content); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if o.variableDefaultsFile != "" {
+		if err := generateVariableDefaults(variablesSrc, o.variableDefaultsFile, write); err != nil {
+			return err
+		}
+	}
+	if o.metadata {
+		if err := generateMetadata(o, write); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// generatedBanner returns the comment WithCommentGenerated puts at the top of each .tf file.
+func generatedBanner(resourceType, apiVersion string) []byte {
+	resource := cleanTypeString(resourceType)
+	if apiVersion = strings.TrimSpace(apiVersion); apiVersion != "" {
+		resource += "@" + apiVersion
+	}
+	return []byte(fmt.Sprintf("# Generated by tfmodmake; do not edit directly.\n# Resource: %s\n\n", resource))
+}
+
+// logCapabilities records which standard variables and AVM interfaces the module gets, and why.
+func logCapabilities(o *generatorOptions, caps openapi.InterfaceCapabilities, supportsIdentity bool) {
+	logger := o.logger
+	if o.dataPlane {
+		logger.Debug("location, tags, managed identity and AVM interfaces disabled", "reason", "data-plane resource")
+		return
+	}
+	capability := func(name string, enabled bool, found, missing string) {
+		if enabled {
+			logger.Debug(name+" enabled", "reason", found)
+		} else {
+			logger.Debug(name+" disabled", "reason", missing)
+		}
+	}
+	capability("location", o.supportsLocation,
+		"body schema has a writable location property", "body schema has no writable location property")
+	capability("tags", o.supportsTags,
+		"body schema has a writable tags property", "body schema has no writable tags property")
+	capability("managed identity", supportsIdentity,
+		"body schema has a writable identity.type or identity.userAssignedIdentities", "body schema has no writable identity.type or identity.userAssignedIdentities")
+	if o.spec == nil {
+		logger.Debug("AVM interfaces disabled", "reason", "no spec to detect capabilities from")
+		return
+	}
+	capability("private endpoints", caps.SupportsPrivateEndpoints,
+		"spec has privateEndpointConnections or privateLinkResources paths", "spec has no privateEndpointConnections or privateLinkResources paths")
+	capability("diagnostic settings", caps.SupportsDiagnostics,
+		"known diagnostics resource type or diagnosticSettings path in the spec", "not a known diagnostics resource type and no diagnosticSettings path in the spec")
+	capability("customer-managed key", caps.SupportsCustomerManagedKey,
+		"PUT body has a writable encryption object", "PUT body has no writable encryption object")
+}
+
 // GenerateInterfacesFile generates main.interfaces.tf with AVM interfaces module wiring.
 // This function can be called separately to opt-in to AVM interfaces scaffolding.
 func GenerateInterfacesFile(resourceType string, spec *openapi3.T, outputDir string) error {
@@ -2,15 +2,24 @@ package terraform
 
 import (
 	"bytes"
+	"encoding/json"
+	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/openapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zclconf/go-cty/cty"
@@ -44,6 +53,976 @@ func TestToSnakeCase(t *testing.T) {
 	}
 }
 
+func TestNamer_ToSnakeCase(t *testing.T) {
+	namer := naming.NewNamer("VNet", "NSG")
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"vNetId", "vnet_id"},
+		{"subnetVNetId", "subnet_vnet_id"},
+		{"VNetPeering", "vnet_peering"},
+		{"VNetwork", "v_network"},
+		{"convnet", "convnet"},
+		{"nsgRules", "nsg_rules"},
+		{"HTTPClient", "http_client"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, namer.ToSnakeCase(tt.input))
+		})
+	}
+
+	var unset *naming.Namer
+	assert.Equal(t, "v_net_id", unset.ToSnakeCase("vNetId"))
+
+	acronyms, err := naming.ReadAcronyms(strings.NewReader("# network\nVNet\n\n  NSG  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"VNet", "NSG"}, acronyms)
+}
+
+func TestGenerate_WithAcronyms(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"vNetId": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					"network": {Value: &openapi3.Schema{
+						Type: &openapi3.Types{"object"},
+						Properties: map[string]*openapi3.SchemaRef{
+							"subnetVNetName": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+						},
+					}},
+				},
+			}},
+		},
+	}
+
+	files, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+	assert.Contains(t, string(files["variables.tf"]), `variable "v_net_id"`)
+
+	files, err = GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithAPIVersion("2024-01-01"), WithAcronyms([]string{"VNet"}))
+	require.NoError(t, err)
+	variables := string(files["variables.tf"])
+	assert.Contains(t, variables, `variable "vnet_id"`)
+	assert.Contains(t, variables, "subnet_vnet_name = optional(string)")
+	assert.NotContains(t, variables, "v_net")
+
+	locals := string(files["locals.tf"])
+	assert.Contains(t, locals, "var.vnet_id")
+	assert.Contains(t, locals, "var.network.subnet_vnet_name")
+	assert.Contains(t, locals, "vNetId")
+}
+
+func TestGenerate_WithIdentifierMaps(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"properties": {
+					"rules": {
+						"type": "array",
+						"uniqueItems": true,
+						"x-ms-identifiers": ["name"],
+						"items": {
+							"type": "object",
+							"required": ["name", "port"],
+							"properties": {
+								"name": {"type": "string"},
+								"port": {"type": "integer"}
+							}
+						}
+					},
+					"tags": {
+						"type": "array",
+						"x-ms-identifiers": [],
+						"items": {
+							"type": "object",
+							"properties": {"key": {"type": "string"}}
+						}
+					}
+				}
+			}
+		}
+	}`), &schema))
+
+	variableTypes := func(t *testing.T, files map[string][]byte) map[string]string {
+		t.Helper()
+		file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		types := make(map[string]string)
+		for _, name := range []string{"rules", "tags"} {
+			block := requireBlock(t, file.Body.(*hclsyntax.Body), "variable", name)
+			types[name] = strings.Join(strings.Fields(string(block.Body.Attributes["type"].Expr.Range().SliceBytes(files["variables.tf"]))), " ")
+		}
+		return types
+	}
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema))
+	require.NoError(t, err)
+	types := variableTypes(t, files)
+	assert.Equal(t, "list(object({ name = string port = number }))", types["rules"])
+	assert.Contains(t, string(files["variables.tf"]), "length(distinct([for x in var.rules : x.name])) == length(var.rules)")
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithIdentifierMaps(true))
+	require.NoError(t, err)
+	types = variableTypes(t, files)
+	assert.Equal(t, "map(object({ port = number }))", types["rules"])
+	// An empty x-ms-identifiers names no key, so the array stays a list.
+	assert.Equal(t, "list(object({ key = optional(string) }))", types["tags"])
+
+	variables := string(files["variables.tf"])
+	assert.Contains(t, variables, "Map values, keyed by `name`:")
+	assert.NotContains(t, variables, "distinct(var.rules)")
+
+	locals := strings.Join(strings.Fields(string(files["locals.tf"])), " ")
+	assert.Contains(t, locals, "rules = var.rules == null ? null : [for k, value in var.rules : merge({ name = k }, value == null ? null : { for k, v in { port = value.port } : k => v if v != null })]")
+}
+
+func TestGenerate_WithCommentGenerated(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {"type": "object", "properties": {"sku": {"type": "string"}}}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithAPIVersion("2025-01-01"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(files["main.tf"]), "Generated by tfmodmake")
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithAPIVersion("2025-01-01"), WithCommentGenerated(true))
+	require.NoError(t, err)
+	banner := "# Generated by tfmodmake; do not edit directly.\n# Resource: Microsoft.Test/widgets@2025-01-01\n\n"
+	for _, name := range []string{"main.tf", "variables.tf", "locals.tf", "outputs.tf", "terraform.tf"} {
+		require.Contains(t, files, name)
+		assert.True(t, strings.HasPrefix(string(files[name]), banner), "%s does not start with the banner:\n%s", name, files[name])
+		_, diags := hclsyntax.ParseConfig(files[name], name, hcl.InitialPos)
+		assert.False(t, diags.HasErrors(), "%s: %s", name, diags.Error())
+	}
+}
+
+func TestGenerate_WithResourceName(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"properties": {
+					"sku": {"type": "string"},
+					"provisioningState": {"type": "string", "readOnly": true}
+				}
+			}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithAPIVersion("2025-01-01"), WithResourceName("primary"))
+	require.NoError(t, err)
+
+	mainFile, diags := hclsyntax.ParseConfig(files["main.tf"], "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	requireBlock(t, mainFile.Body.(*hclsyntax.Body), "resource", "azapi_resource", "primary")
+
+	outputs := string(files["outputs.tf"])
+	assert.Contains(t, outputs, "azapi_resource.primary.id")
+	assert.Contains(t, outputs, "azapi_resource.primary.name")
+	assert.Contains(t, outputs, "azapi_resource.primary.output")
+	assert.NotContains(t, outputs, "azapi_resource.this")
+
+	_, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithResourceName("not-valid!"))
+	require.ErrorContains(t, err, `invalid resource name "not-valid!"`)
+}
+
+func TestGenerate_WithMulti(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"required": ["sku"],
+				"properties": {
+					"sku": {"type": "string", "enum": ["Basic", "Premium"]},
+					"adminPassword": {"type": "string", "x-ms-secret": true},
+					"provisioningState": {"type": "string", "readOnly": true}
+				}
+			}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithAPIVersion("2025-01-01"), WithMulti(true))
+	require.NoError(t, err)
+
+	parse := func(name string) *hclsyntax.Body {
+		t.Helper()
+		file, diags := hclsyntax.ParseConfig(files[name], name, hcl.InitialPos)
+		require.False(t, diags.HasErrors(), "%s: %s", name, diags.Error())
+		return file.Body.(*hclsyntax.Body)
+	}
+	expr := func(name string, attr *hclsyntax.Attribute) string {
+		t.Helper()
+		require.NotNil(t, attr)
+		return string(attr.Expr.Range().SliceBytes(files[name]))
+	}
+
+	variables := parse("variables.tf")
+	instances := requireBlock(t, variables, "variable", "instances")
+	assert.Equal(t, `map(object({
+    name      = string
+    parent_id = string
+    location  = string
+    sku       = string
+  }))`, expr("variables.tf", instances.Body.Attributes["type"]))
+	require.Len(t, instances.Body.Blocks, 1)
+	assert.Equal(t, `alltrue([for instance in var.instances : contains(["Basic", "Premium"], instance.sku)])`, expr("variables.tf", instances.Body.Blocks[0].Body.Attributes["condition"]))
+	for _, name := range []string{"name", "parent_id", "location", "sku"} {
+		assert.Nil(t, findBlock(variables, "variable", name), "variable %s should move into instances", name)
+	}
+	password := requireBlock(t, variables, "variable", "admin_password")
+	assert.Equal(t, "map(string)", expr("variables.tf", password.Body.Attributes["type"]))
+	requireBlock(t, variables, "variable", "enable_telemetry")
+
+	locals := requireBlock(t, parse("locals.tf"), "locals")
+	assert.True(t, strings.HasPrefix(expr("locals.tf", locals.Body.Attributes["resource_body"]), "{ for instance_key, instance in var.instances : instance_key => {"))
+
+	resource := requireBlock(t, parse("main.tf"), "resource", "azapi_resource", "this")
+	assert.Equal(t, "var.instances", expr("main.tf", resource.Body.Attributes["for_each"]))
+	assert.Equal(t, "each.value.name", expr("main.tf", resource.Body.Attributes["name"]))
+	assert.Equal(t, "local.resource_body[each.key]", expr("main.tf", resource.Body.Attributes["body"]))
+	assert.Contains(t, expr("main.tf", resource.Body.Attributes["sensitive_body"]), "lookup(var.admin_password, each.key, null)")
+
+	outputs := parse("outputs.tf")
+	resourceID := requireBlock(t, outputs, "output", "resource_id")
+	assert.Equal(t, "{ for instance_key, resource in azapi_resource.this : instance_key => resource.id }", expr("outputs.tf", resourceID.Body.Attributes["value"]))
+	assert.Equal(t, "The ID of the created resource, keyed by instance.", attributeStringValue(t, resourceID.Body.Attributes["description"]))
+	state := requireBlock(t, outputs, "output", "provisioning_state")
+	assert.Equal(t, "{ for instance_key, resource in azapi_resource.this : instance_key => try(resource.output.properties.provisioningState, null) }", expr("outputs.tf", state.Body.Attributes["value"]))
+
+	_, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithMulti(true), WithActions(true))
+	require.ErrorContains(t, err, "actions are not supported with multiple instances")
+}
+
+func TestGenerate_WithActions(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {"type": "object", "properties": {"sku": {"type": "string"}}}
+		}
+	}`), &schema))
+
+	const instancePath = "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}"
+	doc := &openapi3.T{Paths: openapi3.NewPaths()}
+	doc.Paths.Set(instancePath, &openapi3.PathItem{Put: &openapi3.Operation{}})
+	doc.Paths.Set(instancePath+"/regenerateKey", &openapi3.PathItem{Post: &openapi3.Operation{
+		RequestBody: &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithJSONSchema(openapi3.NewObjectSchema())},
+	}})
+	doc.Paths.Set(instancePath+"/restart", &openapi3.PathItem{Post: &openapi3.Operation{}})
+	// Actions on children belong to the child's module.
+	doc.Paths.Set(instancePath+"/gadgets/{gadgetName}/reset", &openapi3.PathItem{Post: &openapi3.Operation{}})
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSpec(doc), WithAPIVersion("2025-01-01"))
+	require.NoError(t, err)
+	assert.NotContains(t, files, "main.actions.tf")
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSpec(doc), WithAPIVersion("2025-01-01"), WithActions(true))
+	require.NoError(t, err)
+	require.Contains(t, files, "main.actions.tf")
+
+	actions, diags := hclsyntax.ParseConfig(files["main.actions.tf"], "main.actions.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	actionsBody := actions.Body.(*hclsyntax.Body)
+	require.Len(t, actionsBody.Blocks, 2)
+	expr := func(block *hclsyntax.Block, name string) string {
+		t.Helper()
+		require.Contains(t, block.Body.Attributes, name)
+		return string(block.Body.Attributes[name].Expr.Range().SliceBytes(files["main.actions.tf"]))
+	}
+
+	regenerate := requireBlock(t, actionsBody, "resource", "azapi_resource_action", "regenerate_key")
+	assert.Equal(t, "var.regenerate_key_enabled ? 1 : 0", expr(regenerate, "count"))
+	assert.Equal(t, `"Microsoft.Test/widgets@2025-01-01"`, expr(regenerate, "type"))
+	assert.Equal(t, "azapi_resource.this.id", expr(regenerate, "resource_id"))
+	assert.Equal(t, `"regenerateKey"`, expr(regenerate, "action"))
+	assert.Equal(t, `"POST"`, expr(regenerate, "method"))
+	assert.Equal(t, "var.regenerate_key_body", expr(regenerate, "body"))
+
+	restart := requireBlock(t, actionsBody, "resource", "azapi_resource_action", "restart")
+	assert.Equal(t, "azapi_resource.this.id", expr(restart, "resource_id"))
+	assert.NotContains(t, restart.Body.Attributes, "body")
+
+	variables, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	variablesBody := variables.Body.(*hclsyntax.Body)
+	requireBlock(t, variablesBody, "variable", "regenerate_key_enabled")
+	requireBlock(t, variablesBody, "variable", "regenerate_key_body")
+	requireBlock(t, variablesBody, "variable", "restart_enabled")
+	assert.NotContains(t, string(files["variables.tf"]), `variable "restart_body"`)
+	assert.NotContains(t, string(files["variables.tf"]), "reset")
+}
+
+func TestGenerate_WithPreflight(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {"type": "object", "properties": {"sku": {"type": "string"}}}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithAPIVersion("2025-01-01"))
+	require.NoError(t, err)
+	assert.NotContains(t, files, "main.preflight.tf")
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithAPIVersion("2025-01-01"), WithPreflight(true))
+	require.NoError(t, err)
+	require.Contains(t, files, "main.preflight.tf")
+	src := files["main.preflight.tf"]
+
+	preflight, diags := hclsyntax.ParseConfig(src, "main.preflight.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	check := requireBlock(t, preflight.Body.(*hclsyntax.Body), "check", "preflight")
+	data := requireBlock(t, check.Body, "data", "azapi_resource_action", "preflight")
+	expr := func(block *hclsyntax.Block, name string) string {
+		t.Helper()
+		require.Contains(t, block.Body.Attributes, name)
+		return string(block.Body.Attributes[name].Expr.Range().SliceBytes(src))
+	}
+	assert.Equal(t, `"Microsoft.Resources/deployments@2021-04-01"`, expr(data, "type"))
+	assert.Equal(t, `"${var.parent_id}/providers/Microsoft.Resources/deployments/${var.name}-preflight"`, expr(data, "resource_id"))
+	assert.Equal(t, `"validate"`, expr(data, "action"))
+	// The validated template deploys the same body as azapi_resource.this.
+	assert.Contains(t, expr(data, "body"), `resources = [merge(local.resource_body, {
+            type       = "Microsoft.Test/widgets"
+            apiVersion = "2025-01-01"
+            name       = var.name
+          })]`)
+	assert.Contains(t, expr(requireBlock(t, check.Body, "assert"), "condition"), "data.azapi_resource_action.preflight.output.properties.provisioningState")
+
+	t.Run("child resource", func(t *testing.T) {
+		files, err := GenerateFiles("Microsoft.Test/widgets/gadgets", WithSchema(&schema), WithPreflight(true))
+		require.NoError(t, err)
+		assert.NotContains(t, files, "main.preflight.tf")
+	})
+}
+
+func TestGenerate_RequiredRequestParameters(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {"type": "object", "properties": {"sku": {"type": "string"}}}
+		}
+	}`), &schema))
+
+	parameter := func(name, in string, required bool) *openapi3.ParameterRef {
+		return &openapi3.ParameterRef{Value: &openapi3.Parameter{Name: name, In: in, Required: required, Schema: openapi3.NewStringSchema().NewRef()}}
+	}
+	ifMatch := parameter("If-Match", "header", true)
+	ifMatch.Value.Schema.Value.MaxLength = openapi3.Uint64Ptr(64)
+
+	doc := &openapi3.T{Paths: openapi3.NewPaths()}
+	doc.Paths.Set("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}", &openapi3.PathItem{
+		Parameters: openapi3.Parameters{parameter("mode", "query", true)},
+		Put: &openapi3.Operation{Parameters: openapi3.Parameters{
+			parameter("api-version", "query", true),
+			ifMatch,
+			parameter("location", "header", true),
+			parameter("x-ms-client-request-id", "header", false),
+		}},
+	})
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSpec(doc))
+	require.NoError(t, err)
+
+	variables, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	variablesBody := variables.Body.(*hclsyntax.Body)
+	ifMatchVar := requireBlock(t, variablesBody, "variable", "if_match")
+	assert.NotContains(t, ifMatchVar.Body.Attributes, "default")
+	require.Len(t, ifMatchVar.Body.Blocks, 1)
+	assert.Contains(t, string(files["variables.tf"]), "length(var.if_match) <= 64")
+	// location is taken by the standard variable, so the header's variable is suffixed.
+	requireBlock(t, variablesBody, "variable", "location")
+	requireBlock(t, variablesBody, "variable", "location_header")
+	requireBlock(t, variablesBody, "variable", "mode")
+	assert.Nil(t, findBlock(variablesBody, "variable", "api_version"))
+	assert.Nil(t, findBlock(variablesBody, "variable", "x_ms_client_request_id"))
+
+	main, diags := hclsyntax.ParseConfig(files["main.tf"], "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	resource := requireBlock(t, main.Body.(*hclsyntax.Body), "resource", "azapi_resource", "this")
+	expr := func(name string) string {
+		t.Helper()
+		require.Contains(t, resource.Body.Attributes, name)
+		return string(resource.Body.Attributes[name].Expr.Range().SliceBytes(files["main.tf"]))
+	}
+	headers := "{\n    \"If-Match\" = var.if_match\n    \"location\" = var.location_header\n  }"
+	assert.Equal(t, headers, expr("create_headers"))
+	assert.Equal(t, headers, expr("update_headers"))
+	query := "{\n    \"mode\" = [var.mode]\n  }"
+	assert.Equal(t, query, expr("create_query_parameters"))
+	assert.Equal(t, query, expr("update_query_parameters"))
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema))
+	require.NoError(t, err)
+	assert.NotContains(t, string(files["main.tf"]), "create_headers")
+}
+
+func TestGenerate_LongRunningTimeouts(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {"type": "object", "properties": {"sku": {"type": "string"}}}
+		}
+	}`), &schema))
+
+	doc := &openapi3.T{Paths: openapi3.NewPaths()}
+	doc.Paths.Set("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}", &openapi3.PathItem{
+		Put: &openapi3.Operation{Extensions: map[string]any{"x-ms-long-running-operation": true}},
+	})
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSpec(doc))
+	require.NoError(t, err)
+
+	variables, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	timeouts := requireBlock(t, variables.Body.(*hclsyntax.Body), "variable", "timeouts")
+	attr := func(name string) string {
+		t.Helper()
+		require.Contains(t, timeouts.Body.Attributes, name)
+		return string(timeouts.Body.Attributes[name].Expr.Range().SliceBytes(files["variables.tf"]))
+	}
+	assert.Equal(t, `object({
+    create = optional(string, "60m")
+    update = optional(string, "60m")
+    delete = optional(string, "60m")
+  })`, attr("type"))
+	assert.Equal(t, "{}", attr("default"))
+	assert.Equal(t, "false", attr("nullable"))
+
+	main, diags := hclsyntax.ParseConfig(files["main.tf"], "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	resource := requireBlock(t, main.Body.(*hclsyntax.Body), "resource", "azapi_resource", "this")
+	block := requireBlock(t, resource.Body, "timeouts")
+	for _, operation := range []string{"create", "update", "delete"} {
+		require.Contains(t, block.Body.Attributes, operation)
+		assert.Equal(t, "var.timeouts."+operation, string(block.Body.Attributes[operation].Expr.Range().SliceBytes(files["main.tf"])))
+	}
+
+	t.Run("after secrets", func(t *testing.T) {
+		var schema openapi3.Schema
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"type": "object",
+			"properties": {
+				"properties": {"type": "object", "properties": {
+					"sku": {"type": "string"},
+					"password": {"type": "string", "x-ms-secret": true}
+				}}
+			}
+		}`), &schema))
+		files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSpec(doc))
+		require.NoError(t, err)
+		assert.Contains(t, string(files["variables.tf"]), `variable "timeouts"`)
+		assert.NotContains(t, string(files["variables.tf"]), "\n\n\n")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSpec(doc), WithTimeouts(false))
+		require.NoError(t, err)
+		assert.NotContains(t, string(files["variables.tf"]), `variable "timeouts"`)
+		assert.NotContains(t, string(files["main.tf"]), "timeouts")
+	})
+
+	t.Run("synchronous PUT", func(t *testing.T) {
+		doc := &openapi3.T{Paths: openapi3.NewPaths()}
+		doc.Paths.Set("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}", &openapi3.PathItem{
+			Put: &openapi3.Operation{},
+		})
+		files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSpec(doc))
+		require.NoError(t, err)
+		assert.NotContains(t, string(files["variables.tf"]), `variable "timeouts"`)
+		assert.NotContains(t, string(files["main.tf"]), "timeouts")
+	})
+}
+
+func TestGenerate_DeprecatedAPIWarning(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {"type": "object", "properties": {"sku": {"type": "string"}}}
+		}
+	}`), &schema))
+
+	generate := func(t *testing.T, put *openapi3.Operation) string {
+		t.Helper()
+		doc := &openapi3.T{Paths: openapi3.NewPaths()}
+		doc.Paths.Set("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}", &openapi3.PathItem{
+			Put: put,
+		})
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		_, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSpec(doc), WithAPIVersion("2024-01-01"), WithLogger(logger))
+		require.NoError(t, err)
+		return logs.String()
+	}
+
+	logs := generate(t, &openapi3.Operation{Deprecated: true})
+	assert.Contains(t, logs, "level=WARN")
+	assert.Contains(t, logs, "deprecated API")
+	assert.Contains(t, logs, "Microsoft.Test/widgets")
+	assert.Contains(t, logs, "api_version=2024-01-01")
+
+	assert.Empty(t, generate(t, &openapi3.Operation{}))
+}
+
+func TestGenerate_WithSortVariables(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"required": ["zone", "mode"],
+				"properties": {
+					"zone": {"type": "string"},
+					"alias": {"type": "string"},
+					"mode": {"type": "string"},
+					"label": {"type": "string"}
+				}
+			}
+		}
+	}`), &schema))
+
+	variableNames := func(order string) []string {
+		t.Helper()
+		opts := []GeneratorOption{WithSchema(&schema), WithTelemetry(false)}
+		if order != "" {
+			opts = append(opts, WithSortVariables(order))
+		}
+		files, err := GenerateFiles("Microsoft.Test/widgets", opts...)
+		require.NoError(t, err)
+		file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		var names []string
+		for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+			names = append(names, block.Labels[0])
+		}
+		return names
+	}
+
+	standard := []string{"name", "parent_id", "location"}
+	assert.Equal(t, append(standard, "alias", "label", "mode", "zone"), variableNames(""))
+	assert.Equal(t, append(standard, "alias", "label", "mode", "zone"), variableNames(SortVariablesAlpha))
+	assert.Equal(t, append(standard, "mode", "zone", "alias", "label"), variableNames(SortVariablesRequiredFirst))
+	// Without a recorded declaration order, spec order falls back to alphabetical.
+	assert.Equal(t, append(standard, "alias", "label", "mode", "zone"), variableNames(SortVariablesSpec))
+
+	_, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSortVariables("random"))
+	assert.ErrorContains(t, err, `unsupported variable order "random"`)
+}
+
+func TestGenerate_WithVariableDefaultsFile(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"required": ["replicas"],
+				"properties": {
+					"replicas": {"type": "integer", "description": "The number of replicas.\nAt least one."},
+					"mode": {"type": "string", "default": "Standard"},
+					"rules": {"type": "array", "items": {"type": "string"}}
+				}
+			}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema))
+	require.NoError(t, err)
+	assert.NotContains(t, files, DefaultVariableDefaultsFile)
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithVariableDefaultsFile(DefaultVariableDefaultsFile))
+	require.NoError(t, err)
+	require.Contains(t, files, DefaultVariableDefaultsFile)
+
+	tfvars := string(files[DefaultVariableDefaultsFile])
+	_, diags := hclsyntax.ParseConfig(files[DefaultVariableDefaultsFile], DefaultVariableDefaultsFile, hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	// Required variables get an empty value of their type, optional ones their default.
+	assert.Contains(t, tfvars, "# name (required): The name of the resource.\nname = \"\"\n")
+	assert.Contains(t, tfvars, "# replicas (required): The number of replicas.\nreplicas = 0\n")
+	assert.Contains(t, tfvars, "\nrules = null\n")
+	assert.Contains(t, tfvars, "\nenable_telemetry = true\n")
+	assert.NotContains(t, tfvars, "At least one.")
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithVariableDefaultsFile("dev.tfvars"))
+	require.NoError(t, err)
+	assert.Contains(t, files, "dev.tfvars")
+	assert.NotContains(t, files, DefaultVariableDefaultsFile)
+}
+
+func TestGenerate_WithCompactValidations(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"properties": {
+					"tier": {"type": "string", "enum": ["Basic", "Premium"], "maxLength": 10},
+					"label": {"type": "string", "maxLength": 20}
+				}
+			}
+		}
+	}`), &schema))
+
+	validations := func(t *testing.T, opts ...GeneratorOption) (map[string][]*hclsyntax.Block, []byte) {
+		t.Helper()
+		files, err := GenerateFiles("Microsoft.Test/widgets", append([]GeneratorOption{WithSchema(&schema)}, opts...)...)
+		require.NoError(t, err)
+		file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		blocks := make(map[string][]*hclsyntax.Block)
+		for _, name := range []string{"tier", "label"} {
+			for _, block := range requireBlock(t, file.Body.(*hclsyntax.Body), "variable", name).Body.Blocks {
+				if block.Type == "validation" {
+					blocks[name] = append(blocks[name], block)
+				}
+			}
+		}
+		return blocks, files["variables.tf"]
+	}
+
+	blocks, _ := validations(t)
+	assert.Len(t, blocks["tier"], 2)
+	assert.Len(t, blocks["label"], 1)
+
+	blocks, src := validations(t, WithCompactValidations(true))
+	require.Len(t, blocks["tier"], 1)
+	assert.Len(t, blocks["label"], 1)
+
+	tier := blocks["tier"][0]
+	condition := string(tier.Body.Attributes["condition"].Expr.Range().SliceBytes(src))
+	assert.Equal(t, `(var.tier == null || contains(["Basic", "Premium"], var.tier)) && (var.tier == null || length(var.tier) <= 10)`, condition)
+	assert.Equal(t, "tier must be one of: [\"Basic\", \"Premium\"]. tier must have a maximum length of 10.", attributeStringValue(t, tier.Body.Attributes["error_message"]))
+}
+
+func TestGenerate_WithErrorMessagePrefix(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"properties": {
+					"tier": {"type": "string", "enum": ["Basic", "Premium"], "maxLength": 10}
+				}
+			}
+		}
+	}`), &schema))
+
+	messages := func(t *testing.T, opts ...GeneratorOption) []string {
+		t.Helper()
+		files, err := GenerateFiles("Microsoft.Test/widgets", append([]GeneratorOption{WithSchema(&schema), WithErrorMessagePrefix("storage")}, opts...)...)
+		require.NoError(t, err)
+		file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		var messages []string
+		for _, block := range requireBlock(t, file.Body.(*hclsyntax.Body), "variable", "tier").Body.Blocks {
+			if block.Type == "validation" {
+				messages = append(messages, attributeStringValue(t, block.Body.Attributes["error_message"]))
+			}
+		}
+		return messages
+	}
+
+	assert.Equal(t, []string{
+		`[storage] tier must be one of: ["Basic", "Premium"].`,
+		"[storage] tier must have a maximum length of 10.",
+	}, messages(t))
+
+	// A compacted message is prefixed once.
+	assert.Equal(t, []string{
+		`[storage] tier must be one of: ["Basic", "Premium"]. tier must have a maximum length of 10.`,
+	}, messages(t, WithCompactValidations(true)))
+}
+
+func TestGenerate_WithValidateIdentityIDs(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"identity": {
+				"type": "object",
+				"properties": {
+					"type": {"type": "string"},
+					"userAssignedIdentities": {"type": "object", "additionalProperties": {"type": "object"}}
+				}
+			},
+			"properties": {"type": "object", "properties": {"sku": {"type": "string"}}}
+		}
+	}`), &schema))
+
+	validations := func(t *testing.T, opts ...GeneratorOption) ([]*hclsyntax.Block, []byte) {
+		t.Helper()
+		files, err := GenerateFiles("Microsoft.Test/widgets", append([]GeneratorOption{WithSchema(&schema)}, opts...)...)
+		require.NoError(t, err)
+		file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		var blocks []*hclsyntax.Block
+		for _, block := range requireBlock(t, file.Body.(*hclsyntax.Body), "variable", "managed_identities").Body.Blocks {
+			if block.Type == "validation" {
+				blocks = append(blocks, block)
+			}
+		}
+		return blocks, files["variables.tf"]
+	}
+
+	blocks, _ := validations(t)
+	assert.Empty(t, blocks)
+
+	blocks, src := validations(t, WithValidateIdentityIDs(true))
+	require.Len(t, blocks, 1)
+	condition := string(blocks[0].Body.Attributes["condition"].Expr.Range().SliceBytes(src))
+	assert.Equal(t, `alltrue([for id in var.managed_identities.user_assigned_resource_ids : can(regex("^/subscriptions/.+/userAssignedIdentities/.+", id))])`, condition)
+}
+
+func TestGenerate_WithParentIDValidation(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"sku": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+		},
+	}
+
+	validations := func(t *testing.T, opts ...GeneratorOption) ([]*hclsyntax.Block, []byte) {
+		t.Helper()
+		files, err := GenerateFiles("Microsoft.Test/widgets", append([]GeneratorOption{WithSchema(schema)}, opts...)...)
+		require.NoError(t, err)
+		file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		var blocks []*hclsyntax.Block
+		for _, block := range requireBlock(t, file.Body.(*hclsyntax.Body), "variable", "parent_id").Body.Blocks {
+			if block.Type == "validation" {
+				blocks = append(blocks, block)
+			}
+		}
+		return blocks, files["variables.tf"]
+	}
+
+	blocks, _ := validations(t)
+	assert.Empty(t, blocks)
+
+	blocks, src := validations(t, WithParentIDValidation(true))
+	require.Len(t, blocks, 1)
+	condition := string(blocks[0].Body.Attributes["condition"].Expr.Range().SliceBytes(src))
+	assert.Equal(t, `can(regex("^/(subscriptions|providers)/.+", var.parent_id))`, condition)
+}
+
+func TestGenerate_WithDisambiguateCollisions(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"properties": {
+					"ipAddress": {"type": "string"},
+					"IPAddress": {"type": "integer"},
+					"sku": {"type": "string"}
+				}
+			}
+		}
+	}`), &schema))
+
+	_, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `terraform variable name collision: "ip_address" (from properties.ipAddress)`)
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithDisambiguateCollisions(true))
+	require.NoError(t, err)
+
+	variables, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	body := variables.Body.(*hclsyntax.Body)
+	// Properties are named in sorted order, so IPAddress keeps the plain name.
+	first := requireBlock(t, body, "variable", "ip_address")
+	second := requireBlock(t, body, "variable", "ip_address_2")
+	assert.Equal(t, "number", string(first.Body.Attributes["type"].Expr.Range().SliceBytes(files["variables.tf"])))
+	assert.Equal(t, "string", string(second.Body.Attributes["type"].Expr.Range().SliceBytes(files["variables.tf"])))
+	assert.Contains(t, string(files["variables.tf"]), "# ip_address_2 sets properties.ipAddress, as ip_address is taken by another property.\nvariable \"ip_address_2\"")
+
+	locals := strings.Join(strings.Fields(string(files["locals.tf"])), " ")
+	assert.Contains(t, locals, "IPAddress = var.ip_address ")
+	assert.Contains(t, locals, "ipAddress = var.ip_address_2 ")
+	assert.Contains(t, locals, "sku = var.sku")
+
+	// GenerateLocalsFile computes the same renames against the written module.
+	dir := t.TempDir()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithSchema(&schema), WithDisambiguateCollisions(true), WithOutputDir(dir)))
+	missing, err := GenerateLocalsFile("Microsoft.Test/widgets", WithSchema(&schema), WithDisambiguateCollisions(true), WithOutputDir(dir))
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+	regenerated, err := os.ReadFile(filepath.Join(dir, "locals.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, string(files["locals.tf"]), string(regenerated))
+}
+
+func TestGenerateLocalsFile_RejectsMultiInstance(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"identity": {
+				"type": "object",
+				"properties": {"type": {"type": "string"}, "userAssignedIdentities": {"type": "object"}}
+			},
+			"properties": {
+				"type": "object",
+				"properties": {"sku": {"type": "string"}}
+			}
+		}
+	}`), &schema))
+
+	dir := t.TempDir()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithSchema(&schema), WithMulti(true), WithOutputDir(dir)))
+	before, err := os.ReadFile(filepath.Join(dir, "locals.tf"))
+	require.NoError(t, err)
+
+	_, err = GenerateLocalsFile("Microsoft.Test/widgets", WithSchema(&schema), WithOutputDir(dir))
+	require.ErrorContains(t, err, "multi-instance modules are not supported")
+	after, err := os.ReadFile(filepath.Join(dir, "locals.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestGenerate_WithStripDescriptionHTML(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"properties": {
+					"endpoint": {
+						"type": "string",
+						"description": "The endpoint URL.<br>See <a href=\"https://learn.microsoft.com\">the docs</a> for R&amp;D   endpoints at /sites/<siteName>."
+					},
+					"network": {
+						"type": "object",
+						"description": "Network settings.",
+						"properties": {
+							"mode": {"type": "string", "description": "Either <code>Public</code> &amp; <br/>or <code>Private</code>."}
+						}
+					}
+				}
+			}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema))
+	require.NoError(t, err)
+	assert.Contains(t, string(files["variables.tf"]), "<br>")
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithStripDescriptionHTML(true))
+	require.NoError(t, err)
+	variables := string(files["variables.tf"])
+	assert.Contains(t, variables, "The endpoint URL. See the docs for R&D endpoints at /sites/<siteName>.\n")
+	assert.Contains(t, variables, "- `mode` - Either Public & or Private.\n")
+	assert.NotContains(t, variables, "<br")
+	assert.NotContains(t, variables, "&amp;")
+}
+
+func TestGenerate_WithDescriptionMaxLength(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"properties": {
+					"endpoint": {
+						"type": "string",
+						"description": "The endpoint URL<br>used by clients. It must be reachable from every region the service is deployed to."
+					},
+					"network": {
+						"type": "object",
+						"description": "Network settings.",
+						"properties": {
+							"mode": {"type": "string", "description": "Whether the endpoint is reachable from the public internet or only from private networks."}
+						}
+					}
+				}
+			}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema))
+	require.NoError(t, err)
+	assert.Contains(t, string(files["variables.tf"]), "every region the service is deployed to.")
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithStripDescriptionHTML(true), WithDescriptionMaxLength(40))
+	require.NoError(t, err)
+	variables := string(files["variables.tf"])
+	// The HTML is stripped before truncating, which cuts at the last word boundary that fits.
+	assert.Contains(t, variables, "The endpoint URL used by clients. It…\n")
+	assert.Contains(t, variables, "- `mode` - Whether the endpoint is reachable from…\n")
+	assert.Contains(t, variables, "Network settings.\n")
+}
+
+func TestTruncateDescription(t *testing.T) {
+	assert.Equal(t, "Short enough.", truncateDescription("Short enough.", 20))
+	assert.Equal(t, "Unlimited length.", truncateDescription("Unlimited length.", 0))
+	assert.Equal(t, "The quick brown…", truncateDescription("The quick brown fox jumps.", 18))
+	assert.Equal(t, "Supercalifragi…", truncateDescription("Supercalifragilisticexpialidocious", 15))
+	assert.LessOrEqual(t, len([]rune(truncateDescription("The quick brown fox jumps.", 18))), 18)
+}
+
+func TestGenerate_WithDefaultTags(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"tags": {"type": "object", "additionalProperties": {"type": "string"}},
+			"properties": {
+				"type": "object",
+				"properties": {"sku": {"type": "string"}}
+			}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSupportsTags(true))
+	require.NoError(t, err)
+	assert.Contains(t, string(files["main.tf"]), "tags                   = var.tags")
+	assert.NotContains(t, string(files["locals.tf"]), "merge(var.tags")
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSupportsTags(true),
+		WithDefaultTags(map[string]string{"env": "prod", "managed-by": "tfmodmake"}))
+	require.NoError(t, err)
+	locals := strings.Join(strings.Fields(string(files["locals.tf"])), " ")
+	assert.Contains(t, locals, `tags = merge(var.tags, { env = "prod" managed-by = "tfmodmake" })`)
+	assert.Contains(t, string(files["main.tf"]), "tags                   = local.tags")
+
+	// Without tags support there is nothing to merge the defaults into.
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithSupportsTags(false),
+		WithDefaultTags(map[string]string{"env": "prod"}))
+	require.NoError(t, err)
+	assert.NotContains(t, string(files["locals.tf"]), "merge(var.tags")
+	assert.NotContains(t, string(files["main.tf"]), "local.tags")
+}
+
 func TestGenerate(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -57,97 +1036,941 @@ func TestGenerate(t *testing.T) {
 	schema := &openapi3.Schema{
 		Type: &openapi3.Types{"object"},
 		Properties: map[string]*openapi3.SchemaRef{
-			"location": {
-				Value: &openapi3.Schema{
-					Type:        &openapi3.Types{"string"},
-					Description: "Resource location",
+			"location": {
+				Value: &openapi3.Schema{
+					Type:        &openapi3.Types{"string"},
+					Description: "Resource location",
+				},
+			},
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"readOnlyProp": {
+							Value: &openapi3.Schema{
+								Type:     &openapi3.Types{"string"},
+								ReadOnly: true,
+							},
+						},
+						"writableProp": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"string"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	supportsTags := SupportsTags(schema)
+	supportsLocation := SupportsLocation(schema)
+
+	apiVersion := "2024-01-01"
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion(apiVersion), WithSupportsTags(supportsTags), WithSupportsLocation(supportsLocation))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+
+	nameVar := requireBlock(t, varsBody, "variable", "name")
+	assert.Equal(t, "The name of the resource.\n", attributeStringValue(t, nameVar.Body.Attributes["description"]))
+	assert.Equal(t, "string", expressionString(t, nameVar.Body.Attributes["type"].Expr))
+
+	parentVar := requireBlock(t, varsBody, "variable", "parent_id")
+	assert.Equal(t, "The parent resource ID for this resource.\n", attributeStringValue(t, parentVar.Body.Attributes["description"]))
+	assert.Equal(t, "string", expressionString(t, parentVar.Body.Attributes["type"].Expr))
+	assert.Nil(t, findBlock(varsBody, "variable", "tags"))
+
+	locationVar := requireBlock(t, varsBody, "variable", "location")
+	require.NotNil(t, locationVar)
+	assert.Equal(t, "The location of the resource.\n", attributeStringValue(t, locationVar.Body.Attributes["description"]))
+	assert.Equal(t, "string", expressionString(t, locationVar.Body.Attributes["type"].Expr))
+
+	propertiesVar := findBlock(varsBody, "variable", "properties")
+	assert.Nil(t, propertiesVar)
+
+	writableVar := requireBlock(t, varsBody, "variable", "writable_prop")
+	assert.Equal(t, "string", expressionString(t, writableVar.Body.Attributes["type"].Expr))
+	assert.Equal(t, "null", expressionString(t, writableVar.Body.Attributes["default"].Expr))
+
+	localsBody := parseHCLBody(t, "locals.tf")
+	localsBlock := requireBlock(t, localsBody, "locals")
+	localAttr := localsBlock.Body.Attributes["resource_body"]
+	localExpr := expressionString(t, localAttr.Expr)
+	assert.NotContains(t, localExpr, "location")
+	assert.Contains(t, localExpr, "writableProp = var.writable_prop")
+	assert.NotContains(t, localExpr, "readOnlyProp")
+	assert.NotContains(t, localExpr, "var.properties")
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resourceBlock := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, "testResource@2024-01-01", attributeStringValue(t, resourceBlock.Body.Attributes["type"]))
+	assert.Equal(t, "var.name", expressionString(t, resourceBlock.Body.Attributes["name"].Expr))
+	assert.Equal(t, "var.parent_id", expressionString(t, resourceBlock.Body.Attributes["parent_id"].Expr))
+	assert.Equal(t, "var.location", expressionString(t, resourceBlock.Body.Attributes["location"].Expr))
+	bodyExpr := expressionString(t, resourceBlock.Body.Attributes["body"].Expr)
+	assert.Equal(t, "local.resource_body", strings.TrimSpace(bodyExpr))
+	assert.Nil(t, resourceBlock.Body.Attributes["tags"])
+
+	outputsBody := parseHCLBody(t, "outputs.tf")
+	idOutput := requireBlock(t, outputsBody, "output", "resource_id")
+	assert.Equal(t, "The ID of the created resource.", attributeStringValue(t, idOutput.Body.Attributes["description"]))
+	assert.Equal(t, "azapi_resource.this.id", expressionString(t, idOutput.Body.Attributes["value"].Expr))
+
+	nameOutput := requireBlock(t, outputsBody, "output", "name")
+	assert.Equal(t, "The name of the created resource.", attributeStringValue(t, nameOutput.Body.Attributes["description"]))
+	assert.Equal(t, "azapi_resource.this.name", expressionString(t, nameOutput.Body.Attributes["value"].Expr))
+
+	readOnlyOutput := requireBlock(t, outputsBody, "output", "read_only_prop")
+	assert.Equal(t, "Computed value exported from the Azure API response.", attributeStringValue(t, readOnlyOutput.Body.Attributes["description"]))
+	assert.Equal(t, "try(azapi_resource.this.output.properties.readOnlyProp, null)", expressionString(t, readOnlyOutput.Body.Attributes["value"].Expr))
+}
+
+func TestGenerate_NameVariable_UsesNameSchemaValidations(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	maxLen := uint64(63)
+	nameSchema := &openapi3.Schema{
+		Type:      &openapi3.Types{"string"},
+		MinLength: 1,
+		MaxLength: &maxLen,
+		Pattern:   "^[a-z0-9-]{1,63}$",
+	}
+
+	doc := &openapi3.T{
+		Paths: openapi3.NewPaths(),
+	}
+	doc.Paths.Set("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResource/{name}", &openapi3.PathItem{
+		Put: &openapi3.Operation{
+			Parameters: openapi3.Parameters{
+				{
+					Value: &openapi3.Parameter{
+						Name: "name",
+						In:   "path",
+						Schema: &openapi3.SchemaRef{
+							Value: nameSchema,
+						},
+					},
+				},
+			},
+		},
+	})
+
+	err = Generate("Microsoft.Test/testResource", WithSchema(nil), WithLocalName("unused_local"), WithAPIVersion("2024-01-01"), WithSpec(doc))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	nameVar := requireBlock(t, varsBody, "variable", "name")
+
+	var validations []*hclsyntax.Block
+	for _, b := range nameVar.Body.Blocks {
+		if b.Type == "validation" {
+			validations = append(validations, b)
+		}
+	}
+	require.GreaterOrEqual(t, len(validations), 3)
+
+	var conditions []string
+	for _, b := range validations {
+		condAttr := b.Body.Attributes["condition"]
+		require.NotNil(t, condAttr)
+		conditions = append(conditions, expressionString(t, condAttr.Expr))
+	}
+	joined := strings.Join(conditions, "\n")
+
+	assert.Contains(t, joined, "length(var.name) >= 1")
+	assert.Contains(t, joined, "length(var.name) <= 63")
+	assert.Contains(t, joined, "can(regex(\"^[a-z0-9-]{1,63}$\", var.name))")
+}
+
+func TestGenerate_NameValidationSource(t *testing.T) {
+	pathMax := uint64(63)
+	pathSchema := &openapi3.Schema{Type: &openapi3.Types{"string"}, MaxLength: &pathMax}
+	bodyMax := uint64(24)
+	bodyName := &openapi3.Schema{
+		Type:      &openapi3.Types{"string"},
+		ReadOnly:  true,
+		MinLength: 3,
+		MaxLength: &bodyMax,
+		Pattern:   "^[a-z0-9]+$",
+	}
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"name":  {Value: bodyName},
+					"value": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+				},
+			}},
+		},
+	}
+	doc := &openapi3.T{Paths: openapi3.NewPaths()}
+	doc.Paths.Set("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResource/{name}", &openapi3.PathItem{
+		Put: &openapi3.Operation{
+			Parameters: openapi3.Parameters{
+				{Value: &openapi3.Parameter{Name: "name", In: "path", Schema: &openapi3.SchemaRef{Value: pathSchema}}},
+			},
+		},
+	})
+
+	nameConditions := func(t *testing.T, opts ...GeneratorOption) string {
+		t.Helper()
+		opts = append([]GeneratorOption{WithSchema(schema), WithSpec(doc), WithAPIVersion("2024-01-01")}, opts...)
+		files, err := GenerateFiles("Microsoft.Test/testResource", opts...)
+		require.NoError(t, err)
+
+		src := hclwrite.Format(files["variables.tf"])
+		file, diags := hclsyntax.ParseConfig(src, "variables.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		nameVar := requireBlock(t, file.Body.(*hclsyntax.Body), "variable", "name")
+		var conditions []string
+		for _, b := range nameVar.Body.Blocks {
+			if b.Type != "validation" {
+				continue
+			}
+			rng := b.Body.Attributes["condition"].Expr.Range()
+			conditions = append(conditions, string(src[rng.Start.Byte:rng.End.Byte]))
+		}
+		return strings.Join(conditions, "\n")
+	}
+
+	t.Run("path is the default", func(t *testing.T) {
+		conditions := nameConditions(t)
+		assert.Contains(t, conditions, "length(var.name) <= 63")
+		assert.NotContains(t, conditions, "regex")
+	})
+
+	t.Run("body", func(t *testing.T) {
+		conditions := nameConditions(t, WithNameValidationSource(NameValidationSourceBody))
+		assert.Contains(t, conditions, "length(var.name) >= 3")
+		assert.Contains(t, conditions, "length(var.name) <= 24")
+		assert.Contains(t, conditions, `can(regex("^[a-z0-9]+$", var.name))`)
+		assert.NotContains(t, conditions, "63")
+	})
+
+	t.Run("both keeps the most restrictive", func(t *testing.T) {
+		conditions := nameConditions(t, WithNameValidationSource(NameValidationSourceBoth))
+		assert.Contains(t, conditions, "length(var.name) >= 3")
+		assert.Contains(t, conditions, "length(var.name) <= 24")
+		assert.Contains(t, conditions, `can(regex("^[a-z0-9]+$", var.name))`)
+		assert.NotContains(t, conditions, "63")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithNameValidationSource("query"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported name validation source")
+	})
+}
+
+func TestGenerate_TupleItems(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "spec.json")
+	require.NoError(t, os.WriteFile(specPath, []byte(`{
+  "swagger": "2.0",
+  "info": {"title": "Test", "version": "2024-01-01"},
+  "paths": {
+    "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}": {
+      "put": {
+        "parameters": [
+          {"name": "widgetName", "in": "path", "required": true, "type": "string"},
+          {"name": "body", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Widget"}}
+        ],
+        "responses": {"200": {"description": "OK"}}
+      }
+    }
+  },
+  "definitions": {
+    "Widget": {
+      "type": "object",
+      "properties": {
+        "properties": {
+          "type": "object",
+          "properties": {
+            "range": {
+              "type": "array",
+              "items": [{"type": "string"}, {"$ref": "#/definitions/Weight"}]
+            }
+          }
+        }
+      }
+    },
+    "Weight": {"type": "object", "properties": {"value": {"type": "integer"}}}
+  }
+}`), 0o644))
+
+	doc, err := openapi.LoadSpec(specPath)
+	require.NoError(t, err)
+	schema, err := openapi.FindResource(doc, "Microsoft.Test/widgets")
+	require.NoError(t, err)
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema))
+	require.NoError(t, err)
+
+	file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	rangeVar := requireBlock(t, file.Body.(*hclsyntax.Body), "variable", "range")
+	typeExpr := strings.Join(strings.Fields(string(rangeVar.Body.Attributes["type"].Expr.Range().SliceBytes(files["variables.tf"]))), " ")
+	assert.Equal(t, "tuple([string, object({ value = optional(number) })])", typeExpr)
+
+	// Each position is rebuilt from its own element.
+	_, diags = hclsyntax.ParseConfig(files["locals.tf"], "locals.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	locals := strings.Join(strings.Fields(string(files["locals.tf"])), " ")
+	assert.Contains(t, locals, "range = var.range == null ? null : [var.range[0], var.range[1] == null ? null : { for k, v in { value = var.range[1].value } : k => v if v != null }]")
+}
+
+func TestGenerate_NestedObjectValidations(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	maxUsernameLength := uint64(20)
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"windowsProfile": {
+							Value: &openapi3.Schema{
+								Type:     &openapi3.Types{"object"},
+								Required: []string{"adminUsername"},
+								Properties: map[string]*openapi3.SchemaRef{
+									"adminUsername": {
+										Value: &openapi3.Schema{
+											Type:      &openapi3.Types{"string"},
+											MinLength: 1,
+											MaxLength: &maxUsernameLength,
+										},
+									},
+									"licenseType": {
+										Value: &openapi3.Schema{
+											Type: &openapi3.Types{"string"},
+											Enum: []any{"None", "Windows_Server"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	supportsTags := SupportsTags(schema)
+	supportsLocation := SupportsLocation(schema)
+
+	err = Generate("Microsoft.Test/testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"), WithSupportsTags(supportsTags), WithSupportsLocation(supportsLocation))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	winVar := requireBlock(t, varsBody, "variable", "windows_profile")
+
+	var validations []*hclsyntax.Block
+	for _, b := range winVar.Body.Blocks {
+		if b.Type == "validation" {
+			validations = append(validations, b)
+		}
+	}
+	require.GreaterOrEqual(t, len(validations), 2)
+
+	var conditions []string
+	for _, b := range validations {
+		condAttr := b.Body.Attributes["condition"]
+		require.NotNil(t, condAttr)
+		conditions = append(conditions, expressionString(t, condAttr.Expr))
+	}
+	joined := strings.Join(conditions, "\n")
+
+	assert.Contains(t, joined, "var.windows_profile == null")
+	assert.Contains(t, joined, "var.windows_profile.admin_username")
+	assert.Contains(t, joined, "length(var.windows_profile.admin_username) <= 20")
+	assert.Contains(t, joined, "var.windows_profile.license_type")
+	assert.Contains(t, joined, "contains(")
+}
+
+func TestGenerate_QuotesNonIdentifierObjectKeysInLocals(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"autoScalerProfile": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"object"},
+								Properties: map[string]*openapi3.SchemaRef{
+									"balance-similar-node-groups": {
+										Value: &openapi3.Schema{Type: &openapi3.Types{"string"}},
+									},
+									"foo.bar": {
+										Value: &openapi3.Schema{Type: &openapi3.Types{"string"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	apiVersion := "2025-10-01"
+	err = Generate("Microsoft.ContainerService/managedClusters", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion(apiVersion))
+	require.NoError(t, err)
+
+	localsBytes, err := os.ReadFile("locals.tf")
+	require.NoError(t, err)
+	locals := string(localsBytes)
+
+	// Hyphenated keys don't need quotes in HCL.
+	assert.Contains(t, locals, "balance-similar-node-groups")
+	// RHS must reference a valid Terraform attribute name (snake_case).
+	assert.Contains(t, locals, "var.auto_scaler_profile.balance_similar_node_groups")
+
+	// Keys containing '.' must be quoted, otherwise HCL treats '.' as traversal syntax.
+	assert.Contains(t, locals, "\"foo.bar\"")
+	assert.Contains(t, locals, "var.auto_scaler_profile.foo_bar")
+}
+
+func TestGenerate_RenamesKeywordTypeAttributes(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"properties": {
+				"type": "object",
+				"properties": {
+					"rule": {
+						"type": "object",
+						"properties": {
+							"for": {"type": "string"},
+							"true": {"type": "boolean"},
+							"null": {"type": "string"},
+							"name": {"type": "string"}
+						}
+					}
+				}
+			}
+		}
+	}`), &schema))
+
+	for _, style := range []string{LocalsStyleFlat, LocalsStyleNested} {
+		t.Run(style, func(t *testing.T) {
+			files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithLocalsStyle(style), WithValidateAfter(true))
+			require.NoError(t, err)
+
+			variables, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+			name, ruleRef := "rule", "var.rule"
+			if style == LocalsStyleNested {
+				name, ruleRef = "properties", "var.properties.rule"
+			}
+			block := requireBlock(t, variables.Body.(*hclsyntax.Body), "variable", name)
+			typ, diags := typeexpr.TypeConstraint(block.Body.Attributes["type"].Expr)
+			require.False(t, diags.HasErrors(), diags.Error())
+			if style == LocalsStyleNested {
+				typ = typ.AttributeType("rule")
+			}
+			for _, attr := range []string{"for_", "true_", "null_", "name"} {
+				assert.True(t, typ.HasAttribute(attr), attr)
+			}
+
+			// The locals send each renamed attribute under the property name.
+			locals := string(files["locals.tf"])
+			for key, attr := range map[string]string{`"for"`: "for_", `"true"`: "true_", `"null"`: "null_", "name": "name"} {
+				assert.Regexp(t, regexp.QuoteMeta(key)+` += `+regexp.QuoteMeta(ruleRef+"."+attr)+"\n", locals)
+			}
+		})
+	}
+}
+
+func TestGenerate_SkipsSecretsByFullPathNotLeafName(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"a": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"object"},
+								Properties: map[string]*openapi3.SchemaRef{
+									"password": {
+										Value: &openapi3.Schema{
+											Type:       &openapi3.Types{"string"},
+											Extensions: map[string]any{"x-ms-secret": true},
+										},
+									},
+								},
+							},
+						},
+						"b": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"object"},
+								Properties: map[string]*openapi3.SchemaRef{
+									"password": {
+										Value: &openapi3.Schema{Type: &openapi3.Types{"string"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2025-01-01"))
+	require.NoError(t, err)
+
+	localsBytes, err := os.ReadFile("locals.tf")
+	require.NoError(t, err)
+	locals := string(localsBytes)
+
+	// Only the specific secret path should be removed.
+	assert.NotContains(t, locals, "var.a.password")
+	assert.Contains(t, locals, "var.b.password")
+}
+
+func TestGenerate_DefaultOptions(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"optionalString": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+						"optionalObj": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"object"},
+								Properties: map[string]*openapi3.SchemaRef{
+									"nestedOptional": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2025-01-01"))
+	require.NoError(t, err)
+
+	mainBytes, err := os.ReadFile("main.tf")
+	require.NoError(t, err)
+	main := string(mainBytes)
+
+	assert.NotContains(t, main, "ignore_null_property")
+}
+
+func TestGenerate_WithBackendStub(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	err = Generate("testResource", WithAPIVersion("2025-01-01"), WithBackend("azurerm"))
+	require.NoError(t, err)
+
+	tfBytes, err := os.ReadFile("terraform.tf")
+	require.NoError(t, err)
+	tf := string(tfBytes)
+
+	assert.Contains(t, tf, `  # backend "azurerm" {`)
+	assert.Contains(t, tf, `  #   key                  = "terraform.tfstate"`)
+	assert.Contains(t, tf, "  # }")
+
+	// The stub is commented out, so no backend block is parsed.
+	tfBody := parseHCLBody(t, "terraform.tf")
+	tfBlock := requireBlock(t, tfBody, "terraform")
+	assert.Nil(t, findBlock(tfBlock.Body, "backend"))
+	assert.NotNil(t, findBlock(tfBlock.Body, "required_providers"))
+
+	err = Generate("testResource", WithBackend("consul"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported backend")
+}
+
+func TestGenerate_LocalsStyle(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type:     &openapi3.Types{"object"},
+				Required: []string{"sku"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"sku": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Enum: []any{"Basic", "Premium"}}},
+					"network": {Value: &openapi3.Schema{
+						Type: &openapi3.Types{"object"},
+						Properties: map[string]*openapi3.SchemaRef{
+							"subnetName": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+						},
+					}},
+					"adminPassword": {Value: &openapi3.Schema{
+						Type:       &openapi3.Types{"string"},
+						Extensions: map[string]any{"x-ms-secret": true},
+					}},
 				},
+			}},
+		},
+	}
+
+	t.Run("flat", func(t *testing.T) {
+		files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema))
+		require.NoError(t, err)
+
+		for _, name := range []string{"variables.tf", "locals.tf", "main.tf"} {
+			_, diags := hclsyntax.ParseConfig(files[name], name, hcl.InitialPos)
+			require.False(t, diags.HasErrors(), "%s: %s", name, diags.Error())
+		}
+		variables := string(files["variables.tf"])
+		assert.Contains(t, variables, `variable "sku"`)
+		assert.Contains(t, variables, `variable "network"`)
+		assert.NotContains(t, variables, `variable "properties"`)
+		locals := strings.Join(strings.Fields(string(files["locals.tf"])), " ")
+		assert.Contains(t, locals, "sku = var.sku")
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithLocalsStyle(LocalsStyleNested))
+		require.NoError(t, err)
+
+		for _, name := range []string{"variables.tf", "locals.tf", "main.tf"} {
+			_, diags := hclsyntax.ParseConfig(files[name], name, hcl.InitialPos)
+			require.False(t, diags.HasErrors(), "%s: %s", name, diags.Error())
+		}
+		variables := string(files["variables.tf"])
+		assert.Contains(t, variables, `variable "properties"`)
+		assert.NotContains(t, variables, `variable "sku"`)
+		assert.NotContains(t, variables, `variable "network"`)
+		// Validations move onto the fields of the properties object.
+		assert.Contains(t, variables, `contains(["Basic", "Premium"], var.properties.sku)`)
+
+		locals := strings.Join(strings.Fields(string(files["locals.tf"])), " ")
+		assert.Contains(t, locals, "properties = var.properties == null ? null : {")
+		assert.Contains(t, locals, "sku = var.properties.sku")
+		assert.Contains(t, locals, "subnetName = var.properties.network.subnet_name")
+		// Secrets stay out of the body and come from their own ephemeral variable.
+		assert.NotContains(t, locals, "adminPassword")
+		assert.Contains(t, variables, `variable "admin_password"`)
+		assert.Contains(t, string(files["main.tf"]), "adminPassword = var.admin_password")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithLocalsStyle("deep"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported locals style")
+	})
+}
+
+func TestGenerate_WithInlineSmallObjects(t *testing.T) {
+	bagSchema := func(names ...string) *openapi3.Schema {
+		props := make(map[string]*openapi3.SchemaRef, len(names))
+		for _, name := range names {
+			props[name] = &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}}
+		}
+		return &openapi3.Schema{
+			Type: &openapi3.Types{"object"},
+			Properties: map[string]*openapi3.SchemaRef{
+				"properties": {Value: &openapi3.Schema{Type: &openapi3.Types{"object"}, Properties: props}},
 			},
-			"properties": {
-				Value: &openapi3.Schema{
-					Type: &openapi3.Types{"object"},
-					Properties: map[string]*openapi3.SchemaRef{
-						"readOnlyProp": {
-							Value: &openapi3.Schema{
-								Type:     &openapi3.Types{"string"},
-								ReadOnly: true,
-							},
-						},
-						"writableProp": {
-							Value: &openapi3.Schema{
-								Type: &openapi3.Types{"string"},
+		}
+	}
+
+	t.Run("small bag stays inline", func(t *testing.T) {
+		files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(bagSchema("alpha", "beta")), WithInlineSmallObjects(3))
+		require.NoError(t, err)
+
+		variables := string(files["variables.tf"])
+		assert.Contains(t, variables, `variable "properties"`)
+		assert.NotContains(t, variables, `variable "alpha"`)
+		locals := strings.Join(strings.Fields(string(files["locals.tf"])), " ")
+		assert.Contains(t, locals, "alpha = var.properties.alpha")
+	})
+
+	t.Run("large bag flattens", func(t *testing.T) {
+		files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(bagSchema("alpha", "beta", "gamma", "delta", "epsilon")), WithInlineSmallObjects(3))
+		require.NoError(t, err)
+
+		variables := string(files["variables.tf"])
+		assert.NotContains(t, variables, `variable "properties"`)
+		for _, name := range []string{"alpha", "beta", "gamma", "delta", "epsilon"} {
+			assert.Contains(t, variables, `variable "`+name+`"`)
+		}
+		locals := strings.Join(strings.Fields(string(files["locals.tf"])), " ")
+		assert.Contains(t, locals, "alpha = var.alpha")
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		_, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(bagSchema("alpha")), WithInlineSmallObjects(-1))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must not be negative")
+	})
+}
+
+func TestGenerate_FilesAreFormatted(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"location": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"tags": {Value: &openapi3.Schema{
+				Type:                 &openapi3.Types{"object"},
+				AdditionalProperties: openapi3.AdditionalProperties{Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}}},
+			}},
+			"identity": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"type": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Enum: []any{"None", "SystemAssigned"}}},
+				},
+			}},
+			"properties": {Value: &openapi3.Schema{
+				Type:     &openapi3.Types{"object"},
+				Required: []string{"sku"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"sku":    {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Enum: []any{"Basic", "Premium"}}},
+					"prefix": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Pattern: "^[a-z]+$", MaxLength: openapi3.Uint64Ptr(24)}},
+					"rules": {Value: &openapi3.Schema{
+						Type: &openapi3.Types{"array"},
+						Items: &openapi3.SchemaRef{Value: &openapi3.Schema{
+							Type: &openapi3.Types{"object"},
+							Properties: map[string]*openapi3.SchemaRef{
+								"port": {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}, Min: openapi3.Float64Ptr(1), Max: openapi3.Float64Ptr(65535)}},
 							},
-						},
-					},
+						}},
+					}},
+					"adminPassword": {Value: &openapi3.Schema{
+						Type:       &openapi3.Types{"string"},
+						Extensions: map[string]any{"x-ms-secret": true},
+					}},
+					"endpoint": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, ReadOnly: true}},
 				},
-			},
+			}},
 		},
 	}
 
-	supportsTags := SupportsTags(schema)
-	supportsLocation := SupportsLocation(schema)
+	for _, style := range []string{LocalsStyleFlat, LocalsStyleNested} {
+		t.Run(style, func(t *testing.T) {
+			files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithLocalsStyle(style), WithStrictOutputs(true))
+			require.NoError(t, err)
+			for name, content := range files {
+				if !strings.HasSuffix(name, ".tf") {
+					continue
+				}
+				assert.Equal(t, string(hclwrite.Format(content)), string(content), "%s is not canonically formatted", name)
+			}
+		})
+	}
 
-	apiVersion := "2024-01-01"
-	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion(apiVersion), WithSupportsTags(supportsTags), WithSupportsLocation(supportsLocation))
+	t.Run("interfaces", func(t *testing.T) {
+		dir := t.TempDir()
+		caps := openapi.InterfaceCapabilities{
+			SupportsPrivateEndpoints:   true,
+			SupportsDiagnostics:        true,
+			SupportsCustomerManagedKey: true,
+			SupportsManagedIdentity:    true,
+		}
+		require.NoError(t, generateInterfaces(caps, dir))
+		content, err := os.ReadFile(filepath.Join(dir, "main.interfaces.tf"))
+		require.NoError(t, err)
+		assert.Equal(t, string(hclwrite.Format(content)), string(content))
+	})
+	t.Run("locals", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, Generate("Microsoft.Test/widgets", WithSchema(schema), WithOutputDir(dir)))
+		require.NoError(t, os.Remove(filepath.Join(dir, "locals.tf")))
+		_, err := GenerateLocalsFile("Microsoft.Test/widgets", WithSchema(schema), WithOutputDir(dir))
+		require.NoError(t, err)
+		content, err := os.ReadFile(filepath.Join(dir, "locals.tf"))
+		require.NoError(t, err)
+		assert.Equal(t, string(hclwrite.Format(content)), string(content))
+	})
+}
+
+func TestGenerate_WithSkipFiles(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"sku": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+				},
+			}},
+		},
+	}
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithSkipFiles("terraform.tf", "outputs.tf"))
 	require.NoError(t, err)
+	assert.NotContains(t, files, "terraform.tf")
+	assert.NotContains(t, files, "outputs.tf")
+	for _, name := range []string{"variables.tf", "locals.tf", "main.tf"} {
+		assert.Contains(t, files, name)
+	}
 
-	varsBody := parseHCLBody(t, "variables.tf")
+	// Skipped files are still built, so their errors still surface.
+	_, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithSkipFiles("terraform.tf"), WithBackend("gcs"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported backend")
+}
 
-	nameVar := requireBlock(t, varsBody, "variable", "name")
-	assert.Equal(t, "The name of the resource.\n", attributeStringValue(t, nameVar.Body.Attributes["description"]))
-	assert.Equal(t, "string", expressionString(t, nameVar.Body.Attributes["type"].Expr))
+func TestGenerate_WithProviderSource(t *testing.T) {
+	tmpDir := t.TempDir()
 
-	parentVar := requireBlock(t, varsBody, "variable", "parent_id")
-	assert.Equal(t, "The parent resource ID for this resource.\n", attributeStringValue(t, parentVar.Body.Attributes["description"]))
-	assert.Equal(t, "string", expressionString(t, parentVar.Body.Attributes["type"].Expr))
-	assert.Nil(t, findBlock(varsBody, "variable", "tags"))
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
 
-	locationVar := requireBlock(t, varsBody, "variable", "location")
-	require.NotNil(t, locationVar)
-	assert.Equal(t, "The location of the resource.\n", attributeStringValue(t, locationVar.Body.Attributes["description"]))
-	assert.Equal(t, "string", expressionString(t, locationVar.Body.Attributes["type"].Expr))
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
 
-	propertiesVar := findBlock(varsBody, "variable", "properties")
-	assert.Nil(t, propertiesVar)
+	err = Generate("testResource", WithAPIVersion("2025-01-01"), WithProviderSource("registry.internal/azure/azapi"))
+	require.NoError(t, err)
 
-	writableVar := requireBlock(t, varsBody, "variable", "writable_prop")
-	assert.Equal(t, "string", expressionString(t, writableVar.Body.Attributes["type"].Expr))
-	assert.Equal(t, "null", expressionString(t, writableVar.Body.Attributes["default"].Expr))
+	tfBody := parseHCLBody(t, "terraform.tf")
+	tfBlock := requireBlock(t, tfBody, "terraform")
+	providers := requireBlock(t, tfBlock.Body, "required_providers")
+	azapi := expressionString(t, providers.Body.Attributes["azapi"].Expr)
+	assert.Contains(t, azapi, `source  = "registry.internal/azure/azapi"`)
+	assert.Contains(t, azapi, `version = "~> 2.7"`)
+
+	for _, source := range []string{"azapi", "registry.internal/azure/azapi/extra", "azure/az api", ""} {
+		err = Generate("testResource", WithProviderSource(source))
+		require.Error(t, err, source)
+		assert.Contains(t, err.Error(), "invalid provider source")
+	}
+}
 
-	localsBody := parseHCLBody(t, "locals.tf")
-	localsBlock := requireBlock(t, localsBody, "locals")
-	localAttr := localsBlock.Body.Attributes["resource_body"]
-	localExpr := expressionString(t, localAttr.Expr)
-	assert.NotContains(t, localExpr, "location")
-	assert.Contains(t, localExpr, "writableProp = var.writable_prop")
-	assert.NotContains(t, localExpr, "readOnlyProp")
-	assert.NotContains(t, localExpr, "var.properties")
+func TestGenerateExampleFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte(`variable "name" {
+  type = string
+}
 
-	mainBody := parseHCLBody(t, "main.tf")
-	resourceBlock := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
-	assert.Equal(t, "testResource@2024-01-01", attributeStringValue(t, resourceBlock.Body.Attributes["type"]))
-	assert.Equal(t, "var.name", expressionString(t, resourceBlock.Body.Attributes["name"].Expr))
-	assert.Equal(t, "var.parent_id", expressionString(t, resourceBlock.Body.Attributes["parent_id"].Expr))
-	assert.Equal(t, "var.location", expressionString(t, resourceBlock.Body.Attributes["location"].Expr))
-	bodyExpr := expressionString(t, resourceBlock.Body.Attributes["body"].Expr)
-	assert.Equal(t, "local.resource_body", strings.TrimSpace(bodyExpr))
-	assert.Nil(t, resourceBlock.Body.Attributes["tags"])
+variable "rules" {
+  type = list(object({ port = number }))
+}
 
-	outputsBody := parseHCLBody(t, "outputs.tf")
-	idOutput := requireBlock(t, outputsBody, "output", "resource_id")
-	assert.Equal(t, "The ID of the created resource.", attributeStringValue(t, idOutput.Body.Attributes["description"]))
-	assert.Equal(t, "azapi_resource.this.id", expressionString(t, idOutput.Body.Attributes["value"].Expr))
+variable "enabled" {
+  type    = bool
+  default = true
+}
+`), 0o644))
 
-	nameOutput := requireBlock(t, outputsBody, "output", "name")
-	assert.Equal(t, "The name of the created resource.", attributeStringValue(t, nameOutput.Body.Attributes["description"]))
-	assert.Equal(t, "azapi_resource.this.name", expressionString(t, nameOutput.Body.Attributes["value"].Expr))
+	require.NoError(t, GenerateExampleFile("my_module", WithOutputDir(dir)))
+	example, err := os.ReadFile(filepath.Join(dir, "examples", "default", "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, `module "my_module" {
+  source = "../../"
 
-	readOnlyOutput := requireBlock(t, outputsBody, "output", "read_only_prop")
-	assert.Equal(t, "Computed value exported from the Azure API response.", attributeStringValue(t, readOnlyOutput.Body.Attributes["description"]))
-	assert.Equal(t, "try(azapi_resource.this.output.properties.readOnlyProp, null)", expressionString(t, readOnlyOutput.Body.Attributes["value"].Expr))
+  name  = ""
+  rules = []
 }
+`, string(example))
 
-func TestGenerate_NameVariable_UsesNameSchemaValidations(t *testing.T) {
+	assert.ErrorContains(t, GenerateExampleFile("my module", WithOutputDir(dir)), "invalid module name")
+
+	require.NoError(t, GenerateReadmeFile("my_module", "Microsoft.Test/widgets", dir))
+	readme, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(readme), "# my_module\n"))
+
+	// An existing README is left alone.
+	require.NoError(t, GenerateReadmeFile("other", "Microsoft.Test/widgets", dir))
+	unchanged, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, readme, unchanged)
+}
+
+func TestGenerateExampleFile_SeedsSpecExamples(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"kind": {"type": "string", "example": "web"},
+			"properties": {
+				"type": "object",
+				"required": ["sku", "capacity", "zones", "mode", "adminPassword"],
+				"properties": {
+					"sku": {"type": "string", "example": "Premium"},
+					"capacity": {"type": "integer", "examples": [3, 5]},
+					"zones": {"type": "array", "items": {"type": "string"}, "example": ["1", "2"]},
+					"mode": {"type": "string", "example": {"value": "Auto"}},
+					"adminPassword": {"type": "string", "x-ms-secret": true, "example": "hunter2"}
+				}
+			}
+		},
+		"required": ["kind"]
+	}`), &schema))
+
+	dir := t.TempDir()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithSchema(&schema), WithOutputDir(dir)))
+	require.NoError(t, GenerateExampleFile("my_module", WithSchema(&schema), WithOutputDir(dir)))
+	example, err := os.ReadFile(filepath.Join(dir, "examples", "default", "main.tf"))
+	require.NoError(t, err)
+	content := strings.Join(strings.Fields(string(example)), " ")
+
+	assert.Contains(t, content, `kind = "web"`)
+	assert.Contains(t, content, `sku = "Premium"`)
+	assert.Contains(t, content, "capacity = 3 ")
+	assert.Contains(t, content, `zones = ["1", "2"]`)
+	// An example of the wrong type, and secrets, get the placeholder.
+	assert.Contains(t, content, `mode = ""`)
+	assert.NotContains(t, content, "hunter2")
+}
+
+func TestGenerateProviderRequirements(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	originalWd, err := os.Getwd()
@@ -157,61 +1980,192 @@ func TestGenerate_NameVariable_UsesNameSchemaValidations(t *testing.T) {
 	err = os.Chdir(tmpDir)
 	require.NoError(t, err)
 
-	maxLen := uint64(63)
-	nameSchema := &openapi3.Schema{
-		Type:      &openapi3.Types{"string"},
-		MinLength: 1,
-		MaxLength: &maxLen,
-		Pattern:   "^[a-z0-9-]{1,63}$",
+	err = GenerateProviderRequirements(WithProviderSource("registry.internal/azure/azapi"), WithRequiredVersion(">= 1.9"), WithProviderVersion("~> 2.5"), WithBackend("local"))
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "terraform.tf", entries[0].Name())
+
+	tfBody := parseHCLBody(t, "terraform.tf")
+	tfBlock := requireBlock(t, tfBody, "terraform")
+	assert.Equal(t, ">= 1.9", attributeStringValue(t, tfBlock.Body.Attributes["required_version"]))
+	providers := requireBlock(t, tfBlock.Body, "required_providers")
+	azapi := expressionString(t, providers.Body.Attributes["azapi"].Expr)
+	assert.Contains(t, azapi, `source  = "registry.internal/azure/azapi"`)
+	assert.Contains(t, azapi, `version = "~> 2.5"`)
+	content, err := os.ReadFile("terraform.tf")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `# backend "local"`)
+
+	err = GenerateProviderRequirements(WithProviderVersion(" "))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid provider version")
+}
+
+func TestGenerate_WithSchemaValidation(t *testing.T) {
+	schemaValidation := func(t *testing.T, opts ...GeneratorOption) *hclsyntax.Attribute {
+		t.Helper()
+		files, err := GenerateFiles("Microsoft.Test/testResource", append([]GeneratorOption{WithAPIVersion("2025-01-01")}, opts...)...)
+		require.NoError(t, err)
+		file, diags := hclsyntax.ParseConfig(files["main.tf"], "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		resource := requireBlock(t, file.Body.(*hclsyntax.Body), "resource", "azapi_resource", "this")
+		return resource.Body.Attributes["schema_validation_enabled"]
 	}
 
-	doc := &openapi3.T{
-		Paths: openapi3.NewPaths(),
+	// Enabled is azapi's default, so the attribute is omitted.
+	assert.Nil(t, schemaValidation(t))
+	assert.Nil(t, schemaValidation(t, WithSchemaValidation(true)))
+
+	attr := schemaValidation(t, WithSchemaValidation(false))
+	require.NotNil(t, attr)
+	value, diags := attr.Expr.Value(nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.False, value)
+}
+
+func TestGenerate_WithIgnoreNullProperty(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"displayName": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+				},
+			}},
+		},
 	}
-	doc.Paths.Set("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/testResource/{name}", &openapi3.PathItem{
-		Put: &openapi3.Operation{
-			Parameters: openapi3.Parameters{
-				{
-					Value: &openapi3.Parameter{
-						Name: "name",
-						In:   "path",
-						Schema: &openapi3.SchemaRef{
-							Value: nameSchema,
-						},
+
+	files, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithAPIVersion("2025-01-01"), WithIgnoreNullProperty(true))
+	require.NoError(t, err)
+	file, diags := hclsyntax.ParseConfig(files["main.tf"], "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	resource := requireBlock(t, file.Body.(*hclsyntax.Body), "resource", "azapi_resource", "this")
+	attr := resource.Body.Attributes["ignore_null_property"]
+	require.NotNil(t, attr)
+	value, diags := attr.Expr.Value(nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.True, value)
+
+	// azapi drops the null attributes itself, so the body locals keep them.
+	assert.NotContains(t, string(files["locals.tf"]), "if v != null")
+
+	defaults, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithAPIVersion("2025-01-01"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(defaults["main.tf"]), "ignore_null_property")
+	assert.NotEqual(t, string(files["locals.tf"]), string(defaults["locals.tf"]))
+
+	// Without it, the body locals leave out the attributes of unset variables.
+	locals, diags := hclsyntax.ParseConfig(defaults["locals.tf"], "locals.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	block := requireBlock(t, locals.Body.(*hclsyntax.Body), "locals")
+	ctx := &hcl.EvalContext{Variables: map[string]cty.Value{
+		"var": cty.ObjectVal(map[string]cty.Value{"display_name": cty.NullVal(cty.String)}),
+	}}
+	body, diags := block.Body.Attributes["resource_body"].Expr.Value(ctx)
+	require.False(t, diags.HasErrors(), diags.Error())
+	properties := body.GetAttr("properties")
+	assert.False(t, properties.Type().HasAttribute("displayName"))
+
+	ctx.Variables["var"] = cty.ObjectVal(map[string]cty.Value{"display_name": cty.StringVal("widget")})
+	body, diags = block.Body.Attributes["resource_body"].Expr.Value(ctx)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.StringVal("widget"), body.GetAttr("properties").GetAttr("displayName"))
+}
+
+func TestGenerate_WithCommentSource(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"kind": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"publicNetworkAccess": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
 					},
 				},
 			},
 		},
-	})
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithAPIVersion("2025-01-01"), WithCommentSource(true))
+	require.NoError(t, err)
+
+	varsBytes, err := os.ReadFile("variables.tf")
+	require.NoError(t, err)
+	vars := string(varsBytes)
+
+	assert.Contains(t, vars, "# source: properties.publicNetworkAccess\nvariable \"public_network_access\" {")
+	assert.Contains(t, vars, "# source: kind\nvariable \"kind\" {")
+	assert.NotContains(t, vars, "# source: name")
+
+	// Comments must not break parsing.
+	varsBody := parseHCLBody(t, "variables.tf")
+	requireBlock(t, varsBody, "variable", "public_network_access")
 
-	err = Generate("Microsoft.Test/testResource", WithSchema(nil), WithLocalName("unused_local"), WithAPIVersion("2024-01-01"), WithSpec(doc))
+	err = Generate("testResource", WithSchema(schema), WithAPIVersion("2025-01-01"))
 	require.NoError(t, err)
 
-	varsBody := parseHCLBody(t, "variables.tf")
-	nameVar := requireBlock(t, varsBody, "variable", "name")
+	varsBytes, err = os.ReadFile("variables.tf")
+	require.NoError(t, err)
+	assert.NotContains(t, string(varsBytes), "# source:")
+}
 
-	var validations []*hclsyntax.Block
-	for _, b := range nameVar.Body.Blocks {
-		if b.Type == "validation" {
-			validations = append(validations, b)
-		}
+func TestGenerate_WithPreserveUnknownExtensions(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"zone": {Value: &openapi3.Schema{
+							Type: &openapi3.Types{"string"},
+							Extensions: map[string]any{
+								"x-ms-mutability": []any{"create", "read"},
+								// Acted on, so not listed.
+								"x-ms-enum":  map[string]any{"name": "Zone", "modelAsString": true},
+								"x-nullable": false,
+							},
+						}},
+						"sku": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					},
+				},
+			},
+		},
 	}
-	require.GreaterOrEqual(t, len(validations), 3)
 
-	var conditions []string
-	for _, b := range validations {
-		condAttr := b.Body.Attributes["condition"]
-		require.NotNil(t, condAttr)
-		conditions = append(conditions, expressionString(t, condAttr.Expr))
-	}
-	joined := strings.Join(conditions, "\n")
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithPreserveUnknownExtensions(true))
+	require.NoError(t, err)
+	vars := string(files["variables.tf"])
+	assert.Contains(t, vars, "# x-ms-mutability: [\"create\",\"read\"]\nvariable \"zone\" {")
+	assert.NotContains(t, vars, "x-ms-enum")
+	assert.NotContains(t, vars, "x-nullable")
+	assert.Equal(t, 1, strings.Count(vars, "# x-ms-"))
+
+	// Comments must not break parsing.
+	_, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
 
-	assert.Contains(t, joined, "length(var.name) >= 1")
-	assert.Contains(t, joined, "length(var.name) <= 63")
-	assert.Contains(t, joined, "can(regex(\"^[a-z0-9-]{1,63}$\", var.name))")
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(schema))
+	require.NoError(t, err)
+	assert.NotContains(t, string(files["variables.tf"]), "# x-ms-")
 }
 
-func TestGenerate_NestedObjectValidations(t *testing.T) {
+func TestGenerate_WithoutTelemetry(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	originalWd, err := os.Getwd()
@@ -221,7 +2175,6 @@ func TestGenerate_NestedObjectValidations(t *testing.T) {
 	err = os.Chdir(tmpDir)
 	require.NoError(t, err)
 
-	maxUsernameLength := uint64(20)
 	schema := &openapi3.Schema{
 		Type: &openapi3.Types{"object"},
 		Properties: map[string]*openapi3.SchemaRef{
@@ -229,66 +2182,81 @@ func TestGenerate_NestedObjectValidations(t *testing.T) {
 				Value: &openapi3.Schema{
 					Type: &openapi3.Types{"object"},
 					Properties: map[string]*openapi3.SchemaRef{
-						"windowsProfile": {
-							Value: &openapi3.Schema{
-								Type:     &openapi3.Types{"object"},
-								Required: []string{"adminUsername"},
-								Properties: map[string]*openapi3.SchemaRef{
-									"adminUsername": {
-										Value: &openapi3.Schema{
-											Type:      &openapi3.Types{"string"},
-											MinLength: 1,
-											MaxLength: &maxUsernameLength,
-										},
-									},
-									"licenseType": {
-										Value: &openapi3.Schema{
-											Type: &openapi3.Types{"string"},
-											Enum: []any{"None", "Windows_Server"},
-										},
-									},
-								},
-							},
-						},
+						"value": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
 					},
 				},
 			},
 		},
 	}
 
-	supportsTags := SupportsTags(schema)
-	supportsLocation := SupportsLocation(schema)
+	err = Generate("testResource", WithSchema(schema), WithAPIVersion("2025-01-01"))
+	require.NoError(t, err)
+	requireBlock(t, parseHCLBody(t, "variables.tf"), "variable", "enable_telemetry")
 
-	err = Generate("Microsoft.Test/testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"), WithSupportsTags(supportsTags), WithSupportsLocation(supportsLocation))
+	err = Generate("testResource", WithSchema(schema), WithAPIVersion("2025-01-01"), WithTelemetry(false))
 	require.NoError(t, err)
 
 	varsBody := parseHCLBody(t, "variables.tf")
-	winVar := requireBlock(t, varsBody, "variable", "windows_profile")
+	assert.Nil(t, findBlock(varsBody, "variable", "enable_telemetry"))
+	requireBlock(t, varsBody, "variable", "value")
 
-	var validations []*hclsyntax.Block
-	for _, b := range winVar.Body.Blocks {
-		if b.Type == "validation" {
-			validations = append(validations, b)
-		}
+	varsBytes, err := os.ReadFile("variables.tf")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(varsBytes), "}\n\n"), "variables.tf should not end with extra blank lines")
+	assert.False(t, strings.HasSuffix(string(varsBytes), "}\n\n\n"), "variables.tf should not end with extra blank lines")
+}
+
+func TestGenerate_IsDeterministic(t *testing.T) {
+	nested := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"zeta":  {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"alpha": {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}, Enum: []any{float64(3), float64(1)}}},
+			"mu":    {Value: &openapi3.Schema{Type: &openapi3.Types{"boolean"}}},
+		},
+	}
+	props := map[string]*openapi3.SchemaRef{
+		"adminPassword": {Value: &openapi3.Schema{
+			Type:       &openapi3.Types{"string"},
+			Extensions: map[string]any{"x-ms-secret": true},
+		}},
+	}
+	for _, name := range []string{"one", "two", "three", "four", "five", "six", "seven", "eight"} {
+		props[name] = &openapi3.SchemaRef{Value: nested}
+		props[name+"Tier"] = &openapi3.SchemaRef{Value: &openapi3.Schema{
+			Type: &openapi3.Types{"string"},
+			Enum: []any{"Premium", "Basic", "Standard"},
+		}}
+	}
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"tags":       {Value: &openapi3.Schema{Type: &openapi3.Types{"object"}}},
+			"properties": {Value: &openapi3.Schema{Type: &openapi3.Types{"object"}, Properties: props}},
+		},
 	}
-	require.GreaterOrEqual(t, len(validations), 2)
 
-	var conditions []string
-	for _, b := range validations {
-		condAttr := b.Body.Attributes["condition"]
-		require.NotNil(t, condAttr)
-		conditions = append(conditions, expressionString(t, condAttr.Expr))
+	generateInto := func(dir string) {
+		err := Generate("Microsoft.Test/widgets", WithSchema(schema), WithAPIVersion("2025-01-01"), WithSupportsTags(true), WithOutputDir(dir))
+		require.NoError(t, err)
 	}
-	joined := strings.Join(conditions, "\n")
 
-	assert.Contains(t, joined, "var.windows_profile == null")
-	assert.Contains(t, joined, "var.windows_profile.admin_username")
-	assert.Contains(t, joined, "length(var.windows_profile.admin_username) <= 20")
-	assert.Contains(t, joined, "var.windows_profile.license_type")
-	assert.Contains(t, joined, "contains(")
+	firstDir := t.TempDir()
+	generateInto(firstDir)
+	for range 5 {
+		nextDir := t.TempDir()
+		generateInto(nextDir)
+		for _, name := range []string{"terraform.tf", "variables.tf", "locals.tf", "main.tf", "outputs.tf"} {
+			first, err := os.ReadFile(filepath.Join(firstDir, name))
+			require.NoError(t, err)
+			next, err := os.ReadFile(filepath.Join(nextDir, name))
+			require.NoError(t, err)
+			assert.Equal(t, string(first), string(next), "%s differs between runs", name)
+		}
+	}
 }
 
-func TestGenerate_QuotesNonIdentifierObjectKeysInLocals(t *testing.T) {
+func TestGenerateFiles(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	originalWd, err := os.Getwd()
@@ -298,6 +2266,52 @@ func TestGenerate_QuotesNonIdentifierObjectKeysInLocals(t *testing.T) {
 	err = os.Chdir(tmpDir)
 	require.NoError(t, err)
 
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"tags": {Value: &openapi3.Schema{Type: &openapi3.Types{"object"}}},
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"zeta":  {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					"alpha": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Enum: []any{"B", "A"}}},
+				},
+			}},
+		},
+	}
+	opts := []GeneratorOption{WithSchema(schema), WithAPIVersion("2025-01-01"), WithSupportsTags(true)}
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", opts...)
+	require.NoError(t, err)
+
+	keys := make([]string, 0, len(files))
+	for name := range files {
+		keys = append(keys, name)
+	}
+	assert.ElementsMatch(t, []string{"terraform.tf", "variables.tf", "locals.tf", "main.tf", "outputs.tf"}, keys)
+	assert.Contains(t, string(files["main.tf"]), `resource "azapi_resource" "this"`)
+
+	// Nothing is written to disk.
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	for range 5 {
+		next, err := GenerateFiles("Microsoft.Test/widgets", opts...)
+		require.NoError(t, err)
+		assert.Equal(t, files, next)
+	}
+
+	// The in-memory output matches what Generate writes to disk.
+	require.NoError(t, Generate("Microsoft.Test/widgets", opts...))
+	for name, content := range files {
+		onDisk, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, string(onDisk), string(content), "%s differs from Generate output", name)
+	}
+}
+
+func TestGenerate_WithMetadata(t *testing.T) {
 	schema := &openapi3.Schema{
 		Type: &openapi3.Types{"object"},
 		Properties: map[string]*openapi3.SchemaRef{
@@ -305,53 +2319,105 @@ func TestGenerate_QuotesNonIdentifierObjectKeysInLocals(t *testing.T) {
 				Value: &openapi3.Schema{
 					Type: &openapi3.Types{"object"},
 					Properties: map[string]*openapi3.SchemaRef{
-						"autoScalerProfile": {
-							Value: &openapi3.Schema{
-								Type: &openapi3.Types{"object"},
-								Properties: map[string]*openapi3.SchemaRef{
-									"balance-similar-node-groups": {
-										Value: &openapi3.Schema{Type: &openapi3.Types{"string"}},
-									},
-									"foo.bar": {
-										Value: &openapi3.Schema{Type: &openapi3.Types{"string"}},
-									},
-								},
-							},
-						},
+						"value": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
 					},
 				},
 			},
 		},
 	}
 
-	apiVersion := "2025-10-01"
-	err = Generate("Microsoft.ContainerService/managedClusters", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion(apiVersion))
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithAPIVersion("2025-01-01"))
 	require.NoError(t, err)
+	assert.NotContains(t, files, "metadata.json", "metadata.json should be opt-in")
 
-	localsBytes, err := os.ReadFile("locals.tf")
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithAPIVersion("2025-01-01"), WithMetadata("Widgets", "Manages widgets."))
 	require.NoError(t, err)
-	locals := string(localsBytes)
-
-	// Hyphenated keys don't need quotes in HCL.
-	assert.Contains(t, locals, "balance-similar-node-groups")
-	// RHS must reference a valid Terraform attribute name (snake_case).
-	assert.Contains(t, locals, "var.auto_scaler_profile.balance_similar_node_groups")
-
-	// Keys containing '.' must be quoted, otherwise HCL treats '.' as traversal syntax.
-	assert.Contains(t, locals, "\"foo.bar\"")
-	assert.Contains(t, locals, "var.auto_scaler_profile.foo_bar")
+	require.Contains(t, files, "metadata.json")
+
+	var manifest map[string]string
+	require.NoError(t, json.Unmarshal(files["metadata.json"], &manifest))
+	assert.Equal(t, "Microsoft.Test/widgets", manifest["resource_type"])
+	assert.Equal(t, "2025-01-01", manifest["api_version"])
+	assert.Equal(t, "azure/azapi", manifest["provider"])
+	assert.Equal(t, "Widgets", manifest["title"])
+	assert.Equal(t, "Manages widgets.", manifest["description"])
+	_, err = time.Parse(time.RFC3339, manifest["generated_at"])
+	assert.NoError(t, err)
 }
 
-func TestGenerate_SkipsSecretsByFullPathNotLeafName(t *testing.T) {
-	tmpDir := t.TempDir()
+func TestGenerate_DiagnosticSettingsDetection(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"value": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					},
+				},
+			},
+		},
+	}
+	const widgetPath = "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}"
+	specWithPaths := func(paths ...string) *openapi3.T {
+		var opts []openapi3.NewPathsOption
+		for _, path := range paths {
+			opts = append(opts, openapi3.WithPath(path, &openapi3.PathItem{Put: &openapi3.Operation{}}))
+		}
+		return &openapi3.T{Paths: openapi3.NewPaths(opts...)}
+	}
 
-	originalWd, err := os.Getwd()
-	require.NoError(t, err)
-	defer os.Chdir(originalWd)
+	tests := []struct {
+		name         string
+		resourceType string
+		spec         *openapi3.T
+		want         bool
+	}{
+		{
+			name:         "diagnostic settings extension path",
+			resourceType: "Microsoft.Test/widgets",
+			spec:         specWithPaths(widgetPath, widgetPath+"/providers/Microsoft.Insights/diagnosticSettings/{name}"),
+			want:         true,
+		},
+		{
+			name:         "no diagnostic settings path",
+			resourceType: "Microsoft.Test/widgets",
+			spec:         specWithPaths(widgetPath),
+			want:         false,
+		},
+		{
+			name:         "diagnostic settings only on a child resource",
+			resourceType: "Microsoft.Test/widgets",
+			spec:         specWithPaths(widgetPath, widgetPath+"/parts/{partName}/providers/Microsoft.Insights/diagnosticSettings/{name}"),
+			want:         false,
+		},
+		{
+			name:         "known support list",
+			resourceType: "Microsoft.KeyVault/vaults",
+			spec:         specWithPaths("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.KeyVault/vaults/{vaultName}"),
+			want:         true,
+		},
+	}
 
-	err = os.Chdir(tmpDir)
-	require.NoError(t, err)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := GenerateFiles(tc.resourceType, WithSchema(schema), WithSpec(tc.spec), WithAPIVersion("2025-01-01"))
+			require.NoError(t, err)
+
+			file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+			diagVar := findBlock(file.Body.(*hclsyntax.Body), "variable", "diagnostic_settings")
+			if tc.want {
+				assert.NotNil(t, diagVar, "diagnostic_settings variable should be generated")
+			} else {
+				assert.Nil(t, diagVar, "diagnostic_settings variable should not be generated")
+			}
+		})
+	}
+}
 
+func TestGenerate_DiagnosticSettingsCategoryValidations(t *testing.T) {
 	schema := &openapi3.Schema{
 		Type: &openapi3.Types{"object"},
 		Properties: map[string]*openapi3.SchemaRef{
@@ -359,48 +2425,211 @@ func TestGenerate_SkipsSecretsByFullPathNotLeafName(t *testing.T) {
 				Value: &openapi3.Schema{
 					Type: &openapi3.Types{"object"},
 					Properties: map[string]*openapi3.SchemaRef{
-						"a": {
-							Value: &openapi3.Schema{
-								Type: &openapi3.Types{"object"},
-								Properties: map[string]*openapi3.SchemaRef{
-									"password": {
-										Value: &openapi3.Schema{
-											Type:       &openapi3.Types{"string"},
-											Extensions: map[string]any{"x-ms-secret": true},
-										},
-									},
-								},
-							},
-						},
-						"b": {
-							Value: &openapi3.Schema{
-								Type: &openapi3.Types{"object"},
-								Properties: map[string]*openapi3.SchemaRef{
-									"password": {
-										Value: &openapi3.Schema{Type: &openapi3.Types{"string"}},
-									},
-								},
-							},
-						},
+						"value": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
 					},
 				},
 			},
 		},
 	}
+	const widgetPath = "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}"
+	categoryParam := openapi3.NewPathParameter("name").WithSchema(openapi3.NewStringSchema().WithEnum("AuditEvent", "RequestLogs"))
+	categoryGroups := openapi3.NewObjectSchema().WithProperty("properties", openapi3.NewObjectSchema().
+		WithProperty("categoryGroups", openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema().WithEnum("allLogs", "audit"))))
+	responses := openapi3.NewResponses(openapi3.WithStatus(200, &openapi3.ResponseRef{Value: openapi3.NewResponse().WithJSONSchema(categoryGroups)}))
+	doc := &openapi3.T{Paths: openapi3.NewPaths(
+		openapi3.WithPath(widgetPath, &openapi3.PathItem{Put: &openapi3.Operation{}}),
+		openapi3.WithPath(widgetPath+"/providers/Microsoft.Insights/diagnosticSettings/{name}", &openapi3.PathItem{Put: &openapi3.Operation{}}),
+		openapi3.WithPath(widgetPath+"/providers/Microsoft.Insights/diagnosticSettingsCategories/{name}", &openapi3.PathItem{Get: &openapi3.Operation{
+			Parameters: openapi3.Parameters{{Value: categoryParam}},
+			Responses:  responses,
+		}}),
+	)}
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithSpec(doc), WithAPIVersion("2025-01-01"))
+	require.NoError(t, err)
+
+	src := files["variables.tf"]
+	file, diags := hclsyntax.ParseConfig(src, "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	diagVar := requireBlock(t, file.Body.(*hclsyntax.Body), "variable", "diagnostic_settings")
+
+	conditions := map[string]string{}
+	for _, block := range diagVar.Body.Blocks {
+		if block.Type == "validation" {
+			conditions[attributeStringValue(t, block.Body.Attributes["error_message"])] = string(block.Body.Attributes["condition"].Expr.Range().SliceBytes(src))
+		}
+	}
+	assert.Len(t, conditions, 4)
+	assert.Equal(t,
+		`alltrue([for _, v in var.diagnostic_settings : alltrue([for c in v.log_categories : contains(["AuditEvent", "RequestLogs"], c)])])`,
+		conditions["Each of `log_categories` must be one of: 'AuditEvent', 'RequestLogs'."])
+	assert.Equal(t,
+		`alltrue([for _, v in var.diagnostic_settings : alltrue([for c in v.log_groups : contains(["allLogs", "audit"], c)])])`,
+		conditions["Each of `log_groups` must be one of: 'allLogs', 'audit'."])
+
+	// Without a category definition only the destination validations are generated.
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithSpec(&openapi3.T{Paths: openapi3.NewPaths(
+		openapi3.WithPath(widgetPath, &openapi3.PathItem{Put: &openapi3.Operation{}}),
+		openapi3.WithPath(widgetPath+"/providers/Microsoft.Insights/diagnosticSettings/{name}", &openapi3.PathItem{Put: &openapi3.Operation{}}),
+	)}), WithAPIVersion("2025-01-01"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(files["variables.tf"]), "v.log_categories")
+	assert.NotContains(t, string(files["variables.tf"]), "v.log_groups")
+}
+
+func TestGenerate_CustomerManagedKeyDetection(t *testing.T) {
+	const widgetPath = "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}"
+	object := func(props map[string]*openapi3.SchemaRef) *openapi3.Schema {
+		return &openapi3.Schema{Type: &openapi3.Types{"object"}, Properties: props}
+	}
+	keyVaultProperties := object(map[string]*openapi3.SchemaRef{
+		"keyIdentifier": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+	})
+	withProperties := func(props map[string]*openapi3.SchemaRef) *openapi3.Schema {
+		return object(map[string]*openapi3.SchemaRef{"properties": {Value: object(props)}})
+	}
+	specWithBody := func(body *openapi3.Schema) *openapi3.T {
+		return &openapi3.T{Paths: openapi3.NewPaths(openapi3.WithPath(widgetPath, &openapi3.PathItem{Put: &openapi3.Operation{
+			RequestBody: &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithJSONSchema(body)},
+		}}))}
+	}
+	readOnlyEncryption := object(map[string]*openapi3.SchemaRef{"keyVaultProperties": {Value: keyVaultProperties}})
+	readOnlyEncryption.ReadOnly = true
+
+	tests := []struct {
+		name string
+		body *openapi3.Schema
+		want bool
+	}{
+		{
+			name: "encryption with key vault properties",
+			body: withProperties(map[string]*openapi3.SchemaRef{
+				"encryption": {Value: object(map[string]*openapi3.SchemaRef{"keyVaultProperties": {Value: keyVaultProperties}})},
+			}),
+			want: true,
+		},
+		{
+			name: "no encryption properties",
+			body: withProperties(map[string]*openapi3.SchemaRef{
+				"value": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			}),
+			want: false,
+		},
+		{
+			name: "read-only encryption",
+			body: withProperties(map[string]*openapi3.SchemaRef{"encryption": {Value: readOnlyEncryption}}),
+			want: false,
+		},
+		{
+			name: "encryption flag that is not an object",
+			body: withProperties(map[string]*openapi3.SchemaRef{
+				"encryption": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Enum: []any{"Enabled", "Disabled"}}},
+			}),
+			want: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(tc.body), WithSpec(specWithBody(tc.body)), WithAPIVersion("2025-01-01"))
+			require.NoError(t, err)
+
+			file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+			cmkVar := findBlock(file.Body.(*hclsyntax.Body), "variable", "customer_managed_key")
+			if tc.want {
+				assert.NotNil(t, cmkVar, "customer_managed_key variable should be generated")
+			} else {
+				assert.Nil(t, cmkVar, "customer_managed_key variable should not be generated")
+			}
+		})
+	}
+}
+
+func TestGenerate_SelfReferentialSchema(t *testing.T) {
+	node := &openapi3.Schema{Type: &openapi3.Types{"object"}}
+	node.Properties = map[string]*openapi3.SchemaRef{
+		"name":     {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+		"parent":   {Value: node},
+		"children": {Value: &openapi3.Schema{Type: &openapi3.Types{"array"}, Items: &openapi3.SchemaRef{Value: node}}},
+	}
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type:       &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{"tree": {Value: node}},
+			}},
+		},
+	}
+
+	// The default depth limit must terminate too.
+	_, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema))
+	require.NoError(t, err)
+
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
 
-	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2025-01-01"))
+	err = Generate("Microsoft.Test/widgets", WithSchema(schema), WithMaxDepth(2))
 	require.NoError(t, err)
 
-	localsBytes, err := os.ReadFile("locals.tf")
+	varsBody := parseHCLBody(t, "variables.tf")
+	treeVar := requireBlock(t, varsBody, "variable", "tree")
+
+	typeExpr := expressionString(t, treeVar.Body.Attributes["type"].Expr)
+	compact := strings.Join(strings.Fields(typeExpr), " ")
+	assert.Contains(t, compact, "children = optional(list(any))")
+	assert.Contains(t, compact, "parent = optional(object({ children = optional(any) name = optional(string) parent = optional(any) }))")
+
+	description := attributeStringValue(t, treeVar.Body.Attributes["description"])
+	assert.Contains(t, description, "Fields nested more than 2 levels deep are typed as `any`")
+
+	// Locals stop converting at the same level, passing the any-typed value through as-is.
+	locals, err := os.ReadFile("locals.tf")
 	require.NoError(t, err)
-	locals := string(localsBytes)
+	assert.Contains(t, strings.Join(strings.Fields(string(locals)), " "), "parent = var.tree.parent.parent")
+}
 
-	// Only the specific secret path should be removed.
-	assert.NotContains(t, locals, "var.a.password")
-	assert.Contains(t, locals, "var.b.password")
+func TestGenerate_AllOfCycleReturnsError(t *testing.T) {
+	base := &openapi3.Schema{Type: &openapi3.Types{"object"}}
+	derived := &openapi3.Schema{
+		Type:  &openapi3.Types{"object"},
+		AllOf: openapi3.SchemaRefs{{Value: base}},
+	}
+	base.AllOf = openapi3.SchemaRefs{{Value: derived}}
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"settings": {Value: &openapi3.Schema{
+						Type:       &openapi3.Types{"object"},
+						Properties: map[string]*openapi3.SchemaRef{"loop": {Value: derived}},
+					}},
+				},
+			}},
+		},
+	}
+
+	for _, style := range []string{LocalsStyleFlat, LocalsStyleNested} {
+		t.Run(style, func(t *testing.T) {
+			files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithLocalsStyle(style))
+			require.Error(t, err)
+			assert.Nil(t, files)
+			assert.ErrorIs(t, err, openapi.ErrAllOfCycle)
+			assert.Contains(t, err.Error(), "getting effective properties at properties.settings.loop")
+		})
+	}
 }
 
-func TestGenerate_DefaultOptions(t *testing.T) {
+func TestGenerate_DataPlaneMode(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	originalWd, err := os.Getwd()
@@ -413,33 +2642,45 @@ func TestGenerate_DefaultOptions(t *testing.T) {
 	schema := &openapi3.Schema{
 		Type: &openapi3.Types{"object"},
 		Properties: map[string]*openapi3.SchemaRef{
-			"properties": {
-				Value: &openapi3.Schema{
-					Type: &openapi3.Types{"object"},
-					Properties: map[string]*openapi3.SchemaRef{
-						"optionalString": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
-						"optionalObj": {
-							Value: &openapi3.Schema{
-								Type: &openapi3.Types{"object"},
-								Properties: map[string]*openapi3.SchemaRef{
-									"nestedOptional": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
-								},
-							},
-						},
-					},
-				},
-			},
+			"value":       {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"contentType": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"tags":        {Value: &openapi3.Schema{Type: &openapi3.Types{"object"}}},
 		},
 	}
-
-	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2025-01-01"))
+	// A data-plane spec has no ARM instance path for the resource.
+	spec := &openapi3.T{Paths: openapi3.NewPaths(openapi3.WithPath("/secrets/{secret-name}", &openapi3.PathItem{
+		Put: &openapi3.Operation{},
+	}))}
+
+	err = Generate("Microsoft.KeyVault/vaults/secrets",
+		WithSchema(schema),
+		WithSpec(spec),
+		WithAPIVersion("7.4"),
+		WithSupportsTags(true),
+		WithDataPlane(true),
+		WithEndpoint("myvault.vault.azure.net"),
+	)
 	require.NoError(t, err)
 
-	mainBytes, err := os.ReadFile("main.tf")
-	require.NoError(t, err)
-	main := string(mainBytes)
+	varsBody := parseHCLBody(t, "variables.tf")
+	endpointVar := requireBlock(t, varsBody, "variable", "endpoint")
+	assert.Equal(t, "myvault.vault.azure.net", attributeStringValue(t, endpointVar.Body.Attributes["default"]))
+	requireBlock(t, varsBody, "variable", "value")
+	requireBlock(t, varsBody, "variable", "content_type")
+	assert.Nil(t, findBlock(varsBody, "variable", "parent_id"))
+	assert.Nil(t, findBlock(varsBody, "variable", "location"))
 
-	assert.NotContains(t, main, "ignore_null_property")
+	mainBody := parseHCLBody(t, "main.tf")
+	assert.Nil(t, findBlock(mainBody, "resource", "azapi_resource", "this"))
+	resourceBlock := requireBlock(t, mainBody, "resource", "azapi_data_plane_resource", "this")
+	assert.Equal(t, "Microsoft.KeyVault/vaults/secrets@7.4", attributeStringValue(t, resourceBlock.Body.Attributes["type"]))
+	assert.Equal(t, "var.endpoint", expressionString(t, resourceBlock.Body.Attributes["parent_id"].Expr))
+	assert.Nil(t, resourceBlock.Body.Attributes["tags"])
+	assert.Nil(t, resourceBlock.Body.Attributes["location"])
+
+	outputsBody := parseHCLBody(t, "outputs.tf")
+	resourceIDOutput := requireBlock(t, outputsBody, "output", "resource_id")
+	assert.Equal(t, "azapi_data_plane_resource.this.id", expressionString(t, resourceIDOutput.Body.Attributes["value"].Expr))
 }
 
 func TestGenerate_FailsOnFlattenedPropertiesNameCollision(t *testing.T) {
@@ -472,6 +2713,94 @@ func TestGenerate_FailsOnFlattenedPropertiesNameCollision(t *testing.T) {
 	assert.Contains(t, err.Error(), "collision")
 }
 
+func TestGenerate_RequiredPropertiesBag(t *testing.T) {
+	bodySchema := func(childRequired ...string) *openapi3.Schema {
+		return &openapi3.Schema{
+			Type:     &openapi3.Types{"object"},
+			Required: []string{"properties"},
+			Properties: map[string]*openapi3.SchemaRef{
+				"properties": {Value: &openapi3.Schema{
+					Type:     &openapi3.Types{"object"},
+					Required: childRequired,
+					Properties: map[string]*openapi3.SchemaRef{
+						"sku":         {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+						"displayName": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					},
+				}},
+			},
+		}
+	}
+	parseVariables := func(t *testing.T, schema *openapi3.Schema) (*hclsyntax.Body, []byte) {
+		t.Helper()
+		files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema))
+		require.NoError(t, err)
+		file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		return file.Body.(*hclsyntax.Body), files["variables.tf"]
+	}
+
+	t.Run("required child is not nullable", func(t *testing.T) {
+		body, src := parseVariables(t, bodySchema("sku"))
+
+		sku := requireBlock(t, body, "variable", "sku")
+		require.Contains(t, sku.Body.Attributes, "nullable")
+		assert.Equal(t, "false", string(sku.Body.Attributes["nullable"].Expr.Range().SliceBytes(src)))
+		assert.NotContains(t, sku.Body.Attributes, "default")
+
+		displayName := requireBlock(t, body, "variable", "display_name")
+		assert.NotContains(t, displayName.Body.Attributes, "nullable")
+		assert.Empty(t, displayName.Body.Blocks)
+	})
+
+	t.Run("no required child needs one set", func(t *testing.T) {
+		body, src := parseVariables(t, bodySchema())
+
+		displayName := requireBlock(t, body, "variable", "display_name")
+		validation := findBlock(displayName.Body, "validation")
+		require.NotNil(t, validation)
+		assert.Equal(t, "var.display_name != null || var.sku != null", string(validation.Body.Attributes["condition"].Expr.Range().SliceBytes(src)))
+		assert.Equal(t, "At least one of display_name, sku must be set, as the resource requires its properties object.", attributeStringValue(t, validation.Body.Attributes["error_message"]))
+		assert.Empty(t, requireBlock(t, body, "variable", "sku").Body.Blocks)
+	})
+
+	t.Run("required nullable child stays nullable", func(t *testing.T) {
+		var schema openapi3.Schema
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"type": "object",
+			"required": ["properties"],
+			"properties": {
+				"properties": {
+					"type": "object",
+					"required": ["sku", "displayName"],
+					"properties": {
+						"sku": {"type": "string"},
+						"displayName": {"type": "string", "nullable": true, "maxLength": 64}
+					}
+				}
+			}
+		}`), &schema))
+		body, src := parseVariables(t, &schema)
+
+		assert.Contains(t, requireBlock(t, body, "variable", "sku").Body.Attributes, "nullable")
+
+		displayName := requireBlock(t, body, "variable", "display_name")
+		assert.NotContains(t, displayName.Body.Attributes, "nullable")
+		assert.NotContains(t, displayName.Body.Attributes, "default")
+		validation := findBlock(displayName.Body, "validation")
+		require.NotNil(t, validation)
+		assert.Equal(t, "var.display_name == null || length(var.display_name) <= 64", string(validation.Body.Attributes["condition"].Expr.Range().SliceBytes(src)))
+	})
+
+	t.Run("optional bag is unchanged", func(t *testing.T) {
+		schema := bodySchema("sku")
+		schema.Required = nil
+		body, _ := parseVariables(t, schema)
+
+		assert.NotContains(t, requireBlock(t, body, "variable", "sku").Body.Attributes, "nullable")
+		assert.Empty(t, requireBlock(t, body, "variable", "display_name").Body.Blocks)
+	})
+}
+
 func TestGenerate_DoesNotDuplicateSecretVarsFromFlattenedProperties(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -595,6 +2924,148 @@ func TestGenerate_IncludesAdditionalPropertiesDescription(t *testing.T) {
 	assert.Contains(t, desc, "- `query_logging` - Enable query logging.")
 }
 
+func TestGenerate_PatternPropertiesMap(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"labels": {
+				"type": "object",
+				"patternProperties": {
+					"^[a-z][a-z0-9-]*$": {
+						"type": "object",
+						"properties": {
+							"value": {"type": "string"},
+							"isSecret": {"type": "boolean"}
+						}
+					}
+				}
+			}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema))
+	require.NoError(t, err)
+
+	variables, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	labels := requireBlock(t, variables.Body.(*hclsyntax.Body), "variable", "labels")
+
+	typeExpr := string(labels.Body.Attributes["type"].Expr.Range().SliceBytes(files["variables.tf"]))
+	assert.Equal(t, "map(object({\n    is_secret = optional(bool)\n    value     = optional(string)\n  }))", typeExpr)
+
+	var conditions []string
+	for _, block := range labels.Body.Blocks {
+		if block.Type != "validation" {
+			continue
+		}
+		conditions = append(conditions, string(block.Body.Attributes["condition"].Expr.Range().SliceBytes(files["variables.tf"])))
+	}
+	assert.Contains(t, conditions, `var.labels == null || alltrue([for k in keys(var.labels) : can(regex("^[a-z][a-z0-9-]*$", k))])`)
+
+	locals := strings.Join(strings.Fields(string(files["locals.tf"])), " ")
+	assert.Contains(t, locals, "labels = var.labels == null ? null : { for k, value in var.labels : k => value == null ? null : {")
+	assert.Contains(t, locals, "isSecret = value.is_secret")
+}
+
+func TestGenerate_AnyOfNullableWrapper(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"displayName": {
+				"description": "The display name.",
+				"anyOf": [{"type": "null"}, {"type": "string", "maxLength": 64}]
+			},
+			"settings": {
+				"anyOf": [
+					{"type": "object", "properties": {"retries": {"anyOf": [{"type": "integer"}, {"type": "null"}]}}},
+					{"type": "null"}
+				]
+			},
+			"choice": {"anyOf": [{"type": "string"}, {"type": "integer"}]}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema))
+	require.NoError(t, err)
+
+	variables, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	body := variables.Body.(*hclsyntax.Body)
+	typeOf := func(name string) string {
+		block := requireBlock(t, body, "variable", name)
+		return strings.Join(strings.Fields(string(block.Body.Attributes["type"].Expr.Range().SliceBytes(files["variables.tf"]))), " ")
+	}
+
+	assert.Equal(t, "string", typeOf("display_name"))
+	assert.Equal(t, "object({ retries = optional(number) })", typeOf("settings"))
+	// Only a null wrapper is collapsed; a real union is still untyped.
+	assert.Equal(t, "any", typeOf("choice"))
+
+	displayName := requireBlock(t, body, "variable", "display_name")
+	assert.Contains(t, displayName.Body.Attributes, "default")
+	src := string(files["variables.tf"])
+	assert.Contains(t, src, "The display name.")
+	assert.Contains(t, src, "length(var.display_name) <= 64")
+
+	locals := strings.Join(strings.Fields(string(files["locals.tf"])), " ")
+	assert.Contains(t, locals, "retries = var.settings.retries")
+}
+
+func TestGenerate_IncludesArrayItemDescription(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"ipRules": {
+				Value: &openapi3.Schema{
+					Type:        &openapi3.Types{"array"},
+					Description: "The IP rules.",
+					Items: &openapi3.SchemaRef{
+						Value: &openapi3.Schema{
+							Type: &openapi3.Types{"object"},
+							Properties: map[string]*openapi3.SchemaRef{
+								"ipAddressRange": {
+									Value: &openapi3.Schema{
+										Type:        &openapi3.Types{"string"},
+										Description: "The IP address range in CIDR notation.",
+									},
+								},
+								"action": {
+									Value: &openapi3.Schema{
+										Type:        &openapi3.Types{"string"},
+										Description: "The action to take.",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	rulesVar := requireBlock(t, varsBody, "variable", "ip_rules")
+	desc := attributeStringValue(t, rulesVar.Body.Attributes["description"])
+	assert.Contains(t, desc, "The IP rules.")
+	assert.Contains(t, desc, "List item fields:\n- `action` - The action to take.")
+	assert.Contains(t, desc, "- `ip_address_range` - The IP address range in CIDR notation.")
+	assert.NotContains(t, desc, "Map values:")
+}
+
 func TestGenerate_WithTagsSupport(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -645,55 +3116,298 @@ func TestGenerate_UsesPlaceholderWhenVersionMissing(t *testing.T) {
 	require.NoError(t, err)
 	defer os.Chdir(originalWd)
 
-	err = os.Chdir(tmpDir)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"location": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"string"},
+				},
+			},
+		},
+	}
+
+	supportsTags := SupportsTags(schema)
+	supportsLocation := SupportsLocation(schema)
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("placeholder_local"), WithSupportsTags(supportsTags), WithSupportsLocation(supportsLocation))
+	require.NoError(t, err)
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resourceBlock := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, "testResource@apiVersion", attributeStringValue(t, resourceBlock.Body.Attributes["type"]))
+}
+
+func TestGenerate_WithNilSchemaSetsEmptyBody(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	err = Generate("testResource", WithSchema(nil), WithLocalName("unused_local"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	requireBlock(t, varsBody, "variable", "name")
+	requireBlock(t, varsBody, "variable", "parent_id")
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resourceBlock := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	bodyExpr := expressionString(t, resourceBlock.Body.Attributes["body"].Expr)
+	assert.Equal(t, "{}", bodyExpr)
+
+	_, err = os.Stat("locals.tf")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGenerate_WithFailOnEmptyBody(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	readOnly := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"id": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, ReadOnly: true}},
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"provisioningState": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, ReadOnly: true}},
+				},
+			}},
+		},
+	}
+
+	// Permissive by default.
+	_, err = GenerateFiles("Microsoft.Test/testResource", WithSchema(readOnly), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	err = Generate("Microsoft.Test/testResource", WithSchema(readOnly), WithAPIVersion("2024-01-01"), WithFailOnEmptyBody(true))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Microsoft.Test/testResource has no writable body properties")
+	entries, err := os.ReadDir(".")
+	require.NoError(t, err)
+	assert.Empty(t, entries, "no files should be written when the body is empty")
+
+	readOnly.Properties["properties"].Value.Properties["displayName"] = &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}}
+	_, err = GenerateFiles("Microsoft.Test/testResource", WithSchema(readOnly), WithAPIVersion("2024-01-01"), WithFailOnEmptyBody(true))
+	require.NoError(t, err)
+}
+
+func TestGenerate_WithValidateAfter(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"location": {"type": "string"},
+			"tags": {"type": "object", "additionalProperties": {"type": "string"}},
+			"properties": {
+				"type": "object",
+				"required": ["sku"],
+				"properties": {
+					"sku": {"type": "string", "enum": ["Basic", "Premium"]},
+					"capacity": {"type": "integer", "minimum": 1, "maximum": 10},
+					"password": {"type": "string", "x-ms-secret": true},
+					"rules": {
+						"type": "array",
+						"items": {"type": "object", "properties": {"name": {"type": "string"}, "port": {"type": "integer"}}}
+					},
+					"state": {"type": "string", "readOnly": true}
+				}
+			}
+		}
+	}`), &schema))
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(&schema), WithAPIVersion("2024-01-01"), WithValidateAfter(true),
+		WithMulti(true), WithCommentGenerated(true), WithVariableDefaultsFile(DefaultVariableDefaultsFile))
+	require.NoError(t, err)
+	for filename, content := range files {
+		if strings.HasSuffix(filename, ".tf") {
+			assert.NoError(t, checkParses(filename, content))
+			assert.Equal(t, string(hclwrite.Format(content)), string(content), filename)
+		}
+	}
+	assert.ErrorContains(t, checkParses("main.tf", []byte("resource \"x\" {\n")), "main.tf is not valid HCL")
+
+	// A file that doesn't parse, here from a local name that isn't an identifier, fails
+	// generation before any file is written, including those generated before it.
+	dir := t.TempDir()
+	err = Generate("Microsoft.Test/widgets", WithSchema(&schema), WithAPIVersion("2024-01-01"), WithValidateAfter(true),
+		WithLocalName("resource body"), WithOutputDir(dir))
+	require.ErrorContains(t, err, "locals.tf is not valid HCL")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestGenerate_WithAsDataSource(t *testing.T) {
+	readOnly := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"id":   {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, ReadOnly: true}},
+			"name": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, ReadOnly: true}},
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"currentValue": {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}, ReadOnly: true}},
+					"limit":        {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}, ReadOnly: true}},
+				},
+			}},
+		},
+	}
+	assert.True(t, isReadOnlyBody(readOnly))
+
+	// Generating a managing module from an all-read-only body warns about it.
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	_, err := GenerateFiles("Microsoft.Test/usages", WithSchema(readOnly), WithAPIVersion("2024-01-01"), WithLogger(logger))
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "entirely read-only")
+
+	files, err := GenerateFiles("Microsoft.Test/usages", WithSchema(readOnly), WithAPIVersion("2024-01-01"), WithAsDataSource(true))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"variables.tf", "main.tf", "outputs.tf", "terraform.tf"}, slices.Collect(maps.Keys(files)))
+
+	main, diags := hclsyntax.ParseConfig(files["main.tf"], "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	mainBody := main.Body.(*hclsyntax.Body)
+	assert.Nil(t, findBlock(mainBody, "resource", "azapi_resource", "this"))
+	data := requireBlock(t, mainBody, "data", "azapi_resource", "this")
+	assert.NotContains(t, data.Body.Attributes, "body")
+	attr := func(name string) string {
+		t.Helper()
+		require.Contains(t, data.Body.Attributes, name)
+		return strings.Join(strings.Fields(string(data.Body.Attributes[name].Expr.Range().SliceBytes(files["main.tf"]))), " ")
+	}
+	assert.Equal(t, `"Microsoft.Test/usages@2024-01-01"`, attr("type"))
+	assert.Equal(t, "var.name", attr("name"))
+	assert.Equal(t, "var.parent_id", attr("parent_id"))
+	assert.Equal(t, `[ "properties.currentValue", "properties.limit" ]`, attr("response_export_values"))
+
+	variables, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	var names []string
+	for _, block := range variables.Body.(*hclsyntax.Body).Blocks {
+		names = append(names, block.Labels[0])
+	}
+	assert.Equal(t, []string{"name", "parent_id"}, names)
+
+	outputs := string(files["outputs.tf"])
+	assert.Contains(t, outputs, "data.azapi_resource.this.id")
+	assert.Contains(t, outputs, "data.azapi_resource.this.output.properties.limit")
+	assert.NotContains(t, outputs, "value = azapi_resource.this")
+
+	_, err = GenerateFiles("Microsoft.Test/usages", WithSchema(readOnly), WithAsDataSource(true), WithMulti(true))
+	assert.ErrorContains(t, err, "data source modules are not supported with multiple instances")
+}
+
+func TestGenerate_WithRequiredOnly(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type:     &openapi3.Types{"object"},
+		Required: []string{"properties", "sku"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"sku":  {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"kind": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"properties": {Value: &openapi3.Schema{
+				Type:     &openapi3.Types{"object"},
+				Required: []string{"capacity"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"capacity":          {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}}},
+					"displayName":       {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					"provisioningState": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, ReadOnly: true}},
+				},
+			}},
+		},
+	}
+
+	t.Run("flat", func(t *testing.T) {
+		files, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithAPIVersion("2024-01-01"), WithRequiredOnly(true))
+		require.NoError(t, err)
+
+		file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		body := file.Body.(*hclsyntax.Body)
+		assert.NotNil(t, findBlock(body, "variable", "sku"))
+		assert.NotNil(t, findBlock(body, "variable", "capacity"))
+		assert.NotNil(t, findBlock(body, "variable", "name"))
+		assert.NotNil(t, findBlock(body, "variable", "parent_id"))
+		assert.Nil(t, findBlock(body, "variable", "kind"), "optional root property should be omitted")
+		assert.Nil(t, findBlock(body, "variable", "display_name"), "optional child of properties should be omitted")
+
+		locals := string(files["locals.tf"])
+		assert.Contains(t, locals, "var.capacity")
+		assert.Contains(t, locals, "var.sku")
+		assert.NotContains(t, locals, "var.kind")
+		assert.NotContains(t, locals, "var.display_name")
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		files, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithAPIVersion("2024-01-01"), WithRequiredOnly(true), WithLocalsStyle(LocalsStyleNested))
+		require.NoError(t, err)
+
+		variables := string(files["variables.tf"])
+		assert.Contains(t, variables, `variable "properties"`)
+		assert.Contains(t, variables, "capacity = number")
+		assert.NotContains(t, variables, "display_name")
+		assert.NotContains(t, variables, `variable "kind"`)
+		assert.NotContains(t, string(files["locals.tf"]), "display_name")
+	})
+
+	// Optional properties are generated by default.
+	files, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithAPIVersion("2024-01-01"))
 	require.NoError(t, err)
+	assert.Contains(t, string(files["variables.tf"]), `variable "kind"`)
+	assert.Contains(t, string(files["variables.tf"]), `variable "display_name"`)
+}
 
+func TestGenerate_WithEnvPrefix(t *testing.T) {
 	schema := &openapi3.Schema{
 		Type: &openapi3.Types{"object"},
 		Properties: map[string]*openapi3.SchemaRef{
-			"location": {
-				Value: &openapi3.Schema{
-					Type: &openapi3.Types{"string"},
+			"properties": {Value: &openapi3.Schema{
+				Type:     &openapi3.Types{"object"},
+				Required: []string{"sku"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"sku":         {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					"displayName": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
 				},
-			},
+			}},
 		},
 	}
 
-	supportsTags := SupportsTags(schema)
-	supportsLocation := SupportsLocation(schema)
-
-	err = Generate("testResource", WithSchema(schema), WithLocalName("placeholder_local"), WithSupportsTags(supportsTags), WithSupportsLocation(supportsLocation))
-	require.NoError(t, err)
-
-	mainBody := parseHCLBody(t, "main.tf")
-	resourceBlock := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
-	assert.Equal(t, "testResource@apiVersion", attributeStringValue(t, resourceBlock.Body.Attributes["type"]))
-}
-
-func TestGenerate_WithNilSchemaSetsEmptyBody(t *testing.T) {
-	tmpDir := t.TempDir()
-
-	originalWd, err := os.Getwd()
-	require.NoError(t, err)
-	defer os.Chdir(originalWd)
-
-	err = os.Chdir(tmpDir)
+	files, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithAPIVersion("2024-01-01"))
 	require.NoError(t, err)
+	assert.NotContains(t, files, ".env.example")
+	assert.NotContains(t, string(files["variables.tf"]), "# env:")
 
-	err = Generate("testResource", WithSchema(nil), WithLocalName("unused_local"), WithAPIVersion("2024-01-01"))
+	files, err = GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithAPIVersion("2024-01-01"), WithEnvPrefix("TF_VAR_"))
 	require.NoError(t, err)
 
-	varsBody := parseHCLBody(t, "variables.tf")
-	requireBlock(t, varsBody, "variable", "name")
-	requireBlock(t, varsBody, "variable", "parent_id")
+	assert.Equal(t, "# Required module inputs (variables without a default).\n"+
+		"TF_VAR_name=\n"+
+		"TF_VAR_parent_id=\n"+
+		"TF_VAR_location=\n"+
+		"TF_VAR_sku=\n", string(files[".env.example"]))
 
-	mainBody := parseHCLBody(t, "main.tf")
-	resourceBlock := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
-	bodyExpr := expressionString(t, resourceBlock.Body.Attributes["body"].Expr)
-	assert.Equal(t, "{}", bodyExpr)
+	variables := string(files["variables.tf"])
+	assert.True(t, strings.HasPrefix(variables, "# env: TF_VAR_name\nvariable \"name\" {"), variables)
+	assert.Contains(t, variables, "# env: TF_VAR_sku\nvariable \"sku\" {")
+	assert.NotContains(t, variables, "TF_VAR_display_name")
 
-	_, err = os.Stat("locals.tf")
-	assert.True(t, os.IsNotExist(err))
+	_, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
 }
 
 func TestMapType(t *testing.T) {
@@ -727,6 +3441,21 @@ func TestMapType(t *testing.T) {
 			},
 			want: "list(string)",
 		},
+		{
+			name: "array of arrays of strings",
+			schema: &openapi3.Schema{
+				Type: &openapi3.Types{"array"},
+				Items: &openapi3.SchemaRef{
+					Value: &openapi3.Schema{
+						Type: &openapi3.Types{"array"},
+						Items: &openapi3.SchemaRef{
+							Value: &openapi3.Schema{Type: &openapi3.Types{"string"}},
+						},
+					},
+				},
+			},
+			want: "list(list(string))",
+		},
 		{
 			name: "object",
 			schema: &openapi3.Schema{
@@ -737,6 +3466,19 @@ func TestMapType(t *testing.T) {
 			},
 			want: "object({\n  prop1 = optional(string)\n})",
 		},
+		{
+			name: "object with attribute defaults",
+			schema: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"tier":     {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Default: "Standard"}},
+					"capacity": {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}, Default: float64(2)}},
+					"enabled":  {Value: &openapi3.Schema{Type: &openapi3.Types{"boolean"}, Default: true}},
+					"mismatch": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Default: float64(1)}},
+				},
+			},
+			want: "object({\n  capacity = optional(number, 2)\n  enabled  = optional(bool, true)\n  mismatch = optional(string)\n  tier     = optional(string, \"Standard\")\n})",
+		},
 		{
 			name: "object with additionalProperties object",
 			schema: &openapi3.Schema{
@@ -756,11 +3498,67 @@ func TestMapType(t *testing.T) {
 			},
 			want: "map(object({\n  max_concurrent = optional(number)\n  query_logging  = string\n}))",
 		},
+		{
+			name: "typeless schema with properties",
+			schema: &openapi3.Schema{
+				Properties: map[string]*openapi3.SchemaRef{
+					"prop1": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+				},
+			},
+			want: "object({\n  prop1 = optional(string)\n})",
+		},
+		{
+			name: "typeless schema with additionalProperties",
+			schema: &openapi3.Schema{
+				AdditionalProperties: openapi3.AdditionalProperties{
+					Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}}},
+				},
+			},
+			want: "map(number)",
+		},
+		{
+			name: "allOf of object properties and additionalProperties",
+			schema: &openapi3.Schema{
+				AllOf: openapi3.SchemaRefs{
+					{Value: &openapi3.Schema{
+						Type: &openapi3.Types{"object"},
+						Properties: map[string]*openapi3.SchemaRef{
+							"name": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+						},
+					}},
+					{Value: &openapi3.Schema{
+						AdditionalProperties: openapi3.AdditionalProperties{
+							Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+						},
+					}},
+				},
+			},
+			want: "object({\n  name = optional(string)\n})",
+		},
+		{
+			name: "allOf of object type and additionalProperties",
+			schema: &openapi3.Schema{
+				AllOf: openapi3.SchemaRefs{
+					{Value: &openapi3.Schema{Type: &openapi3.Types{"object"}}},
+					{Value: &openapi3.Schema{
+						AdditionalProperties: openapi3.AdditionalProperties{
+							Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}}},
+						},
+					}},
+				},
+			},
+			want: "map(number)",
+		},
+		{
+			name:   "typeless schema without properties",
+			schema: &openapi3.Schema{},
+			want:   "any",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotTokens, err := mapType(tt.schema)
+			gotTokens, err := newTypeMapper(DefaultMaxDepth, false, nil).mapType(tt.schema)
 			require.NoError(t, err)
 			got := string(gotTokens.Bytes())
 			assert.Equal(t, tt.want, got)
@@ -768,6 +3566,78 @@ func TestMapType(t *testing.T) {
 	}
 }
 
+func TestTypeMapper_CachesRepeatedShapes(t *testing.T) {
+	shared := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"id":   {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"port": {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}}},
+		},
+	}
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"primary":   {Value: shared},
+			"secondary": {Value: shared},
+			"backups": {Value: &openapi3.Schema{
+				Type:  &openapi3.Types{"array"},
+				Items: &openapi3.SchemaRef{Value: shared},
+			}},
+		},
+	}
+
+	types := newTypeMapper(DefaultMaxDepth, false, nil)
+	_, err := types.mapType(schema)
+	require.NoError(t, err)
+
+	// root, backups array, shared object, and its two scalar fields.
+	assert.Len(t, types.cache, 5)
+
+	first, err := types.mapType(shared)
+	require.NoError(t, err)
+	second, err := types.mapType(shared)
+	require.NoError(t, err)
+	assert.Equal(t, string(first.Bytes()), string(second.Bytes()))
+	assert.NotSame(t, first[0], second[0], "cached tokens must be cloned for each caller")
+}
+
+func BenchmarkTypeMapper_RepeatedShapes(b *testing.B) {
+	shared := &openapi3.Schema{
+		Type:       &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{},
+	}
+	for _, name := range []string{"alpha", "bravo", "charlie", "delta", "echo"} {
+		shared.Properties[name] = &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}}
+	}
+	props := make([]*openapi3.Schema, 0, 50)
+	for range 50 {
+		props = append(props, &openapi3.Schema{
+			Type:       &openapi3.Types{"object"},
+			Properties: map[string]*openapi3.SchemaRef{"settings": {Value: shared}},
+		})
+	}
+
+	b.Run("shared", func(b *testing.B) {
+		for b.Loop() {
+			types := newTypeMapper(DefaultMaxDepth, false, nil)
+			for _, p := range props {
+				if _, err := types.mapType(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		for b.Loop() {
+			for _, p := range props {
+				if _, err := newTypeMapper(DefaultMaxDepth, false, nil).mapType(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
 func TestBuildNestedDescription(t *testing.T) {
 	schema := &openapi3.Schema{
 		Type: &openapi3.Types{"object"},
@@ -785,7 +3655,7 @@ func TestBuildNestedDescription(t *testing.T) {
 		},
 	}
 
-	got, err := buildNestedDescription(schema, "")
+	got, err := buildNestedDescription(schema, "", DefaultMaxDepth, false, 0, nil)
 	require.NoError(t, err)
 	assert.Contains(t, got, "- `prop1` - Description 1")
 	assert.Contains(t, got, "- `nested` - Nested object")
@@ -821,7 +3691,7 @@ func TestConstructValue_MapAdditionalPropertiesObject(t *testing.T) {
 		{Type: hclsyntax.TokenDot, Bytes: []byte(".")},
 		{Type: hclsyntax.TokenIdent, Bytes: []byte("kube_dns_overrides")},
 	}
-	tokens, err := constructValue(schema, accessPath, false, nil, "", false, "")
+	tokens, err := (&bodyBuilder{o: newGeneratorOptions("")}).constructValue(schema, accessPath, false, "", false, false, DefaultMaxDepth)
 	require.NoError(t, err)
 
 	f := hclwrite.NewEmptyFile()
@@ -833,14 +3703,173 @@ func TestConstructValue_MapAdditionalPropertiesObject(t *testing.T) {
 	require.False(t, diags.HasErrors())
 	attr := parsed.Body().GetAttribute("attr")
 	resultTokens := attr.BuildTokens(nil)
-	expected := `attr = var.kube_dns_overrides == null ? null : { for k, value in var.kube_dns_overrides : k => value == null ? null : {
+	expected := `attr = var.kube_dns_overrides == null ? null : { for k, value in var.kube_dns_overrides : k => value == null ? null : { for k, v in {
   maxConcurrent = value.max_concurrent
   queryLogging  = value.query_logging
-} }
+} : k => v if v != null } }
+`
+	assert.Equal(t, expected, string(resultTokens.Bytes()))
+}
+
+func TestConstructValue_ArrayOfArrays(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"array"},
+		Items: &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				Type: &openapi3.Types{"array"},
+				Items: &openapi3.SchemaRef{
+					Value: &openapi3.Schema{
+						Type: &openapi3.Types{"object"},
+						Properties: map[string]*openapi3.SchemaRef{
+							"portNumber": {
+								Value: &openapi3.Schema{
+									Type: &openapi3.Types{"integer"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	accessPath := hclwrite.Tokens{
+		{Type: hclsyntax.TokenIdent, Bytes: []byte("var")},
+		{Type: hclsyntax.TokenDot, Bytes: []byte(".")},
+		{Type: hclsyntax.TokenIdent, Bytes: []byte("port_groups")},
+	}
+	tokens, err := (&bodyBuilder{o: newGeneratorOptions("")}).constructValue(schema, accessPath, false, "", false, false, DefaultMaxDepth)
+	require.NoError(t, err)
+
+	f := hclwrite.NewEmptyFile()
+	f.Body().SetAttributeRaw("attr", tokens)
+	buf := new(bytes.Buffer)
+	_, err = f.WriteTo(buf)
+	require.NoError(t, err)
+	parsed, diags := hclwrite.ParseConfig(buf.Bytes(), "test.tf", hcl.Pos{Line: 1, Column: 1})
+	require.False(t, diags.HasErrors())
+	attr := parsed.Body().GetAttribute("attr")
+	resultTokens := attr.BuildTokens(nil)
+	// The outer list is null-guarded, and each inner list and object element is null-guarded in turn.
+	expected := `attr = var.port_groups == null ? null : [for item in var.port_groups : item == null ? null : [for item in item : item == null ? null : { for k, v in {
+  portNumber = item.port_number
+} : k => v if v != null }]]
+`
+	assert.Equal(t, expected, string(resultTokens.Bytes()))
+}
+
+func TestConstructValue_TypelessObject(t *testing.T) {
+	// Some specs omit type on object schemas that declare properties.
+	schema := &openapi3.Schema{
+		Properties: map[string]*openapi3.SchemaRef{
+			"skuName": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"string"},
+				},
+			},
+		},
+	}
+
+	accessPath := hclwrite.Tokens{
+		{Type: hclsyntax.TokenIdent, Bytes: []byte("var")},
+		{Type: hclsyntax.TokenDot, Bytes: []byte(".")},
+		{Type: hclsyntax.TokenIdent, Bytes: []byte("sku")},
+	}
+	tokens, err := (&bodyBuilder{o: newGeneratorOptions("")}).constructValue(schema, accessPath, false, "", false, false, DefaultMaxDepth)
+	require.NoError(t, err)
+
+	f := hclwrite.NewEmptyFile()
+	f.Body().SetAttributeRaw("attr", tokens)
+	buf := new(bytes.Buffer)
+	_, err = f.WriteTo(buf)
+	require.NoError(t, err)
+	parsed, diags := hclwrite.ParseConfig(buf.Bytes(), "test.tf", hcl.Pos{Line: 1, Column: 1})
+	require.False(t, diags.HasErrors())
+	attr := parsed.Body().GetAttribute("attr")
+	resultTokens := attr.BuildTokens(nil)
+	expected := `attr = var.sku == null ? null : { for k, v in {
+  skuName = var.sku.sku_name
+} : k => v if v != null }
 `
 	assert.Equal(t, expected, string(resultTokens.Bytes()))
 }
 
+func TestConstructValue_QuotesKeywordAndNonIdentifierKeys(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"true":   {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"123abc": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"for":    {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			"name":   {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+		},
+	}
+
+	tokens, err := (&bodyBuilder{o: newGeneratorOptions("")}).constructValue(schema, hclwrite.TokensForIdentifier("var"), true, "", false, false, DefaultMaxDepth)
+	require.NoError(t, err)
+	src := hclwrite.Format(tokens.Bytes())
+
+	for _, key := range []string{"true", "123abc", "for"} {
+		assert.Regexp(t, `(?m)^  "`+key+`"\s+= var\.`, string(src))
+	}
+	assert.Regexp(t, `(?m)^  name\s+= var\.name$`, string(src))
+
+	// Unquoted, `for` would open a for expression and `true` would be a bool key.
+	expr, diags := hclsyntax.ParseExpression(src, "locals.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	filter, ok := expr.(*hclsyntax.ForExpr)
+	require.True(t, ok, "expected a null-filtering for expression, got %T", expr)
+	object, ok := filter.CollExpr.(*hclsyntax.ObjectConsExpr)
+	require.True(t, ok, "expected an object constructor, got %T", filter.CollExpr)
+	var keys []string
+	for _, item := range object.Items {
+		key, diags := item.KeyExpr.Value(nil)
+		require.False(t, diags.HasErrors(), diags.Error())
+		keys = append(keys, key.AsString())
+	}
+	assert.ElementsMatch(t, []string{"true", "123abc", "for", "name"}, keys)
+}
+
+func TestConstructValue_DropEmpty(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"network": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"subnetId": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+				},
+			}},
+			"zones": {Value: &openapi3.Schema{
+				Type:  &openapi3.Types{"array"},
+				Items: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+			}},
+		},
+	}
+
+	build := func(t *testing.T, dropEmpty bool) string {
+		t.Helper()
+		tokens, err := (&bodyBuilder{o: newGeneratorOptions("", WithDropEmpty(dropEmpty))}).constructValue(schema, hclwrite.TokensForIdentifier("var"), true, "", false, false, DefaultMaxDepth)
+		require.NoError(t, err)
+		return string(hclwrite.Format(tokens.Bytes()))
+	}
+
+	assert.Equal(t, `{ for k, v in {
+  network = var.network == null ? null : { for k, v in {
+    subnetId = var.network.subnet_id
+  } : k => v if v != null }
+  zones = var.zones == null ? null : [for item in var.zones : item]
+} : k => v if v != null }`, build(t, false))
+
+	// An object whose attributes are all null and an empty list both become null.
+	assert.Equal(t, `{ for k, v in {
+  network = try(alltrue([for v in values(var.network) : v == null]), true) ? null : { for k, v in {
+    subnetId = var.network.subnet_id
+  } : k => v if v != null }
+  zones = try(length(var.zones), 0) == 0 ? null : [for item in var.zones : item]
+} : k => v if v != null }`, build(t, true))
+}
+
 func parseHCLBody(t *testing.T, path string) *hclsyntax.Body {
 	t.Helper()
 
@@ -1033,6 +4062,156 @@ func TestGenerate_WithSecretFields(t *testing.T) {
 	assert.Contains(t, sensitiveBodyVersionExpr, "var.api_key_version")
 }
 
+func TestGenerate_WriteOnlyFieldsAreSecrets(t *testing.T) {
+	// writeOnly is the standard OpenAPI marker for inputs never returned in responses, so it is
+	// handled like x-ms-secret without the extension.
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"userName":      {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					"adminPassword": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, WriteOnly: true}},
+				},
+			}},
+		},
+	}
+
+	files, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	parse := func(name string) *hclsyntax.Body {
+		file, diags := hclsyntax.ParseConfig(files[name], name, hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		return file.Body.(*hclsyntax.Body)
+	}
+
+	varsBody := parse("variables.tf")
+	passwordVar := requireBlock(t, varsBody, "variable", "admin_password")
+	ephemeral, diags := passwordVar.Body.Attributes["ephemeral"].Expr.Value(nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.True(t, ephemeral.True(), "writeOnly field should be ephemeral")
+	requireBlock(t, varsBody, "variable", "admin_password_version")
+	userNameVar := requireBlock(t, varsBody, "variable", "user_name")
+	assert.Nil(t, userNameVar.Body.Attributes["ephemeral"])
+
+	assert.NotContains(t, string(files["locals.tf"]), "adminPassword")
+
+	resource := requireBlock(t, parse("main.tf"), "resource", "azapi_resource", "this")
+	require.NotNil(t, resource.Body.Attributes["sensitive_body"])
+	require.NotNil(t, resource.Body.Attributes["sensitive_body_version"])
+	main := string(files["main.tf"])
+	assert.Contains(t, main, "adminPassword = var.admin_password")
+	assert.Contains(t, main, `"properties.adminPassword" = var.admin_password_version`)
+}
+
+func TestGenerate_WithHeuristicSecrets(t *testing.T) {
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"userName":      {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					"adminPassword": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					"apiKey":        {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Extensions: map[string]any{"x-ms-secret": true}}},
+					"keySource":     {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Enum: []any{"Microsoft.KeyVault", "Microsoft.Storage"}}},
+					"tokenLifetime": {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}}},
+				},
+			}},
+		},
+	}
+
+	files, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema))
+	require.NoError(t, err)
+	assert.Contains(t, string(files["locals.tf"]), "adminPassword")
+
+	files, err = GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithHeuristicSecrets(true))
+	require.NoError(t, err)
+
+	parse := func(name string) *hclsyntax.Body {
+		file, diags := hclsyntax.ParseConfig(files[name], name, hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		return file.Body.(*hclsyntax.Body)
+	}
+
+	varsBody := parse("variables.tf")
+	passwordVar := requireBlock(t, varsBody, "variable", "admin_password")
+	require.Contains(t, passwordVar.Body.Attributes, "ephemeral")
+	ephemeral, diags := passwordVar.Body.Attributes["ephemeral"].Expr.Value(nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.True(t, ephemeral.True(), "adminPassword should be ephemeral")
+	requireBlock(t, varsBody, "variable", "admin_password_version")
+	// Fields already marked are secrets once, not twice.
+	requireBlock(t, varsBody, "variable", "api_key_version")
+	assert.Equal(t, 1, strings.Count(string(files["variables.tf"]), `variable "api_key"`))
+	for _, name := range []string{"user_name", "key_source", "token_lifetime"} {
+		assert.NotContains(t, requireBlock(t, varsBody, "variable", name).Body.Attributes, "ephemeral", name)
+	}
+
+	assert.NotContains(t, string(files["locals.tf"]), "adminPassword")
+	main := string(files["main.tf"])
+	assert.Contains(t, main, "adminPassword = var.admin_password")
+	assert.Contains(t, main, `"properties.adminPassword" = var.admin_password_version`)
+	assert.Contains(t, main, `"properties.apiKey"        = var.api_key_version`)
+	// The caller's schema is not modified.
+	assert.NotContains(t, schema.Properties["properties"].Value.Properties["adminPassword"].Value.Extensions, "x-ms-secret")
+}
+
+func TestGenerate_WithNestedSecrets(t *testing.T) {
+	secret := func() *openapi3.SchemaRef {
+		return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Extensions: map[string]any{"x-ms-secret": true}}}
+	}
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {Value: &openapi3.Schema{
+				Type: &openapi3.Types{"object"},
+				Properties: map[string]*openapi3.SchemaRef{
+					"userName":      {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+					"adminPassword": secret(),
+					"connection": {Value: &openapi3.Schema{
+						Type: &openapi3.Types{"object"},
+						Properties: map[string]*openapi3.SchemaRef{
+							"host":   {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+							"apiKey": secret(),
+						},
+					}},
+				},
+			}},
+		},
+	}
+
+	files, err := GenerateFiles("Microsoft.Test/testResource", WithSchema(schema), WithNestedSecrets(true))
+	require.NoError(t, err)
+
+	file, diags := hclsyntax.ParseConfig(files["variables.tf"], "variables.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	varsBody := file.Body.(*hclsyntax.Body)
+	for _, name := range []string{"admin_password", "admin_password_version", "api_key", "api_key_version"} {
+		assert.Nil(t, findBlock(varsBody, "variable", name), name)
+	}
+	requireBlock(t, varsBody, "variable", "user_name")
+	connectionVar := requireBlock(t, varsBody, "variable", "connection")
+	assert.NotContains(t, connectionVar.Body.Attributes, "ephemeral")
+
+	secretsVar := requireBlock(t, varsBody, "variable", "sensitive_body")
+	ephemeral, diags := secretsVar.Body.Attributes["ephemeral"].Expr.Value(nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.True(t, ephemeral.True())
+	typeExpr := string(secretsVar.Body.Attributes["type"].Expr.Range().SliceBytes(files["variables.tf"]))
+	assert.Equal(t, "object({\n    properties = optional(object({\n      adminPassword = optional(string)\n      connection = optional(object({\n        apiKey = optional(string)\n      }))\n    }))\n  })", typeExpr)
+	requireBlock(t, varsBody, "variable", "sensitive_body_version")
+
+	assert.NotContains(t, string(files["locals.tf"]), "adminPassword")
+	assert.NotContains(t, string(files["locals.tf"]), "apiKey")
+	main := string(files["main.tf"])
+	assert.Contains(t, main, "sensitive_body = var.sensitive_body\n")
+	assert.Contains(t, main, `"properties.adminPassword"     = var.sensitive_body_version`)
+	assert.Contains(t, main, `"properties.connection.apiKey" = var.sensitive_body_version`)
+}
+
 func TestGenerate_ArraySecretItems_TreatedAsSingleSecretArray(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -3,7 +3,9 @@ package terraform
 import (
 	"unicode"
 
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -25,9 +27,45 @@ func isHCLIdentifier(s string) bool {
 	return true
 }
 
+// hclKeywords are identifiers HCL gives a meaning of their own where an expression starts: the
+// literals true, false and null, and for, which opens a for expression. An object key spelled
+// like one must be quoted to be taken as a plain string.
+var hclKeywords = map[string]struct{}{
+	"true":  {},
+	"false": {},
+	"null":  {},
+	"for":   {},
+}
+
+// tokensForObjectKey returns key as an object constructor key: a bare identifier where HCL
+// reads it as one, and a quoted string otherwise, e.g. for keys containing dots, starting with
+// a digit or spelled like a keyword.
 func tokensForObjectKey(key string) hclwrite.Tokens {
-	if isHCLIdentifier(key) {
+	if _, keyword := hclKeywords[key]; !keyword && isHCLIdentifier(key) {
 		return hclwrite.TokensForIdentifier(key)
 	}
 	return hclwrite.TokensForValue(cty.StringVal(key))
 }
+
+// objectAttributeName returns the Terraform name of property as an attribute of an object type.
+// Type attributes must be bare identifiers, and one spelled like an HCL keyword either doesn't
+// parse (for) or reads as a literal, so it gets a trailing underscore, e.g. for_. The locals
+// map the renamed attribute back to the property.
+func objectAttributeName(namer *naming.Namer, property string) string {
+	name := namer.ToSnakeCase(property)
+	if _, keyword := hclKeywords[name]; keyword {
+		return name + "_"
+	}
+	return name
+}
+
+// separateBlocks appends a newline to a non-empty body unless it already ends in a blank line,
+// so that the next block is set off by exactly one blank line whatever came before it.
+func separateBlocks(body *hclwrite.Body) {
+	tokens := body.BuildTokens(nil)
+	n := len(tokens)
+	if n == 0 || (n >= 2 && tokens[n-1].Type == hclsyntax.TokenNewline && tokens[n-2].Type == hclsyntax.TokenNewline) {
+		return
+	}
+	body.AppendNewline()
+}
@@ -0,0 +1,86 @@
+package terraform
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/matt-FFFFFF/tfmodmake/openapi"
+)
+
+// identifierMapKey returns the property keying the items of an array marked with
+// x-ms-identifiers, for use as the key of a map variable. Only a single identifier naming a
+// writable string property of object items qualifies: composite or nested identifiers can't be
+// a map key, and a read-only one could not be set.
+func identifierMapKey(schema *openapi3.Schema) (string, bool) {
+	if schema == nil || !slices.Contains(openapi.EffectiveTypes(schema), "array") || schema.Items == nil || schema.Items.Value == nil {
+		return "", false
+	}
+	var identifiers []string
+	switch v := schema.Extensions["x-ms-identifiers"].(type) {
+	case []string:
+		identifiers = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				identifiers = append(identifiers, s)
+			}
+		}
+	}
+	if len(identifiers) != 1 {
+		return "", false
+	}
+	// Identifiers may be written as a JSON pointer into the item, e.g. "/name".
+	key := strings.TrimPrefix(identifiers[0], "/")
+	if key == "" || strings.Contains(key, "/") {
+		return "", false
+	}
+
+	items := schema.Items.Value
+	if !slices.Contains(openapi.EffectiveTypes(items), "object") {
+		return "", false
+	}
+	props, err := openapi.GetEffectiveProperties(items)
+	if err != nil {
+		return "", false
+	}
+	prop, ok := props[key]
+	if !ok || prop == nil || prop.Value == nil || !isWritableProperty(prop.Value) || isSecretField(prop.Value) {
+		return "", false
+	}
+	if !slices.Contains(openapi.EffectiveTypes(prop.Value), "string") {
+		return "", false
+	}
+	return key, true
+}
+
+// identifierMapValueSchema returns the item schema of an identifier map without its key
+// property, which is carried by the map key instead. allOf components are merged so the key is
+// dropped wherever it was declared.
+func identifierMapValueSchema(items *openapi3.Schema, key string) (*openapi3.Schema, error) {
+	props, err := openapi.GetEffectiveProperties(items)
+	if err != nil {
+		return nil, err
+	}
+	required, err := openapi.GetEffectiveRequired(items)
+	if err != nil {
+		return nil, err
+	}
+
+	value := *items
+	value.AllOf = nil
+	value.Type = &openapi3.Types{"object"}
+	value.Properties = make(openapi3.Schemas, len(props))
+	for name, prop := range props {
+		if name != key {
+			value.Properties[name] = prop
+		}
+	}
+	value.Required = nil
+	for _, name := range required {
+		if name != key {
+			value.Required = append(value.Required, name)
+		}
+	}
+	return &value, nil
+}
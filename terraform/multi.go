@@ -0,0 +1,491 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// instancesVariable is the map variable a multi-instance module creates one resource per entry
+// of.
+const instancesVariable = "instances"
+
+// multiInstanceFiles are the files WithMulti rewrites. They are all generated before any is
+// rewritten, as each rewrite depends on the variables and locals the others declare.
+var multiInstanceFiles = []string{"variables.tf", "locals.tf", "main.tf", "outputs.tf"}
+
+// sharedVariables are the variables a multi-instance module keeps at module level rather than
+// moving into var.instances, as they configure the module rather than one resource.
+var sharedVariables = map[string]struct{}{
+	"customer_managed_key": {},
+	"diagnostic_settings":  {},
+	"enable_telemetry":     {},
+	"endpoint":             {},
+	"lock":                 {},
+	"private_endpoints":    {},
+	"private_endpoints_manage_dns_zone_group": {},
+	"role_assignments":                        {},
+	"timeouts":                                {},
+}
+
+// multiInstanceRewriter turns a generated single-resource module into one creating a resource
+// per entry of var.instances:
+//   - each per-resource variable becomes an attribute of the instances object, with its
+//     validations checked for every instance;
+//   - secret variables, which must stay ephemeral, become maps keyed by instance;
+//   - locals built from those variables become maps keyed by instance;
+//   - the resource iterates over var.instances, and each output becomes a map keyed by instance.
+type multiInstanceRewriter struct {
+	resourceBlockType string
+	resourceName      string
+	// instanceVars are the variables moved into var.instances.
+	instanceVars map[string]struct{}
+	// secretVars are the secret and secret version variables, keyed by instance.
+	secretVars map[string]struct{}
+	// instanceLocals are the locals keyed by instance.
+	instanceLocals map[string]struct{}
+	// errorMessagePrefix namespaces the error messages of added validations.
+	errorMessagePrefix string
+}
+
+// toMultiInstance rewrites the multiInstanceFiles in files in place.
+func toMultiInstance(files map[string][]byte, resourceBlockType, resourceName, errorMessagePrefix string, secrets []secretField) error {
+	m := &multiInstanceRewriter{
+		resourceBlockType:  resourceBlockType,
+		resourceName:       resourceName,
+		instanceVars:       make(map[string]struct{}),
+		secretVars:         make(map[string]struct{}),
+		instanceLocals:     make(map[string]struct{}),
+		errorMessagePrefix: errorMessagePrefix,
+	}
+	for _, secret := range secrets {
+		m.secretVars[secret.varName] = struct{}{}
+		m.secretVars[secret.varName+"_version"] = struct{}{}
+	}
+
+	steps := []struct {
+		filename string
+		rewrite  func([]byte) ([]byte, error)
+	}{
+		{"variables.tf", m.rewriteVariables},
+		{"locals.tf", m.rewriteLocals},
+		{"main.tf", m.rewriteMain},
+		{"outputs.tf", m.rewriteOutputs},
+	}
+	for _, step := range steps {
+		src, ok := files[step.filename]
+		if !ok {
+			continue
+		}
+		rewritten, err := step.rewrite(src)
+		if err != nil {
+			return fmt.Errorf("rewriting %s for multiple instances: %w", step.filename, err)
+		}
+		files[step.filename] = rewritten
+	}
+	return nil
+}
+
+// rewriteVariables replaces the per-resource variables with var.instances, declared where the
+// first of them was, and keys the secret variables by instance.
+func (m *multiInstanceRewriter) rewriteVariables(src []byte) ([]byte, error) {
+	file, diags := hclwrite.ParseConfig(src, "variables.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	parsed, diags := hclsyntax.ParseConfig(src, "variables.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	descriptions := make(map[string]string)
+	for _, block := range parsed.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "variable" || len(block.Labels) != 1 {
+			continue
+		}
+		if attr, ok := block.Body.Attributes["description"]; ok {
+			if value, diags := attr.Expr.Value(nil); !diags.HasErrors() && value.Type() == cty.String && value.IsKnown() && !value.IsNull() {
+				descriptions[block.Labels[0]] = value.AsString()
+			}
+		}
+	}
+
+	for _, block := range file.Body().Blocks() {
+		if block.Type() != "variable" || len(block.Labels()) != 1 {
+			continue
+		}
+		name := block.Labels()[0]
+		if _, ok := sharedVariables[name]; ok {
+			continue
+		}
+		if _, ok := m.secretVars[name]; ok {
+			continue
+		}
+		if name == instancesVariable {
+			return nil, fmt.Errorf("variable %q would be shadowed by the %s map", name, instancesVariable)
+		}
+		m.instanceVars[name] = struct{}{}
+	}
+
+	out := hclwrite.NewEmptyFile()
+	body := out.Body()
+	var instancesBody *hclwrite.Body
+	var attrs []hclwrite.ObjectAttrTokens
+	var docs []string
+	var validations []*hclwrite.Block
+	for _, block := range file.Body().Blocks() {
+		if block.Type() != "variable" || len(block.Labels()) != 1 {
+			body.AppendBlock(block)
+			body.AppendNewline()
+			continue
+		}
+		name := block.Labels()[0]
+		if _, ok := m.instanceVars[name]; !ok {
+			if _, ok := m.secretVars[name]; ok {
+				m.keySecretVariable(block, descriptions[name])
+			}
+			body.AppendBlock(block)
+			body.AppendNewline()
+			continue
+		}
+
+		if instancesBody == nil {
+			instancesBody = body.AppendNewBlock("variable", []string{instancesVariable}).Body()
+			body.AppendNewline()
+		}
+		varBody := block.Body()
+		typeTokens := hclwrite.TokensForIdentifier("any")
+		if attr := varBody.GetAttribute("type"); attr != nil {
+			typeTokens = attr.Expr().BuildTokens(nil)
+		}
+		if attr := varBody.GetAttribute("default"); attr != nil {
+			defaultTokens := attr.Expr().BuildTokens(nil)
+			if strings.TrimSpace(string(defaultTokens.Bytes())) == "null" {
+				typeTokens = hclwrite.TokensForFunctionCall("optional", typeTokens)
+			} else {
+				typeTokens = hclwrite.TokensForFunctionCall("optional", typeTokens, defaultTokens)
+			}
+		}
+		attrs = append(attrs, hclwrite.ObjectAttrTokens{Name: hclwrite.TokensForIdentifier(name), Value: typeTokens})
+		doc, _, _ := strings.Cut(strings.TrimSpace(descriptions[name]), "\n")
+		docs = append(docs, fmt.Sprintf("- `%s` - %s", name, doc))
+
+		for _, nested := range varBody.Blocks() {
+			if nested.Type() != "validation" {
+				continue
+			}
+			condition := nested.Body().GetAttribute("condition")
+			if condition == nil {
+				continue
+			}
+			tokens, _ := replaceTraversals(condition.Expr().BuildTokens(nil), "var", func(name string) hclwrite.Tokens {
+				if _, ok := m.instanceVars[name]; ok {
+					return hclgen.TokensForTraversal("instance", name)
+				}
+				return nil
+			})
+			nested.Body().SetAttributeRaw("condition", tokensForAllTrue("instance", hclgen.TokensForTraversal("var", instancesVariable), tokens))
+			validations = append(validations, nested)
+		}
+	}
+
+	if instancesBody != nil {
+		description := "A map of the resources to create, keyed by an arbitrary instance key. Each instance takes:\n\n" + strings.Join(docs, "\n")
+		instancesBody.SetAttributeRaw("description", hclgen.TokensForHeredoc(description))
+		instancesBody.SetAttributeRaw("type", hclwrite.TokensForFunctionCall("map", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject(attrs))))
+		instancesBody.SetAttributeValue("nullable", cty.False)
+		for _, validation := range validations {
+			instancesBody.AppendBlock(validation)
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// keySecretVariable turns a secret, or secret version, variable into a map keyed by instance.
+// Secrets can't move into var.instances, as the whole map would have to be ephemeral and
+// ephemeral values can't drive for_each.
+func (m *multiInstanceRewriter) keySecretVariable(block *hclwrite.Block, description string) {
+	name := block.Labels()[0]
+	varBody := block.Body()
+	varBody.SetAttributeRaw("description", hclgen.TokensForHeredoc(strings.TrimSpace(description)+"\n\nA map keyed by the instance keys of var."+instancesVariable+"."))
+	if attr := varBody.GetAttribute("type"); attr != nil {
+		varBody.SetAttributeRaw("type", hclwrite.TokensForFunctionCall("map", attr.Expr().BuildTokens(nil)))
+	}
+	varBody.SetAttributeRaw("default", hclwrite.TokensForObject(nil))
+	varBody.SetAttributeValue("nullable", cty.False)
+
+	secretName, isVersion := strings.CutSuffix(name, "_version")
+	if _, ok := m.secretVars[secretName]; !isVersion || !ok {
+		for _, nested := range varBody.Blocks() {
+			if nested.Type() != "validation" || nested.Body().GetAttribute("condition") == nil {
+				continue
+			}
+			tokens, _ := replaceTraversals(nested.Body().GetAttribute("condition").Expr().BuildTokens(nil), "var", func(ref string) hclwrite.Tokens {
+				if ref == name {
+					return hclwrite.TokensForIdentifier("value")
+				}
+				return nil
+			})
+			nested.Body().SetAttributeRaw("condition", tokensForAllTrue("value", hclgen.TokensForTraversal("var", name), tokens))
+		}
+		return
+	}
+
+	// The version must be set for every instance the secret is set for.
+	for _, nested := range varBody.Blocks() {
+		if nested.Type() == "validation" {
+			varBody.RemoveBlock(nested)
+		}
+	}
+	lookup := hclwrite.TokensForFunctionCall("lookup", hclgen.TokensForTraversal("var", name), hclwrite.TokensForIdentifier("instance_key"), hclwrite.TokensForIdentifier("null"))
+	condition := append(lookup, &hclwrite.Token{Type: hclsyntax.TokenNotEqual, Bytes: []byte("!=")})
+	condition = append(condition, hclwrite.TokensForIdentifier("null")...)
+	appendValidation(varBody,
+		tokensForAllTrue("instance_key", hclwrite.TokensForFunctionCall("keys", hclgen.TokensForTraversal("var", secretName)), condition),
+		prefixedErrorMessage(m.errorMessagePrefix, fmt.Sprintf("When %s is set for an instance, %s must also be set for it.", secretName, name)))
+}
+
+// rewriteLocals keys each local built from per-resource variables, directly or through other
+// such locals, by instance.
+func (m *multiInstanceRewriter) rewriteLocals(src []byte) ([]byte, error) {
+	file, diags := hclwrite.ParseConfig(src, "locals.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	exprs := make(map[string]hclwrite.Tokens)
+	bodies := make(map[string]*hclwrite.Body)
+	for _, block := range file.Body().Blocks() {
+		if block.Type() != "locals" {
+			continue
+		}
+		for name, attr := range block.Body().Attributes() {
+			exprs[name] = attr.Expr().BuildTokens(nil)
+			bodies[name] = block.Body()
+		}
+	}
+
+	// A local is per instance when it refers to a variable or local that is.
+	for changed := true; changed; {
+		changed = false
+		for name, tokens := range exprs {
+			if _, ok := m.instanceLocals[name]; ok {
+				continue
+			}
+			_, refersToVar := replaceTraversals(tokens, "var", func(ref string) hclwrite.Tokens {
+				return m.instanceReference(ref, "instance", "instance_key")
+			})
+			_, refersToLocal := replaceTraversals(tokens, "local", func(ref string) hclwrite.Tokens {
+				return m.instanceLocalReference(ref, hclwrite.TokensForIdentifier("instance_key"))
+			})
+			if refersToVar || refersToLocal {
+				m.instanceLocals[name] = struct{}{}
+				changed = true
+			}
+		}
+	}
+
+	for name := range m.instanceLocals {
+		tokens, _ := replaceTraversals(exprs[name], "var", func(ref string) hclwrite.Tokens {
+			return m.instanceReference(ref, "instance", "instance_key")
+		})
+		tokens, _ = replaceTraversals(tokens, "local", func(ref string) hclwrite.Tokens {
+			return m.instanceLocalReference(ref, hclwrite.TokensForIdentifier("instance_key"))
+		})
+		bodies[name].SetAttributeRaw(name, tokensForForObject("instance_key", "instance", hclgen.TokensForTraversal("var", instancesVariable), tokens))
+	}
+	return file.Bytes(), nil
+}
+
+// rewriteMain makes the resource iterate over var.instances, reading its inputs from each.value.
+func (m *multiInstanceRewriter) rewriteMain(src []byte) ([]byte, error) {
+	file, diags := hclwrite.ParseConfig(src, "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	out := hclwrite.NewEmptyFile()
+	body := out.Body()
+	for i, block := range file.Body().Blocks() {
+		if i > 0 {
+			body.AppendNewline()
+		}
+		rewriteBodyExpressions(block.Body(), func(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+			tokens, varChanged := replaceTraversals(tokens, "var", func(ref string) hclwrite.Tokens {
+				return m.instanceReference(ref, "each.value", "each.key")
+			})
+			tokens, localChanged := replaceTraversals(tokens, "local", func(ref string) hclwrite.Tokens {
+				return m.instanceLocalReference(ref, hclgen.TokensForTraversal("each", "key"))
+			})
+			return tokens, varChanged || localChanged
+		})
+
+		labels := block.Labels()
+		if block.Type() != "resource" || len(labels) != 2 || labels[0] != m.resourceBlockType || labels[1] != m.resourceName {
+			body.AppendBlock(block)
+			continue
+		}
+		resourceBody := body.AppendNewBlock("resource", labels).Body()
+		resourceBody.SetAttributeRaw("for_each", hclgen.TokensForTraversal("var", instancesVariable))
+		resourceBody.AppendUnstructuredTokens(block.Body().BuildTokens(nil))
+	}
+	return out.Bytes(), nil
+}
+
+// rewriteOutputs makes each output referring to the resource a map keyed by instance, and
+// checks each precondition for every instance.
+func (m *multiInstanceRewriter) rewriteOutputs(src []byte) ([]byte, error) {
+	file, diags := hclwrite.ParseConfig(src, "outputs.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	parsed, diags := hclsyntax.ParseConfig(src, "outputs.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	descriptions := make(map[string]string)
+	for _, block := range parsed.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "output" || len(block.Labels) != 1 {
+			continue
+		}
+		if attr, ok := block.Body.Attributes["description"]; ok {
+			if value, diags := attr.Expr.Value(nil); !diags.HasErrors() && value.Type() == cty.String && value.IsKnown() && !value.IsNull() {
+				descriptions[block.Labels[0]] = value.AsString()
+			}
+		}
+	}
+
+	resources := hclgen.TokensForTraversal(m.resourceBlockType, m.resourceName)
+	toResource := func(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+		return replaceTraversals(tokens, m.resourceBlockType, func(ref string) hclwrite.Tokens {
+			if ref == m.resourceName {
+				return hclwrite.TokensForIdentifier("resource")
+			}
+			return nil
+		})
+	}
+	for _, block := range file.Body().Blocks() {
+		if block.Type() != "output" || len(block.Labels()) != 1 {
+			continue
+		}
+		outBody := block.Body()
+		value := outBody.GetAttribute("value")
+		if value == nil {
+			continue
+		}
+		tokens, ok := toResource(value.Expr().BuildTokens(nil))
+		if !ok {
+			continue
+		}
+		outBody.SetAttributeRaw("value", tokensForForObject("instance_key", "resource", resources, tokens))
+		if description, ok := descriptions[block.Labels()[0]]; ok {
+			outBody.SetAttributeValue("description", cty.StringVal(strings.TrimSuffix(description, ".")+", keyed by instance."))
+		}
+		for _, nested := range outBody.Blocks() {
+			if nested.Type() != "precondition" || nested.Body().GetAttribute("condition") == nil {
+				continue
+			}
+			if condition, ok := toResource(nested.Body().GetAttribute("condition").Expr().BuildTokens(nil)); ok {
+				nested.Body().SetAttributeRaw("condition", tokensForAllTrue("resource", resources, condition))
+			}
+		}
+	}
+	return file.Bytes(), nil
+}
+
+// instanceReference returns what var.<name> becomes for one instance: <instance>.<name> for a
+// per-resource variable, or the instance's entry of a secret variable. It returns nil for
+// module-level variables.
+func (m *multiInstanceRewriter) instanceReference(name, instance, key string) hclwrite.Tokens {
+	if _, ok := m.instanceVars[name]; ok {
+		return hclgen.TokensForTraversal(append(strings.Split(instance, "."), name)...)
+	}
+	if _, ok := m.secretVars[name]; ok {
+		return hclwrite.TokensForFunctionCall("lookup", hclgen.TokensForTraversal("var", name), hclgen.TokensForTraversal(strings.Split(key, ".")...), hclwrite.TokensForIdentifier("null"))
+	}
+	return nil
+}
+
+// instanceLocalReference returns local.<name>[key] for a local keyed by instance, or nil.
+func (m *multiInstanceRewriter) instanceLocalReference(name string, key hclwrite.Tokens) hclwrite.Tokens {
+	if _, ok := m.instanceLocals[name]; !ok {
+		return nil
+	}
+	tokens := hclgen.TokensForTraversal("local", name)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+	tokens = append(tokens, key...)
+	return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+}
+
+// replaceTraversals returns tokens with each traversal starting root.<name>, e.g. var.sku,
+// having that prefix replaced by what replace returns for name; replace returns nil to keep it.
+// It also reports whether anything was replaced.
+func replaceTraversals(tokens hclwrite.Tokens, root string, replace func(name string) hclwrite.Tokens) (hclwrite.Tokens, bool) {
+	var out hclwrite.Tokens
+	replaced := false
+	for i := 0; i < len(tokens); i++ {
+		if i+2 < len(tokens) &&
+			tokens[i].Type == hclsyntax.TokenIdent && string(tokens[i].Bytes) == root &&
+			tokens[i+1].Type == hclsyntax.TokenDot && tokens[i+2].Type == hclsyntax.TokenIdent &&
+			(i == 0 || tokens[i-1].Type != hclsyntax.TokenDot) {
+			if replacement := replace(string(tokens[i+2].Bytes)); replacement != nil {
+				out = append(out, replacement...)
+				replaced = true
+				i += 2
+				continue
+			}
+		}
+		out = append(out, tokens[i])
+	}
+	return out, replaced
+}
+
+// rewriteBodyExpressions applies rewrite to every attribute expression in body and its nested
+// blocks.
+func rewriteBodyExpressions(body *hclwrite.Body, rewrite func(hclwrite.Tokens) (hclwrite.Tokens, bool)) {
+	for name, attr := range body.Attributes() {
+		if tokens, ok := rewrite(attr.Expr().BuildTokens(nil)); ok {
+			body.SetAttributeRaw(name, tokens)
+		}
+	}
+	for _, block := range body.Blocks() {
+		rewriteBodyExpressions(block.Body(), rewrite)
+	}
+}
+
+// tokensForForObject builds `{ for <keyVar>, <valueVar> in <collection> : <keyVar> => <value> }`.
+func tokensForForObject(keyVar, valueVar string, collection, value hclwrite.Tokens) hclwrite.Tokens {
+	tokens := hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte(keyVar)},
+		&hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte(valueVar)},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")},
+	}
+	tokens = append(tokens, collection...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte(keyVar)})
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenFatArrow, Bytes: []byte("=>")})
+	tokens = append(tokens, value...)
+	return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrace, Bytes: []byte("}")})
+}
+
+// tokensForAllTrue builds `alltrue([for <valueVar> in <collection> : <condition>])`.
+func tokensForAllTrue(valueVar string, collection, condition hclwrite.Tokens) hclwrite.Tokens {
+	tokens := hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte(valueVar)},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")},
+	}
+	tokens = append(tokens, collection...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	tokens = append(tokens, condition...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+	return hclwrite.TokensForFunctionCall("alltrue", tokens)
+}
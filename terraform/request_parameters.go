@@ -0,0 +1,81 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/openapi"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// requestParameterVariable is a required header or query parameter of the PUT request and the
+// variable supplying it.
+type requestParameterVariable struct {
+	param   openapi.RequestParameter
+	varName string
+}
+
+// newRequestParameterVariables names a variable after each parameter. The names are final once
+// emitRequestParameterVars has resolved collisions with the other variables.
+func newRequestParameterVariables(params []openapi.RequestParameter, namer *naming.Namer) []requestParameterVariable {
+	vars := make([]requestParameterVariable, 0, len(params))
+	for _, param := range params {
+		vars = append(vars, requestParameterVariable{param: param, varName: namer.ToSnakeCase(param.Name)})
+	}
+	return vars
+}
+
+// emitRequestParameterVars generates a required variable per parameter, validated by the
+// parameter's string constraints, open enums only when openEnums is set. A name already taken, e.g. location by a location header, is
+// suffixed with the parameter location before falling back to claimName.
+func emitRequestParameterVars(body *hclwrite.Body, params []requestParameterVariable, taken func(string) bool, claimName func(string, string) (string, error), appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body, openEnums bool) error {
+	for i := range params {
+		param := &params[i]
+		name := param.varName
+		if taken(name) {
+			name += "_" + param.param.In
+		}
+		name, err := claimName(name, fmt.Sprintf("%s parameter %s", param.param.In, param.param.Name))
+		if err != nil {
+			return err
+		}
+		param.varName = name
+
+		description := param.param.Description
+		if description == "" {
+			description = fmt.Sprintf("The %s %s parameter required when creating or updating the resource.", param.param.Name, param.param.In)
+		}
+		varBody := appendVariable(name, description, hclwrite.TokensForIdentifier("string"))
+		varBody.SetAttributeValue("nullable", cty.False)
+		generateValidations(varBody, name, param.param.Schema, true, openEnums)
+		body.AppendNewline()
+	}
+	return nil
+}
+
+// setRequestParameterAttributes sends the parameters with the create and update requests of
+// the resource, headers through create_headers and update_headers and query parameters through
+// create_query_parameters and update_query_parameters.
+func setRequestParameterAttributes(resourceBody *hclwrite.Body, params []requestParameterVariable) {
+	var headers, query []hclwrite.ObjectAttrTokens
+	for _, param := range params {
+		value := hclgen.TokensForTraversal("var", param.varName)
+		switch param.param.In {
+		case "header":
+			headers = append(headers, hclwrite.ObjectAttrTokens{Name: hclwrite.TokensForValue(cty.StringVal(param.param.Name)), Value: value})
+		case "query":
+			// Query parameter values are lists, as a parameter may be repeated.
+			query = append(query, hclwrite.ObjectAttrTokens{Name: hclwrite.TokensForValue(cty.StringVal(param.param.Name)), Value: hclwrite.TokensForTuple([]hclwrite.Tokens{value})})
+		}
+	}
+	if len(headers) > 0 {
+		resourceBody.SetAttributeRaw("create_headers", hclwrite.TokensForObject(headers))
+		resourceBody.SetAttributeRaw("update_headers", hclwrite.TokensForObject(headers))
+	}
+	if len(query) > 0 {
+		resourceBody.SetAttributeRaw("create_query_parameters", hclwrite.TokensForObject(query))
+		resourceBody.SetAttributeRaw("update_query_parameters", hclwrite.TokensForObject(query))
+	}
+}
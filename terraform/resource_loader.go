@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/matt-FFFFFF/tfmodmake/armschema"
 	"github.com/matt-FFFFFF/tfmodmake/openapi"
 )
 
@@ -62,6 +63,9 @@ func LoadResource(ctx context.Context, specs []string, resourceType string) (Gen
 
 		// Apply writability overrides
 		openapi.AnnotateSchemaRefOrigins(schema)
+		// Declaration order only matters to WithSortVariables(SortVariablesSpec), which falls back
+		// to alphabetical order where it is unknown.
+		_ = openapi.AnnotatePropertyOrder(schema, specPath)
 		if resolver, err := openapi.NewPropertyWritabilityResolver(specPath); err == nil && resolver != nil {
 			openapi.ApplyPropertyWritabilityOverrides(schema, resolver)
 		}
@@ -80,11 +84,97 @@ func LoadResource(ctx context.Context, specs []string, resourceType string) (Gen
 	}
 
 	// Resource not found in any spec
-	return nil, buildResourceNotFoundError(resourceType, loadErrors, searchErrors)
+	return nil, buildNotFoundError("resource type "+resourceType, loadErrors, searchErrors)
 }
 
-func buildResourceNotFoundError(resourceType string, loadErrors, searchErrors []string) error {
-	errMsg := fmt.Sprintf("resource type %s not found in any of the provided specs", resourceType)
+// LoadSchemaDefinition loads a named schema definition from a list of specs, bypassing the
+// resource path inference used by LoadResource. It returns the first successful match.
+// The resource type and API version used in main.tf are still supplied by the caller.
+func LoadSchemaDefinition(ctx context.Context, specs []string, definitionName string) (GeneratorOption, error) {
+	var loadErrors []string
+	var searchErrors []string
+
+	for _, specPath := range specs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		loadedDoc, err := openapi.LoadSpec(specPath)
+		if err != nil {
+			loadErrors = append(loadErrors, fmt.Sprintf("- %s: %v", specPath, err))
+			continue
+		}
+
+		schema, err := openapi.FindSchemaDefinition(loadedDoc, specPath, definitionName)
+		if err != nil {
+			searchErrors = append(searchErrors, fmt.Sprintf("- %s: %v", specPath, err))
+			continue
+		}
+
+		var apiVersion string
+		if loadedDoc.Info != nil {
+			apiVersion = loadedDoc.Info.Version
+		}
+
+		openapi.AnnotateSchemaRefOrigins(schema)
+		// Declaration order only matters to WithSortVariables(SortVariablesSpec), which falls back
+		// to alphabetical order where it is unknown.
+		_ = openapi.AnnotatePropertyOrder(schema, specPath)
+		if resolver, err := openapi.NewPropertyWritabilityResolver(specPath); err == nil && resolver != nil {
+			openapi.ApplyPropertyWritabilityOverrides(schema, resolver)
+		}
+
+		supportsTags := SupportsTags(schema)
+		supportsLocation := SupportsLocation(schema)
+
+		return func(o *generatorOptions) {
+			o.schema = schema
+			o.spec = loadedDoc
+			o.apiVersion = apiVersion
+			o.supportsTags = supportsTags
+			o.supportsLocation = supportsLocation
+		}, nil
+	}
+
+	return nil, buildNotFoundError("schema definition "+definitionName, loadErrors, searchErrors)
+}
+
+// LoadARMSchemaResource loads a resource type from a list of ARM template schemas or Bicep types
+// (see the armschema package) instead of OpenAPI specs. It returns the first successful match.
+// There is no spec behind the resource, so the capabilities, name parameter and actions that
+// come from its operations are not available.
+func LoadARMSchemaResource(ctx context.Context, sources []string, resourceType string) (GeneratorOption, error) {
+	var searchErrors []string
+
+	for _, source := range sources {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		resource, err := armschema.FindResource(source, resourceType)
+		if err != nil {
+			searchErrors = append(searchErrors, fmt.Sprintf("- %s: %v", source, err))
+			continue
+		}
+
+		schema := resource.Schema
+		openapi.AnnotateSchemaRefOrigins(schema)
+		supportsTags := SupportsTags(schema)
+		supportsLocation := SupportsLocation(schema)
+
+		return func(o *generatorOptions) {
+			o.schema = schema
+			o.apiVersion = resource.APIVersion
+			o.supportsTags = supportsTags
+			o.supportsLocation = supportsLocation
+		}, nil
+	}
+
+	return nil, buildNotFoundError("resource type "+resourceType, nil, searchErrors)
+}
+
+func buildNotFoundError(subject string, loadErrors, searchErrors []string) error {
+	errMsg := fmt.Sprintf("%s not found in any of the provided specs", subject)
 	if len(loadErrors) > 0 {
 		errMsg += fmt.Sprintf("\n\nSpec load errors:\n%s", strings.Join(loadErrors, "\n"))
 	}
@@ -0,0 +1,153 @@
+package terraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// widgetSwagger and widgetTemplateSchema describe the same resource as an OpenAPI spec and as
+// an ARM template deployment schema.
+const widgetSwagger = `{
+  "swagger": "2.0",
+  "info": {"title": "Test", "version": "2024-01-01"},
+  "paths": {
+    "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}": {
+      "put": {
+        "operationId": "Widgets_CreateOrUpdate",
+        "parameters": [
+          {"name": "subscriptionId", "in": "path", "required": true, "type": "string"},
+          {"name": "resourceGroupName", "in": "path", "required": true, "type": "string"},
+          {"name": "widgetName", "in": "path", "required": true, "type": "string"},
+          {"name": "body", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Widget"}}
+        ],
+        "responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/Widget"}}}
+      }
+    }
+  },
+  "definitions": {
+    "Widget": {
+      "type": "object",
+      "properties": {
+        "id": {"type": "string", "readOnly": true},
+        "name": {"type": "string", "readOnly": true},
+        "type": {"type": "string", "readOnly": true},
+        "location": {"type": "string", "description": "The geo-location of the widget."},
+        "tags": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Resource tags."},
+        "properties": {"$ref": "#/definitions/WidgetProperties"}
+      },
+      "required": ["location"]
+    },
+    "WidgetProperties": {
+      "type": "object",
+      "properties": {
+        "sku": {"type": "string", "enum": ["Basic", "Premium"], "description": "The widget SKU."},
+        "capacity": {"type": "integer", "format": "int32", "minimum": 1, "maximum": 10, "description": "The widget capacity."},
+        "enabled": {"type": "boolean", "description": "Whether the widget is enabled."},
+        "labels": {"type": "array", "items": {"type": "string"}, "description": "Widget labels."},
+        "provisioningState": {"type": "string", "readOnly": true}
+      },
+      "required": ["sku"]
+    }
+  }
+}`
+
+const widgetTemplateSchema = `{
+  "id": "https://schema.management.azure.com/schemas/2024-01-01/Microsoft.Test.json#",
+  "$schema": "http://json-schema.org/draft-04/schema#",
+  "title": "Microsoft.Test",
+  "resourceDefinitions": {
+    "widgets": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string", "enum": ["2024-01-01"]},
+        "name": {"type": "string", "description": "The name of the widget."},
+        "type": {"type": "string", "enum": ["Microsoft.Test/widgets"]},
+        "location": {"type": "string", "description": "The geo-location of the widget."},
+        "tags": {
+          "oneOf": [
+            {"type": "object", "additionalProperties": {"type": "string"}, "properties": {}},
+            {"$ref": "https://schema.management.azure.com/schemas/common/definitions.json#/definitions/expression"}
+          ],
+          "description": "Resource tags."
+        },
+        "properties": {
+          "oneOf": [
+            {"$ref": "#/definitions/WidgetProperties"},
+            {"$ref": "https://schema.management.azure.com/schemas/common/definitions.json#/definitions/expression"}
+          ]
+        },
+        "dependsOn": {"type": "array", "items": {"type": "string"}}
+      },
+      "required": ["name", "type", "apiVersion", "location"]
+    }
+  },
+  "definitions": {
+    "WidgetProperties": {
+      "type": "object",
+      "properties": {
+        "sku": {
+          "oneOf": [
+            {"type": "string", "enum": ["Basic", "Premium"]},
+            {"$ref": "https://schema.management.azure.com/schemas/common/definitions.json#/definitions/expression"}
+          ],
+          "description": "The widget SKU."
+        },
+        "capacity": {
+          "oneOf": [
+            {"type": "integer", "minimum": 1, "maximum": 10},
+            {"$ref": "https://schema.management.azure.com/schemas/common/definitions.json#/definitions/expression"}
+          ],
+          "description": "The widget capacity."
+        },
+        "enabled": {
+          "oneOf": [
+            {"type": "boolean"},
+            {"$ref": "https://schema.management.azure.com/schemas/common/definitions.json#/definitions/expression"}
+          ],
+          "description": "Whether the widget is enabled."
+        },
+        "labels": {
+          "oneOf": [
+            {"type": "array", "items": {"type": "string"}},
+            {"$ref": "https://schema.management.azure.com/schemas/common/definitions.json#/definitions/expression"}
+          ],
+          "description": "Widget labels."
+        }
+      },
+      "required": ["sku"]
+    }
+  }
+}`
+
+func TestLoadARMSchemaResource_MatchesSpec(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "widgets.json")
+	schemaPath := filepath.Join(dir, "Microsoft.Test.json")
+	require.NoError(t, os.WriteFile(specPath, []byte(widgetSwagger), 0o644))
+	require.NoError(t, os.WriteFile(schemaPath, []byte(widgetTemplateSchema), 0o644))
+
+	fromSpec, err := LoadResource(context.Background(), []string{specPath}, "Microsoft.Test/widgets")
+	require.NoError(t, err)
+	fromSchema, err := LoadARMSchemaResource(context.Background(), []string{schemaPath}, "Microsoft.Test/widgets")
+	require.NoError(t, err)
+
+	specFiles, err := GenerateFiles("Microsoft.Test/widgets", fromSpec)
+	require.NoError(t, err)
+	schemaFiles, err := GenerateFiles("Microsoft.Test/widgets", fromSchema)
+	require.NoError(t, err)
+
+	// Template schemas carry no read-only fields, so only the response exports differ.
+	for _, name := range []string{"variables.tf", "locals.tf"} {
+		assert.Equal(t, string(specFiles[name]), string(schemaFiles[name]), name)
+	}
+	assert.Contains(t, string(schemaFiles["main.tf"]), `"Microsoft.Test/widgets@2024-01-01"`)
+
+	_, err = LoadARMSchemaResource(context.Background(), []string{schemaPath}, "Microsoft.Test/gadgets")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
@@ -2,6 +2,8 @@ package terraform
 
 import (
 	"fmt"
+	"maps"
+	"regexp"
 	"slices"
 	"sort"
 	"strings"
@@ -55,6 +57,80 @@ func isSecretField(schema *openapi3.Schema) bool {
 	return false
 }
 
+// heuristicSecretPattern matches property names that likely hold a secret, for
+// WithHeuristicSecrets.
+var heuristicSecretPattern = regexp.MustCompile(`(?i)(password|secret|key|token|connectionstring)`)
+
+// markHeuristicSecrets returns schema with the writable string properties named like secrets
+// (see heuristicSecretPattern) marked with x-ms-secret, copying only the schemas it changes.
+// This routes secrets of specs that forgot the marker through sensitive_body. Enums never hold
+// a secret, and properties already detected as secrets are left as they are. Nesting beyond
+// depth levels is not searched.
+func markHeuristicSecrets(schema *openapi3.Schema, depth int) (*openapi3.Schema, error) {
+	if schema == nil || depth <= 0 {
+		return schema, nil
+	}
+
+	if isArraySchema(schema) {
+		if schema.Items == nil || schema.Items.Value == nil {
+			return schema, nil
+		}
+		items, err := markHeuristicSecrets(schema.Items.Value, depth-1)
+		if err != nil || items == schema.Items.Value {
+			return schema, err
+		}
+		marked := *schema
+		marked.Items = &openapi3.SchemaRef{Value: items}
+		return &marked, nil
+	}
+
+	props, err := openapi.GetEffectiveProperties(schema)
+	if err != nil {
+		return nil, fmt.Errorf("getting effective properties while marking secret fields: %w", err)
+	}
+	changed := false
+	markedProps := make(map[string]*openapi3.SchemaRef, len(props))
+	for name, ref := range props {
+		markedProps[name] = ref
+		if ref == nil || ref.Value == nil || !isWritableProperty(ref.Value) || isSecretField(ref.Value) {
+			continue
+		}
+		prop := ref.Value
+		if heuristicSecretPattern.MatchString(name) && slices.Contains(openapi.EffectiveTypes(prop), "string") && len(prop.Enum) == 0 {
+			secret := *prop
+			secret.Extensions = maps.Clone(prop.Extensions)
+			if secret.Extensions == nil {
+				secret.Extensions = make(map[string]any)
+			}
+			secret.Extensions["x-ms-secret"] = true
+			markedProps[name] = &openapi3.SchemaRef{Value: &secret}
+			changed = true
+			continue
+		}
+		nested, err := markHeuristicSecrets(prop, depth-1)
+		if err != nil {
+			return nil, err
+		}
+		if nested != prop {
+			markedProps[name] = &openapi3.SchemaRef{Value: nested}
+			changed = true
+		}
+	}
+	if !changed {
+		return schema, nil
+	}
+
+	required, err := openapi.GetEffectiveRequired(schema)
+	if err != nil {
+		return nil, fmt.Errorf("getting effective required while marking secret fields: %w", err)
+	}
+	marked := *schema
+	marked.AllOf = nil
+	marked.Properties = markedProps
+	marked.Required = required
+	return &marked, nil
+}
+
 func isArraySchema(schema *openapi3.Schema) bool {
 	if schema == nil {
 		return false
@@ -65,8 +141,10 @@ func isArraySchema(schema *openapi3.Schema) bool {
 	return schema.Items != nil
 }
 
-func schemaContainsSecretFields(schema *openapi3.Schema) (bool, error) {
-	if schema == nil {
+// schemaContainsSecretFields reports whether schema or any writable descendant is a secret.
+// The search stops after depth levels so self-referential schemas terminate.
+func schemaContainsSecretFields(schema *openapi3.Schema, depth int) (bool, error) {
+	if schema == nil || depth <= 0 {
 		return false, nil
 	}
 
@@ -78,7 +156,7 @@ func schemaContainsSecretFields(schema *openapi3.Schema) (bool, error) {
 		if schema.Items == nil || schema.Items.Value == nil {
 			return false, nil
 		}
-		return schemaContainsSecretFields(schema.Items.Value)
+		return schemaContainsSecretFields(schema.Items.Value, depth-1)
 	}
 
 	if schema.Type == nil || !slices.Contains(*schema.Type, "object") {
@@ -97,7 +175,7 @@ func schemaContainsSecretFields(schema *openapi3.Schema) (bool, error) {
 		if !isWritableProperty(prop.Value) {
 			continue
 		}
-		hasSecrets, err := schemaContainsSecretFields(prop.Value)
+		hasSecrets, err := schemaContainsSecretFields(prop.Value, depth-1)
 		if err != nil {
 			return false, err
 		}
@@ -107,7 +185,7 @@ func schemaContainsSecretFields(schema *openapi3.Schema) (bool, error) {
 	}
 
 	if schema.AdditionalProperties.Schema != nil && schema.AdditionalProperties.Schema.Value != nil {
-		hasSecrets, err := schemaContainsSecretFields(schema.AdditionalProperties.Schema.Value)
+		hasSecrets, err := schemaContainsSecretFields(schema.AdditionalProperties.Schema.Value, depth-1)
 		if err != nil {
 			return false, err
 		}
@@ -120,9 +198,10 @@ func schemaContainsSecretFields(schema *openapi3.Schema) (bool, error) {
 }
 
 // collectSecretFields traverses the schema and collects all fields marked with x-ms-secret.
-func collectSecretFields(schema *openapi3.Schema, pathPrefix string) ([]secretField, error) {
+// Nesting beyond depth levels is not searched.
+func collectSecretFields(schema *openapi3.Schema, pathPrefix string, depth int, namer *naming.Namer) ([]secretField, error) {
 	var secrets []secretField
-	if schema == nil {
+	if schema == nil || depth <= 0 {
 		return secrets, nil
 	}
 
@@ -150,7 +229,7 @@ func collectSecretFields(schema *openapi3.Schema, pathPrefix string) ([]secretFi
 		if isSecretField(propSchema) {
 			secrets = append(secrets, secretField{
 				path:    currentPath,
-				varName: naming.ToSnakeCase(name),
+				varName: namer.ToSnakeCase(name),
 				schema:  propSchema,
 			})
 		}
@@ -165,14 +244,14 @@ func collectSecretFields(schema *openapi3.Schema, pathPrefix string) ([]secretFi
 		//   - avoiding invalid HCL keys like "secrets[]".
 		if !isSecretField(propSchema) && isArraySchema(propSchema) {
 			if propSchema.Items != nil && propSchema.Items.Value != nil {
-				hasSecrets, err := schemaContainsSecretFields(propSchema.Items.Value)
+				hasSecrets, err := schemaContainsSecretFields(propSchema.Items.Value, depth-1)
 				if err != nil {
 					return nil, err
 				}
 				if hasSecrets {
 					secrets = append(secrets, secretField{
 						path:    currentPath,
-						varName: naming.ToSnakeCase(name),
+						varName: namer.ToSnakeCase(name),
 						schema:  propSchema,
 					})
 					continue
@@ -182,7 +261,7 @@ func collectSecretFields(schema *openapi3.Schema, pathPrefix string) ([]secretFi
 
 		// Recursively check nested objects
 		if propSchema.Type != nil && slices.Contains(*propSchema.Type, "object") && len(propSchema.Properties) > 0 {
-			nested, err := collectSecretFields(propSchema, currentPath)
+			nested, err := collectSecretFields(propSchema, currentPath, depth-1, namer)
 			if err != nil {
 				return nil, err
 			}
@@ -229,7 +308,8 @@ func (n *sensitiveBodyNode) ensureChild(key string) *sensitiveBodyNode {
 	return child
 }
 
-func tokensForSensitiveBody(secrets []secretField, valueFor func(secretField) hclwrite.Tokens) hclwrite.Tokens {
+// newSensitiveBodyTree nests the secrets by the segments of their paths.
+func newSensitiveBodyTree(secrets []secretField) *sensitiveBodyNode {
 	root := &sensitiveBodyNode{}
 	for i := range secrets {
 		path := strings.TrimSpace(secrets[i].path)
@@ -247,6 +327,11 @@ func tokensForSensitiveBody(secrets []secretField, valueFor func(secretField) hc
 		}
 		node.secret = &secrets[i]
 	}
+	return root
+}
+
+func tokensForSensitiveBody(secrets []secretField, valueFor func(secretField) hclwrite.Tokens) hclwrite.Tokens {
+	root := newSensitiveBodyTree(secrets)
 
 	var render func(node *sensitiveBodyNode) hclwrite.Tokens
 	render = func(node *sensitiveBodyNode) hclwrite.Tokens {
@@ -278,3 +363,41 @@ func tokensForSensitiveBody(secrets []secretField, valueFor func(secretField) hc
 
 	return render(root)
 }
+
+// sensitiveBodyVariable is the variable holding every secret, nested as in the request body,
+// when secrets are not flattened into variables of their own.
+const sensitiveBodyVariable = "sensitive_body"
+
+// secretVariables returns the secret variables to declare, along with a version variable each:
+// one per secret, or the single sensitiveBodyVariable holding them all when nested.
+func secretVariables(secrets []secretField, nested bool) []secretField {
+	if !nested || len(secrets) == 0 {
+		return secrets
+	}
+	return []secretField{{varName: sensitiveBodyVariable}}
+}
+
+// tokensForSensitiveBodyType returns the type of the sensitiveBodyVariable holding secrets: an
+// object nesting them by their paths, each attribute optional like the flattened secret
+// variables, typed by typeFor at the leaves.
+func tokensForSensitiveBodyType(secrets []secretField, typeFor func(secretField) (hclwrite.Tokens, error)) (hclwrite.Tokens, error) {
+	var render func(node *sensitiveBodyNode) (hclwrite.Tokens, error)
+	render = func(node *sensitiveBodyNode) (hclwrite.Tokens, error) {
+		if node.secret != nil && len(node.children) == 0 {
+			return typeFor(*node.secret)
+		}
+		var attrs []hclwrite.ObjectAttrTokens
+		for _, k := range slices.Sorted(maps.Keys(node.children)) {
+			childType, err := render(node.children[k])
+			if err != nil {
+				return nil, err
+			}
+			attrs = append(attrs, hclwrite.ObjectAttrTokens{
+				Name:  tokensForObjectKey(k),
+				Value: hclwrite.TokensForFunctionCall("optional", childType),
+			})
+		}
+		return hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject(attrs)), nil
+	}
+	return render(newSensitiveBodyTree(secrets))
+}
@@ -2,11 +2,14 @@ package terraform
 
 import (
 	"fmt"
+	"math"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/matt-FFFFFF/tfmodmake/hclgen"
@@ -16,8 +19,9 @@ import (
 )
 
 // generateValidations adds validation blocks to the variable body based on schema constraints.
-// It generates null-safe validations for strings, arrays, numbers, and enums.
-func generateValidations(varBody *hclwrite.Body, tfName string, propSchema *openapi3.Schema, isRequired bool) {
+// It generates null-safe validations for strings, arrays, numbers, and enums. Open enums are
+// only validated when openEnums is set, see isOpenEnum.
+func generateValidations(varBody *hclwrite.Body, tfName string, propSchema *openapi3.Schema, isRequired, openEnums bool) {
 	if propSchema == nil {
 		return
 	}
@@ -26,7 +30,8 @@ func generateValidations(varBody *hclwrite.Body, tfName string, propSchema *open
 	resolvedSchema := resolveSchemaForValidation(propSchema)
 
 	// Generate enum validation
-	generateEnumValidation(varBody, tfName, resolvedSchema, isRequired)
+	generateEnumValidation(varBody, tfName, resolvedSchema, isRequired, openEnums)
+	generateNotEnumValidation(varBody, tfName, resolvedSchema, isRequired)
 
 	// Generate string validations
 	generateStringValidations(varBody, tfName, resolvedSchema, isRequired)
@@ -36,13 +41,14 @@ func generateValidations(varBody *hclwrite.Body, tfName string, propSchema *open
 
 	// Generate numeric validations
 	generateNumericValidations(varBody, tfName, resolvedSchema, isRequired)
+
+	// Generate map key and value validations
+	generateMapKeyValidation(varBody, tfName, propSchema, isRequired)
+	generateMapValueValidations(varBody, tfName, propSchema, isRequired)
 }
 
-func generateNestedObjectValidations(varBody *hclwrite.Body, tfName string, objSchema *openapi3.Schema) error {
-	if objSchema == nil || objSchema.Type == nil {
-		return nil
-	}
-	if !slices.Contains(*objSchema.Type, "object") {
+func generateNestedObjectValidations(varBody *hclwrite.Body, tfName string, objSchema *openapi3.Schema, namer *naming.Namer, openEnums bool) error {
+	if !slices.Contains(openapi.EffectiveTypes(objSchema), "object") {
 		return nil
 	}
 
@@ -69,7 +75,7 @@ func generateNestedObjectValidations(varBody *hclwrite.Body, tfName string, objS
 	}
 	var keys []keyPair
 	for k := range effectiveProps {
-		snake := naming.ToSnakeCase(k)
+		snake := objectAttributeName(namer, k)
 		if snake == "" {
 			continue
 		}
@@ -102,7 +108,7 @@ func generateNestedObjectValidations(varBody *hclwrite.Body, tfName string, objS
 		displayName := fmt.Sprintf("%s.%s", tfName, kp.snake)
 		childRequired := slices.Contains(effectiveRequired, kp.original)
 
-		appendValidationsForExpr(varBody, displayName, parentRef, childRef, childSchema, childRequired)
+		appendValidationsForExpr(varBody, displayName, parentRef, childRef, childSchema, childRequired, openEnums)
 	}
 
 	return nil
@@ -125,9 +131,9 @@ func isScalarOrScalarArraySchema(schema *openapi3.Schema) bool {
 	return slices.Contains(itemTypes, "string") || slices.Contains(itemTypes, "integer") || slices.Contains(itemTypes, "number") || slices.Contains(itemTypes, "boolean")
 }
 
-func appendValidationsForExpr(varBody *hclwrite.Body, displayName string, parentRef, valueRef hclwrite.Tokens, schema *openapi3.Schema, isRequired bool) {
+func appendValidationsForExpr(varBody *hclwrite.Body, displayName string, parentRef, valueRef hclwrite.Tokens, schema *openapi3.Schema, isRequired, openEnums bool) {
 	// Enum
-	if condition, ok := enumConditionTokens(valueRef, schema); ok {
+	if condition, ok := enumConditionTokens(valueRef, schema); ok && (openEnums || !isOpenEnum(schema)) {
 		if !isRequired {
 			condition = wrapWithNullGuard(valueRef, condition)
 		}
@@ -136,6 +142,13 @@ func appendValidationsForExpr(varBody *hclwrite.Body, displayName string, parent
 	}
 
 	// Strings
+	if condition, ok := stringExactLengthConditionTokens(valueRef, schema); ok {
+		if !isRequired {
+			condition = wrapWithNullGuard(valueRef, condition)
+		}
+		condition = wrapWithNullGuard(parentRef, condition)
+		appendValidation(varBody, condition, fmt.Sprintf("%s must be exactly %d characters.", displayName, *schema.MaxLength))
+	}
 	if condition, ok := stringMinLengthConditionTokens(valueRef, schema); ok {
 		if !isRequired {
 			condition = wrapWithNullGuard(valueRef, condition)
@@ -150,12 +163,12 @@ func appendValidationsForExpr(varBody *hclwrite.Body, displayName string, parent
 		condition = wrapWithNullGuard(parentRef, condition)
 		appendValidation(varBody, condition, fmt.Sprintf("%s must have a maximum length of %d.", displayName, *schema.MaxLength))
 	}
-	if condition, ok := stringFormatConditionTokens(valueRef, schema); ok {
+	if condition, description, ok := stringFormatConditionTokens(valueRef, schema); ok {
 		if !isRequired {
 			condition = wrapWithNullGuard(valueRef, condition)
 		}
 		condition = wrapWithNullGuard(parentRef, condition)
-		appendValidation(varBody, condition, fmt.Sprintf("%s must be a valid UUID.", displayName))
+		appendValidation(varBody, condition, fmt.Sprintf("%s must be %s.", displayName, description))
 	}
 	if condition, ok := stringPatternConditionTokens(valueRef, schema); ok {
 		if !isRequired {
@@ -219,6 +232,103 @@ func appendValidation(varBody *hclwrite.Body, condition hclwrite.Tokens, errorMe
 	validationBody.SetAttributeValue("error_message", cty.StringVal(errorMessage))
 }
 
+// compactValidations replaces the validation blocks of each variable in body, when it has more
+// than one, with a single block whose condition ANDs theirs and whose error message joins
+// theirs.
+func compactValidations(body *hclwrite.Body) error {
+	for _, variable := range body.Blocks() {
+		if variable.Type() != "variable" {
+			continue
+		}
+		varBody := variable.Body()
+		var validations []*hclwrite.Block
+		for _, block := range varBody.Blocks() {
+			if block.Type() == "validation" {
+				validations = append(validations, block)
+			}
+		}
+		if len(validations) < 2 {
+			continue
+		}
+
+		var condition hclwrite.Tokens
+		messages := make([]string, 0, len(validations))
+		for i, block := range validations {
+			validationBody := block.Body()
+			conditionAttr := validationBody.GetAttribute("condition")
+			messageAttr := validationBody.GetAttribute("error_message")
+			if conditionAttr == nil || messageAttr == nil {
+				return fmt.Errorf("compacting validations of %s: validation without condition or error_message", strings.Join(variable.Labels(), "."))
+			}
+			message, err := literalString(messageAttr.Expr().BuildTokens(nil))
+			if err != nil {
+				return fmt.Errorf("compacting validations of %s: %w", strings.Join(variable.Labels(), "."), err)
+			}
+			messages = append(messages, message)
+
+			if i > 0 {
+				condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenAnd, Bytes: []byte("&&")})
+			}
+			condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenOParen, Bytes: []byte("(")})
+			condition = append(condition, conditionAttr.Expr().BuildTokens(nil)...)
+			condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenCParen, Bytes: []byte(")")})
+			varBody.RemoveBlock(block)
+		}
+		appendValidation(varBody, condition, strings.Join(messages, " "))
+	}
+	return nil
+}
+
+// prefixValidationMessages starts the error message of each validation of the variables in body
+// with the prefix (see prefixedErrorMessage).
+func prefixValidationMessages(body *hclwrite.Body, prefix string) error {
+	for _, variable := range body.Blocks() {
+		if variable.Type() != "variable" {
+			continue
+		}
+		for _, block := range variable.Body().Blocks() {
+			if block.Type() != "validation" {
+				continue
+			}
+			messageAttr := block.Body().GetAttribute("error_message")
+			if messageAttr == nil {
+				continue
+			}
+			message, err := literalString(messageAttr.Expr().BuildTokens(nil))
+			if err != nil {
+				return fmt.Errorf("prefixing validations of %s: %w", strings.Join(variable.Labels(), "."), err)
+			}
+			block.Body().SetAttributeValue("error_message", cty.StringVal(prefixedErrorMessage(prefix, message)))
+		}
+	}
+	return nil
+}
+
+// prefixedErrorMessage returns message namespaced as "[prefix] message", or message itself when
+// prefix is empty.
+func prefixedErrorMessage(prefix, message string) string {
+	if prefix == "" {
+		return message
+	}
+	return "[" + prefix + "] " + message
+}
+
+// literalString evaluates tokens holding a string literal, such as a generated error_message.
+func literalString(tokens hclwrite.Tokens) (string, error) {
+	expr, diags := hclsyntax.ParseExpression(tokens.Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	if !value.Type().Equals(cty.String) || value.IsNull() {
+		return "", fmt.Errorf("expected a string literal, got %s", value.Type().FriendlyName())
+	}
+	return value.AsString(), nil
+}
+
 func wrapWithNullGuard(nullRef, inner hclwrite.Tokens) hclwrite.Tokens {
 	if len(nullRef) == 0 {
 		return inner
@@ -232,6 +342,8 @@ func wrapWithNullGuard(nullRef, inner hclwrite.Tokens) hclwrite.Tokens {
 	return out
 }
 
+// enumValuesForError returns enum values rendered for error messages: strings are quoted,
+// while numbers and booleans are shown as literals to match the validation condition.
 func enumValuesForError(schema *openapi3.Schema) []string {
 	if schema == nil {
 		return nil
@@ -240,7 +352,31 @@ func enumValuesForError(schema *openapi3.Schema) []string {
 	if !ok {
 		return nil
 	}
-	return values
+	if enumLiteralKind(schema) != "" {
+		return values
+	}
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, fmt.Sprintf("%q", v))
+	}
+	return quoted
+}
+
+// enumLiteralKind reports whether enum values of schema should be compared as numbers
+// ("number") or booleans ("bool") rather than strings (""), based on the schema type.
+func enumLiteralKind(schema *openapi3.Schema) string {
+	if schema == nil || schema.Type == nil {
+		return ""
+	}
+	switch {
+	case slices.Contains(*schema.Type, "string"):
+		return ""
+	case slices.Contains(*schema.Type, "integer") || slices.Contains(*schema.Type, "number"):
+		return "number"
+	case slices.Contains(*schema.Type, "boolean"):
+		return "bool"
+	}
+	return ""
 }
 
 func enumValues(schema *openapi3.Schema) ([]string, bool) {
@@ -279,29 +415,73 @@ func enumValues(schema *openapi3.Schema) ([]string, bool) {
 	for _, v := range enumValues {
 		raw = append(raw, fmt.Sprintf("%v", v))
 	}
+	if enumLiteralKind(schema) == "number" {
+		sort.Slice(raw, func(i, j int) bool {
+			a, errA := strconv.ParseFloat(raw[i], 64)
+			b, errB := strconv.ParseFloat(raw[j], 64)
+			if errA != nil || errB != nil {
+				return raw[i] < raw[j]
+			}
+			return a < b
+		})
+		return raw, true
+	}
 	sort.Strings(raw)
 	return raw, true
 }
 
+// isOpenEnum reports whether schema is an open enum: its x-ms-enum sets modelAsString, so the
+// service may add values beyond those the spec lists and a contains check could reject values
+// valid in a later service release. A closed enum (modelAsString false or unset) is validated
+// as is any plain enum.
+func isOpenEnum(schema *openapi3.Schema) bool {
+	if schema == nil || schema.Extensions == nil {
+		return false
+	}
+	enumMap, ok := schema.Extensions["x-ms-enum"].(map[string]any)
+	if !ok {
+		return false
+	}
+	modelAsString, _ := enumMap["modelAsString"].(bool)
+	return modelAsString
+}
+
 func enumConditionTokens(valueRef hclwrite.Tokens, schema *openapi3.Schema) (hclwrite.Tokens, bool) {
 	values, ok := enumValues(schema)
 	if !ok {
 		return nil, false
 	}
+	kind := enumLiteralKind(schema)
 	var enumTokens []hclwrite.Tokens
 	for _, v := range values {
-		enumTokens = append(enumTokens, hclwrite.TokensForValue(cty.StringVal(v)))
+		enumTokens = append(enumTokens, hclwrite.TokensForValue(enumLiteralValue(kind, v)))
 	}
 	enumList := hclwrite.TokensForTuple(enumTokens)
 	containsCall := hclwrite.TokensForFunctionCall("contains", enumList, valueRef)
 	return containsCall, true
 }
 
+// enumLiteralValue converts a raw enum value into a cty value of the given kind, falling
+// back to a string when the value cannot be parsed as that kind.
+func enumLiteralValue(kind, raw string) cty.Value {
+	switch kind {
+	case "number":
+		if n, err := cty.ParseNumberVal(raw); err == nil {
+			return n
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return cty.BoolVal(b)
+		}
+	}
+	return cty.StringVal(raw)
+}
+
 func stringMinLengthConditionTokens(valueRef hclwrite.Tokens, schema *openapi3.Schema) (hclwrite.Tokens, bool) {
 	if schema == nil || schema.Type == nil || !slices.Contains(*schema.Type, "string") {
 		return nil, false
 	}
-	if schema.MinLength == 0 {
+	if schema.MinLength == 0 || hasExactLength(schema) {
 		return nil, false
 	}
 	lengthCall := hclwrite.TokensForFunctionCall("length", valueRef)
@@ -316,7 +496,7 @@ func stringMaxLengthConditionTokens(valueRef hclwrite.Tokens, schema *openapi3.S
 	if schema == nil || schema.Type == nil || !slices.Contains(*schema.Type, "string") {
 		return nil, false
 	}
-	if schema.MaxLength == nil {
+	if schema.MaxLength == nil || hasExactLength(schema) {
 		return nil, false
 	}
 	lengthCall := hclwrite.TokensForFunctionCall("length", valueRef)
@@ -327,21 +507,57 @@ func stringMaxLengthConditionTokens(valueRef hclwrite.Tokens, schema *openapi3.S
 	return condition, true
 }
 
-func stringFormatConditionTokens(valueRef hclwrite.Tokens, schema *openapi3.Schema) (hclwrite.Tokens, bool) {
+// hasExactLength reports whether minLength and maxLength pin a string to a single length. Such
+// strings get one exact-length validation instead of separate minimum and maximum ones.
+func hasExactLength(schema *openapi3.Schema) bool {
+	return schema.MinLength > 0 && schema.MaxLength != nil && *schema.MaxLength == schema.MinLength
+}
+
+func stringExactLengthConditionTokens(valueRef hclwrite.Tokens, schema *openapi3.Schema) (hclwrite.Tokens, bool) {
 	if schema == nil || schema.Type == nil || !slices.Contains(*schema.Type, "string") {
 		return nil, false
 	}
-	if schema.Format != "uuid" {
+	if !hasExactLength(schema) {
 		return nil, false
 	}
-	regexPattern := "^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$"
+	lengthCall := hclwrite.TokensForFunctionCall("length", valueRef)
+	var condition hclwrite.Tokens
+	condition = append(condition, lengthCall...)
+	condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte(" == ")})
+	condition = append(condition, hclwrite.TokensForValue(cty.NumberUIntVal(*schema.MaxLength))...)
+	return condition, true
+}
+
+// isoDurationPattern matches ISO 8601 durations such as PT1H or P30D.
+const isoDurationPattern = "^P(?:[0-9]+Y)?(?:[0-9]+M)?(?:[0-9]+W)?(?:[0-9]+D)?(?:T(?:[0-9]+H)?(?:[0-9]+M)?(?:[0-9]+(?:\\.[0-9]+)?S)?)?$"
+
+// stringFormatConditionTokens returns a regex condition for supported string formats,
+// along with the description used in the error message.
+func stringFormatConditionTokens(valueRef hclwrite.Tokens, schema *openapi3.Schema) (hclwrite.Tokens, string, bool) {
+	if schema == nil || schema.Type == nil || !slices.Contains(*schema.Type, "string") {
+		return nil, "", false
+	}
+	var regexPattern, description string
+	switch schema.Format {
+	case "uuid":
+		regexPattern = "^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$"
+		description = "a valid UUID"
+	case "duration":
+		regexPattern = isoDurationPattern
+		description = "an ISO 8601 duration"
+	case "arm-id", "azure-resource-id":
+		regexPattern = armResourceIDPattern
+		description = "an ARM resource ID starting with /subscriptions/"
+	default:
+		return nil, "", false
+	}
 	regexCall := hclwrite.TokensForFunctionCall("can",
 		hclwrite.TokensForFunctionCall("regex",
 			hclwrite.TokensForValue(cty.StringVal(regexPattern)),
 			valueRef,
 		),
 	)
-	return regexCall, true
+	return regexCall, description, true
 }
 
 func stringPatternConditionTokens(valueRef hclwrite.Tokens, schema *openapi3.Schema) (hclwrite.Tokens, bool) {
@@ -397,6 +613,11 @@ func arrayUniqueItemsConditionTokens(valueRef hclwrite.Tokens, schema *openapi3.
 	if !schema.UniqueItems {
 		return nil, false
 	}
+	// Items identified by x-ms-identifiers are compared on their identifier instead, by
+	// generateArrayIdentifierUniqueValidation.
+	if _, ok := identifierMapKey(schema); ok {
+		return nil, false
+	}
 	lengthCall := hclwrite.TokensForFunctionCall("length", valueRef)
 	distinctCall := hclwrite.TokensForFunctionCall("distinct", valueRef)
 	lengthDistinctCall := hclwrite.TokensForFunctionCall("length", distinctCall)
@@ -480,6 +701,56 @@ func numericMultipleOfConditionTokens(valueRef hclwrite.Tokens, schema *openapi3
 	return condition, true
 }
 
+// nameValidationSchema returns the schema whose constraints are applied to var.name for the
+// given NameValidationSource*. pathSchema is the instance path name parameter and body the
+// request body schema; either may be nil. For NameValidationSourceBoth the two are combined as
+// an allOf, so resolveSchemaForValidation keeps the most restrictive bounds.
+func nameValidationSchema(source string, pathSchema, body *openapi3.Schema) (*openapi3.Schema, error) {
+	if source == NameValidationSourcePath {
+		return pathSchema, nil
+	}
+
+	bodySchema, err := bodyNameSchema(body)
+	if err != nil {
+		return nil, err
+	}
+	if source == NameValidationSourceBody || pathSchema == nil {
+		return bodySchema, nil
+	}
+	if bodySchema == nil {
+		return pathSchema, nil
+	}
+	return &openapi3.Schema{AllOf: openapi3.SchemaRefs{
+		{Value: resolveSchemaForValidation(pathSchema)},
+		{Value: resolveSchemaForValidation(bodySchema)},
+	}}, nil
+}
+
+// bodyNameSchema returns the schema of the name field in a request body, preferring
+// properties.name over the top-level name.
+func bodyNameSchema(body *openapi3.Schema) (*openapi3.Schema, error) {
+	if body == nil {
+		return nil, nil
+	}
+	props, err := openapi.GetEffectiveProperties(body)
+	if err != nil {
+		return nil, fmt.Errorf("getting effective properties for body name: %w", err)
+	}
+	if bag := props["properties"]; bag != nil && bag.Value != nil {
+		bagProps, err := openapi.GetEffectiveProperties(bag.Value)
+		if err != nil {
+			return nil, fmt.Errorf("getting effective properties for body name: %w", err)
+		}
+		if name := bagProps["name"]; name != nil && name.Value != nil {
+			return name.Value, nil
+		}
+	}
+	if name := props["name"]; name != nil && name.Value != nil {
+		return name.Value, nil
+	}
+	return nil, nil
+}
+
 // resolveSchemaForValidation resolves $ref, allOf, oneOf, anyOf to get effective schema for validation.
 func resolveSchemaForValidation(schema *openapi3.Schema) *openapi3.Schema {
 	if schema == nil {
@@ -513,6 +784,7 @@ func resolveSchemaForValidation(schema *openapi3.Schema) *openapi3.Schema {
 		merged.MaxItems = schema.MaxItems
 		merged.UniqueItems = schema.UniqueItems
 		merged.Format = schema.Format
+		merged.Not = schema.Not
 		if schema.Extensions != nil {
 			merged.Extensions = make(map[string]any)
 			for k, v := range schema.Extensions {
@@ -621,9 +893,10 @@ func resolveSchemaForValidation(schema *openapi3.Schema) *openapi3.Schema {
 	return resolved
 }
 
-// generateEnumValidation generates validation for enum values.
-func generateEnumValidation(varBody *hclwrite.Body, tfName string, schema *openapi3.Schema, isRequired bool) {
-	if schema == nil {
+// generateEnumValidation generates validation for enum values, skipping open enums unless
+// openEnums is set.
+func generateEnumValidation(varBody *hclwrite.Body, tfName string, schema *openapi3.Schema, isRequired, openEnums bool) {
+	if schema == nil || (!openEnums && isOpenEnum(schema)) {
 		return
 	}
 
@@ -638,7 +911,99 @@ func generateEnumValidation(varBody *hclwrite.Body, tfName string, schema *opena
 	appendValidation(varBody, condition, fmt.Sprintf("%s must be one of: %s.", tfName, joinEnumValues(enumValuesForError(schema))))
 }
 
-// joinEnumValues joins enum values for error messages, limiting to a reasonable length.
+// notSchema returns the schema excluded by schema.Not, inheriting the parent's type when the
+// excluded schema does not declare one (e.g. `not: {enum: [...]}` on a string property).
+func notSchema(schema *openapi3.Schema) *openapi3.Schema {
+	if schema == nil || schema.Not == nil || schema.Not.Value == nil {
+		return nil
+	}
+	excluded := *schema.Not.Value
+	if excluded.Type == nil {
+		excluded.Type = schema.Type
+	}
+	return &excluded
+}
+
+// notConstraintDescription documents a `not` constraint for the variable description so that
+// it is not silently dropped. It returns "" when the schema has no `not`.
+func notConstraintDescription(schema *openapi3.Schema) string {
+	excluded := notSchema(schema)
+	if excluded == nil {
+		return ""
+	}
+	if _, ok := enumValues(excluded); ok {
+		return fmt.Sprintf("Must not be one of: %s.", joinEnumValues(enumValuesForError(excluded)))
+	}
+	if excluded.Type != nil && len(*excluded.Type) > 0 {
+		return fmt.Sprintf("Must not match the excluded %s schema (`not` constraint, not validated).", strings.Join(*excluded.Type, "/"))
+	}
+	return "Must not match the excluded schema (`not` constraint, not validated)."
+}
+
+// generateNotEnumValidation generates a negated enum validation for `not: {enum: [...]}`.
+func generateNotEnumValidation(varBody *hclwrite.Body, tfName string, schema *openapi3.Schema, isRequired bool) {
+	excluded := notSchema(schema)
+	if excluded == nil {
+		return
+	}
+
+	varRef := hclgen.TokensForTraversal("var", tfName)
+	containsCall, ok := enumConditionTokens(varRef, excluded)
+	if !ok {
+		return
+	}
+	var condition hclwrite.Tokens
+	condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenBang, Bytes: []byte("!")})
+	condition = append(condition, containsCall...)
+	if !isRequired {
+		condition = wrapWithNullGuard(varRef, condition)
+	}
+	appendValidation(varBody, condition, fmt.Sprintf("%s must not be one of: %s.", tfName, joinEnumValues(enumValuesForError(excluded))))
+}
+
+// armResourceIDPattern is the shape check applied to ARM resource ID fields.
+const armResourceIDPattern = "^/subscriptions/.+"
+
+// isARMResourceIDField reports whether a string field holds an ARM resource ID, either because the
+// schema has an ARM ID format (see isARMResourceIDFormat) or is marked with x-ms-azure-resource
+// or, when useNameHeuristic is set, because the property name ends in "ResourceId". The name
+// heuristic is opt-in as it can produce false positives.
+func isARMResourceIDField(name string, schema *openapi3.Schema, useNameHeuristic bool) bool {
+	resolved := resolveSchemaForValidation(schema)
+	if resolved == nil || resolved.Type == nil || !slices.Contains(*resolved.Type, "string") {
+		return false
+	}
+	if isARMResourceIDFormat(resolved.Format) {
+		return true
+	}
+	if marked, ok := resolved.Extensions["x-ms-azure-resource"].(bool); ok && marked {
+		return true
+	}
+	return useNameHeuristic && strings.HasSuffix(strings.ToLower(name), "resourceid")
+}
+
+// isARMResourceIDFormat reports whether format is one of the custom string formats some specs
+// use for ARM resource IDs, which stringFormatConditionTokens validates.
+func isARMResourceIDFormat(format string) bool {
+	return format == "arm-id" || format == "azure-resource-id"
+}
+
+// generateResourceIDValidation generates a validation checking the value looks like an ARM resource ID.
+func generateResourceIDValidation(varBody *hclwrite.Body, tfName string, isRequired bool) {
+	varRef := hclgen.TokensForTraversal("var", tfName)
+	condition := hclwrite.TokensForFunctionCall("can",
+		hclwrite.TokensForFunctionCall("regex",
+			hclwrite.TokensForValue(cty.StringVal(armResourceIDPattern)),
+			varRef,
+		),
+	)
+	if !isRequired {
+		condition = wrapWithNullGuard(varRef, condition)
+	}
+	appendValidation(varBody, condition, fmt.Sprintf("%s must be an ARM resource ID starting with /subscriptions/.", tfName))
+}
+
+// joinEnumValues joins display-ready enum values for error messages, limiting to a reasonable length.
 func joinEnumValues(values []string) string {
 	const maxLength = 200
 	const maxCount = 10
@@ -647,13 +1012,8 @@ func joinEnumValues(values []string) string {
 		return "[]"
 	}
 
-	quoted := make([]string, 0, len(values))
-	for _, v := range values {
-		quoted = append(quoted, fmt.Sprintf("%q", v))
-	}
-
 	if len(values) <= maxCount {
-		joined := fmt.Sprintf("[%s]", strings.Join(quoted, ", "))
+		joined := fmt.Sprintf("[%s]", strings.Join(values, ", "))
 		if len(joined) <= maxLength {
 			return joined
 		}
@@ -662,8 +1022,8 @@ func joinEnumValues(values []string) string {
 	// Too many or too long, show first few and count
 	out := "["
 	count := 0
-	for i := 0; i < len(quoted) && i < maxCount; i++ {
-		part := quoted[i]
+	for i := 0; i < len(values) && i < maxCount; i++ {
+		part := values[i]
 		if count > 0 {
 			part = ", " + part
 		}
@@ -693,6 +1053,13 @@ func generateStringValidations(varBody *hclwrite.Body, tfName string, schema *op
 
 	varRef := hclgen.TokensForTraversal("var", tfName)
 
+	if condition, ok := stringExactLengthConditionTokens(varRef, schema); ok {
+		if !isRequired {
+			condition = wrapWithNullGuard(varRef, condition)
+		}
+		appendValidation(varBody, condition, fmt.Sprintf("%s must be exactly %d characters.", tfName, *schema.MaxLength))
+	}
+
 	if condition, ok := stringMinLengthConditionTokens(varRef, schema); ok {
 		if !isRequired {
 			condition = wrapWithNullGuard(varRef, condition)
@@ -707,11 +1074,11 @@ func generateStringValidations(varBody *hclwrite.Body, tfName string, schema *op
 		appendValidation(varBody, condition, fmt.Sprintf("%s must have a maximum length of %d.", tfName, *schema.MaxLength))
 	}
 
-	if condition, ok := stringFormatConditionTokens(varRef, schema); ok {
+	if condition, description, ok := stringFormatConditionTokens(varRef, schema); ok {
 		if !isRequired {
 			condition = wrapWithNullGuard(varRef, condition)
 		}
-		appendValidation(varBody, condition, fmt.Sprintf("%s must be a valid UUID.", tfName))
+		appendValidation(varBody, condition, fmt.Sprintf("%s must be %s.", tfName, description))
 	}
 
 	if condition, ok := stringPatternConditionTokens(varRef, schema); ok {
@@ -756,6 +1123,121 @@ func generateArrayValidations(varBody *hclwrite.Body, tfName string, schema *ope
 	}
 }
 
+// generateArrayIdentifierUniqueValidation checks uniqueItems on an array of objects whose items
+// are identified by a single x-ms-identifiers property by comparing the identifiers: ARM treats
+// items sharing an identifier as the same item, however their other attributes differ, and
+// distinct on objects is structural.
+func generateArrayIdentifierUniqueValidation(varBody *hclwrite.Body, tfName string, schema *openapi3.Schema, isRequired bool, namer *naming.Namer) {
+	resolved := resolveSchemaForValidation(schema)
+	if resolved == nil || !resolved.UniqueItems {
+		return
+	}
+	key, ok := identifierMapKey(resolved)
+	if !ok {
+		return
+	}
+
+	varRef := hclgen.TokensForTraversal("var", tfName)
+	var forExpr hclwrite.Tokens
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")})
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("x")})
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")})
+	listRef := cloneTokens(varRef)
+	listRef[0].SpacesBefore = 1
+	forExpr = append(forExpr, listRef...)
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	forExpr = append(forExpr, hclgen.TokensForTraversal("x", objectAttributeName(namer, key))...)
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+	var condition hclwrite.Tokens
+	condition = append(condition, hclwrite.TokensForFunctionCall("length", hclwrite.TokensForFunctionCall("distinct", forExpr))...)
+	condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte(" == ")})
+	condition = append(condition, hclwrite.TokensForFunctionCall("length", varRef)...)
+	if !isRequired {
+		condition = wrapWithNullGuard(varRef, condition)
+	}
+	appendValidation(varBody, condition, fmt.Sprintf("%s must contain items with unique %s values.", tfName, objectAttributeName(namer, key)))
+}
+
+// generateMapKeyValidation checks the keys of a patternProperties map against its key pattern.
+func generateMapKeyValidation(varBody *hclwrite.Body, tfName string, schema *openapi3.Schema, isRequired bool) {
+	if len(schema.Properties) > 0 {
+		return
+	}
+	pattern, err := openapi.GetPatternProperties(schema)
+	if err != nil || pattern == nil {
+		return
+	}
+
+	varRef := hclgen.TokensForTraversal("var", tfName)
+
+	// alltrue([for k in keys(var.x) : can(regex("pattern", k))])
+	var forExpr hclwrite.Tokens
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")})
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("k")})
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")})
+	forExpr = append(forExpr, hclwrite.TokensForFunctionCall("keys", varRef)...)
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	forExpr = append(forExpr, hclwrite.TokensForFunctionCall("can",
+		hclwrite.TokensForFunctionCall("regex",
+			hclwrite.TokensForValue(cty.StringVal(pattern.KeyPattern)),
+			hclwrite.TokensForIdentifier("k"),
+		),
+	)...)
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+	condition := hclwrite.TokensForFunctionCall("alltrue", forExpr)
+	if !isRequired {
+		condition = wrapWithNullGuard(varRef, condition)
+	}
+	appendValidation(varBody, condition, fmt.Sprintf("All keys of %s must match the pattern: %s.", tfName, pattern.KeyPattern))
+}
+
+// generateMapValueValidations checks the string length constraints of the additionalProperties
+// schema of a map against each of its values, e.g.
+// alltrue([for v in values(var.x) : length(v) <= 256]).
+func generateMapValueValidations(varBody *hclwrite.Body, tfName string, schema *openapi3.Schema, isRequired bool) {
+	if len(schema.Properties) > 0 {
+		return
+	}
+	valueSchema := resolveSchemaForValidation(openapi.GetEffectiveAdditionalProperties(schema))
+	if valueSchema == nil {
+		return
+	}
+
+	varRef := hclgen.TokensForTraversal("var", tfName)
+	valueRef := hclwrite.TokensForIdentifier("v")
+	appendForEachValue := func(valueCondition hclwrite.Tokens, errorMessage string) {
+		var forExpr hclwrite.Tokens
+		forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+		forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")})
+		forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("v")})
+		forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")})
+		forExpr = append(forExpr, hclwrite.TokensForFunctionCall("values", varRef)...)
+		forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+		forExpr = append(forExpr, valueCondition...)
+		forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+		condition := hclwrite.TokensForFunctionCall("alltrue", forExpr)
+		if !isRequired {
+			condition = wrapWithNullGuard(varRef, condition)
+		}
+		appendValidation(varBody, condition, errorMessage)
+	}
+
+	if condition, ok := stringExactLengthConditionTokens(valueRef, valueSchema); ok {
+		appendForEachValue(condition, fmt.Sprintf("All values of %s must be exactly %d characters.", tfName, *valueSchema.MaxLength))
+	}
+	if condition, ok := stringMinLengthConditionTokens(valueRef, valueSchema); ok {
+		appendForEachValue(condition, fmt.Sprintf("All values of %s must have a minimum length of %d.", tfName, valueSchema.MinLength))
+	}
+	if condition, ok := stringMaxLengthConditionTokens(valueRef, valueSchema); ok {
+		appendForEachValue(condition, fmt.Sprintf("All values of %s must have a maximum length of %d.", tfName, *valueSchema.MaxLength))
+	}
+}
+
 // generateNumericValidations generates validation for numeric constraints.
 func generateNumericValidations(varBody *hclwrite.Body, tfName string, schema *openapi3.Schema, isRequired bool) {
 	if schema == nil || schema.Type == nil {
@@ -789,3 +1271,130 @@ func generateNumericValidations(varBody *hclwrite.Body, tfName string, schema *o
 		appendValidation(varBody, condition, fmt.Sprintf("%s must be a multiple of %v.", tfName, *schema.MultipleOf))
 	}
 }
+
+// integerFormatRanges are the bounds of the OpenAPI integer formats.
+var integerFormatRanges = map[string]struct {
+	bits     int
+	min, max int64
+}{
+	"int32": {bits: 32, min: math.MinInt32, max: math.MaxInt32},
+	"int64": {bits: 64, min: math.MinInt64, max: math.MaxInt64},
+}
+
+// generateIntegerRangeValidation adds a validation keeping an int32 or int64 field within the
+// range of its format, so overflow is caught at plan time rather than rejected by the API.
+// Bounds already implied by the schema's minimum and maximum are omitted.
+func generateIntegerRangeValidation(varBody *hclwrite.Body, tfName string, schema *openapi3.Schema, isRequired bool) {
+	resolved := resolveSchemaForValidation(schema)
+	if resolved == nil || resolved.Type == nil || !slices.Contains(*resolved.Type, "integer") {
+		return
+	}
+	bounds, ok := integerFormatRanges[resolved.Format]
+	if !ok {
+		return
+	}
+	needMin := resolved.Min == nil || *resolved.Min < float64(bounds.min)
+	needMax := resolved.Max == nil || *resolved.Max > float64(bounds.max)
+	if !needMin && !needMax {
+		return
+	}
+
+	varRef := hclgen.TokensForTraversal("var", tfName)
+	var condition hclwrite.Tokens
+	if needMin {
+		condition = append(condition, varRef...)
+		condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenGreaterThanEq, Bytes: []byte(" >= ")})
+		condition = append(condition, hclwrite.TokensForValue(cty.NumberIntVal(bounds.min))...)
+	}
+	if needMax {
+		if needMin {
+			condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenAnd, Bytes: []byte(" && ")})
+		}
+		condition = append(condition, varRef...)
+		condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenLessThanEq, Bytes: []byte(" <= ")})
+		condition = append(condition, hclwrite.TokensForValue(cty.NumberIntVal(bounds.max))...)
+	}
+	if !isRequired {
+		if needMin && needMax {
+			condition = append(hclwrite.Tokens{{Type: hclsyntax.TokenOParen, Bytes: []byte("(")}}, condition...)
+			condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenCParen, Bytes: []byte(")")})
+		}
+		condition = wrapWithNullGuard(varRef, condition)
+	}
+	appendValidation(varBody, condition, fmt.Sprintf("%s must fit in a %d-bit integer (%d to %d).", tfName, bounds.bits, bounds.min, bounds.max))
+}
+
+// rangePair names sibling numeric properties that bound the same quantity, e.g. minReplicas
+// and maxReplicas.
+type rangePair struct {
+	lower string
+	upper string
+}
+
+// rangeBoundPrefixes are the lower/upper bound name prefixes recognised by findRangePairs.
+var rangeBoundPrefixes = [][2]string{{"min", "max"}, {"minimum", "maximum"}}
+
+// findRangePairs returns the numeric properties in props whose names differ only by a min/max
+// prefix followed by the same capitalised suffix (minReplicas/maxReplicas,
+// minimumCount/maximumCount), sorted by the lower bound's name.
+func findRangePairs(props map[string]*openapi3.SchemaRef) []rangePair {
+	isNumeric := func(ref *openapi3.SchemaRef) bool {
+		if ref == nil || ref.Value == nil {
+			return false
+		}
+		resolved := resolveSchemaForValidation(ref.Value)
+		return resolved.Type != nil && (slices.Contains(*resolved.Type, "integer") || slices.Contains(*resolved.Type, "number"))
+	}
+
+	var pairs []rangePair
+	for name, ref := range props {
+		for _, prefixes := range rangeBoundPrefixes {
+			suffix, ok := strings.CutPrefix(name, prefixes[0])
+			if !ok || suffix == "" || suffix[0] < 'A' || suffix[0] > 'Z' {
+				continue
+			}
+			upper := prefixes[1] + suffix
+			if isNumeric(ref) && isNumeric(props[upper]) {
+				pairs = append(pairs, rangePair{lower: name, upper: upper})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].lower < pairs[j].lower
+	})
+	return pairs
+}
+
+// generateRangeOrderValidation adds a validation to the upper bound variable requiring it to be
+// at least the lower bound variable. Optional bounds are null-guarded so either may be omitted.
+func generateRangeOrderValidation(varBody *hclwrite.Body, lowerName, upperName string, lowerRequired, upperRequired bool) {
+	lowerRef := hclgen.TokensForTraversal("var", lowerName)
+	upperRef := hclgen.TokensForTraversal("var", upperName)
+
+	var condition hclwrite.Tokens
+	condition = append(condition, lowerRef...)
+	condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenLessThanEq, Bytes: []byte(" <= ")})
+	condition = append(condition, upperRef...)
+	if !upperRequired {
+		condition = wrapWithNullGuard(upperRef, condition)
+	}
+	if !lowerRequired {
+		condition = wrapWithNullGuard(lowerRef, condition)
+	}
+	appendValidation(varBody, condition, fmt.Sprintf("%s must be greater than or equal to %s.", upperName, lowerName))
+}
+
+// generateAnyOfSetValidation adds a validation requiring at least one of the named variables to
+// be non-null.
+func generateAnyOfSetValidation(varBody *hclwrite.Body, names []string) {
+	var condition hclwrite.Tokens
+	for i, name := range names {
+		if i > 0 {
+			condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenOr, Bytes: []byte(" || ")})
+		}
+		condition = append(condition, hclgen.TokensForTraversal("var", name)...)
+		condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenNotEqual, Bytes: []byte(" != ")})
+		condition = append(condition, hclwrite.TokensForIdentifier("null")...)
+	}
+	appendValidation(varBody, condition, fmt.Sprintf("At least one of %s must be set, as the resource requires its properties object.", strings.Join(names, ", ")))
+}
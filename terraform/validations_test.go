@@ -1,6 +1,7 @@
 package terraform
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -97,6 +98,101 @@ func TestGenerateValidations_StringMaxLength(t *testing.T) {
 	assert.Contains(t, errorMsg, "maximum length of 50")
 }
 
+func TestGenerateValidations_MapValueMaxLength(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	maxLen := uint64(256)
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"labels": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"object"},
+								AdditionalProperties: openapi3.AdditionalProperties{
+									Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{
+										Type:      &openapi3.Types{"string"},
+										MaxLength: &maxLen,
+									}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	labelsVar := requireBlock(t, varsBody, "variable", "labels")
+	assert.Equal(t, "map(string)", expressionString(t, labelsVar.Body.Attributes["type"].Expr))
+
+	validationBlock := findBlock(labelsVar.Body, "validation")
+	require.NotNil(t, validationBlock, "labels variable should have a map value maxLength validation")
+
+	conditionExpr := expressionString(t, validationBlock.Body.Attributes["condition"].Expr)
+	assert.Equal(t, "var.labels == null || alltrue([for v in values(var.labels) : length(v) <= 256])", conditionExpr)
+
+	errorMsg := attributeStringValue(t, validationBlock.Body.Attributes["error_message"])
+	assert.Equal(t, "All values of labels must have a maximum length of 256.", errorMsg)
+}
+
+func TestGenerateValidations_StringExactLength(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	exactLen := uint64(8)
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"shortCode": {
+							Value: &openapi3.Schema{
+								Type:      &openapi3.Types{"string"},
+								MinLength: 8,
+								MaxLength: &exactLen,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	codeVar := requireBlock(t, varsBody, "variable", "short_code")
+
+	validations := findAllBlocks(codeVar.Body, "validation")
+	require.Len(t, validations, 1, "equal minLength and maxLength should collapse into one validation")
+
+	conditionExpr := expressionString(t, validations[0].Body.Attributes["condition"].Expr)
+	assert.Equal(t, "var.short_code == null || length(var.short_code) == 8", conditionExpr)
+
+	errorMsg := attributeStringValue(t, validations[0].Body.Attributes["error_message"])
+	assert.Equal(t, "short_code must be exactly 8 characters.", errorMsg)
+}
+
 func TestGenerateValidations_StringUUIDFormat(t *testing.T) {
 	tmpDir := t.TempDir()
 	originalWd, err := os.Getwd()
@@ -326,6 +422,60 @@ func TestGenerateValidations_ArrayUniqueItems(t *testing.T) {
 	assert.Contains(t, errorMsg, "unique items")
 }
 
+func TestGenerateValidations_ArrayUniqueItemsByIdentifier(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"ipRules": {
+							Value: &openapi3.Schema{
+								Type:        &openapi3.Types{"array"},
+								UniqueItems: true,
+								Extensions:  map[string]any{"x-ms-identifiers": []any{"ruleId"}},
+								Items: &openapi3.SchemaRef{
+									Value: &openapi3.Schema{
+										Type: &openapi3.Types{"object"},
+										Properties: map[string]*openapi3.SchemaRef{
+											"ruleId": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+											"action": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	rulesVar := requireBlock(t, varsBody, "variable", "ip_rules")
+
+	validationBlock := findBlock(rulesVar.Body, "validation")
+	require.NotNil(t, validationBlock, "ipRules variable should have uniqueItems validation")
+
+	conditionExpr := expressionString(t, validationBlock.Body.Attributes["condition"].Expr)
+	assert.Equal(t, "var.ip_rules == null || length(distinct([for x in var.ip_rules : x.rule_id])) == length(var.ip_rules)", conditionExpr)
+	assert.NotContains(t, conditionExpr, "distinct(var.ip_rules)")
+
+	errorMsg := attributeStringValue(t, validationBlock.Body.Attributes["error_message"])
+	assert.Equal(t, "ip_rules must contain items with unique rule_id values.", errorMsg)
+}
+
 func TestGenerateValidations_NumberMinimum(t *testing.T) {
 	tmpDir := t.TempDir()
 	originalWd, err := os.Getwd()
@@ -604,6 +754,74 @@ func TestGenerateValidations_EnumViaAllOf(t *testing.T) {
 	assert.Contains(t, errorMsg, "Standard")
 }
 
+func TestGenerateValidations_EnumAndPattern(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"region": {
+							Value: &openapi3.Schema{
+								Type:    &openapi3.Types{"string"},
+								Enum:    []any{"eu-west", "us-east"},
+								Pattern: "^[a-z]+-[a-z]+$",
+							},
+						},
+						// Enum and pattern from separate allOf components must both survive the merge.
+						"zone": {
+							Value: &openapi3.Schema{
+								AllOf: []*openapi3.SchemaRef{
+									{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Pattern: "^[0-9]$"}},
+									{Value: &openapi3.Schema{Enum: []any{"1", "2", "3"}}},
+								},
+							},
+						},
+						"placement": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"object"},
+								Properties: map[string]*openapi3.SchemaRef{
+									"rack": {
+										Value: &openapi3.Schema{
+											Type:    &openapi3.Types{"string"},
+											Enum:    []any{"r1", "r2"},
+											Pattern: "^r[0-9]$",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	for _, name := range []string{"region", "zone", "placement"} {
+		variable := requireBlock(t, varsBody, "variable", name)
+		validations := findAllBlocks(variable.Body, "validation")
+		require.Len(t, validations, 2, "%s should have both an enum and a pattern validation", name)
+
+		enumMsg := attributeStringValue(t, validations[0].Body.Attributes["error_message"])
+		assert.Contains(t, enumMsg, "must be one of", name)
+		patternMsg := attributeStringValue(t, validations[1].Body.Attributes["error_message"])
+		assert.Contains(t, patternMsg, "must match the pattern", name)
+		assert.Contains(t, expressionString(t, validations[1].Body.Attributes["condition"].Expr), "regex(", name)
+	}
+}
+
 func TestGenerateValidations_XMsEnum(t *testing.T) {
 	tmpDir := t.TempDir()
 	originalWd, err := os.Getwd()
@@ -656,6 +874,253 @@ func TestGenerateValidations_XMsEnum(t *testing.T) {
 	assert.Contains(t, errorMsg, "Shared")
 }
 
+func TestGenerateValidations_XMsEnumModelAsString(t *testing.T) {
+	xMsEnum := func(name string, modelAsString bool) map[string]any {
+		return map[string]any{
+			"x-ms-enum": map[string]any{
+				"name":          name,
+				"modelAsString": modelAsString,
+				"values": []any{
+					map[string]any{"value": "Basic"},
+					map[string]any{"value": "Premium"},
+				},
+			},
+		}
+	}
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"closedTier": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Extensions: xMsEnum("ClosedTier", false)}},
+						"openTier":   {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Extensions: xMsEnum("OpenTier", true)}},
+						"settings": {Value: &openapi3.Schema{
+							Type: &openapi3.Types{"object"},
+							Properties: map[string]*openapi3.SchemaRef{
+								"mode": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Extensions: xMsEnum("Mode", true)}},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	files, err := GenerateFiles("Microsoft.Test/widgets", WithSchema(schema))
+	require.NoError(t, err)
+	vars := string(files["variables.tf"])
+	assert.Contains(t, vars, `contains(["Basic", "Premium"], var.closed_tier)`)
+	assert.NotContains(t, vars, "var.open_tier)")
+	assert.NotContains(t, vars, "var.settings.mode)")
+
+	files, err = GenerateFiles("Microsoft.Test/widgets", WithSchema(schema), WithOpenEnumValidation(true))
+	require.NoError(t, err)
+	vars = string(files["variables.tf"])
+	assert.Contains(t, vars, `contains(["Basic", "Premium"], var.closed_tier)`)
+	assert.Contains(t, vars, `contains(["Basic", "Premium"], var.open_tier)`)
+	assert.Contains(t, vars, `contains(["Basic", "Premium"], var.settings.mode)`)
+}
+
+func TestGenerateValidations_IntegerEnum(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"replicaCount": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"integer"},
+								Enum: []any{float64(4), float64(1), float64(2)},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	replicaVar := requireBlock(t, varsBody, "variable", "replica_count")
+
+	validationBlock := findBlock(replicaVar.Body, "validation")
+	require.NotNil(t, validationBlock, "replica_count variable should have enum validation")
+
+	conditionExpr := expressionString(t, validationBlock.Body.Attributes["condition"].Expr)
+	// Integer enums are compared as numbers, sorted numerically and unquoted
+	assert.Contains(t, conditionExpr, "contains([1, 2, 4], var.replica_count)")
+	assert.NotContains(t, conditionExpr, `"1"`)
+
+	errorMsg := attributeStringValue(t, validationBlock.Body.Attributes["error_message"])
+	assert.Contains(t, errorMsg, "must be one of: [1, 2, 4].")
+}
+
+func TestGenerateValidations_BooleanEnum(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"acceptTerms": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"boolean"},
+								Enum: []any{true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	termsVar := requireBlock(t, varsBody, "variable", "accept_terms")
+
+	validationBlock := findBlock(termsVar.Body, "validation")
+	require.NotNil(t, validationBlock, "accept_terms variable should have enum validation")
+
+	conditionExpr := expressionString(t, validationBlock.Body.Attributes["condition"].Expr)
+	// Boolean enums are compared as bool literals, not the strings "true"/"false"
+	assert.Contains(t, conditionExpr, "contains([true], var.accept_terms)")
+	assert.NotContains(t, conditionExpr, `"true"`)
+
+	errorMsg := attributeStringValue(t, validationBlock.Body.Attributes["error_message"])
+	assert.Contains(t, errorMsg, "must be one of: [true].")
+}
+
+func TestGenerateValidations_ARMResourceID(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"subnet": {
+							Value: &openapi3.Schema{
+								Type:       &openapi3.Types{"string"},
+								Extensions: map[string]any{"x-ms-azure-resource": true},
+							},
+						},
+						"workspaceResourceId": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"string"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	subnetVar := requireBlock(t, varsBody, "variable", "subnet")
+
+	validationBlock := findBlock(subnetVar.Body, "validation")
+	require.NotNil(t, validationBlock, "subnet variable should have ARM resource ID validation")
+
+	conditionExpr := expressionString(t, validationBlock.Body.Attributes["condition"].Expr)
+	assert.Contains(t, conditionExpr, `var.subnet == null || can(regex("^/subscriptions/.+", var.subnet))`)
+
+	description := attributeStringValue(t, subnetVar.Body.Attributes["description"])
+	assert.Contains(t, description, "Expected to be an ARM resource ID")
+
+	// The name heuristic is opt-in.
+	workspaceVar := requireBlock(t, varsBody, "variable", "workspace_resource_id")
+	assert.Nil(t, findBlock(workspaceVar.Body, "validation"))
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"), WithResourceIDNameHeuristic(true))
+	require.NoError(t, err)
+
+	varsBody = parseHCLBody(t, "variables.tf")
+	workspaceVar = requireBlock(t, varsBody, "variable", "workspace_resource_id")
+	validationBlock = findBlock(workspaceVar.Body, "validation")
+	require.NotNil(t, validationBlock, "workspace_resource_id should be validated when the name heuristic is enabled")
+	conditionExpr = expressionString(t, validationBlock.Body.Attributes["condition"].Expr)
+	assert.Contains(t, conditionExpr, `can(regex("^/subscriptions/.+", var.workspace_resource_id))`)
+}
+
+func TestGenerateValidations_ARMResourceIDFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"subnet": {
+							Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Format: "arm-id"},
+						},
+						// Marked both ways, it is still validated once.
+						"vault": {
+							Value: &openapi3.Schema{
+								Type:       &openapi3.Types{"string"},
+								Format:     "azure-resource-id",
+								Extensions: map[string]any{"x-ms-azure-resource": true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	for _, tfName := range []string{"subnet", "vault"} {
+		variable := requireBlock(t, varsBody, "variable", tfName)
+		require.Len(t, variable.Body.Blocks, 1, "%s should have a single validation", tfName)
+		validation := variable.Body.Blocks[0]
+		assert.Equal(t, "var."+tfName+` == null || can(regex("^/subscriptions/.+", var.`+tfName+"))", expressionString(t, validation.Body.Attributes["condition"].Expr))
+		assert.Equal(t, tfName+" must be an ARM resource ID starting with /subscriptions/.", attributeStringValue(t, validation.Body.Attributes["error_message"]))
+		assert.Contains(t, attributeStringValue(t, variable.Body.Attributes["description"]), "Expected to be an ARM resource ID")
+	}
+}
+
 func TestGenerateValidations_MultipleConstraints(t *testing.T) {
 	tmpDir := t.TempDir()
 	originalWd, err := os.Getwd()
@@ -793,3 +1258,262 @@ func findAllBlocks(body *hclsyntax.Body, typ string) []*hclsyntax.Block {
 	}
 	return blocks
 }
+
+func TestGenerateValidations_DurationFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"retention": {
+							Value: &openapi3.Schema{
+								Type:   &openapi3.Types{"string"},
+								Format: "duration",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	retentionVar := requireBlock(t, varsBody, "variable", "retention")
+
+	validationBlock := findBlock(retentionVar.Body, "validation")
+	require.NotNil(t, validationBlock, "retention variable should have a duration validation")
+
+	conditionExpr := expressionString(t, validationBlock.Body.Attributes["condition"].Expr)
+	assert.Contains(t, conditionExpr, `can(regex("^P(?:[0-9]+Y)?`)
+	assert.Contains(t, conditionExpr, `var.retention))`)
+
+	errorMessage := attributeStringValue(t, validationBlock.Body.Attributes["error_message"])
+	assert.Equal(t, "retention must be an ISO 8601 duration.", errorMessage)
+}
+
+func TestGenerateValidations_NotEnum(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"state": {
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{"string"},
+								Not: &openapi3.SchemaRef{
+									Value: &openapi3.Schema{Enum: []any{"Disabled"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = Generate("testResource", WithSchema(schema), WithLocalName("resource_body"), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	stateVar := requireBlock(t, varsBody, "variable", "state")
+
+	validationBlock := findBlock(stateVar.Body, "validation")
+	require.NotNil(t, validationBlock, "state variable should have a negated enum validation")
+
+	conditionExpr := expressionString(t, validationBlock.Body.Attributes["condition"].Expr)
+	assert.Contains(t, conditionExpr, `var.state == null || !contains(["Disabled"], var.state)`)
+
+	errorMessage := attributeStringValue(t, validationBlock.Body.Attributes["error_message"])
+	assert.Equal(t, `state must not be one of: ["Disabled"].`, errorMessage)
+
+	description := attributeStringValue(t, stateVar.Body.Attributes["description"])
+	assert.Contains(t, description, `Must not be one of: ["Disabled"].`)
+}
+
+func TestGenerateValidations_NumericExclusiveBoundsOpenAPI31(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	// OpenAPI 3.1 expresses exclusive bounds as numbers rather than booleans.
+	spec := `{
+  "openapi": "3.1.0",
+  "info": {"title": "test", "version": "2025-01-01"},
+  "paths": {},
+  "components": {
+    "schemas": {
+      "Widget": {
+        "type": "object",
+        "properties": {
+          "ratio": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+          "count": {"type": "integer", "minimum": 5, "exclusiveMinimum": 2}
+        }
+      }
+    }
+  }
+}`
+	require.NoError(t, os.WriteFile("spec.json", []byte(spec), 0o644))
+
+	loaded, err := LoadSchemaDefinition(context.Background(), []string{"spec.json"}, "Widget")
+	require.NoError(t, err)
+	require.NoError(t, Generate("Microsoft.Test/widgets", loaded))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+
+	ratioVar := requireBlock(t, varsBody, "variable", "ratio")
+	var ratioConditions []string
+	for _, block := range ratioVar.Body.Blocks {
+		if block.Type == "validation" {
+			ratioConditions = append(ratioConditions, expressionString(t, block.Body.Attributes["condition"].Expr))
+		}
+	}
+	assert.Contains(t, ratioConditions, "var.ratio == null || var.ratio > 0")
+	assert.Contains(t, ratioConditions, "var.ratio == null || var.ratio < 1")
+
+	// An inclusive minimum that is stricter than the exclusive one wins.
+	countVar := requireBlock(t, varsBody, "variable", "count")
+	validationBlock := findBlock(countVar.Body, "validation")
+	require.NotNil(t, validationBlock)
+	assert.Equal(t, "var.count == null || var.count >= 5", expressionString(t, validationBlock.Body.Attributes["condition"].Expr))
+}
+
+func TestGenerateValidations_RangeOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	integer := func() *openapi3.SchemaRef {
+		return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}}}
+	}
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type:     &openapi3.Types{"object"},
+					Required: []string{"maxReplicas"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"minReplicas":  integer(),
+						"maxReplicas":  integer(),
+						"minimumCount": integer(),
+						"maximumCount": integer(),
+						// Not a pair: the upper bound is not numeric.
+						"minVersion": integer(),
+						"maxVersion": {Value: &openapi3.Schema{Type: &openapi3.Types{"string"}}},
+						// Not a pair: "min" is not followed by a capitalised suffix.
+						"mint": integer(),
+						"maxt": integer(),
+					},
+				},
+			},
+		},
+	}
+
+	conditionsFor := func(t *testing.T, varsBody *hclsyntax.Body, name string) []string {
+		t.Helper()
+		variable := requireBlock(t, varsBody, "variable", name)
+		var conditions []string
+		for _, block := range findAllBlocks(variable.Body, "validation") {
+			conditions = append(conditions, expressionString(t, block.Body.Attributes["condition"].Expr))
+		}
+		return conditions
+	}
+
+	require.NoError(t, Generate("testResource", WithSchema(schema), WithAPIVersion("2024-01-01")))
+	varsBody := parseHCLBody(t, "variables.tf")
+	assert.Empty(t, conditionsFor(t, varsBody, "max_replicas"), "range validations are opt-in")
+
+	require.NoError(t, Generate("testResource", WithSchema(schema), WithAPIVersion("2024-01-01"), WithRangeValidations(true)))
+	varsBody = parseHCLBody(t, "variables.tf")
+
+	assert.Equal(t, []string{"var.min_replicas == null || var.min_replicas <= var.max_replicas"}, conditionsFor(t, varsBody, "max_replicas"))
+	assert.Equal(t, []string{"var.minimum_count == null || var.maximum_count == null || var.minimum_count <= var.maximum_count"}, conditionsFor(t, varsBody, "maximum_count"))
+	assert.Empty(t, conditionsFor(t, varsBody, "min_replicas"))
+	assert.Empty(t, conditionsFor(t, varsBody, "max_version"))
+	assert.Empty(t, conditionsFor(t, varsBody, "maxt"))
+
+	maxReplicas := requireBlock(t, varsBody, "variable", "max_replicas")
+	validationBlock := findBlock(maxReplicas.Body, "validation")
+	require.NotNil(t, validationBlock)
+	assert.Equal(t, "max_replicas must be greater than or equal to min_replicas.", attributeStringValue(t, validationBlock.Body.Attributes["error_message"]))
+}
+
+func TestGenerateValidations_IntegerFormatRange(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	minZero := 0.0
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{"object"},
+		Properties: map[string]*openapi3.SchemaRef{
+			"properties": {
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{"object"},
+					Properties: map[string]*openapi3.SchemaRef{
+						"maxPods":   {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}, Format: "int32"}},
+						"diskBytes": {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}, Format: "int64", Min: &minZero}},
+						"count":     {Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}}},
+					},
+				},
+			},
+		},
+	}
+
+	conditionsFor := func(t *testing.T, varsBody *hclsyntax.Body, name string) []string {
+		t.Helper()
+		variable := requireBlock(t, varsBody, "variable", name)
+		var conditions []string
+		for _, block := range findAllBlocks(variable.Body, "validation") {
+			conditions = append(conditions, expressionString(t, block.Body.Attributes["condition"].Expr))
+		}
+		return conditions
+	}
+
+	require.NoError(t, Generate("testResource", WithSchema(schema), WithAPIVersion("2024-01-01")))
+	varsBody := parseHCLBody(t, "variables.tf")
+	assert.Empty(t, conditionsFor(t, varsBody, "max_pods"), "integer range checks are opt-in")
+
+	require.NoError(t, Generate("testResource", WithSchema(schema), WithAPIVersion("2024-01-01"), WithIntRangeChecks(true)))
+	varsBody = parseHCLBody(t, "variables.tf")
+
+	assert.Equal(t, []string{"var.max_pods == null || (var.max_pods >= -2147483648 && var.max_pods <= 2147483647)"}, conditionsFor(t, varsBody, "max_pods"))
+	maxPods := requireBlock(t, varsBody, "variable", "max_pods")
+	validationBlock := findBlock(maxPods.Body, "validation")
+	require.NotNil(t, validationBlock)
+	assert.Equal(t, "max_pods must fit in a 32-bit integer (-2147483648 to 2147483647).", attributeStringValue(t, validationBlock.Body.Attributes["error_message"]))
+
+	// The minimum already bounds the field from below, so only the upper int64 bound is added.
+	assert.Contains(t, conditionsFor(t, varsBody, "disk_bytes"), "var.disk_bytes == null || var.disk_bytes <= 9223372036854775807")
+	assert.Empty(t, conditionsFor(t, varsBody, "count"))
+}